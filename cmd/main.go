@@ -73,16 +73,14 @@ func main() {
 	jobQueue := jobs.NewQueue(max(1, cfg.Agent.BundleConcurrency), store)
 	cronSvc := service.NewRunnableTransServiceWithQueueAndStore(*cfg, cronScheduler, jobQueue, store)
 
-	sourceConfigs := []library.SourceConfig{
-		{ID: "movies", Name: "Movies", Path: cfg.Media.MovieDir},
-		{ID: "animations", Name: "Animations", Path: cfg.Media.AnimationDir},
-		{ID: "teleplays", Name: "Teleplays", Path: cfg.Media.TeleplayDir},
-		{ID: "tvshows", Name: "TV Shows", Path: cfg.Media.ShowDir},
-		{ID: "documentaries", Name: "Documentaries", Path: cfg.Media.DocumentaryDir},
+	sourceConfigs := make([]library.SourceConfig, 0)
+	for _, source := range cfg.Media.Sources() {
+		sourceConfigs = append(sourceConfigs, library.SourceConfig{ID: source.ID, Name: source.Name, Path: source.Path})
 	}
 	scanner := library.NewScanner(
 		sourceConfigs,
 		cfg.Translate.TargetLanguage,
+		library.WithTargetLanguages(cfg.Translate.Targets()...),
 		library.WithEmbeddedDetector(func(mediaPath string) (bool, bool, []string) {
 			descriptions, err := media.NewOperator(mediaPath).ReadSubtitleDescription()
 			if err != nil {
@@ -107,12 +105,24 @@ func main() {
 		scanner,
 		jobQueue,
 		httpapi.WithJobDataStore(store),
+		httpapi.WithCharacterProfileStore(store),
+		httpapi.WithSongModeStore(store),
+		httpapi.WithUsageStore(store),
+		httpapi.WithTranslationReviewStore(store),
+		httpapi.WithTranslationMemoryRecorder(cronSvc.RememberEditedLines),
+		httpapi.WithTermMapStore(store),
+		httpapi.WithTermMapEditor(cronSvc.EditTermMap),
+		httpapi.WithReadingLimits(cfg.Translate.Reading),
 		httpapi.WithRuntimeSettingsStore(settingsStore),
 		httpapi.WithRuntimeSettingsApplier(func(next config.RuntimeSettings) error {
 			if err := cronSvc.ApplyRuntimeSettings(next); err != nil {
 				return err
 			}
-			return scanner.UpdateTargetLanguage(next.TargetLanguage)
+			targets := next.TargetLanguages
+			if len(targets) == 0 {
+				targets = []string{next.TargetLanguage}
+			}
+			return scanner.UpdateTargetLanguages(targets)
 		}),
 		httpapi.WithUI(cfg.HTTP.UIStaticDir, cfg.HTTP.UIEnabled),
 	)
@@ -3,8 +3,8 @@ module github.com/MimeLyc/contextual-sub-translator
 go 1.24.0
 
 require (
-	github.com/MimeLyc/agent-core-go v0.0.0-20260215131438-6acc9d11d10d
 	github.com/stretchr/testify v1.10.0
+	modernc.org/sqlite v1.39.1
 )
 
 require (
@@ -19,7 +19,6 @@ require (
 	modernc.org/libc v1.66.10 // indirect
 	modernc.org/mathutil v1.7.1 // indirect
 	modernc.org/memory v1.11.0 // indirect
-	modernc.org/sqlite v1.39.1 // indirect
 )
 
 require (
@@ -4,19 +4,17 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sort"
 	"strings"
-	"time"
 
-	coreagent "github.com/MimeLyc/agent-core-go/pkg/agent"
-	coretools "github.com/MimeLyc/agent-core-go/pkg/tools"
 	projecttools "github.com/MimeLyc/contextual-sub-translator/internal/tools"
 	"github.com/MimeLyc/contextual-sub-translator/pkg/log"
 )
 
-// LLMConfig configures the underlying LLM provider for the agent.
-// Temperature is kept for backward compatibility.
-// The current agent-core-go API path does not expose these knobs yet.
+// LLMConfig configures the LLM provider behind the agent.
 type LLMConfig struct {
+	// Provider selects the wire format: openai (default), anthropic or ollama
+	Provider    string
 	APIKey      string
 	APIURL      string
 	Model       string
@@ -27,7 +25,11 @@ type LLMConfig struct {
 
 // Validate validates the configuration.
 func (c LLMConfig) Validate() error {
-	if strings.TrimSpace(c.APIKey) == "" {
+	provider, err := ParseProvider(c.Provider)
+	if err != nil {
+		return err
+	}
+	if provider.RequiresAPIKey() && strings.TrimSpace(c.APIKey) == "" {
 		return fmt.Errorf("API key is required")
 	}
 	if strings.TrimSpace(c.APIURL) == "" {
@@ -54,211 +56,179 @@ type Agent interface {
 	Close() error
 }
 
-// LLMAgent implements the Agent interface using agent-core-go.
+// LLMAgent implements the Agent interface with a tool-calling loop over a Provider.
 type LLMAgent struct {
-	agent         coreagent.Agent
+	providerName  string
+	model         string
+	provider      Provider
+	registry      *projecttools.Registry
 	maxIterations int
 }
 
-// NewLLMAgent creates a new LLM-based agent backed by agent-core-go.
+// NewLLMAgent creates a new LLM-based agent for the provider selected by cfg.
 func NewLLMAgent(cfg LLMConfig, registry *projecttools.Registry, maxIterations int) (*LLMAgent, error) {
-	if maxIterations <= 0 {
-		maxIterations = 10
-	}
 	if err := cfg.Validate(); err != nil {
 		return nil, fmt.Errorf("invalid LLM config: %w", err)
 	}
-
-	for _, field := range unsupportedConfigNotes(cfg) {
-		log.Warn("LLM config field %s is currently ignored by agent-core-go adapter", field)
-	}
-
-	coreRegistry, err := toCoreRegistry(registry)
+	provider, err := NewProvider(cfg)
 	if err != nil {
-		return nil, err
-	}
-
-	timeout := 5 * time.Minute
-	if cfg.Timeout > 0 {
-		timeout = time.Duration(cfg.Timeout) * time.Second
+		return nil, fmt.Errorf("invalid LLM config: %w", err)
 	}
+	providerType, _ := ParseProvider(cfg.Provider)
+	llmAgent := NewLLMAgentWithProvider(provider, registry, maxIterations)
+	llmAgent.providerName = string(providerType)
+	llmAgent.model = cfg.Model
+	return llmAgent, nil
+}
 
-	coreCfg := coreagent.AgentConfig{
-		Type: coreagent.AgentTypeAPI,
-		API: &coreagent.APIConfig{
-			ProviderType:  coreagent.ProviderTypeOpenAI,
-			BaseURL:       normalizeBaseURL(cfg.APIURL),
-			APIKey:        cfg.APIKey,
-			Model:         cfg.Model,
-			MaxTokens:     cfg.MaxTokens,
-			Timeout:       timeout,
-			MaxAttempts:   5,
-			MaxIterations: maxIterations,
-			MaxMessages:   50,
-		},
-		Registry: coreRegistry,
+// NewLLMAgentWithProvider creates a new agent on top of an existing provider.
+func NewLLMAgentWithProvider(provider Provider, registry *projecttools.Registry, maxIterations int) *LLMAgent {
+	if maxIterations <= 0 {
+		maxIterations = 10
 	}
-
-	coreAgent, err := coreagent.NewAgent(coreCfg)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create agent-core-go agent: %w", err)
+	if registry == nil {
+		registry = projecttools.NewRegistry()
 	}
-
 	return &LLMAgent{
-		agent:         coreAgent,
+		provider:      provider,
+		registry:      registry,
 		maxIterations: maxIterations,
-	}, nil
+	}
 }
 
-// Execute runs the agent with the given request.
+// Execute runs the agent with the given request. The model is called until it
+// answers without tool calls, at most MaxIterations times.
 func (a *LLMAgent) Execute(ctx context.Context, req AgentRequest) (*AgentResult, error) {
-	if a == nil || a.agent == nil {
+	if a == nil || a.provider == nil {
 		return nil, fmt.Errorf("agent is not initialized")
 	}
 
 	maxIterations := a.getMaxIterations(req)
-	result, err := a.executeWith(a.agent, ctx, req, maxIterations)
-	if err != nil {
-		return nil, fmt.Errorf("agent execution failed: %w", err)
+	chat := ChatRequest{
+		SystemPrompt: req.SystemPrompt,
+		Messages:     []Message{{Role: RoleUser, Content: req.UserMessage}},
+		Tools:        a.toolSpecs(),
+		JSONMode:     req.JSONMode,
 	}
+	result := &AgentResult{}
+	seenIDs := make(map[string]bool)
+	recorder := usageRecorderFromContext(ctx)
 
-	return convertAgentResult(result), nil
-}
-
-func (a *LLMAgent) executeWith(agentInstance coreagent.Agent, ctx context.Context, req AgentRequest, maxIterations int) (coreagent.AgentResult, error) {
-	return agentInstance.Execute(ctx, coreagent.AgentRequest{
-		Task:         req.UserMessage,
-		SystemPrompt: req.SystemPrompt,
-		Options: coreagent.AgentOptions{
-			MaxIterations: maxIterations,
-		},
-	})
-}
+	for result.Iterations < maxIterations {
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("agent execution failed: %w", err)
+		}
+		result.Iterations++
 
-func convertAgentResult(result coreagent.AgentResult) *AgentResult {
-	converted := &AgentResult{
-		Content:    result.Message,
-		ToolCalls:  convertToolCalls(result.ToolCalls),
-		Iterations: result.Usage.TotalIterations,
-	}
-	if strings.TrimSpace(converted.Content) == "" {
-		converted.Content = result.Summary
-	}
-	return converted
-}
+		resp, err := a.provider.Complete(ctx, chat)
+		if err != nil {
+			return nil, fmt.Errorf("agent execution failed: %w", err)
+		}
+		result.Usage = result.Usage.Add(resp.Usage)
+		if recorder != nil {
+			recorder.RecordUsage(ctx, UsageRecord{Provider: a.providerName, Model: a.model, Usage: resp.Usage})
+		}
+		if len(resp.ToolCalls) == 0 {
+			if resp.Truncated {
+				return nil, fmt.Errorf("agent execution failed: max tokens reached")
+			}
+			result.Content = resp.Content
+			return result, nil
+		}
 
-// Close releases any resources held by the agent.
-func (a *LLMAgent) Close() error {
-	if a == nil || a.agent == nil {
-		return nil
+		// Tool results are paired with their call by ID; some backends
+		// leave IDs empty or reuse them across turns.
+		for i := range resp.ToolCalls {
+			if id := resp.ToolCalls[i].ID; id == "" || seenIDs[id] {
+				resp.ToolCalls[i].ID = fmt.Sprintf("call_%d_%d", result.Iterations, i+1)
+			}
+			seenIDs[resp.ToolCalls[i].ID] = true
+		}
+		chat.Messages = append(chat.Messages, Message{
+			Role:      RoleAssistant,
+			Content:   resp.Content,
+			ToolCalls: resp.ToolCalls,
+		})
+		for _, call := range resp.ToolCalls {
+			record := a.executeTool(ctx, call)
+			result.ToolCalls = append(result.ToolCalls, record)
+			chat.Messages = append(chat.Messages, Message{
+				Role:       RoleTool,
+				Content:    record.Result,
+				ToolCallID: call.ID,
+				ToolName:   call.Name,
+				IsError:    record.IsError,
+			})
+		}
 	}
-	return a.agent.Close()
-}
 
-func (a *LLMAgent) getMaxIterations(req AgentRequest) int {
-	if req.MaxIterations > 0 {
-		return req.MaxIterations
-	}
-	return a.maxIterations
+	return nil, fmt.Errorf("agent execution failed: max iterations (%d) reached", maxIterations)
 }
 
-func normalizeBaseURL(baseURL string) string {
-	normalized := strings.TrimSpace(strings.TrimRight(baseURL, "/"))
-	if strings.HasSuffix(normalized, "/chat/completions") {
-		normalized = strings.TrimSuffix(normalized, "/chat/completions")
+func (a *LLMAgent) executeTool(ctx context.Context, call ToolCall) ToolCallRecord {
+	record := ToolCallRecord{
+		ToolName:  call.Name,
+		Arguments: string(call.Arguments),
 	}
-	if strings.HasSuffix(normalized, "/v1") {
-		normalized = strings.TrimSuffix(normalized, "/v1")
+	tool, ok := a.registry.Get(call.Name)
+	if !ok {
+		record.Result = fmt.Sprintf("unknown tool %q", call.Name)
+		record.IsError = true
+		return record
 	}
-	return normalized
-}
 
-func unsupportedConfigNotes(cfg LLMConfig) []string {
-	var notes []string
-	if cfg.Temperature != 0 {
-		notes = append(notes, "temperature")
+	toolResult, err := tool.Execute(ctx, call.Arguments)
+	if err != nil {
+		record.Result = err.Error()
+		record.IsError = true
+		return record
 	}
-	return notes
+	record.Result = toolResult.Content
+	record.IsError = toolResult.IsError
+	log.Debug("Agent tool call %s finished: is_error=%t", call.Name, record.IsError)
+	return record
 }
 
-func toCoreRegistry(registry *projecttools.Registry) (*coretools.Registry, error) {
-	coreRegistry := coretools.NewRegistry()
-	if registry == nil {
-		return coreRegistry, nil
-	}
+// toolSpecs lists the registered tools sorted by name, so requests are stable
+func (a *LLMAgent) toolSpecs() []ToolSpec {
+	names := a.registry.List()
+	sort.Strings(names)
 
-	for _, name := range registry.List() {
-		tool, ok := registry.Get(name)
+	specs := make([]ToolSpec, 0, len(names))
+	for _, name := range names {
+		tool, ok := a.registry.Get(name)
 		if !ok {
 			continue
 		}
-		if err := coreRegistry.Register(toolAdapter{tool: tool}); err != nil {
-			return nil, fmt.Errorf("register tool %q: %w", name, err)
+		parameters := tool.Parameters()
+		if !json.Valid(parameters) {
+			parameters = json.RawMessage(`{"type":"object","properties":{}}`)
 		}
-	}
-
-	return coreRegistry, nil
-}
-
-func convertToolCalls(coreCalls []coreagent.ToolCallRecord) []ToolCallRecord {
-	if len(coreCalls) == 0 {
-		return nil
-	}
-
-	result := make([]ToolCallRecord, 0, len(coreCalls))
-	for _, call := range coreCalls {
-		arguments, err := json.Marshal(call.Input)
-		if err != nil {
-			arguments = []byte("{}")
-		}
-
-		result = append(result, ToolCallRecord{
-			ToolName:  call.Name,
-			Arguments: string(arguments),
-			Result:    call.Output,
-			IsError:   call.IsError,
+		specs = append(specs, ToolSpec{
+			Name:        name,
+			Description: tool.Description(),
+			Parameters:  parameters,
 		})
 	}
-
-	return result
-}
-
-type toolAdapter struct {
-	tool projecttools.Tool
-}
-
-func (a toolAdapter) Name() string {
-	return a.tool.Name()
+	return specs
 }
 
-func (a toolAdapter) Description() string {
-	return a.tool.Description()
-}
-
-func (a toolAdapter) InputSchema() map[string]any {
-	var schema map[string]any
-	if err := json.Unmarshal(a.tool.Parameters(), &schema); err != nil || schema == nil {
-		return map[string]any{
-			"type":       "object",
-			"properties": map[string]any{},
-		}
+// Model returns the model the agent talks to, empty when built on a bare provider.
+func (a *LLMAgent) Model() string {
+	if a == nil {
+		return ""
 	}
-	return schema
+	return a.model
 }
 
-func (a toolAdapter) Execute(ctx context.Context, _ *coretools.ToolContext, input map[string]any) (coretools.ToolResult, error) {
-	payload, err := json.Marshal(input)
-	if err != nil {
-		return coretools.NewErrorResultf("failed to marshal tool input: %v", err), nil
-	}
+// Close releases any resources held by the agent.
+func (a *LLMAgent) Close() error {
+	return nil
+}
 
-	toolResult, execErr := a.tool.Execute(ctx, json.RawMessage(payload))
-	if execErr != nil {
-		return coretools.NewErrorResult(execErr), nil
+func (a *LLMAgent) getMaxIterations(req AgentRequest) int {
+	if req.MaxIterations > 0 {
+		return req.MaxIterations
 	}
-
-	return coretools.ToolResult{
-		Content: toolResult.Content,
-		IsError: toolResult.IsError,
-	}, nil
+	return a.maxIterations
 }
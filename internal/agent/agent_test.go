@@ -140,14 +140,21 @@ func TestLLMAgent_Execute_WithToolCalling(t *testing.T) {
 	assert.JSONEq(t, `{"text":"hello"}`, result.ToolCalls[0].Result)
 }
 
-func TestUnsupportedConfigNotes(t *testing.T) {
+func TestLLMConfig_Validate_Provider(t *testing.T) {
 	t.Parallel()
 
-	notes := unsupportedConfigNotes(LLMConfig{
-		Temperature: 0.2,
-	})
+	local := LLMConfig{Provider: "ollama", APIURL: "http://localhost:11434", Model: "qwen2.5"}
+	require.NoError(t, local.Validate())
 
-	assert.Contains(t, notes, "temperature")
+	hosted := local
+	hosted.Provider = "anthropic"
+	require.Error(t, hosted.Validate())
+
+	unknown := local
+	unknown.Provider = "bard"
+	err := unknown.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported LLM provider")
 }
 
 func TestLLMAgent_Execute_ReasoningContentErrorReturnsFailure(t *testing.T) {
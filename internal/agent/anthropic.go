@@ -0,0 +1,203 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+const (
+	anthropicVersion = "2023-06-01"
+	// anthropicDefaultMaxTokens is sent when no max tokens are configured,
+	// the messages API requires the field
+	anthropicDefaultMaxTokens = 4096
+)
+
+// anthropicProvider talks to the Anthropic messages API.
+type anthropicProvider struct {
+	cfg     LLMConfig
+	baseURL string
+	http    httpClient
+}
+
+func newAnthropicProvider(cfg LLMConfig, client httpClient) *anthropicProvider {
+	return &anthropicProvider{
+		cfg:     cfg,
+		baseURL: trimBaseURL(cfg.APIURL, "/messages", "/v1"),
+		http:    client,
+	}
+}
+
+type anthropicRequest struct {
+	Model       string             `json:"model"`
+	System      string             `json:"system,omitempty"`
+	Messages    []anthropicMessage `json:"messages"`
+	Tools       []anthropicTool    `json:"tools,omitempty"`
+	Temperature float64            `json:"temperature"`
+	MaxTokens   int                `json:"max_tokens"`
+}
+
+type anthropicMessage struct {
+	Role    string           `json:"role"`
+	Content []anthropicBlock `json:"content"`
+}
+
+type anthropicBlock struct {
+	Type string `json:"type"`
+
+	// text blocks
+	Text string `json:"text,omitempty"`
+
+	// tool_use blocks
+	ID    string          `json:"id,omitempty"`
+	Name  string          `json:"name,omitempty"`
+	Input json.RawMessage `json:"input,omitempty"`
+
+	// tool_result blocks
+	ToolUseID string `json:"tool_use_id,omitempty"`
+	Content   string `json:"content,omitempty"`
+	IsError   bool   `json:"is_error,omitempty"`
+}
+
+type anthropicTool struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	InputSchema json.RawMessage `json:"input_schema"`
+}
+
+type anthropicResponse struct {
+	Content    []anthropicBlock `json:"content"`
+	StopReason string           `json:"stop_reason"`
+	Usage      struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
+}
+
+// Complete sends req to the messages API. The API has no JSON response mode;
+// in JSON mode the system prompt asks for a bare object and, when no tools are
+// offered, the answer is prefilled with "{" so it can only continue an object.
+func (p *anthropicProvider) Complete(ctx context.Context, req ChatRequest) (ChatResponse, error) {
+	payload := anthropicRequest{
+		Model:       p.cfg.Model,
+		System:      req.SystemPrompt,
+		Temperature: p.cfg.Temperature,
+		MaxTokens:   p.cfg.MaxTokens,
+	}
+	if payload.MaxTokens <= 0 {
+		payload.MaxTokens = anthropicDefaultMaxTokens
+	}
+	for _, msg := range req.Messages {
+		payload.Messages = appendAnthropicMessage(payload.Messages, msg)
+	}
+	for _, spec := range req.Tools {
+		payload.Tools = append(payload.Tools, anthropicTool{
+			Name:        spec.Name,
+			Description: spec.Description,
+			InputSchema: spec.Parameters,
+		})
+	}
+	prefill := ""
+	if req.JSONMode {
+		if payload.System != "" {
+			payload.System += "\n\n"
+		}
+		payload.System += "Respond with a single JSON object and nothing else."
+		if len(req.Tools) == 0 {
+			prefill = "{"
+			payload.Messages = append(payload.Messages, anthropicMessage{
+				Role:    string(RoleAssistant),
+				Content: []anthropicBlock{{Type: "text", Text: prefill}},
+			})
+		}
+	}
+
+	headers := map[string]string{
+		"x-api-key":         p.cfg.APIKey,
+		"anthropic-version": anthropicVersion,
+	}
+	body, err := p.http.postJSON(ctx, p.baseURL+"/v1/messages", headers, payload, anthropicError)
+	if err != nil {
+		return ChatResponse{}, err
+	}
+
+	var resp anthropicResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return ChatResponse{}, fmt.Errorf("parse Anthropic response: %w", err)
+	}
+
+	ret := ChatResponse{
+		Truncated: resp.StopReason == "max_tokens",
+		Usage: Usage{
+			PromptTokens:     resp.Usage.InputTokens,
+			CompletionTokens: resp.Usage.OutputTokens,
+		},
+	}
+	for _, block := range resp.Content {
+		switch block.Type {
+		case "text":
+			ret.Content += block.Text
+		case "tool_use":
+			ret.ToolCalls = append(ret.ToolCalls, ToolCall{
+				ID:        block.ID,
+				Name:      block.Name,
+				Arguments: toolArguments(block.Input),
+			})
+		}
+	}
+	if prefill != "" && len(ret.ToolCalls) == 0 {
+		ret.Content = prefill + ret.Content
+	}
+	return ret, nil
+}
+
+// appendAnthropicMessage converts msg to content blocks. Tool results are user
+// messages in the messages API; consecutive results share one message.
+func appendAnthropicMessage(messages []anthropicMessage, msg Message) []anthropicMessage {
+	switch msg.Role {
+	case RoleTool:
+		block := anthropicBlock{
+			Type:      "tool_result",
+			ToolUseID: msg.ToolCallID,
+			Content:   msg.Content,
+			IsError:   msg.IsError,
+		}
+		if n := len(messages); n > 0 && messages[n-1].Role == string(RoleUser) && messages[n-1].Content[0].Type == "tool_result" {
+			messages[n-1].Content = append(messages[n-1].Content, block)
+			return messages
+		}
+		return append(messages, anthropicMessage{Role: string(RoleUser), Content: []anthropicBlock{block}})
+	case RoleAssistant:
+		converted := anthropicMessage{Role: string(RoleAssistant)}
+		if msg.Content != "" {
+			converted.Content = append(converted.Content, anthropicBlock{Type: "text", Text: msg.Content})
+		}
+		for _, call := range msg.ToolCalls {
+			converted.Content = append(converted.Content, anthropicBlock{
+				Type:  "tool_use",
+				ID:    call.ID,
+				Name:  call.Name,
+				Input: toolArguments(call.Arguments),
+			})
+		}
+		return append(messages, converted)
+	default:
+		return append(messages, anthropicMessage{
+			Role:    string(RoleUser),
+			Content: []anthropicBlock{{Type: "text", Text: msg.Content}},
+		})
+	}
+}
+
+func anthropicError(status int, body []byte) error {
+	var resp struct {
+		Error struct {
+			Type    string `json:"type"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(body, &resp); err == nil && resp.Error.Message != "" {
+		return fmt.Errorf("Anthropic API error %d: %s - %s", status, resp.Error.Type, resp.Error.Message)
+	}
+	return fmt.Errorf("Anthropic API error %d: %s", status, apiErrorMessage(status, body))
+}
@@ -0,0 +1,132 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// ollamaProvider talks to the native chat API of a local Ollama server.
+type ollamaProvider struct {
+	cfg     LLMConfig
+	baseURL string
+	http    httpClient
+}
+
+func newOllamaProvider(cfg LLMConfig, client httpClient) *ollamaProvider {
+	return &ollamaProvider{
+		cfg:     cfg,
+		baseURL: trimBaseURL(cfg.APIURL, "/api/chat", "/api", "/v1"),
+		http:    client,
+	}
+}
+
+type ollamaRequest struct {
+	Model    string          `json:"model"`
+	Messages []ollamaMessage `json:"messages"`
+	Tools    []openAITool    `json:"tools,omitempty"`
+	Format   string          `json:"format,omitempty"`
+	Stream   bool            `json:"stream"`
+	Options  ollamaOptions   `json:"options"`
+}
+
+type ollamaOptions struct {
+	Temperature float64 `json:"temperature"`
+	NumPredict  int     `json:"num_predict,omitempty"`
+}
+
+type ollamaMessage struct {
+	Role      string           `json:"role"`
+	Content   string           `json:"content"`
+	ToolCalls []ollamaToolCall `json:"tool_calls,omitempty"`
+	ToolName  string           `json:"tool_name,omitempty"`
+}
+
+type ollamaToolCall struct {
+	Function struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	} `json:"function"`
+}
+
+type ollamaResponse struct {
+	Message struct {
+		Content   string           `json:"content"`
+		ToolCalls []ollamaToolCall `json:"tool_calls"`
+	} `json:"message"`
+	DoneReason      string `json:"done_reason"`
+	PromptEvalCount int    `json:"prompt_eval_count"`
+	EvalCount       int    `json:"eval_count"`
+}
+
+// Complete sends req to /api/chat. Ollama does not assign tool call IDs, the
+// agent loop fills them in.
+func (p *ollamaProvider) Complete(ctx context.Context, req ChatRequest) (ChatResponse, error) {
+	payload := ollamaRequest{
+		Model: p.cfg.Model,
+		Options: ollamaOptions{
+			Temperature: p.cfg.Temperature,
+			NumPredict:  p.cfg.MaxTokens,
+		},
+	}
+	if req.JSONMode {
+		payload.Format = "json"
+	}
+	if req.SystemPrompt != "" {
+		payload.Messages = append(payload.Messages, ollamaMessage{Role: "system", Content: req.SystemPrompt})
+	}
+	for _, msg := range req.Messages {
+		converted := ollamaMessage{Role: string(msg.Role), Content: msg.Content}
+		if msg.Role == RoleTool {
+			converted.ToolName = msg.ToolName
+		}
+		for _, call := range msg.ToolCalls {
+			var toolCall ollamaToolCall
+			toolCall.Function.Name = call.Name
+			toolCall.Function.Arguments = toolArguments(call.Arguments)
+			converted.ToolCalls = append(converted.ToolCalls, toolCall)
+		}
+		payload.Messages = append(payload.Messages, converted)
+	}
+	payload.Tools = openAITools(req.Tools)
+
+	headers := map[string]string{}
+	if p.cfg.APIKey != "" {
+		headers["Authorization"] = "Bearer " + p.cfg.APIKey
+	}
+	body, err := p.http.postJSON(ctx, p.baseURL+"/api/chat", headers, payload, ollamaError)
+	if err != nil {
+		return ChatResponse{}, err
+	}
+
+	var resp ollamaResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return ChatResponse{}, fmt.Errorf("parse Ollama response: %w", err)
+	}
+
+	ret := ChatResponse{
+		Content:   resp.Message.Content,
+		Truncated: resp.DoneReason == "length",
+		Usage: Usage{
+			PromptTokens:     resp.PromptEvalCount,
+			CompletionTokens: resp.EvalCount,
+		},
+	}
+	for _, call := range resp.Message.ToolCalls {
+		ret.ToolCalls = append(ret.ToolCalls, ToolCall{
+			Name:      call.Function.Name,
+			Arguments: toolArguments(call.Function.Arguments),
+		})
+	}
+	return ret, nil
+}
+
+func ollamaError(status int, body []byte) error {
+	var resp struct {
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(body, &resp); err == nil && resp.Error != "" {
+		return fmt.Errorf("Ollama API error %d: %s", status, resp.Error)
+	}
+	return fmt.Errorf("Ollama API error %d: %s", status, apiErrorMessage(status, body))
+}
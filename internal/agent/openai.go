@@ -0,0 +1,167 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// openAIProvider talks to OpenAI-compatible chat completions endpoints:
+// OpenAI, OpenRouter, DeepSeek, llama.cpp's server and the like.
+type openAIProvider struct {
+	cfg     LLMConfig
+	baseURL string
+	http    httpClient
+}
+
+func newOpenAIProvider(cfg LLMConfig, client httpClient) *openAIProvider {
+	return &openAIProvider{
+		cfg:     cfg,
+		baseURL: trimBaseURL(cfg.APIURL, "/chat/completions", "/v1"),
+		http:    client,
+	}
+}
+
+type openAIRequest struct {
+	Model          string                `json:"model"`
+	Messages       []openAIMessage       `json:"messages"`
+	Tools          []openAITool          `json:"tools,omitempty"`
+	Temperature    float64               `json:"temperature"`
+	MaxTokens      int                   `json:"max_tokens,omitempty"`
+	ResponseFormat *openAIResponseFormat `json:"response_format,omitempty"`
+}
+
+type openAIMessage struct {
+	Role       string           `json:"role"`
+	Content    string           `json:"content"`
+	ToolCalls  []openAIToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string           `json:"tool_call_id,omitempty"`
+}
+
+type openAIToolCall struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
+
+type openAITool struct {
+	Type     string `json:"type"`
+	Function struct {
+		Name        string          `json:"name"`
+		Description string          `json:"description"`
+		Parameters  json.RawMessage `json:"parameters"`
+	} `json:"function"`
+}
+
+type openAIResponseFormat struct {
+	Type string `json:"type"`
+}
+
+type openAIResponse struct {
+	Choices []struct {
+		FinishReason string `json:"finish_reason"`
+		Message      struct {
+			Content   *string          `json:"content"`
+			ToolCalls []openAIToolCall `json:"tool_calls"`
+		} `json:"message"`
+	} `json:"choices"`
+	Usage struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage"`
+}
+
+// Complete sends req to /v1/chat/completions. JSON mode sets the json_object response format.
+func (p *openAIProvider) Complete(ctx context.Context, req ChatRequest) (ChatResponse, error) {
+	payload := openAIRequest{
+		Model:       p.cfg.Model,
+		Temperature: p.cfg.Temperature,
+		MaxTokens:   p.cfg.MaxTokens,
+	}
+	if req.JSONMode {
+		payload.ResponseFormat = &openAIResponseFormat{Type: "json_object"}
+	}
+	if req.SystemPrompt != "" {
+		payload.Messages = append(payload.Messages, openAIMessage{Role: "system", Content: req.SystemPrompt})
+	}
+	for _, msg := range req.Messages {
+		converted := openAIMessage{Role: string(msg.Role), Content: msg.Content, ToolCallID: msg.ToolCallID}
+		for _, call := range msg.ToolCalls {
+			var toolCall openAIToolCall
+			toolCall.ID = call.ID
+			toolCall.Type = "function"
+			toolCall.Function.Name = call.Name
+			toolCall.Function.Arguments = string(toolArguments(call.Arguments))
+			converted.ToolCalls = append(converted.ToolCalls, toolCall)
+		}
+		payload.Messages = append(payload.Messages, converted)
+	}
+	payload.Tools = openAITools(req.Tools)
+
+	headers := map[string]string{}
+	if p.cfg.APIKey != "" {
+		headers["Authorization"] = "Bearer " + p.cfg.APIKey
+	}
+	body, err := p.http.postJSON(ctx, p.baseURL+"/v1/chat/completions", headers, payload, openAIError)
+	if err != nil {
+		return ChatResponse{}, err
+	}
+
+	var resp openAIResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return ChatResponse{}, fmt.Errorf("parse OpenAI response: %w", err)
+	}
+	if len(resp.Choices) == 0 {
+		return ChatResponse{}, fmt.Errorf("OpenAI response has no choices")
+	}
+
+	choice := resp.Choices[0]
+	ret := ChatResponse{
+		Truncated: choice.FinishReason == "length",
+		Usage: Usage{
+			PromptTokens:     resp.Usage.PromptTokens,
+			CompletionTokens: resp.Usage.CompletionTokens,
+		},
+	}
+	if choice.Message.Content != nil {
+		ret.Content = *choice.Message.Content
+	}
+	for _, call := range choice.Message.ToolCalls {
+		ret.ToolCalls = append(ret.ToolCalls, ToolCall{
+			ID:        call.ID,
+			Name:      call.Function.Name,
+			Arguments: toolArguments(json.RawMessage(call.Function.Arguments)),
+		})
+	}
+	return ret, nil
+}
+
+// openAITools converts tool specs to function tools, the format Ollama shares
+func openAITools(specs []ToolSpec) []openAITool {
+	var tools []openAITool
+	for _, spec := range specs {
+		var tool openAITool
+		tool.Type = "function"
+		tool.Function.Name = spec.Name
+		tool.Function.Description = spec.Description
+		tool.Function.Parameters = spec.Parameters
+		tools = append(tools, tool)
+	}
+	return tools
+}
+
+func openAIError(status int, body []byte) error {
+	var resp struct {
+		Error struct {
+			Type    string `json:"type"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(body, &resp); err == nil && resp.Error.Message != "" {
+		return fmt.Errorf("OpenAI API error %d: %s - %s", status, resp.Error.Type, resp.Error.Message)
+	}
+	return fmt.Errorf("OpenAI API error %d: %s", status, apiErrorMessage(status, body))
+}
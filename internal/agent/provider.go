@@ -0,0 +1,285 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ProviderType identifies the wire format of an LLM backend.
+type ProviderType string
+
+const (
+	// ProviderOpenAI speaks the OpenAI chat completions API, also served by
+	// OpenRouter, DeepSeek and llama.cpp's server
+	ProviderOpenAI ProviderType = "openai"
+	// ProviderAnthropic speaks the Anthropic messages API
+	ProviderAnthropic ProviderType = "anthropic"
+	// ProviderOllama speaks the native chat API of a local Ollama server
+	ProviderOllama ProviderType = "ollama"
+)
+
+// ParseProvider parses a provider name; empty selects ProviderOpenAI.
+func ParseProvider(raw string) (ProviderType, error) {
+	switch ProviderType(strings.ToLower(strings.TrimSpace(raw))) {
+	case "", ProviderOpenAI:
+		return ProviderOpenAI, nil
+	case ProviderAnthropic:
+		return ProviderAnthropic, nil
+	case ProviderOllama:
+		return ProviderOllama, nil
+	default:
+		return "", fmt.Errorf("unsupported LLM provider %q (want openai, anthropic or ollama)", raw)
+	}
+}
+
+// RequiresAPIKey reports whether the provider refuses requests without an API key.
+// Local servers run without one.
+func (p ProviderType) RequiresAPIKey() bool {
+	return p != ProviderOllama
+}
+
+// Role is the author of a chat message.
+type Role string
+
+const (
+	RoleUser      Role = "user"
+	RoleAssistant Role = "assistant"
+	RoleTool      Role = "tool"
+)
+
+// Message is one provider-neutral chat message.
+type Message struct {
+	Role    Role
+	Content string
+
+	// ToolCalls are the tools requested by an assistant message
+	ToolCalls []ToolCall
+
+	// ToolCallID, ToolName and IsError describe the call a tool message answers
+	ToolCallID string
+	ToolName   string
+	IsError    bool
+}
+
+// ToolCall is a tool invocation requested by the model.
+type ToolCall struct {
+	ID        string
+	Name      string
+	Arguments json.RawMessage
+}
+
+// ToolSpec describes a tool offered to the model.
+type ToolSpec struct {
+	Name        string
+	Description string
+	Parameters  json.RawMessage
+}
+
+// ChatRequest is one round trip to the model.
+type ChatRequest struct {
+	SystemPrompt string
+	Messages     []Message
+	Tools        []ToolSpec
+
+	// JSONMode asks the backend to constrain the answer to a JSON object
+	JSONMode bool
+}
+
+// ChatResponse is the answer of the model to a ChatRequest.
+type ChatResponse struct {
+	Content   string
+	ToolCalls []ToolCall
+
+	// Truncated is set when the answer stopped at the max tokens limit
+	Truncated bool
+
+	// Usage is the token count the backend reported for this round trip
+	Usage Usage
+}
+
+// Provider sends chat requests to one LLM backend. Implementations honour the
+// model, temperature, max tokens and timeout of the LLMConfig they were built from.
+type Provider interface {
+	Complete(ctx context.Context, req ChatRequest) (ChatResponse, error)
+}
+
+// NewProvider creates the provider adapter selected by cfg.Provider.
+func NewProvider(cfg LLMConfig) (Provider, error) {
+	providerType, err := ParseProvider(cfg.Provider)
+	if err != nil {
+		return nil, err
+	}
+	client := newHTTPClient(cfg.Timeout)
+	switch providerType {
+	case ProviderAnthropic:
+		return newAnthropicProvider(cfg, client), nil
+	case ProviderOllama:
+		return newOllamaProvider(cfg, client), nil
+	default:
+		return newOpenAIProvider(cfg, client), nil
+	}
+}
+
+const (
+	defaultTimeout     = 5 * time.Minute
+	defaultMaxAttempts = 5
+)
+
+// retryBackoff is the wait before retry attempt+1; replaced in tests
+var retryBackoff = func(attempt int) time.Duration {
+	return time.Duration(math.Pow(2, float64(attempt-1))) * 2 * time.Second
+}
+
+// httpClient posts JSON requests and retries rate limits, timeouts and server errors.
+type httpClient struct {
+	client      *http.Client
+	maxAttempts int
+}
+
+func newHTTPClient(timeoutSeconds int) httpClient {
+	timeout := defaultTimeout
+	if timeoutSeconds > 0 {
+		timeout = time.Duration(timeoutSeconds) * time.Second
+	}
+	return httpClient{
+		client:      &http.Client{Timeout: timeout},
+		maxAttempts: defaultMaxAttempts,
+	}
+}
+
+// postJSON posts payload to url and returns the response body. apiError turns
+// an error response into the error returned once retries are exhausted.
+func (c httpClient) postJSON(
+	ctx context.Context,
+	url string,
+	headers map[string]string,
+	payload any,
+	apiError func(status int, body []byte) error,
+) ([]byte, error) {
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+
+	var lastErr error
+	for attempt := 1; attempt <= c.maxAttempts; attempt++ {
+		body, status, err := c.do(ctx, url, headers, encoded)
+		if err == nil && status < 400 {
+			return body, nil
+		}
+		if err != nil {
+			lastErr = fmt.Errorf("LLM API request failed: %w", err)
+		} else {
+			lastErr = &APIError{StatusCode: status, Err: apiError(status, body)}
+		}
+		if attempt == c.maxAttempts || !shouldRetry(ctx, status, err) {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(retryBackoff(attempt)):
+		}
+	}
+	return nil, lastErr
+}
+
+func (c httpClient) do(ctx context.Context, url string, headers map[string]string, payload []byte) ([]byte, int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return nil, 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, resp.StatusCode, err
+	}
+	return body, resp.StatusCode, nil
+}
+
+func shouldRetry(ctx context.Context, status int, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if err != nil {
+		return !errors.Is(err, context.Canceled)
+	}
+	return retryableStatus(status)
+}
+
+func retryableStatus(status int) bool {
+	return status == http.StatusTooManyRequests || status == http.StatusRequestTimeout || status >= 500
+}
+
+// APIError is an error response of an LLM API.
+type APIError struct {
+	StatusCode int
+	Err        error
+}
+
+func (e *APIError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *APIError) Unwrap() error {
+	return e.Err
+}
+
+// IsRetryable reports whether err is a failure another model may not have:
+// a rate limit, a server error or a timeout.
+func IsRetryable(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return retryableStatus(apiErr.StatusCode)
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return errors.Is(err, context.DeadlineExceeded)
+}
+
+// apiErrorMessage returns the body of an error response, or the status text
+// when the body is empty
+func apiErrorMessage(status int, body []byte) string {
+	if msg := strings.TrimSpace(string(body)); msg != "" {
+		return msg
+	}
+	return http.StatusText(status)
+}
+
+// trimBaseURL strips trailing slashes and the given endpoint suffixes from a configured URL
+func trimBaseURL(raw string, suffixes ...string) string {
+	normalized := strings.TrimSpace(strings.TrimRight(strings.TrimSpace(raw), "/"))
+	for _, suffix := range suffixes {
+		normalized = strings.TrimSuffix(normalized, suffix)
+	}
+	return normalized
+}
+
+// toolArguments returns raw tool arguments, defaulting to an empty object
+func toolArguments(raw json.RawMessage) json.RawMessage {
+	if len(bytes.TrimSpace(raw)) == 0 {
+		return json.RawMessage("{}")
+	}
+	return raw
+}
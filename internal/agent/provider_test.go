@@ -0,0 +1,300 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/MimeLyc/contextual-sub-translator/internal/tools"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newWireServer serves path with reply, recording the decoded request bodies
+// and headers of every call.
+func newWireServer(
+	t *testing.T,
+	path string,
+	reply func(call int, w http.ResponseWriter),
+) (*httptest.Server, *[]map[string]any, *[]http.Header) {
+	t.Helper()
+
+	var bodies []map[string]any
+	var headers []http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != path {
+			http.NotFound(w, r)
+			return
+		}
+		raw, _ := io.ReadAll(r.Body)
+		var body map[string]any
+		require.NoError(t, json.Unmarshal(raw, &body))
+		bodies = append(bodies, body)
+		headers = append(headers, r.Header.Clone())
+
+		w.Header().Set("Content-Type", "application/json")
+		reply(len(bodies), w)
+	}))
+	t.Cleanup(server.Close)
+	return server, &bodies, &headers
+}
+
+func newEchoRegistry(t *testing.T) *tools.Registry {
+	t.Helper()
+	registry := tools.NewRegistry()
+	require.NoError(t, registry.Register(echoAdapterTool{}))
+	return registry
+}
+
+func TestParseProvider(t *testing.T) {
+	t.Parallel()
+
+	for raw, want := range map[string]ProviderType{
+		"":          ProviderOpenAI,
+		"openai":    ProviderOpenAI,
+		"Anthropic": ProviderAnthropic,
+		" ollama ":  ProviderOllama,
+	} {
+		got, err := ParseProvider(raw)
+		require.NoError(t, err, raw)
+		assert.Equal(t, want, got, raw)
+	}
+
+	_, err := ParseProvider("claude-cli")
+	require.Error(t, err)
+}
+
+func TestOpenAIProvider_HonoursConfig(t *testing.T) {
+	t.Parallel()
+
+	server, bodies, headers := newWireServer(t, "/v1/chat/completions", func(_ int, w http.ResponseWriter) {
+		_, _ = w.Write([]byte(`{"choices":[{"finish_reason":"stop","message":{"role":"assistant","content":"{\"ok\":true}"}}]}`))
+	})
+
+	a, err := NewLLMAgent(LLMConfig{
+		APIKey:      "test-key",
+		APIURL:      server.URL + "/v1",
+		Model:       "gpt-test",
+		MaxTokens:   512,
+		Temperature: 0.3,
+		Timeout:     10,
+	}, nil, 1)
+	require.NoError(t, err)
+
+	result, err := a.Execute(context.Background(), AgentRequest{
+		SystemPrompt: "system",
+		UserMessage:  "hello",
+		JSONMode:     true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, `{"ok":true}`, result.Content)
+
+	require.Len(t, *bodies, 1)
+	body := (*bodies)[0]
+	assert.Equal(t, "gpt-test", body["model"])
+	assert.InDelta(t, 0.3, body["temperature"], 1e-9)
+	assert.EqualValues(t, 512, body["max_tokens"])
+	assert.Equal(t, map[string]any{"type": "json_object"}, body["response_format"])
+	messages := body["messages"].([]any)
+	require.Len(t, messages, 2)
+	assert.Equal(t, "system", messages[0].(map[string]any)["role"])
+	assert.Equal(t, "Bearer test-key", (*headers)[0].Get("Authorization"))
+}
+
+func TestOpenAIProvider_TruncatedAnswerFails(t *testing.T) {
+	t.Parallel()
+
+	server, _, _ := newWireServer(t, "/v1/chat/completions", func(_ int, w http.ResponseWriter) {
+		_, _ = w.Write([]byte(`{"choices":[{"finish_reason":"length","message":{"role":"assistant","content":"[{\"ind"}}]}`))
+	})
+
+	a, err := NewLLMAgent(LLMConfig{APIKey: "test-key", APIURL: server.URL, Model: "gpt-test"}, nil, 1)
+	require.NoError(t, err)
+
+	_, err = a.Execute(context.Background(), AgentRequest{UserMessage: "hello"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "max tokens")
+}
+
+func TestAnthropicProvider_ToolCallingLoop(t *testing.T) {
+	t.Parallel()
+
+	server, bodies, headers := newWireServer(t, "/v1/messages", func(call int, w http.ResponseWriter) {
+		if call == 1 {
+			_, _ = w.Write([]byte(`{
+				"content":[
+					{"type":"text","text":"Let me check."},
+					{"type":"tool_use","id":"toolu_1","name":"echo","input":{"text":"a"}},
+					{"type":"tool_use","id":"toolu_2","name":"echo","input":{"text":"b"}}
+				],
+				"stop_reason":"tool_use"
+			}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"content":[{"type":"text","text":"done"}],"stop_reason":"end_turn"}`))
+	})
+
+	a, err := NewLLMAgent(LLMConfig{
+		Provider:    "anthropic",
+		APIKey:      "test-key",
+		APIURL:      server.URL,
+		Model:       "claude-test",
+		Temperature: 0.2,
+		Timeout:     10,
+	}, newEchoRegistry(t), 5)
+	require.NoError(t, err)
+
+	result, err := a.Execute(context.Background(), AgentRequest{SystemPrompt: "system", UserMessage: "hello"})
+	require.NoError(t, err)
+	assert.Equal(t, "done", result.Content)
+	assert.Equal(t, 2, result.Iterations)
+	require.Len(t, result.ToolCalls, 2)
+	assert.JSONEq(t, `{"text":"b"}`, result.ToolCalls[1].Result)
+
+	assert.Equal(t, "test-key", (*headers)[0].Get("x-api-key"))
+	assert.Equal(t, anthropicVersion, (*headers)[0].Get("anthropic-version"))
+
+	first := (*bodies)[0]
+	assert.Equal(t, "system", first["system"])
+	assert.EqualValues(t, anthropicDefaultMaxTokens, first["max_tokens"])
+	assert.InDelta(t, 0.2, first["temperature"], 1e-9)
+	tool := first["tools"].([]any)[0].(map[string]any)
+	assert.Equal(t, "echo", tool["name"])
+	assert.Contains(t, tool, "input_schema")
+
+	// Both tool results go back in one user message
+	messages := (*bodies)[1]["messages"].([]any)
+	require.Len(t, messages, 3)
+	results := messages[2].(map[string]any)
+	assert.Equal(t, "user", results["role"])
+	blocks := results["content"].([]any)
+	require.Len(t, blocks, 2)
+	assert.Equal(t, "tool_result", blocks[0].(map[string]any)["type"])
+	assert.Equal(t, "toolu_1", blocks[0].(map[string]any)["tool_use_id"])
+	assert.Equal(t, "toolu_2", blocks[1].(map[string]any)["tool_use_id"])
+}
+
+func TestAnthropicProvider_JSONModePrefillsObject(t *testing.T) {
+	t.Parallel()
+
+	server, bodies, _ := newWireServer(t, "/v1/messages", func(_ int, w http.ResponseWriter) {
+		_, _ = w.Write([]byte(`{"content":[{"type":"text","text":"\"ok\": true}"}],"stop_reason":"end_turn"}`))
+	})
+
+	a, err := NewLLMAgent(LLMConfig{
+		Provider:  "anthropic",
+		APIKey:    "test-key",
+		APIURL:    server.URL + "/v1/messages",
+		Model:     "claude-test",
+		MaxTokens: 300,
+	}, nil, 1)
+	require.NoError(t, err)
+
+	result, err := a.Execute(context.Background(), AgentRequest{UserMessage: "hello", JSONMode: true})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"ok": true}`, result.Content)
+
+	body := (*bodies)[0]
+	assert.EqualValues(t, 300, body["max_tokens"])
+	assert.Contains(t, body["system"], "JSON object")
+	messages := body["messages"].([]any)
+	require.Len(t, messages, 2)
+	assert.Equal(t, "assistant", messages[1].(map[string]any)["role"])
+}
+
+func TestAnthropicProvider_ErrorResponse(t *testing.T) {
+	t.Parallel()
+
+	server, _, _ := newWireServer(t, "/v1/messages", func(_ int, w http.ResponseWriter) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"type":"error","error":{"type":"invalid_request_error","message":"model not found"}}`))
+	})
+
+	a, err := NewLLMAgent(LLMConfig{Provider: "anthropic", APIKey: "test-key", APIURL: server.URL, Model: "nope"}, nil, 1)
+	require.NoError(t, err)
+
+	_, err = a.Execute(context.Background(), AgentRequest{UserMessage: "hello"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "model not found")
+}
+
+func TestOllamaProvider_ToolCallingLoop(t *testing.T) {
+	t.Parallel()
+
+	server, bodies, headers := newWireServer(t, "/api/chat", func(call int, w http.ResponseWriter) {
+		if call == 1 {
+			_, _ = w.Write([]byte(`{
+				"message":{"role":"assistant","content":"","tool_calls":[{"function":{"name":"echo","arguments":{"text":"hi"}}}]},
+				"done":true,"done_reason":"stop"
+			}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"message":{"role":"assistant","content":"{\"done\":true}"},"done":true,"done_reason":"stop"}`))
+	})
+
+	a, err := NewLLMAgent(LLMConfig{
+		Provider:    "ollama",
+		APIURL:      server.URL + "/api",
+		Model:       "qwen2.5:7b",
+		MaxTokens:   128,
+		Temperature: 0.1,
+	}, newEchoRegistry(t), 5)
+	require.NoError(t, err)
+
+	result, err := a.Execute(context.Background(), AgentRequest{UserMessage: "hello", JSONMode: true})
+	require.NoError(t, err)
+	assert.Equal(t, `{"done":true}`, result.Content)
+	require.Len(t, result.ToolCalls, 1)
+	assert.JSONEq(t, `{"text":"hi"}`, result.ToolCalls[0].Result)
+	assert.Empty(t, (*headers)[0].Get("Authorization"))
+
+	first := (*bodies)[0]
+	assert.Equal(t, "json", first["format"])
+	assert.Equal(t, false, first["stream"])
+	options := first["options"].(map[string]any)
+	assert.InDelta(t, 0.1, options["temperature"], 1e-9)
+	assert.EqualValues(t, 128, options["num_predict"])
+
+	messages := (*bodies)[1]["messages"].([]any)
+	require.Len(t, messages, 3)
+	toolMessage := messages[2].(map[string]any)
+	assert.Equal(t, "tool", toolMessage["role"])
+	assert.Equal(t, "echo", toolMessage["tool_name"])
+}
+
+func TestHTTPClient_RetriesRateLimits(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		_, _ = w.Write([]byte(`{"choices":[{"finish_reason":"stop","message":{"content":"ok"}}]}`))
+	}))
+	t.Cleanup(server.Close)
+
+	backoff := retryBackoff
+	retryBackoff = func(int) time.Duration { return time.Millisecond }
+	t.Cleanup(func() { retryBackoff = backoff })
+
+	a, err := NewLLMAgent(LLMConfig{APIKey: "test-key", APIURL: server.URL, Model: "gpt-test"}, nil, 1)
+	require.NoError(t, err)
+
+	result, err := a.Execute(context.Background(), AgentRequest{UserMessage: "hello"})
+	require.NoError(t, err)
+	assert.Equal(t, "ok", result.Content)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestHTTPClient_HonoursTimeout(t *testing.T) {
+	t.Parallel()
+
+	client := newHTTPClient(1)
+	assert.Equal(t, time.Second, client.client.Timeout)
+	assert.Equal(t, defaultTimeout, newHTTPClient(0).client.Timeout)
+}
@@ -0,0 +1,72 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+
+	projecttools "github.com/MimeLyc/contextual-sub-translator/internal/tools"
+	"github.com/MimeLyc/contextual-sub-translator/pkg/log"
+)
+
+// Route is an ordered fallback chain of agents, one per model of a job stage.
+type Route []*LLMAgent
+
+// NewRoute creates an agent per model, each configured like cfg otherwise.
+// No models routes to cfg.Model alone.
+func NewRoute(cfg LLMConfig, models []string, registry *projecttools.Registry, maxIterations int) (Route, error) {
+	if len(models) == 0 {
+		models = []string{cfg.Model}
+	}
+	route := make(Route, 0, len(models))
+	for _, model := range models {
+		modelCfg := cfg
+		modelCfg.Model = model
+		llmAgent, err := NewLLMAgent(modelCfg, registry, maxIterations)
+		if err != nil {
+			return nil, fmt.Errorf("model %q: %w", model, err)
+		}
+		route = append(route, llmAgent)
+	}
+	return route, nil
+}
+
+// Execute runs req on the first agent of the route. When it fails with a rate
+// limit, a server error or a timeout, the next agent takes over.
+func (r Route) Execute(ctx context.Context, req AgentRequest) (*AgentResult, error) {
+	if len(r) == 0 {
+		return nil, fmt.Errorf("agent is not initialized")
+	}
+
+	var lastErr error
+	for i, llmAgent := range r {
+		result, err := llmAgent.Execute(ctx, req)
+		if err == nil {
+			return result, nil
+		}
+		lastErr = err
+		if i == len(r)-1 || ctx.Err() != nil || !IsRetryable(err) {
+			break
+		}
+		log.Warn("Model %s failed: %v; falling back to %s", llmAgent.Model(), err, r[i+1].Model())
+	}
+	return nil, lastErr
+}
+
+// Models returns the models of the route in order.
+func (r Route) Models() []string {
+	models := make([]string, 0, len(r))
+	for _, llmAgent := range r {
+		models = append(models, llmAgent.Model())
+	}
+	return models
+}
+
+// Close releases the agents of the route.
+func (r Route) Close() error {
+	for _, llmAgent := range r {
+		if err := llmAgent.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
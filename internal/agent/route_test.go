@@ -0,0 +1,91 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newModelServer answers chat completions per model: with status when it is
+// not 200, with a reply naming the model otherwise
+func newModelServer(t *testing.T, statuses map[string]int, calls *[]string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw, _ := io.ReadAll(r.Body)
+		var body struct {
+			Model string `json:"model"`
+		}
+		require.NoError(t, json.Unmarshal(raw, &body))
+		*calls = append(*calls, body.Model)
+
+		if status := statuses[body.Model]; status != 0 && status != http.StatusOK {
+			w.WriteHeader(status)
+			_, _ = w.Write([]byte(`{"error":{"type":"server_error","message":"` + http.StatusText(status) + `"}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"choices":[{"finish_reason":"stop","message":{"content":"answered by ` + body.Model + `"}}]}`))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestRoute_FallsBackOnRetryableErrors(t *testing.T) {
+	backoff := retryBackoff
+	retryBackoff = func(int) time.Duration { return time.Millisecond }
+	t.Cleanup(func() { retryBackoff = backoff })
+
+	var calls []string
+	server := newModelServer(t, map[string]int{"strong": http.StatusServiceUnavailable}, &calls)
+
+	route, err := NewRoute(LLMConfig{APIKey: "test-key", APIURL: server.URL, Model: "default"}, []string{"strong", "cheap"}, nil, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"strong", "cheap"}, route.Models())
+
+	result, err := route.Execute(context.Background(), AgentRequest{UserMessage: "hello"})
+	require.NoError(t, err)
+	assert.Equal(t, "answered by cheap", result.Content)
+	assert.Equal(t, []string{"strong", "strong", "strong", "strong", "strong", "cheap"}, calls)
+}
+
+func TestRoute_StopsOnClientErrors(t *testing.T) {
+	t.Parallel()
+
+	var calls []string
+	server := newModelServer(t, map[string]int{"strong": http.StatusBadRequest}, &calls)
+
+	route, err := NewRoute(LLMConfig{APIKey: "test-key", APIURL: server.URL, Model: "default"}, []string{"strong", "cheap"}, nil, 1)
+	require.NoError(t, err)
+
+	_, err = route.Execute(context.Background(), AgentRequest{UserMessage: "hello"})
+	require.Error(t, err)
+	assert.False(t, IsRetryable(err))
+	assert.Equal(t, []string{"strong"}, calls)
+}
+
+func TestNewRoute_DefaultsToConfiguredModel(t *testing.T) {
+	t.Parallel()
+
+	route, err := NewRoute(LLMConfig{APIKey: "test-key", APIURL: "https://example.com", Model: "default"}, nil, nil, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"default"}, route.Models())
+
+	_, err = Route{}.Execute(context.Background(), AgentRequest{})
+	require.Error(t, err)
+}
+
+func TestIsRetryable(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, IsRetryable(&APIError{StatusCode: http.StatusTooManyRequests, Err: assert.AnError}))
+	assert.True(t, IsRetryable(&APIError{StatusCode: http.StatusBadGateway, Err: assert.AnError}))
+	assert.False(t, IsRetryable(&APIError{StatusCode: http.StatusUnauthorized, Err: assert.AnError}))
+	assert.True(t, IsRetryable(context.DeadlineExceeded))
+	assert.False(t, IsRetryable(context.Canceled))
+}
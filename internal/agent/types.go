@@ -11,6 +11,9 @@ type AgentRequest struct {
 	// MaxIterations is the maximum number of tool-calling iterations
 	// Default: 10
 	MaxIterations int
+
+	// JSONMode asks the provider to constrain the final answer to a JSON object
+	JSONMode bool
 }
 
 // AgentResult represents the result from an agent execution
@@ -23,6 +26,23 @@ type AgentResult struct {
 
 	// Iterations is the number of LLM calls made
 	Iterations int
+
+	// Usage sums the tokens of all LLM calls made
+	Usage Usage
+}
+
+// Usage counts the tokens of one or more LLM calls
+type Usage struct {
+	PromptTokens     int
+	CompletionTokens int
+}
+
+// Add returns the sum of u and other
+func (u Usage) Add(other Usage) Usage {
+	return Usage{
+		PromptTokens:     u.PromptTokens + other.PromptTokens,
+		CompletionTokens: u.CompletionTokens + other.CompletionTokens,
+	}
 }
 
 // ToolCallRecord records a single tool call and its result
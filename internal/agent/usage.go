@@ -0,0 +1,34 @@
+package agent
+
+import "context"
+
+// UsageRecord is the token usage of one LLM call.
+type UsageRecord struct {
+	Provider string
+	Model    string
+	Usage    Usage
+}
+
+// UsageRecorder receives the usage of every LLM call made under a context.
+type UsageRecorder interface {
+	RecordUsage(ctx context.Context, record UsageRecord)
+}
+
+type usageRecorderContextKey struct{}
+
+// WithUsageRecorder returns a context under which LLMAgent reports the usage
+// of each call, tool iterations and retries included, to rec.
+func WithUsageRecorder(ctx context.Context, rec UsageRecorder) context.Context {
+	if rec == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, usageRecorderContextKey{}, rec)
+}
+
+func usageRecorderFromContext(ctx context.Context) UsageRecorder {
+	if ctx == nil {
+		return nil
+	}
+	rec, _ := ctx.Value(usageRecorderContextKey{}).(UsageRecorder)
+	return rec
+}
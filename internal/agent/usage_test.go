@@ -0,0 +1,96 @@
+package agent
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type usageSink struct {
+	mu      sync.Mutex
+	records []UsageRecord
+}
+
+func (s *usageSink) RecordUsage(_ context.Context, record UsageRecord) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records = append(s.records, record)
+}
+
+func TestLLMAgent_RecordsUsageOfEveryCall(t *testing.T) {
+	t.Parallel()
+
+	server, _, _ := newWireServer(t, "/v1/chat/completions", func(call int, w http.ResponseWriter) {
+		if call == 1 {
+			_, _ = w.Write([]byte(`{
+				"choices":[{"finish_reason":"tool_calls","message":{"role":"assistant","content":null,
+					"tool_calls":[{"id":"c1","type":"function","function":{"name":"echo","arguments":"{\"text\":\"a\"}"}}]}}],
+				"usage":{"prompt_tokens":100,"completion_tokens":10}
+			}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{
+			"choices":[{"finish_reason":"stop","message":{"role":"assistant","content":"done"}}],
+			"usage":{"prompt_tokens":150,"completion_tokens":20}
+		}`))
+	})
+
+	a, err := NewLLMAgent(LLMConfig{APIKey: "test-key", APIURL: server.URL, Model: "gpt-test"}, newEchoRegistry(t), 5)
+	require.NoError(t, err)
+
+	sink := &usageSink{}
+	result, err := a.Execute(WithUsageRecorder(context.Background(), sink), AgentRequest{UserMessage: "hello"})
+	require.NoError(t, err)
+	assert.Equal(t, Usage{PromptTokens: 250, CompletionTokens: 30}, result.Usage)
+
+	require.Len(t, sink.records, 2)
+	assert.Equal(t, UsageRecord{Provider: "openai", Model: "gpt-test", Usage: Usage{PromptTokens: 100, CompletionTokens: 10}}, sink.records[0])
+	assert.Equal(t, Usage{PromptTokens: 150, CompletionTokens: 20}, sink.records[1].Usage)
+}
+
+func TestProviders_ParseUsage(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		provider string
+		path     string
+		reply    string
+	}{
+		{
+			name:     "anthropic",
+			provider: "anthropic",
+			path:     "/v1/messages",
+			reply:    `{"content":[{"type":"text","text":"ok"}],"stop_reason":"end_turn","usage":{"input_tokens":42,"output_tokens":7}}`,
+		},
+		{
+			name:     "ollama",
+			provider: "ollama",
+			path:     "/api/chat",
+			reply:    `{"message":{"role":"assistant","content":"ok"},"done":true,"done_reason":"stop","prompt_eval_count":42,"eval_count":7}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			server, _, _ := newWireServer(t, tt.path, func(_ int, w http.ResponseWriter) {
+				_, _ = w.Write([]byte(tt.reply))
+			})
+			a, err := NewLLMAgent(LLMConfig{Provider: tt.provider, APIKey: "test-key", APIURL: server.URL, Model: "m"}, nil, 1)
+			require.NoError(t, err)
+
+			sink := &usageSink{}
+			result, err := a.Execute(WithUsageRecorder(context.Background(), sink), AgentRequest{UserMessage: "hello"})
+			require.NoError(t, err)
+			assert.Equal(t, Usage{PromptTokens: 42, CompletionTokens: 7}, result.Usage)
+			require.Len(t, sink.records, 1)
+			assert.Equal(t, tt.provider, sink.records[0].Provider)
+		})
+	}
+}
@@ -4,8 +4,16 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
+	"strings"
 
+	"github.com/MimeLyc/contextual-sub-translator/internal/agent"
+	"github.com/MimeLyc/contextual-sub-translator/internal/media"
+	"github.com/MimeLyc/contextual-sub-translator/internal/reading"
+	"github.com/MimeLyc/contextual-sub-translator/internal/songs"
+	"github.com/MimeLyc/contextual-sub-translator/internal/subtitle"
+	"github.com/MimeLyc/contextual-sub-translator/internal/translator"
 	"github.com/MimeLyc/contextual-sub-translator/pkg/log"
 	"golang.org/x/text/language"
 )
@@ -16,12 +24,15 @@ import (
 //
 // Environment Variables:
 // LLM Configuration:
-// - LLM_API_KEY: API key for the LLM provider (required)
+// - LLM_PROVIDER: Wire format of the LLM API: openai, anthropic or ollama (default: openai)
+// - LLM_API_KEY: API key for the LLM provider (required except for ollama)
 // - LLM_API_URL: API endpoint URL (default: https://openrouter.ai/api/v1)
 // - LLM_MODEL: Model name to use (default: openai/gpt-3.5-turbo)
 // - LLM_MAX_TOKENS: Maximum tokens for responses (default: 8000)
 // - LLM_TEMPERATURE: Temperature for responses (default: 0.7)
 // - LLM_TIMEOUT: Request timeout in seconds (default: 30)
+// - LLM_MODELS_TRANSLATE, LLM_MODELS_REPAIR, LLM_MODELS_TERMS, LLM_MODELS_CONTEXT:
+//   comma separated models of a stage in fallback order (default: LLM_MODEL)
 //
 // Media Directory Configuration:
 // - MOVIE_DIR: Movie directory (default: /movies)
@@ -29,6 +40,8 @@ import (
 // - TELEPLAY_DIR: Teleplay directory (default: /teleplays)
 // - SHOW_DIR: Show directory (default: /shows)
 // - DOCUMENTARY_DIR: Documentary directory (default: /documentaries)
+// - MUX_POLICY: off, copy or in_place for all sources, or per source like
+//   "tvshows=in_place,movies=copy" (default: off)
 //
 // System Configuration:
 // - PUID: User ID (default: 1000)
@@ -38,12 +51,19 @@ import (
 // - LOG_LEVEL: Log verbosity level (default: INFO)
 //
 // Translate Configuration:
+// - TARGET_LANGUAGES: comma separated target languages, e.g. zh-Hans,zh-Hant,en (default: zh)
 // - CRON_EXPR: Cron expression (default: 0 0 * * *)
+// - OUTPUT_MODE: translated, bilingual, bilingual_original_first or bilingual_styled (default: translated)
+// - OUTPUT_ENCODING: character encoding of written subtitles, e.g. UTF-8, GBK, Big5,
+//   Shift_JIS, UTF-16, or "source" to keep the encoding of the source subtitle (default: UTF-8)
 //
 // Search Configuration:
 // - SEARCH_API_KEY: Tavily API key (optional)
 // - SEARCH_API_URL: Tavily API URL (default: https://api.tavily.com/search)
 //
+// OCR Configuration:
+// - OCR_COMMAND: tesseract binary used for image subtitles (default: tesseract)
+//
 // Agent Configuration:
 // - AGENT_MAX_ITERATIONS: Max tool iterations per request (default: 10)
 // - AGENT_BUNDLE_CONCURRENCY: Parallel bundle workers (default: 1)
@@ -64,16 +84,48 @@ type Config struct {
 	// Search Configuration (for web search tool)
 	Search SearchConfig `json:"search"`
 
+	// OCR Configuration (for image-based subtitle streams)
+	OCR OCRConfig `json:"ocr"`
+
 	// Agent Configuration
 	Agent AgentConfig `json:"agent"`
 
+	// Budget Configuration (spend caps on LLM usage)
+	Budget BudgetConfig `json:"budget"`
+
 	// HTTP/UI Configuration
 	HTTP HTTPConfig `json:"http"`
 }
 
 type TranslateConfig struct {
+	// TargetLanguage is the first of TargetLanguages; jobs without a language
+	// of their own translate to it
 	TargetLanguage language.Tag `json:"target_language"`
-	CronExpr       string       `json:"cron_expr"`
+	// TargetLanguages are all languages media are translated to, one job per
+	// language a media file lacks
+	TargetLanguages []language.Tag      `json:"target_languages"`
+	CronExpr        string              `json:"cron_expr"`
+	OutputMode      subtitle.OutputMode `json:"output_mode"`
+	OutputEncoding  string              `json:"output_encoding"`
+	// SongMode is how songs are translated in series without a mode of their own
+	SongMode songs.Mode `json:"song_mode"`
+	// Reading holds the reading speed limits translated lines are checked against
+	Reading reading.Config `json:"reading"`
+	// Condense sends lines over a reading limit back to the model to be shortened
+	Condense bool `json:"condense"`
+	// Review has a second model call check each translated batch
+	Review bool `json:"review"`
+	// ReviewSeverity is the severity from which reviewed lines are translated again
+	ReviewSeverity translator.Severity `json:"review_severity"`
+	// Pivot is the language subtitles are first translated to before the
+	// target, e.g. English between Japanese and Portuguese; und translates
+	// directly
+	Pivot language.Tag `json:"pivot"`
+	// PivotOutput writes the pivot translation next to the media as well
+	PivotOutput bool `json:"pivot_output"`
+	// ScriptConversion derives a Chinese target from a subtitle in another
+	// variant of Chinese without the model, e.g. zh-Hant from zh-Hans
+	ScriptConversion bool `json:"script_conversion"`
 }
 
 // SearchConfig holds the configuration for web search tool
@@ -82,12 +134,53 @@ type SearchConfig struct {
 	APIURL string `json:"api_url"` // Tavily API URL
 }
 
+// OCRConfig holds the configuration for recognizing image-based subtitles
+type OCRConfig struct {
+	Command string `json:"command"` // tesseract binary
+}
+
 // AgentConfig holds the configuration for the agent
 type AgentConfig struct {
 	MaxIterations     int `json:"max_iterations"`     // Max tool calling iterations
 	BundleConcurrency int `json:"bundle_concurrency"` // Parallel bundle processing workers
 }
 
+// BudgetConfig caps LLM spend. Days and months are UTC calendar days and
+// months; the job and series caps count all usage of a job or series.
+type BudgetConfig struct {
+	Daily   BudgetCap `json:"daily"`
+	Monthly BudgetCap `json:"monthly"`
+	Job     BudgetCap `json:"job"`
+	Series  BudgetCap `json:"series"`
+}
+
+// BudgetCap limits tokens and cost in USD; zero leaves a limit unset.
+type BudgetCap struct {
+	Tokens int     `json:"tokens"`
+	Cost   float64 `json:"cost"`
+}
+
+// Validate rejects negative limits.
+func (b BudgetConfig) Validate() error {
+	for name, limit := range map[string]BudgetCap{"daily": b.Daily, "monthly": b.Monthly, "job": b.Job, "series": b.Series} {
+		if limit.Tokens < 0 || limit.Cost < 0 {
+			return fmt.Errorf("%s budget must not be negative", name)
+		}
+	}
+	return nil
+}
+
+// Reached describes the limit spend has reached, empty while under the cap.
+func (c BudgetCap) Reached(tokens int, cost float64) string {
+	if c.Tokens > 0 && tokens >= c.Tokens {
+		return fmt.Sprintf("%d of %d tokens used", tokens, c.Tokens)
+	}
+	if c.Cost > 0 && cost >= c.Cost {
+		return fmt.Sprintf("$%.2f of $%.2f spent", cost, c.Cost)
+	}
+	return ""
+}
+
 // HTTPConfig holds HTTP server and UI static hosting configuration
 type HTTPConfig struct {
 	Addr        string `json:"addr"`
@@ -96,14 +189,64 @@ type HTTPConfig struct {
 }
 
 // LLMConfig holds the configuration for LLM client
-// Supports any LLM provider (OpenRouter, OpenAI, Anthropic, etc.)
+// Supports OpenAI-compatible APIs (OpenAI, OpenRouter, llama.cpp, etc.),
+// the Anthropic messages API and local Ollama servers
 type LLMConfig struct {
+	Provider    string  `json:"provider"`
 	APIKey      string  `json:"api_key"`
 	APIURL      string  `json:"api_url"`
 	Model       string  `json:"model"`
 	MaxTokens   int     `json:"max_tokens"`
 	Temperature float64 `json:"temperature"`
 	Timeout     int     `json:"timeout"`
+	// StageModels maps LLM stages to their models in fallback order
+	StageModels map[string][]string `json:"stage_models"`
+	// Prices maps models to their price; "*" prices models without an entry
+	Prices map[string]ModelPrice `json:"prices"`
+}
+
+// ModelPrice is the price of a model in USD per million tokens
+type ModelPrice struct {
+	Prompt     float64 `json:"prompt"`
+	Completion float64 `json:"completion"`
+}
+
+// CostOf returns the cost in USD of a call to model, zero for unpriced models.
+func (c LLMConfig) CostOf(model string, promptTokens int, completionTokens int) float64 {
+	price, ok := c.Prices[model]
+	if !ok {
+		price = c.Prices["*"]
+	}
+	return (float64(promptTokens)*price.Prompt + float64(completionTokens)*price.Completion) / 1e6
+}
+
+// LLM stages that can run on their own models
+const (
+	// StageTranslate translates subtitle batches
+	StageTranslate = "translate"
+	// StageRepair retries a batch whose translation failed validation
+	StageRepair = "repair"
+	// StageTerms generates term maps and extracts terms from search results
+	StageTerms = "terms"
+	// StageContext attributes speakers and summarizes episodes
+	StageContext = "context"
+	// StageCondense shortens lines over a reading speed limit
+	StageCondense = "condense"
+	// StageReview checks translated batches for mistakes
+	StageReview = "review"
+)
+
+// ModelsFor returns the models of stage in fallback order. Stages without
+// models of their own run on Model, except repair, which returns nil to repair
+// on the model that translated the batch.
+func (c LLMConfig) ModelsFor(stage string) []string {
+	if models := c.StageModels[stage]; len(models) > 0 {
+		return models
+	}
+	if stage == StageRepair {
+		return nil
+	}
+	return []string{c.Model}
 }
 
 // MediaConfig holds the configuration for media directories
@@ -113,6 +256,49 @@ type MediaConfig struct {
 	TeleplayDir    string `json:"teleplay_dir"`
 	ShowDir        string `json:"show_dir"`
 	DocumentaryDir string `json:"documentary_dir"`
+	// MuxPolicy maps source IDs to the mux mode of translated subtitles,
+	// the "*" entry applies to sources without their own entry
+	MuxPolicy map[string]media.MuxMode `json:"mux_policy"`
+}
+
+// MediaSource is a configured media directory
+type MediaSource struct {
+	ID   string
+	Name string
+	Path string
+}
+
+// Sources returns the configured media directories with their source IDs
+func (c MediaConfig) Sources() []MediaSource {
+	return []MediaSource{
+		{ID: "movies", Name: "Movies", Path: c.MovieDir},
+		{ID: "animations", Name: "Animations", Path: c.AnimationDir},
+		{ID: "teleplays", Name: "Teleplays", Path: c.TeleplayDir},
+		{ID: "tvshows", Name: "TV Shows", Path: c.ShowDir},
+		{ID: "documentaries", Name: "Documentaries", Path: c.DocumentaryDir},
+	}
+}
+
+// MuxModeFor returns the mux mode of the source that contains mediaPath
+func (c MediaConfig) MuxModeFor(mediaPath string) media.MuxMode {
+	mediaPath = filepath.Clean(mediaPath)
+	for _, source := range c.Sources() {
+		if source.Path == "" {
+			continue
+		}
+		rel, err := filepath.Rel(filepath.Clean(source.Path), mediaPath)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		if mode, ok := c.MuxPolicy[source.ID]; ok {
+			return mode
+		}
+		break
+	}
+	if mode, ok := c.MuxPolicy["*"]; ok {
+		return mode
+	}
+	return media.MuxOff
 }
 
 func (c MediaConfig) MediaPaths() []string {
@@ -153,12 +339,15 @@ func NewFromEnv(opts ...Option) (*Config, error) {
 
 	config := &Config{
 		LLM: LLMConfig{
+			Provider:    getEnvString("LLM_PROVIDER", string(agent.ProviderOpenAI)),
 			APIKey:      getEnvString("LLM_API_KEY", ""),
 			APIURL:      getEnvString("LLM_API_URL", "https://openrouter.ai/api/v1"),
 			Model:       getEnvString("LLM_MODEL", "openai/gpt-3.5-turbo"),
 			MaxTokens:   getEnvInt("LLM_MAX_TOKENS", 8000),
 			Temperature: getEnvFloat("LLM_TEMPERATURE", 0.7),
 			Timeout:     getEnvInt("LLM_TIMEOUT", 30),
+			StageModels: getEnvStageModels(StageTranslate, StageRepair, StageTerms, StageContext, StageCondense, StageReview),
+			Prices:      getEnvPrices("LLM_PRICES"),
 		},
 		Media: MediaConfig{
 			MovieDir:       getEnvString("MOVIE_DIR", "/movies"),
@@ -166,6 +355,7 @@ func NewFromEnv(opts ...Option) (*Config, error) {
 			TeleplayDir:    getEnvString("TELEPLAY_DIR", "/teleplays"),
 			ShowDir:        getEnvString("SHOW_DIR", "/shows"),
 			DocumentaryDir: getEnvString("DOCUMENTARY_DIR", "/documentaries"),
+			MuxPolicy:      getEnvMuxPolicy("MUX_POLICY"),
 		},
 		System: SystemConfig{
 			PUID:    getEnvInt("PUID", 1000),
@@ -175,32 +365,57 @@ func NewFromEnv(opts ...Option) (*Config, error) {
 			DataDir: getEnvString("DATA_DIR", "/app/data"),
 		},
 		Translate: TranslateConfig{
-			//TODO: get from env
-			TargetLanguage: language.Chinese,
-			CronExpr:       getEnvString("CRON_EXPR", "0 0 * * *"),
+			TargetLanguages: getEnvLanguages("TARGET_LANGUAGES", []language.Tag{language.Chinese}),
+			CronExpr:        getEnvString("CRON_EXPR", "0 0 * * *"),
+			OutputMode:      getEnvOutputMode("OUTPUT_MODE", subtitle.OutputTranslated),
+			OutputEncoding:  getEnvEncoding("OUTPUT_ENCODING", subtitle.EncodingUTF8),
+			SongMode:        getEnvSongMode("SONG_MODE", songs.ModeLyrics),
+			Reading: reading.Config{
+				Latin: getEnvReadingLimits("READING_LATIN", reading.DefaultConfig().Latin),
+				CJK:   getEnvReadingLimits("READING_CJK", reading.DefaultConfig().CJK),
+			},
+			Condense:         getEnvBool("CONDENSE_ENABLED", true),
+			Review:           getEnvBool("REVIEW_ENABLED", false),
+			ReviewSeverity:   getEnvSeverity("REVIEW_RETRANSLATE_SEVERITY", translator.SeverityMajor),
+			Pivot:            getEnvLanguage("PIVOT_LANGUAGE", language.Und),
+			PivotOutput:      getEnvBool("PIVOT_OUTPUT", false),
+			ScriptConversion: getEnvBool("SCRIPT_CONVERSION_ENABLED", true),
 		},
 		Search: SearchConfig{
 			APIKey: getEnvString("SEARCH_API_KEY", ""),
 			APIURL: getEnvString("SEARCH_API_URL", "https://api.tavily.com/search"),
 		},
+		OCR: OCRConfig{
+			Command: getEnvString("OCR_COMMAND", "tesseract"),
+		},
 		Agent: AgentConfig{
 			MaxIterations:     getEnvInt("AGENT_MAX_ITERATIONS", 10),
 			BundleConcurrency: getEnvInt("AGENT_BUNDLE_CONCURRENCY", 1),
 		},
+		Budget: BudgetConfig{
+			Daily:   getEnvBudgetCap("BUDGET_DAILY"),
+			Monthly: getEnvBudgetCap("BUDGET_MONTHLY"),
+			Job:     getEnvBudgetCap("BUDGET_JOB"),
+			Series:  getEnvBudgetCap("BUDGET_SERIES"),
+		},
 		HTTP: HTTPConfig{
 			Addr:        getEnvString("HTTP_ADDR", ":8080"),
 			UIStaticDir: getEnvString("UI_STATIC_DIR", "/app/web"),
 			UIEnabled:   getEnvBool("UI_ENABLE", true),
 		},
 	}
+	config.Translate.TargetLanguage = config.Translate.TargetLanguages[0]
 
 	log.Debug(
-		"Config loaded: llm_api_url=%s llm_model=%s llm_timeout=%d search_enabled=%t cron_expr=%s agent_max_iterations=%d agent_bundle_concurrency=%d http_addr=%s ui_enabled=%t ui_static_dir=%s",
+		"Config loaded: llm_provider=%s llm_api_url=%s llm_model=%s llm_timeout=%d search_enabled=%t cron_expr=%s output_mode=%s output_encoding=%s agent_max_iterations=%d agent_bundle_concurrency=%d http_addr=%s ui_enabled=%t ui_static_dir=%s",
+		config.LLM.Provider,
 		config.LLM.APIURL,
 		config.LLM.Model,
 		config.LLM.Timeout,
 		config.Search.APIKey != "",
 		config.Translate.CronExpr,
+		config.Translate.OutputMode,
+		config.Translate.OutputEncoding,
 		config.Agent.MaxIterations,
 		config.Agent.BundleConcurrency,
 		config.HTTP.Addr,
@@ -231,9 +446,16 @@ func (c Config) DBPath() string {
 
 // validate checks if all required configuration is properly set
 func (c *Config) validate() error {
-	if c.LLM.APIKey == "" {
+	provider, err := agent.ParseProvider(c.LLM.Provider)
+	if err != nil {
+		return fmt.Errorf("invalid LLM_PROVIDER: %w", err)
+	}
+	if c.LLM.APIKey == "" && provider.RequiresAPIKey() {
 		return fmt.Errorf("LLM_API_KEY is required")
 	}
+	if err := c.Budget.Validate(); err != nil {
+		return fmt.Errorf("invalid BUDGET_*: %w", err)
+	}
 	return nil
 }
 
@@ -265,6 +487,14 @@ func getEnvFloat(key string, defaultValue float64) float64 {
 	return defaultValue
 }
 
+// getEnvBudgetCap reads a budget cap from <prefix>_TOKENS and <prefix>_COST
+func getEnvBudgetCap(prefix string) BudgetCap {
+	return BudgetCap{
+		Tokens: getEnvInt(prefix+"_TOKENS", 0),
+		Cost:   getEnvFloat(prefix+"_COST", 0),
+	}
+}
+
 // getEnvBool gets a boolean value from environment variables with default
 func getEnvBool(key string, defaultValue bool) bool {
 	if value := os.Getenv(key); value != "" {
@@ -274,3 +504,191 @@ func getEnvBool(key string, defaultValue bool) bool {
 	}
 	return defaultValue
 }
+
+// getEnvOutputMode gets a subtitle output mode from environment variables with default
+func getEnvOutputMode(key string, defaultValue subtitle.OutputMode) subtitle.OutputMode {
+	if value := os.Getenv(key); value != "" {
+		if mode, err := subtitle.ParseOutputMode(value); err == nil {
+			return mode
+		}
+	}
+	return defaultValue
+}
+
+// getEnvReadingLimits reads <prefix>_MAX_CPS and <prefix>_MAX_LINE_LENGTH
+func getEnvReadingLimits(prefix string, defaultValue reading.Limits) reading.Limits {
+	return reading.Limits{
+		MaxCPS:        getEnvFloat(prefix+"_MAX_CPS", defaultValue.MaxCPS),
+		MaxLineLength: getEnvInt(prefix+"_MAX_LINE_LENGTH", defaultValue.MaxLineLength),
+	}
+}
+
+// getEnvSongMode gets a song mode from environment variables with default
+func getEnvSongMode(key string, defaultValue songs.Mode) songs.Mode {
+	if value := os.Getenv(key); value != "" {
+		if mode, err := songs.ParseMode(value); err == nil {
+			return mode
+		}
+	}
+	return defaultValue
+}
+
+// getEnvLanguages reads a comma separated list of language tags from
+// environment variables. Invalid and repeated tags are dropped; an empty list
+// falls back to the default.
+func getEnvLanguages(key string, defaultValue []language.Tag) []language.Tag {
+	var ret []language.Tag
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if tag, err := language.Parse(strings.TrimSpace(value)); err == nil && !slices.Contains(ret, tag) {
+			ret = append(ret, tag)
+		}
+	}
+	if len(ret) == 0 {
+		return defaultValue
+	}
+	return ret
+}
+
+// getEnvLanguage gets a language tag from environment variables with default
+func getEnvLanguage(key string, defaultValue language.Tag) language.Tag {
+	if value := os.Getenv(key); value != "" {
+		if tag, err := language.Parse(strings.TrimSpace(value)); err == nil {
+			return tag
+		}
+	}
+	return defaultValue
+}
+
+// ParseLanguages parses language tags in order, skipping empty and repeated
+// ones.
+func ParseLanguages(values []string) ([]language.Tag, error) {
+	var ret []language.Tag
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		tag, err := language.Parse(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid language %q: %w", value, err)
+		}
+		if !slices.Contains(ret, tag) {
+			ret = append(ret, tag)
+		}
+	}
+	return ret, nil
+}
+
+// Targets returns the languages media are translated to, TargetLanguage
+// alone when no list is set
+func (c TranslateConfig) Targets() []language.Tag {
+	if len(c.TargetLanguages) > 0 {
+		return c.TargetLanguages
+	}
+	return []language.Tag{c.TargetLanguage}
+}
+
+// getEnvSeverity gets a review severity from environment variables with default
+func getEnvSeverity(key string, defaultValue translator.Severity) translator.Severity {
+	if value := os.Getenv(key); value != "" {
+		if severity, err := translator.ParseSeverity(value); err == nil {
+			return severity
+		}
+	}
+	return defaultValue
+}
+
+// getEnvEncoding gets a subtitle output encoding from environment variables with default
+func getEnvEncoding(key string, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		if encoding, err := subtitle.ParseEncoding(value); err == nil {
+			return encoding
+		}
+	}
+	return defaultValue
+}
+
+// getEnvMuxPolicy parses a mux policy from environment variables. A bare mode
+// applies to all sources, "source=mode" pairs apply to single sources.
+// Entries with an invalid mode are ignored.
+func getEnvMuxPolicy(key string) map[string]media.MuxMode {
+	return parseMuxPolicy(os.Getenv(key))
+}
+
+func parseMuxPolicy(raw string) map[string]media.MuxMode {
+	ret := make(map[string]media.MuxMode)
+	for _, entry := range strings.Split(raw, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		source, value, ok := strings.Cut(entry, "=")
+		if !ok {
+			source, value = "*", entry
+		}
+		mode, err := media.ParseMuxMode(value)
+		if err != nil {
+			log.Warn("Ignoring mux policy entry %q: %v", entry, err)
+			continue
+		}
+		ret[strings.TrimSpace(source)] = mode
+	}
+	return ret
+}
+
+// getEnvStageModels reads the models of each stage from LLM_MODELS_<STAGE>
+func getEnvStageModels(stages ...string) map[string][]string {
+	ret := make(map[string][]string)
+	for _, stage := range stages {
+		var models []string
+		for _, model := range strings.Split(os.Getenv("LLM_MODELS_"+strings.ToUpper(stage)), ",") {
+			if model = strings.TrimSpace(model); model != "" {
+				models = append(models, model)
+			}
+		}
+		if len(models) > 0 {
+			ret[stage] = models
+		}
+	}
+	return ret
+}
+
+// getEnvPrices parses a price table from environment variables. Entries are
+// "model=prompt/completion" in USD per million tokens; the model "*" prices
+// all models without an entry. Invalid entries are ignored.
+func getEnvPrices(key string) map[string]ModelPrice {
+	return parsePrices(os.Getenv(key))
+}
+
+func parsePrices(raw string) map[string]ModelPrice {
+	ret := make(map[string]ModelPrice)
+	for _, entry := range strings.Split(raw, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		// Model names may contain "/" but not "=", so split at the last "="
+		idx := strings.LastIndex(entry, "=")
+		if idx <= 0 {
+			log.Warn("Ignoring price entry %q: want model=prompt/completion", entry)
+			continue
+		}
+		prompt, completion, ok := strings.Cut(entry[idx+1:], "/")
+		if !ok {
+			log.Warn("Ignoring price entry %q: want model=prompt/completion", entry)
+			continue
+		}
+		promptPrice, err := strconv.ParseFloat(strings.TrimSpace(prompt), 64)
+		if err != nil || promptPrice < 0 {
+			log.Warn("Ignoring price entry %q: invalid prompt price", entry)
+			continue
+		}
+		completionPrice, err := strconv.ParseFloat(strings.TrimSpace(completion), 64)
+		if err != nil || completionPrice < 0 {
+			log.Warn("Ignoring price entry %q: invalid completion price", entry)
+			continue
+		}
+		ret[strings.TrimSpace(entry[:idx])] = ModelPrice{Prompt: promptPrice, Completion: completionPrice}
+	}
+	return ret
+}
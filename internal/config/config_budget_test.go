@@ -0,0 +1,56 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewFromEnv_Budget(t *testing.T) {
+	t.Setenv("LLM_API_KEY", "test-key")
+	t.Setenv("BUDGET_DAILY_COST", "2.5")
+	t.Setenv("BUDGET_MONTHLY_TOKENS", "5000000")
+	t.Setenv("BUDGET_JOB_TOKENS", "200000")
+
+	cfg, err := NewFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, BudgetConfig{
+		Daily:   BudgetCap{Cost: 2.5},
+		Monthly: BudgetCap{Tokens: 5000000},
+		Job:     BudgetCap{Tokens: 200000},
+	}, cfg.Budget)
+
+	t.Setenv("BUDGET_SERIES_COST", "-1")
+	_, err = NewFromEnv()
+	require.Error(t, err)
+}
+
+func TestBudgetCap_Reached(t *testing.T) {
+	assert.Empty(t, BudgetCap{}.Reached(1e9, 1e6), "zero caps are unlimited")
+	assert.Empty(t, BudgetCap{Tokens: 1000, Cost: 1}.Reached(999, 0.99))
+	assert.Equal(t, "1000 of 1000 tokens used", BudgetCap{Tokens: 1000}.Reached(1000, 0))
+	assert.Equal(t, "$1.20 of $1.00 spent", BudgetCap{Tokens: 1000, Cost: 1}.Reached(10, 1.2))
+}
+
+func TestRuntimeSettings_Budget(t *testing.T) {
+	cfg := &Config{}
+	WithRuntimeSettings(RuntimeSettings{Budget: &BudgetConfig{Daily: BudgetCap{Cost: 3}}})(cfg)
+	assert.Equal(t, BudgetCap{Cost: 3}, cfg.Budget.Daily)
+	assert.Equal(t, &cfg.Budget, cfg.RuntimeSettings().Budget)
+
+	WithRuntimeSettings(RuntimeSettings{})(cfg)
+	assert.Equal(t, BudgetCap{Cost: 3}, cfg.Budget.Daily, "settings without a budget keep the caps")
+
+	settings := RuntimeSettings{
+		LLMAPIURL:      "https://example.test/v1",
+		LLMAPIKey:      "ak-test",
+		LLMModel:       "model-test",
+		CronExpr:       "*/5 * * * *",
+		TargetLanguage: "zh",
+		Budget:         &BudgetConfig{Job: BudgetCap{Tokens: 100}},
+	}
+	require.NoError(t, settings.Validate())
+	settings.Budget.Job.Tokens = -5
+	require.Error(t, settings.Validate())
+}
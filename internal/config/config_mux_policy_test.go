@@ -0,0 +1,37 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/MimeLyc/contextual-sub-translator/internal/media"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewFromEnv_MuxPolicy(t *testing.T) {
+	t.Setenv("LLM_API_KEY", "test-key")
+	t.Setenv("SHOW_DIR", "/data/shows")
+	t.Setenv("MOVIE_DIR", "/data/movies")
+	t.Setenv("ANIMATION_DIR", "/data/anime")
+	t.Setenv("MUX_POLICY", "copy, tvshows=in_place, movies=replace")
+
+	cfg, err := NewFromEnv()
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]media.MuxMode{
+		"*":       media.MuxCopy,
+		"tvshows": media.MuxInPlace,
+	}, cfg.Media.MuxPolicy)
+	assert.Equal(t, media.MuxInPlace, cfg.Media.MuxModeFor("/data/shows/Show/S01/ep01.mkv"))
+	assert.Equal(t, media.MuxCopy, cfg.Media.MuxModeFor("/data/anime/ep01.mkv"))
+	assert.Equal(t, media.MuxCopy, cfg.Media.MuxModeFor("/data/movies/film.mkv"), "invalid entries fall back to the default")
+	assert.Equal(t, media.MuxCopy, cfg.Media.MuxModeFor("/data/shows-archive/ep01.mkv"))
+}
+
+func TestMediaConfig_MuxModeForDefaultsToOff(t *testing.T) {
+	cfg := MediaConfig{ShowDir: "/data/shows"}
+	assert.Equal(t, media.MuxOff, cfg.MuxModeFor("/data/shows/ep01.mkv"))
+
+	cfg.MuxPolicy = map[string]media.MuxMode{"movies": media.MuxCopy}
+	assert.Equal(t, media.MuxOff, cfg.MuxModeFor("/data/shows/ep01.mkv"))
+}
@@ -0,0 +1,28 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/MimeLyc/contextual-sub-translator/internal/subtitle"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewFromEnv_OutputEncoding(t *testing.T) {
+	t.Setenv("LLM_API_KEY", "test-key")
+
+	t.Setenv("OUTPUT_ENCODING", "")
+	cfg, err := NewFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, subtitle.EncodingUTF8, cfg.Translate.OutputEncoding)
+
+	t.Setenv("OUTPUT_ENCODING", "gbk")
+	cfg, err = NewFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, subtitle.EncodingGB18030, cfg.Translate.OutputEncoding)
+
+	t.Setenv("OUTPUT_ENCODING", "latin-2")
+	cfg, err = NewFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, subtitle.EncodingUTF8, cfg.Translate.OutputEncoding, "unsupported encodings fall back to UTF-8")
+}
@@ -0,0 +1,30 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/language"
+)
+
+func TestNewFromEnv_Pivot(t *testing.T) {
+	t.Setenv("LLM_API_KEY", "test-key")
+
+	cfg, err := NewFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, language.Und, cfg.Translate.Pivot)
+	assert.False(t, cfg.Translate.PivotOutput)
+
+	t.Setenv("PIVOT_LANGUAGE", "en")
+	t.Setenv("PIVOT_OUTPUT", "true")
+	cfg, err = NewFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, language.English, cfg.Translate.Pivot)
+	assert.True(t, cfg.Translate.PivotOutput)
+
+	t.Setenv("PIVOT_LANGUAGE", "not a language")
+	cfg, err = NewFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, language.Und, cfg.Translate.Pivot, "invalid values fall back to the default")
+}
@@ -0,0 +1,32 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePrices(t *testing.T) {
+	prices := parsePrices(" openai/gpt-4o-mini=0.15/0.6, qwen2.5:7b=0/0, *=1/2, broken=1, bad=x/1")
+
+	assert.Equal(t, map[string]ModelPrice{
+		"openai/gpt-4o-mini": {Prompt: 0.15, Completion: 0.6},
+		"qwen2.5:7b":         {},
+		"*":                  {Prompt: 1, Completion: 2},
+	}, prices)
+}
+
+func TestLLMConfig_CostOf(t *testing.T) {
+	t.Setenv("LLM_API_KEY", "test-key")
+	t.Setenv("LLM_PRICES", "gpt-a=2/8")
+
+	cfg, err := NewFromEnv()
+	require.NoError(t, err)
+
+	assert.InDelta(t, 0.0036, cfg.LLM.CostOf("gpt-a", 1000, 200), 1e-12)
+	assert.Zero(t, cfg.LLM.CostOf("gpt-b", 1000, 200), "unpriced models are free")
+
+	cfg.LLM.Prices["*"] = ModelPrice{Prompt: 1, Completion: 1}
+	assert.InDelta(t, 0.0012, cfg.LLM.CostOf("gpt-b", 1000, 200), 1e-12)
+}
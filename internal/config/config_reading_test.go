@@ -0,0 +1,31 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/MimeLyc/contextual-sub-translator/internal/reading"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewFromEnv_Reading(t *testing.T) {
+	t.Setenv("LLM_API_KEY", "test-key")
+
+	cfg, err := NewFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, reading.DefaultConfig(), cfg.Translate.Reading)
+	assert.True(t, cfg.Translate.Condense)
+	assert.Equal(t, []string{cfg.LLM.Model}, cfg.LLM.ModelsFor(StageCondense))
+
+	t.Setenv("READING_CJK_MAX_CPS", "11.5")
+	t.Setenv("READING_CJK_MAX_LINE_LENGTH", "0")
+	t.Setenv("READING_LATIN_MAX_LINE_LENGTH", "wide")
+	t.Setenv("CONDENSE_ENABLED", "false")
+	t.Setenv("LLM_MODELS_CONDENSE", "small-model")
+	cfg, err = NewFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, reading.Limits{MaxCPS: 11.5}, cfg.Translate.Reading.CJK)
+	assert.Equal(t, reading.DefaultConfig().Latin, cfg.Translate.Reading.Latin, "invalid values fall back to the default")
+	assert.False(t, cfg.Translate.Condense)
+	assert.Equal(t, []string{"small-model"}, cfg.LLM.ModelsFor(StageCondense))
+}
@@ -0,0 +1,33 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/MimeLyc/contextual-sub-translator/internal/translator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewFromEnv_Review(t *testing.T) {
+	t.Setenv("LLM_API_KEY", "test-key")
+
+	cfg, err := NewFromEnv()
+	require.NoError(t, err)
+	assert.False(t, cfg.Translate.Review)
+	assert.Equal(t, translator.SeverityMajor, cfg.Translate.ReviewSeverity)
+	assert.Equal(t, []string{cfg.LLM.Model}, cfg.LLM.ModelsFor(StageReview))
+
+	t.Setenv("REVIEW_ENABLED", "true")
+	t.Setenv("REVIEW_RETRANSLATE_SEVERITY", "Critical")
+	t.Setenv("LLM_MODELS_REVIEW", "strict-model, backup-model")
+	cfg, err = NewFromEnv()
+	require.NoError(t, err)
+	assert.True(t, cfg.Translate.Review)
+	assert.Equal(t, translator.SeverityCritical, cfg.Translate.ReviewSeverity)
+	assert.Equal(t, []string{"strict-model", "backup-model"}, cfg.LLM.ModelsFor(StageReview))
+
+	t.Setenv("REVIEW_RETRANSLATE_SEVERITY", "fatal")
+	cfg, err = NewFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, translator.SeverityMajor, cfg.Translate.ReviewSeverity, "invalid values fall back to the default")
+}
@@ -0,0 +1,21 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewFromEnv_ScriptConversion(t *testing.T) {
+	t.Setenv("LLM_API_KEY", "test-key")
+
+	cfg, err := NewFromEnv()
+	require.NoError(t, err)
+	assert.True(t, cfg.Translate.ScriptConversion)
+
+	t.Setenv("SCRIPT_CONVERSION_ENABLED", "false")
+	cfg, err = NewFromEnv()
+	require.NoError(t, err)
+	assert.False(t, cfg.Translate.ScriptConversion)
+}
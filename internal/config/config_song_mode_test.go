@@ -0,0 +1,28 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/MimeLyc/contextual-sub-translator/internal/songs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewFromEnv_SongMode(t *testing.T) {
+	t.Setenv("LLM_API_KEY", "test-key")
+
+	t.Setenv("SONG_MODE", "")
+	cfg, err := NewFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, songs.ModeLyrics, cfg.Translate.SongMode)
+
+	t.Setenv("SONG_MODE", "Original")
+	cfg, err = NewFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, songs.ModeOriginal, cfg.Translate.SongMode)
+
+	t.Setenv("SONG_MODE", "karaoke")
+	cfg, err = NewFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, songs.ModeLyrics, cfg.Translate.SongMode, "unsupported modes fall back to lyrics")
+}
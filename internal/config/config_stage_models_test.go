@@ -0,0 +1,26 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewFromEnv_StageModels(t *testing.T) {
+	t.Setenv("LLM_API_KEY", "test-key")
+	t.Setenv("LLM_MODEL", "default-model")
+	t.Setenv("LLM_MODELS_TRANSLATE", "strong-model, backup-model,")
+	t.Setenv("LLM_MODELS_TERMS", "cheap-model")
+
+	cfg, err := NewFromEnv()
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"strong-model", "backup-model"}, cfg.LLM.ModelsFor(StageTranslate))
+	assert.Equal(t, []string{"cheap-model"}, cfg.LLM.ModelsFor(StageTerms))
+	assert.Equal(t, []string{"default-model"}, cfg.LLM.ModelsFor(StageContext))
+	assert.Nil(t, cfg.LLM.ModelsFor(StageRepair), "repair runs on the translate models by default")
+
+	cfg.LLM.StageModels[StageRepair] = []string{"repair-model"}
+	assert.Equal(t, []string{"repair-model"}, cfg.LLM.ModelsFor(StageRepair))
+}
@@ -0,0 +1,40 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/language"
+)
+
+func TestNewFromEnv_TargetLanguages(t *testing.T) {
+	t.Setenv("LLM_API_KEY", "test-key")
+
+	cfg, err := NewFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, language.Chinese, cfg.Translate.TargetLanguage)
+	assert.Equal(t, []language.Tag{language.Chinese}, cfg.Translate.Targets())
+
+	t.Setenv("TARGET_LANGUAGES", "zh-Hans, zh-Hant,,en, zh-Hans")
+	cfg, err = NewFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, language.SimplifiedChinese, cfg.Translate.TargetLanguage)
+	assert.Equal(t, []language.Tag{language.SimplifiedChinese, language.TraditionalChinese, language.English}, cfg.Translate.Targets())
+
+	t.Setenv("TARGET_LANGUAGES", "not a language")
+	cfg, err = NewFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, []language.Tag{language.Chinese}, cfg.Translate.Targets(), "invalid values fall back to the default")
+}
+
+func TestParseLanguages(t *testing.T) {
+	t.Parallel()
+
+	tags, err := ParseLanguages([]string{" en", "", "ja", "en"})
+	require.NoError(t, err)
+	assert.Equal(t, []language.Tag{language.English, language.Japanese}, tags)
+
+	_, err = ParseLanguages([]string{"en", "not a language"})
+	require.Error(t, err)
+}
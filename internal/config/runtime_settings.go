@@ -8,6 +8,8 @@ import (
 	"strings"
 	"sync"
 
+	"github.com/MimeLyc/contextual-sub-translator/internal/agent"
+	"github.com/MimeLyc/contextual-sub-translator/internal/subtitle"
 	"github.com/robfig/cron/v3"
 	"golang.org/x/text/language"
 )
@@ -15,11 +17,19 @@ import (
 const DefaultRuntimeSettingsFile = "/app/config/settings.json"
 
 type RuntimeSettings struct {
+	// LLMProvider is openai, anthropic or ollama; empty means openai
+	LLMProvider    string `json:"llm_provider,omitempty"`
 	LLMAPIURL      string `json:"llm_api_url"`
 	LLMAPIKey      string `json:"llm_api_key"`
 	LLMModel       string `json:"llm_model"`
 	CronExpr       string `json:"cron_expr"`
 	TargetLanguage string `json:"target_language"`
+	// TargetLanguages lists every target language; empty means TargetLanguage
+	// alone. When set, its first language replaces TargetLanguage
+	TargetLanguages []string `json:"target_languages,omitempty"`
+	OutputMode      string   `json:"output_mode"`
+	// Budget replaces the BUDGET_* caps when set
+	Budget *BudgetConfig `json:"budget,omitempty"`
 }
 
 func RuntimeSettingsFilePath() string {
@@ -27,10 +37,14 @@ func RuntimeSettingsFilePath() string {
 }
 
 func (s RuntimeSettings) Validate() error {
+	provider, err := agent.ParseProvider(s.LLMProvider)
+	if err != nil {
+		return fmt.Errorf("invalid llm_provider: %w", err)
+	}
 	if strings.TrimSpace(s.LLMAPIURL) == "" {
 		return fmt.Errorf("llm_api_url is required")
 	}
-	if strings.TrimSpace(s.LLMAPIKey) == "" {
+	if strings.TrimSpace(s.LLMAPIKey) == "" && provider.RequiresAPIKey() {
 		return fmt.Errorf("llm_api_key is required")
 	}
 	if strings.TrimSpace(s.LLMModel) == "" {
@@ -42,27 +56,67 @@ func (s RuntimeSettings) Validate() error {
 	if _, err := cron.ParseStandard(s.CronExpr); err != nil {
 		return fmt.Errorf("invalid cron_expr: %w", err)
 	}
-	if strings.TrimSpace(s.TargetLanguage) == "" {
-		return fmt.Errorf("target_language is required")
+	if _, err := s.Languages(); err != nil {
+		return err
 	}
-	if _, err := language.Parse(s.TargetLanguage); err != nil {
-		return fmt.Errorf("invalid target_language: %w", err)
+	if _, err := subtitle.ParseOutputMode(s.OutputMode); err != nil {
+		return fmt.Errorf("invalid output_mode: %w", err)
+	}
+	if s.Budget != nil {
+		if err := s.Budget.Validate(); err != nil {
+			return fmt.Errorf("invalid budget: %w", err)
+		}
 	}
 	return nil
 }
 
+// Languages returns the target languages of the settings, the first being
+// the primary one.
+func (s RuntimeSettings) Languages() ([]language.Tag, error) {
+	if len(s.TargetLanguages) == 0 {
+		if strings.TrimSpace(s.TargetLanguage) == "" {
+			return nil, fmt.Errorf("target_language is required")
+		}
+		tag, err := language.Parse(s.TargetLanguage)
+		if err != nil {
+			return nil, fmt.Errorf("invalid target_language: %w", err)
+		}
+		return []language.Tag{tag}, nil
+	}
+	tags, err := ParseLanguages(s.TargetLanguages)
+	if err != nil {
+		return nil, fmt.Errorf("invalid target_languages: %w", err)
+	}
+	if len(tags) == 0 {
+		return nil, fmt.Errorf("target_languages must not be empty")
+	}
+	return tags, nil
+}
+
 func (c *Config) RuntimeSettings() RuntimeSettings {
+	budget := c.Budget
+	targets := make([]string, 0, len(c.Translate.Targets()))
+	for _, tag := range c.Translate.Targets() {
+		targets = append(targets, tag.String())
+	}
 	return RuntimeSettings{
-		LLMAPIURL:      c.LLM.APIURL,
-		LLMAPIKey:      c.LLM.APIKey,
-		LLMModel:       c.LLM.Model,
-		CronExpr:       c.Translate.CronExpr,
-		TargetLanguage: c.Translate.TargetLanguage.String(),
+		LLMProvider:     c.LLM.Provider,
+		LLMAPIURL:       c.LLM.APIURL,
+		LLMAPIKey:       c.LLM.APIKey,
+		LLMModel:        c.LLM.Model,
+		CronExpr:        c.Translate.CronExpr,
+		TargetLanguage:  targets[0],
+		TargetLanguages: targets,
+		OutputMode:      string(c.Translate.OutputMode),
+		Budget:          &budget,
 	}
 }
 
 func WithRuntimeSettings(settings RuntimeSettings) Option {
 	return func(c *Config) {
+		if strings.TrimSpace(settings.LLMProvider) != "" {
+			c.LLM.Provider = settings.LLMProvider
+		}
 		if strings.TrimSpace(settings.LLMAPIURL) != "" {
 			c.LLM.APIURL = settings.LLMAPIURL
 		}
@@ -75,8 +129,17 @@ func WithRuntimeSettings(settings RuntimeSettings) Option {
 		if strings.TrimSpace(settings.CronExpr) != "" {
 			c.Translate.CronExpr = settings.CronExpr
 		}
-		if tag, err := language.Parse(settings.TargetLanguage); err == nil {
-			c.Translate.TargetLanguage = tag
+		if tags, err := settings.Languages(); err == nil {
+			c.Translate.TargetLanguage = tags[0]
+			c.Translate.TargetLanguages = tags
+		}
+		if strings.TrimSpace(settings.OutputMode) != "" {
+			if mode, err := subtitle.ParseOutputMode(settings.OutputMode); err == nil {
+				c.Translate.OutputMode = mode
+			}
+		}
+		if settings.Budget != nil {
+			c.Budget = *settings.Budget
 		}
 	}
 }
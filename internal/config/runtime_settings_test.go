@@ -5,8 +5,10 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/MimeLyc/contextual-sub-translator/internal/subtitle"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/text/language"
 )
 
 func TestRuntimeSettings_Validate(t *testing.T) {
@@ -26,6 +28,35 @@ func TestRuntimeSettings_Validate(t *testing.T) {
 	invalidLang := valid
 	invalidLang.TargetLanguage = ""
 	require.Error(t, invalidLang.Validate())
+
+	several := valid
+	several.TargetLanguages = []string{"zh-Hans", "zh-Hant", "en"}
+	require.NoError(t, several.Validate())
+
+	invalidList := valid
+	invalidList.TargetLanguages = []string{"zh", "not a language"}
+	require.Error(t, invalidList.Validate())
+
+	bilingual := valid
+	bilingual.OutputMode = "bilingual_original_first"
+	require.NoError(t, bilingual.Validate())
+
+	invalidMode := valid
+	invalidMode.OutputMode = "side_by_side"
+	require.Error(t, invalidMode.Validate())
+
+	local := valid
+	local.LLMProvider = "ollama"
+	local.LLMAPIKey = ""
+	require.NoError(t, local.Validate())
+
+	keyless := local
+	keyless.LLMProvider = "anthropic"
+	require.Error(t, keyless.Validate())
+
+	invalidProvider := valid
+	invalidProvider.LLMProvider = "palm"
+	require.Error(t, invalidProvider.Validate())
 }
 
 func TestRuntimeSettingsFile_RoundTrip(t *testing.T) {
@@ -62,6 +93,7 @@ func TestWithRuntimeSettings_OverridesConfig(t *testing.T) {
 		LLMModel:       "file-model",
 		CronExpr:       "*/30 * * * *",
 		TargetLanguage: "ja",
+		OutputMode:     "bilingual",
 	}
 
 	cfg, err := NewFromEnv(WithRuntimeSettings(override))
@@ -71,6 +103,16 @@ func TestWithRuntimeSettings_OverridesConfig(t *testing.T) {
 	assert.Equal(t, override.LLMModel, cfg.LLM.Model)
 	assert.Equal(t, override.CronExpr, cfg.Translate.CronExpr)
 	assert.Equal(t, "ja", cfg.Translate.TargetLanguage.String())
+	assert.Equal(t, subtitle.OutputBilingual, cfg.Translate.OutputMode)
+	assert.Equal(t, []language.Tag{language.Japanese}, cfg.Translate.Targets())
+
+	override.TargetLanguages = []string{"zh-Hant", "en"}
+	cfg, err = NewFromEnv(WithRuntimeSettings(override))
+	require.NoError(t, err)
+	assert.Equal(t, language.TraditionalChinese, cfg.Translate.TargetLanguage)
+	assert.Equal(t, []language.Tag{language.TraditionalChinese, language.English}, cfg.Translate.Targets())
+	assert.Equal(t, []string{"zh-Hant", "en"}, cfg.RuntimeSettings().TargetLanguages)
+	assert.Equal(t, "zh-Hant", cfg.RuntimeSettings().TargetLanguage)
 }
 
 func TestRuntimeSettingsStore_UpdatePersistsFile(t *testing.T) {
@@ -0,0 +1,154 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/MimeLyc/contextual-sub-translator/internal/persistence"
+)
+
+// characterProfileStore keeps the character profiles the translator uses for
+// pronouns and politeness levels, keyed by series directory
+type characterProfileStore interface {
+	ListCharacterProfiles(ctx context.Context, seriesKey string) ([]persistence.CharacterProfile, error)
+	PutCharacterProfile(ctx context.Context, profile persistence.CharacterProfile) error
+	DeleteCharacterProfile(ctx context.Context, seriesKey string, name string) (bool, error)
+}
+
+func WithCharacterProfileStore(store characterProfileStore) Option {
+	return func(s *Server) {
+		s.characters = store
+	}
+}
+
+var characterGenders = map[string]bool{"": true, "male": true, "female": true, "unknown": true}
+
+type characterProfileResponse struct {
+	Name      string    `json:"name"`
+	Gender    string    `json:"gender"`
+	Formality string    `json:"formality"`
+	Nicknames []string  `json:"nicknames"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type characterListResponse struct {
+	Series     string                     `json:"series"`
+	Characters []characterProfileResponse `json:"characters"`
+}
+
+type updateCharacterRequest struct {
+	Series    string   `json:"series"`
+	Name      string   `json:"name"`
+	Gender    string   `json:"gender"`
+	Formality string   `json:"formality"`
+	Nicknames []string `json:"nicknames"`
+}
+
+// handleCharacters serves the character profiles of a series. The series is
+// its directory, e.g. the path of a library item:
+//
+//	GET    /api/characters?series={dir}
+//	PUT    /api/characters                         body: updateCharacterRequest
+//	DELETE /api/characters?series={dir}&name={name}
+func (s *Server) handleCharacters(w http.ResponseWriter, r *http.Request) {
+	if s.characters == nil {
+		writeError(w, http.StatusNotImplemented, "character profile store is not configured")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		series := cleanSeriesKey(r.URL.Query().Get("series"))
+		if series == "" {
+			writeError(w, http.StatusBadRequest, "series is required")
+			return
+		}
+		profiles, err := s.characters.ListCharacterProfiles(r.Context(), series)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		resp := characterListResponse{Series: series, Characters: make([]characterProfileResponse, 0, len(profiles))}
+		for _, profile := range profiles {
+			resp.Characters = append(resp.Characters, newCharacterProfileResponse(profile))
+		}
+		writeJSON(w, http.StatusOK, resp)
+	case http.MethodPut:
+		var req updateCharacterRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid json body")
+			return
+		}
+		profile := persistence.CharacterProfile{
+			SeriesKey: cleanSeriesKey(req.Series),
+			Name:      strings.TrimSpace(req.Name),
+			Gender:    strings.ToLower(strings.TrimSpace(req.Gender)),
+			Formality: strings.TrimSpace(req.Formality),
+			UpdatedAt: time.Now().UTC(),
+		}
+		if profile.SeriesKey == "" || profile.Name == "" {
+			writeError(w, http.StatusBadRequest, "series and name are required")
+			return
+		}
+		if !characterGenders[profile.Gender] {
+			writeError(w, http.StatusBadRequest, "gender must be male, female or unknown")
+			return
+		}
+		for _, nickname := range req.Nicknames {
+			if nickname = strings.TrimSpace(nickname); nickname != "" {
+				profile.Nicknames = append(profile.Nicknames, nickname)
+			}
+		}
+		if err := s.characters.PutCharacterProfile(r.Context(), profile); err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, newCharacterProfileResponse(profile))
+	case http.MethodDelete:
+		series := cleanSeriesKey(r.URL.Query().Get("series"))
+		name := strings.TrimSpace(r.URL.Query().Get("name"))
+		if series == "" || name == "" {
+			writeError(w, http.StatusBadRequest, "series and name are required")
+			return
+		}
+		deleted, err := s.characters.DeleteCharacterProfile(r.Context(), series, name)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if !deleted {
+			writeError(w, http.StatusNotFound, "character not found")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+func newCharacterProfileResponse(profile persistence.CharacterProfile) characterProfileResponse {
+	nicknames := profile.Nicknames
+	if nicknames == nil {
+		nicknames = []string{}
+	}
+	return characterProfileResponse{
+		Name:      profile.Name,
+		Gender:    profile.Gender,
+		Formality: profile.Formality,
+		Nicknames: nicknames,
+		UpdatedAt: profile.UpdatedAt,
+	}
+}
+
+// cleanSeriesKey normalizes a series directory the way the translator keys it
+func cleanSeriesKey(raw string) string {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return ""
+	}
+	return filepath.Clean(raw)
+}
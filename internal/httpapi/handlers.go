@@ -2,6 +2,7 @@ package httpapi
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/url"
 	"strings"
@@ -9,6 +10,8 @@ import (
 	"github.com/MimeLyc/contextual-sub-translator/internal/config"
 	"github.com/MimeLyc/contextual-sub-translator/internal/jobs"
 	"github.com/MimeLyc/contextual-sub-translator/internal/library"
+	"github.com/MimeLyc/contextual-sub-translator/internal/subtitle"
+	"golang.org/x/text/language"
 )
 
 func (s *Server) handleListSources(w http.ResponseWriter, r *http.Request) {
@@ -101,14 +104,16 @@ func (s *Server) handleListEpisodesByItem(w http.ResponseWriter, r *http.Request
 		ret = append(ret, item)
 	}
 	writeJSON(w, http.StatusOK, episodesListResponse{
-		TargetLanguage: s.scanner.TargetLanguage(),
-		Episodes:       ret,
+		TargetLanguage:  s.scanner.TargetLanguage(),
+		TargetLanguages: s.scanner.TargetLanguages(),
+		Episodes:        ret,
 	})
 }
 
 type episodesListResponse struct {
-	TargetLanguage string            `json:"target_language"`
-	Episodes       []episodeResponse `json:"episodes"`
+	TargetLanguage  string            `json:"target_language"`
+	TargetLanguages []string          `json:"target_languages"`
+	Episodes        []episodeResponse `json:"episodes"`
 }
 
 type episodeResponse struct {
@@ -161,6 +166,14 @@ type enqueueJobRequest struct {
 	MediaPath    string `json:"media_path"`
 	SubtitlePath string `json:"subtitle_path"`
 	NFOPath      string `json:"nfo_path"`
+	OutputMode   string `json:"output_mode"`
+	// TargetLanguage is the language to translate to; empty queues one job
+	// per configured target language the media has no subtitle in
+	TargetLanguage string `json:"target_language"`
+	// StreamIndex and StreamPolicy choose the embedded subtitle stream when
+	// no subtitle_path is given
+	StreamIndex  *int                   `json:"stream_index"`
+	StreamPolicy *subtitle.StreamPolicy `json:"stream_policy"`
 }
 
 func (s *Server) handleJobs(w http.ResponseWriter, r *http.Request) {
@@ -180,36 +193,103 @@ func (s *Server) handleJobs(w http.ResponseWriter, r *http.Request) {
 			writeError(w, http.StatusBadRequest, "media_path is required")
 			return
 		}
-		if req.DedupeKey == "" {
-			keySuffix := req.SubtitlePath
-			if keySuffix == "" {
-				keySuffix = "[embedded]"
+		if req.StreamIndex != nil && *req.StreamIndex < 0 {
+			writeError(w, http.StatusBadRequest, "stream_index must not be negative")
+			return
+		}
+		var targets []string
+		if strings.TrimSpace(req.TargetLanguage) != "" {
+			tag, err := language.Parse(strings.TrimSpace(req.TargetLanguage))
+			if err != nil {
+				writeError(w, http.StatusBadRequest, "invalid target_language")
+				return
+			}
+			targets = []string{tag.String()}
+		} else {
+			// only the languages the media has no subtitle in, as a scan would
+			missing, err := s.scanner.MissingTargets(req.MediaPath)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, fmt.Sprintf("failed to look up subtitles of %s: %v", req.MediaPath, err))
+				return
+			}
+			if len(missing) == 0 {
+				writeJSON(w, http.StatusOK, map[string]any{
+					"created": false,
+					"job":     nil,
+					"jobs":    []*jobs.TranslationJob{},
+				})
+				return
 			}
-			req.DedupeKey = req.MediaPath + "|" + keySuffix
+			targets = missing
+		}
+		outputMode, err := s.resolveOutputMode(req.OutputMode)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
 		}
 
-		job, created := s.queue.Enqueue(jobs.EnqueueRequest{
-			Source:    req.Source,
-			DedupeKey: req.DedupeKey,
-			Payload: jobs.JobPayload{
-				MediaFile:    req.MediaPath,
-				SubtitleFile: req.SubtitlePath,
-				NFOFile:      req.NFOPath,
-			},
-		})
+		// one job per target language, told apart by the dedupe key
+		var created bool
+		queued := make([]*jobs.TranslationJob, 0, len(targets))
+		for _, target := range targets {
+			dedupeKey := req.DedupeKey
+			if dedupeKey == "" {
+				keySuffix := req.SubtitlePath
+				if keySuffix == "" {
+					keySuffix = "[embedded]"
+					if req.StreamIndex != nil {
+						keySuffix = fmt.Sprintf("[embedded:s:%d]", *req.StreamIndex)
+					}
+				}
+				dedupeKey = req.MediaPath + "|" + keySuffix + "|" + target
+			} else if len(targets) > 1 {
+				dedupeKey += "|" + target
+			}
+			job, jobCreated := s.queue.Enqueue(jobs.EnqueueRequest{
+				Source:    req.Source,
+				DedupeKey: dedupeKey,
+				Payload: jobs.JobPayload{
+					MediaFile:      req.MediaPath,
+					SubtitleFile:   req.SubtitlePath,
+					NFOFile:        req.NFOPath,
+					OutputMode:     string(outputMode),
+					TargetLanguage: target,
+					StreamIndex:    req.StreamIndex,
+					StreamPolicy:   req.StreamPolicy,
+				},
+			})
+			created = created || jobCreated
+			queued = append(queued, job)
+		}
 		code := http.StatusCreated
 		if !created {
 			code = http.StatusOK
 		}
 		writeJSON(w, code, map[string]any{
 			"created": created,
-			"job":     job,
+			"job":     queued[0],
+			"jobs":    queued,
 		})
 	default:
 		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
 	}
 }
 
+// resolveOutputMode validates a requested output mode and falls back to the
+// runtime settings when none is given
+func (s *Server) resolveOutputMode(raw string) (subtitle.OutputMode, error) {
+	if strings.TrimSpace(raw) == "" && s.settings != nil {
+		if settings, err := s.settings.GetRuntimeSettings(); err == nil {
+			raw = settings.OutputMode
+		}
+	}
+	mode, err := subtitle.ParseOutputMode(raw)
+	if err != nil {
+		return "", fmt.Errorf("invalid output_mode: %w", err)
+	}
+	return mode, nil
+}
+
 func (s *Server) handleScan(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
@@ -241,6 +321,15 @@ func (s *Server) handleSettings(w http.ResponseWriter, r *http.Request) {
 			writeError(w, http.StatusBadRequest, "invalid json body")
 			return
 		}
+		if req.Budget == nil {
+			// clients that predate budgets keep the current caps
+			current, err := s.settings.GetRuntimeSettings()
+			if err != nil {
+				writeError(w, http.StatusInternalServerError, err.Error())
+				return
+			}
+			req.Budget = current.Budget
+		}
 		if err := req.Validate(); err != nil {
 			writeError(w, http.StatusBadRequest, err.Error())
 			return
@@ -15,6 +15,7 @@ import (
 
 	"github.com/MimeLyc/contextual-sub-translator/internal/jobs"
 	"github.com/MimeLyc/contextual-sub-translator/internal/library"
+	"github.com/MimeLyc/contextual-sub-translator/internal/reading"
 	"github.com/MimeLyc/contextual-sub-translator/internal/subtitle"
 	"golang.org/x/text/language"
 )
@@ -33,6 +34,24 @@ var (
 	jobTargetLanguagePattern = regexp.MustCompile(`^[A-Za-z]{2,3}(?:[-_][A-Za-z0-9]{2,8})*$`)
 )
 
+// translationMemoryRecorder adds lines edited by hand to the translation
+// memory of the series of a job
+type translationMemoryRecorder func(ctx context.Context, job *jobs.TranslationJob, sourceLang string, targetLang string, lines []subtitle.Line)
+
+func WithTranslationMemoryRecorder(record translationMemoryRecorder) Option {
+	return func(s *Server) {
+		s.rememberEdits = record
+	}
+}
+
+// WithReadingLimits checks the translated lines of job details against the
+// reading speed limits of their target language
+func WithReadingLimits(cfg reading.Config) Option {
+	return func(s *Server) {
+		s.readingLimits = cfg
+	}
+}
+
 type jobDetailResponse struct {
 	Job            *jobs.TranslationJob `json:"job"`
 	TargetLanguage string               `json:"target_language"`
@@ -42,6 +61,15 @@ type jobDetailResponse struct {
 	PreviewOffset  int                  `json:"preview_offset"`
 	PreviewLimit   int                  `json:"preview_limit"`
 	Editable       bool                 `json:"editable"`
+	// SourceDiagnostics lists the repairs made while reading the source subtitle
+	SourceDiagnostics []subtitle.Diagnostic `json:"source_diagnostics"`
+	// ReadingViolations counts the translated lines of the whole job over a
+	// reading speed limit
+	ReadingViolations int `json:"reading_violations"`
+	// Review sums up what the reviewer found, omitted when the job was not reviewed
+	Review *jobReviewResponse `json:"review,omitempty"`
+	// Usage sums the LLM calls made for the job, omitted when usage is not recorded
+	Usage *usageTotalsResponse `json:"usage,omitempty"`
 }
 
 type jobProgressResponse struct {
@@ -65,6 +93,11 @@ type jobPreviewLine struct {
 	Index          int    `json:"index"`
 	OriginalText   string `json:"original_text"`
 	TranslatedText string `json:"translated_text"`
+	// Violations lists the reading speed limits the translation breaks
+	Violations []reading.Violation `json:"violations,omitempty"`
+	// Review holds the issues the reviewer found in the line, omitted when
+	// its batch was not reviewed
+	Review *lineReviewResponse `json:"review,omitempty"`
 }
 
 type updateJobLinesRequest struct {
@@ -79,8 +112,12 @@ type updateJobLineRequest struct {
 type jobSnapshot struct {
 	Job             *jobs.TranslationJob
 	TargetLanguage  string
+	SourceLanguage  string
 	OutputPath      string
+	OutputMode      subtitle.OutputMode
+	OutputFile      *subtitle.File
 	SourceLines     []subtitle.Line
+	SourceDiags     []subtitle.Diagnostic
 	OutputLines     []subtitle.Line
 	TranslatedByIdx map[int]string
 	TotalLines      int
@@ -207,15 +244,26 @@ func (s *Server) buildJobDetail(ctx context.Context, jobID string, offset int, l
 	}
 
 	progress := computeJobProgress(snapshot.TotalLines, snapshot.TranslatedByIdx)
+	limits := s.readingLimitsFor(snapshot.TargetLanguage)
 	detail := jobDetailResponse{
 		Job:            snapshot.Job,
 		TargetLanguage: snapshot.TargetLanguage,
 		Progress:       progress,
 		Episode:        s.resolveJobEpisodeInfo(ctx, snapshot.Job, snapshot.OutputPath),
-		Preview:        buildPreviewLines(snapshot.SourceLines, snapshot.TranslatedByIdx, offset, limit, snapshot.TotalLines),
+		Preview:        buildPreviewLines(snapshot.SourceLines, snapshot.TranslatedByIdx, offset, limit, snapshot.TotalLines, limits),
 		PreviewOffset:  offset,
 		PreviewLimit:   limit,
 		Editable:       snapshot.Job.Status == jobs.StatusSuccess,
+
+		SourceDiagnostics: snapshot.SourceDiags,
+		ReadingViolations: countReadingViolations(snapshot.SourceLines, snapshot.TranslatedByIdx, limits),
+	}
+	detail.Usage, err = s.jobUsage(ctx, jobID)
+	if err != nil {
+		return jobDetailResponse{}, err
+	}
+	if err := s.applyJobReviews(ctx, jobID, &detail); err != nil {
+		return jobDetailResponse{}, err
 	}
 	return detail, nil
 }
@@ -247,19 +295,34 @@ func (s *Server) updateJobLines(ctx context.Context, jobID string, patches []upd
 		}
 		writable[patch.Index-1].TranslatedText = patch.TranslatedText
 	}
+	edited := make([]subtitle.Line, 0, len(patches))
+	for _, patch := range patches {
+		edited = append(edited, writable[patch.Index-1])
+	}
 
 	langTag, err := language.Parse(snapshot.TargetLanguage)
 	if err != nil {
 		langTag = language.Und
 	}
-	if err := subtitle.NewWriter().Write(snapshot.OutputPath, &subtitle.File{
+	output := &subtitle.File{
 		Lines:    writable,
 		Language: langTag,
 		Format:   "SRT",
 		Path:     snapshot.OutputPath,
-	}); err != nil {
+	}
+	// Keep styles, cue settings, blocks and the encoding of an existing output when rewriting it.
+	if existing := snapshot.OutputFile; existing != nil {
+		output.Format = existing.Format
+		output.ASS = existing.ASS
+		output.VTT = existing.VTT
+		output.Encoding = existing.Encoding
+	}
+	if err := subtitle.NewWriterWithEncoding(snapshot.OutputMode, subtitle.EncodingSource).Write(snapshot.OutputPath, output); err != nil {
 		return jobDetailResponse{}, err
 	}
+	if s.rememberEdits != nil && snapshot.SourceLanguage != "" {
+		s.rememberEdits(ctx, job, snapshot.SourceLanguage, snapshot.TargetLanguage, edited)
+	}
 
 	return s.buildJobDetail(ctx, jobID, 0, defaultJobPreviewLimit)
 }
@@ -269,17 +332,34 @@ func (s *Server) buildSnapshot(ctx context.Context, job *jobs.TranslationJob) (j
 		return jobSnapshot{}, errJobNotFound
 	}
 
-	targetLanguage := detectJobTargetLanguage(job, s.scanner.TargetLanguage())
-	outputPath := buildOutputSubtitlePath(job, targetLanguage)
-
-	sourceLines, err := s.readSourceLinesForJob(ctx, job)
+	sourceFile, err := s.readSourceFileForJob(ctx, job)
 	if err != nil {
 		return jobSnapshot{}, err
 	}
-	outputLines, err := readSubtitleLinesIfFileExists(outputPath)
+	targetLanguage := detectJobTargetLanguage(job, s.scanner.TargetLanguage())
+	outputPath := buildOutputSubtitlePath(job, targetLanguage, sourceFile)
+	var sourceLines []subtitle.Line
+	sourceLanguage := ""
+	sourceDiags := make([]subtitle.Diagnostic, 0)
+	if sourceFile != nil {
+		sourceLines = sourceFile.Lines
+		sourceLanguage = sourceFile.Language.String()
+		sourceDiags = append(sourceDiags, sourceFile.Diagnostics...)
+	}
+	outputMode, err := subtitle.ParseOutputMode(job.Payload.OutputMode)
+	if err != nil {
+		outputMode = subtitle.OutputTranslated
+	}
+	// Bilingual outputs also carry the original text; keep only the translation.
+	outputFile, ok, err := readSubtitleFileByPath(outputPath)
 	if err != nil {
 		return jobSnapshot{}, err
 	}
+	var outputLines []subtitle.Line
+	if ok {
+		outputFile = subtitle.StripOriginal(outputFile, sourceLines, outputMode)
+		outputLines = outputFile.Lines
+	}
 	translations, err := s.loadCheckpointTranslations(ctx, job.ID)
 	if err != nil {
 		return jobSnapshot{}, err
@@ -301,8 +381,12 @@ func (s *Server) buildSnapshot(ctx context.Context, job *jobs.TranslationJob) (j
 	return jobSnapshot{
 		Job:             job,
 		TargetLanguage:  targetLanguage,
+		SourceLanguage:  sourceLanguage,
 		OutputPath:      outputPath,
+		OutputMode:      outputMode,
+		OutputFile:      outputFile,
 		SourceLines:     sourceLines,
+		SourceDiags:     sourceDiags,
 		OutputLines:     outputLines,
 		TranslatedByIdx: translations,
 		TotalLines:      totalLines,
@@ -330,7 +414,7 @@ func computeJobProgress(total int, translated map[int]string) jobProgressRespons
 	}
 }
 
-func buildPreviewLines(source []subtitle.Line, translated map[int]string, offset int, limit int, total int) []jobPreviewLine {
+func buildPreviewLines(source []subtitle.Line, translated map[int]string, offset int, limit int, total int, limits reading.Limits) []jobPreviewLine {
 	if total <= 0 || offset >= total {
 		return []jobPreviewLine{}
 	}
@@ -346,18 +430,42 @@ func buildPreviewLines(source []subtitle.Line, translated map[int]string, offset
 	for i := offset; i < end; i++ {
 		idx := i + 1
 		original := ""
+		var violations []reading.Violation
 		if i < len(source) {
 			original = source[i].Text
+			violations = reading.Check(translated[idx], source[i].EndTime-source[i].StartTime, limits)
 		}
 		ret = append(ret, jobPreviewLine{
 			Index:          idx,
 			OriginalText:   original,
 			TranslatedText: translated[idx],
+			Violations:     violations,
 		})
 	}
 	return ret
 }
 
+// readingLimitsFor returns the reading speed limits of a target language
+func (s *Server) readingLimitsFor(targetLanguage string) reading.Limits {
+	tag, err := language.Parse(targetLanguage)
+	if err != nil {
+		tag = language.Und
+	}
+	return s.readingLimits.For(tag)
+}
+
+// countReadingViolations counts the translated lines over a reading speed
+// limit. Lines need the timing of their source line to be checked.
+func countReadingViolations(source []subtitle.Line, translated map[int]string, limits reading.Limits) int {
+	count := 0
+	for i, line := range source {
+		if len(reading.Check(translated[i+1], line.EndTime-line.StartTime, limits)) > 0 {
+			count++
+		}
+	}
+	return count
+}
+
 func makeWritableLines(source []subtitle.Line, output []subtitle.Line, translated map[int]string, total int) []subtitle.Line {
 	ret := make([]subtitle.Line, total)
 	for i := 0; i < total; i++ {
@@ -384,41 +492,54 @@ func makeWritableLines(source []subtitle.Line, output []subtitle.Line, translate
 	return ret
 }
 
-func (s *Server) readSourceLinesForJob(ctx context.Context, job *jobs.TranslationJob) ([]subtitle.Line, error) {
+func (s *Server) readSourceFileForJob(ctx context.Context, job *jobs.TranslationJob) (*subtitle.File, error) {
 	if job == nil {
 		return nil, errJobNotFound
 	}
-	if lines, ok, err := readSubtitleLinesByPath(job.Payload.SubtitleFile); err != nil {
+	if s.jobData != nil {
+		// The subtitle the job translated, e.g. a pivot subtitle found on disk.
+		recorded, ok, err := s.jobData.GetSubtitleCache(ctx, jobSourceCacheKey(job.ID))
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return &recorded, nil
+		}
+	}
+	if file, ok, err := readSubtitleFileByPath(job.Payload.SubtitleFile); err != nil {
 		return nil, err
 	} else if ok {
-		return lines, nil
+		return file, nil
 	}
 	if s.jobData == nil || strings.TrimSpace(job.Payload.MediaFile) == "" {
 		return nil, nil
 	}
-	cacheKey := subtitleCacheKey(job.Payload.MediaFile)
-	cached, ok, err := s.jobData.GetSubtitleCache(ctx, cacheKey)
-	if err != nil {
-		return nil, err
+	if job.Payload.StreamIndex == nil {
+		// The stream was selected while the job ran; look up what it cached.
+		cached, ok, err := s.jobData.GetSubtitleCacheForJob(ctx, job.ID)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return &cached, nil
+		}
 	}
-	if !ok {
-		return nil, nil
+	streamIndex := 0
+	if job.Payload.StreamIndex != nil {
+		streamIndex = *job.Payload.StreamIndex
 	}
-	return cached.Lines, nil
-}
-
-func readSubtitleLinesIfFileExists(path string) ([]subtitle.Line, error) {
-	lines, ok, err := readSubtitleLinesByPath(path)
+	cacheKey := subtitleCacheKey(job.Payload.MediaFile, streamIndex)
+	cached, ok, err := s.jobData.GetSubtitleCache(ctx, cacheKey)
 	if err != nil {
 		return nil, err
 	}
 	if !ok {
 		return nil, nil
 	}
-	return lines, nil
+	return &cached, nil
 }
 
-func readSubtitleLinesByPath(path string) ([]subtitle.Line, bool, error) {
+func readSubtitleFileByPath(path string) (*subtitle.File, bool, error) {
 	path = strings.TrimSpace(path)
 	if path == "" {
 		return nil, false, nil
@@ -433,7 +554,7 @@ func readSubtitleLinesByPath(path string) ([]subtitle.Line, bool, error) {
 	if err != nil {
 		return nil, false, err
 	}
-	return file.Lines, true, nil
+	return file, true, nil
 }
 
 func (s *Server) loadCheckpointTranslations(ctx context.Context, jobID string) (map[int]string, error) {
@@ -462,6 +583,9 @@ func (s *Server) loadCheckpointTranslations(ctx context.Context, jobID string) (
 
 func detectJobTargetLanguage(job *jobs.TranslationJob, fallback string) string {
 	if job != nil {
+		if normalized, ok := normalizeTargetLanguage(job.Payload.TargetLanguage); ok {
+			return normalized
+		}
 		parts := strings.Split(job.DedupeKey, "|")
 		if len(parts) > 0 {
 			candidate := strings.TrimSpace(parts[len(parts)-1])
@@ -495,12 +619,18 @@ func normalizeTargetLanguage(raw string) (string, bool) {
 	return tag.String(), true
 }
 
-func buildOutputSubtitlePath(job *jobs.TranslationJob, targetLanguage string) string {
+// buildOutputSubtitlePath returns the path a job writes its translation to.
+// Jobs of an embedded stream take the extension of the subtitle read from
+// it, e.g. .ass for an ASS stream.
+func buildOutputSubtitlePath(job *jobs.TranslationJob, targetLanguage string, sourceFile *subtitle.File) string {
 	if job == nil {
 		return ""
 	}
 	mediaPath := strings.TrimSpace(job.Payload.MediaFile)
 	subPath := strings.TrimSpace(job.Payload.SubtitleFile)
+	if subPath == "" && sourceFile != nil {
+		subPath = sourceFile.Path
+	}
 	if subPath == "" {
 		subPath = syntheticEmbeddedSubtitlePath(mediaPath)
 	}
@@ -531,8 +661,12 @@ func syntheticEmbeddedSubtitlePath(mediaPath string) string {
 	return filepath.Join(filepath.Dir(mediaPath), stem+"_ctxtrans_embedded.srt")
 }
 
-func subtitleCacheKey(mediaPath string) string {
-	return mediaPath + "|s:0"
+func subtitleCacheKey(mediaPath string, streamIndex int) string {
+	return fmt.Sprintf("%s|s:%d", mediaPath, streamIndex)
+}
+
+func jobSourceCacheKey(jobID string) string {
+	return "job:" + jobID + "|source"
 }
 
 func (s *Server) resolveJobEpisodeInfo(ctx context.Context, job *jobs.TranslationJob, outputPath string) jobEpisodeInfo {
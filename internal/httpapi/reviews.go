@@ -0,0 +1,82 @@
+package httpapi
+
+import (
+	"context"
+
+	"github.com/MimeLyc/contextual-sub-translator/internal/persistence"
+)
+
+// translationReviewStore keeps what the reviewer found in the translated
+// batches of each job
+type translationReviewStore interface {
+	ListTranslationReviews(ctx context.Context, jobID string) ([]persistence.TranslationReview, error)
+}
+
+func WithTranslationReviewStore(store translationReviewStore) Option {
+	return func(s *Server) {
+		s.reviews = store
+	}
+}
+
+// jobReviewResponse sums up the reviews of the batches of a job
+type jobReviewResponse struct {
+	// Score is the mean score of the reviewed batches, from 0 to 100
+	Score        float64 `json:"score"`
+	Batches      int     `json:"batches"`
+	Issues       int     `json:"issues"`
+	Retranslated int     `json:"retranslated"`
+}
+
+// lineReviewResponse is the review of a preview line: the score of its batch
+// and the issues found in the line
+type lineReviewResponse struct {
+	Score  float64                   `json:"score"`
+	Issues []persistence.ReviewIssue `json:"issues"`
+}
+
+// applyJobReviews adds the reviews of a job to its detail. Jobs without
+// reviews are left as they are.
+func (s *Server) applyJobReviews(ctx context.Context, jobID string, detail *jobDetailResponse) error {
+	if s.reviews == nil {
+		return nil
+	}
+	reviews, err := s.reviews.ListTranslationReviews(ctx, jobID)
+	if err != nil {
+		return err
+	}
+	if len(reviews) == 0 {
+		return nil
+	}
+
+	summary := &jobReviewResponse{Batches: len(reviews)}
+	for _, review := range reviews {
+		summary.Score += review.Score
+		summary.Issues += len(review.Issues)
+		for _, issue := range review.Issues {
+			if issue.Retranslated {
+				summary.Retranslated++
+			}
+		}
+	}
+	summary.Score /= float64(len(reviews))
+	detail.Review = summary
+
+	for i := range detail.Preview {
+		line := &detail.Preview[i]
+		for _, review := range reviews {
+			// preview lines count from 1, batches from 0
+			if line.Index <= review.BatchStart || line.Index > review.BatchEnd {
+				continue
+			}
+			lineReview := &lineReviewResponse{Score: review.Score, Issues: []persistence.ReviewIssue{}}
+			for _, issue := range review.Issues {
+				if issue.Line == line.Index {
+					lineReview.Issues = append(lineReview.Issues, issue)
+				}
+			}
+			line.Review = lineReview
+			break
+		}
+	}
+	return nil
+}
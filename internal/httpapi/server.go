@@ -13,6 +13,7 @@ import (
 	"github.com/MimeLyc/contextual-sub-translator/internal/jobs"
 	"github.com/MimeLyc/contextual-sub-translator/internal/library"
 	"github.com/MimeLyc/contextual-sub-translator/internal/persistence"
+	"github.com/MimeLyc/contextual-sub-translator/internal/reading"
 	"github.com/MimeLyc/contextual-sub-translator/internal/subtitle"
 )
 
@@ -26,6 +27,7 @@ type runtimeSettingsApplier func(next config.RuntimeSettings) error
 type jobDataStore interface {
 	LoadBatchCheckpoints(ctx context.Context, jobID string) ([]persistence.BatchCheckpoint, error)
 	GetSubtitleCache(ctx context.Context, cacheKey string) (subtitle.File, bool, error)
+	GetSubtitleCacheForJob(ctx context.Context, jobID string) (subtitle.File, bool, error)
 }
 
 type Server struct {
@@ -35,6 +37,15 @@ type Server struct {
 	apply    runtimeSettingsApplier
 	jobData  jobDataStore
 
+	characters    characterProfileStore
+	songModes     songModeStore
+	usage         usageStore
+	reviews       translationReviewStore
+	rememberEdits translationMemoryRecorder
+	termMaps      termMapStore
+	editTermMap   termMapEditor
+	readingLimits reading.Config
+
 	uiEnabled   bool
 	uiStaticDir string
 
@@ -112,6 +123,10 @@ func (s *Server) routes() {
 	s.mux.HandleFunc("/api/jobs/", s.handleJobDetailRoutes)
 	s.mux.HandleFunc("/api/scan", s.handleScan)
 	s.mux.HandleFunc("/api/settings", s.handleSettings)
+	s.mux.HandleFunc("/api/characters", s.handleCharacters)
+	s.mux.HandleFunc("/api/songs", s.handleSongs)
+	s.mux.HandleFunc("/api/termmaps", s.handleTermMaps)
+	s.mux.HandleFunc("/api/usage", s.handleUsage)
 	s.mux.HandleFunc("/", s.handleStatic)
 }
 
@@ -17,7 +17,11 @@ import (
 	"github.com/MimeLyc/contextual-sub-translator/internal/jobs"
 	"github.com/MimeLyc/contextual-sub-translator/internal/library"
 	"github.com/MimeLyc/contextual-sub-translator/internal/persistence"
+	"github.com/MimeLyc/contextual-sub-translator/internal/reading"
+	"github.com/MimeLyc/contextual-sub-translator/internal/subtitle"
+	"github.com/MimeLyc/contextual-sub-translator/internal/termmap"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/text/encoding/simplifiedchinese"
 	"golang.org/x/text/language"
 )
 
@@ -121,6 +125,148 @@ func TestServer_CreateJob_RequiresMediaPath(t *testing.T) {
 	require.Equal(t, http.StatusBadRequest, rec.Code)
 }
 
+func TestServer_CreateJob_OutputMode(t *testing.T) {
+	scanner := library.NewScanner(nil, language.Chinese)
+	queue := jobs.NewQueue(1, nil)
+	srv := NewServer(scanner, queue, WithRuntimeSettingsStore(&fakeSettingsStore{
+		current: config.RuntimeSettings{OutputMode: "bilingual_styled"},
+	}))
+
+	createJob := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/jobs", bytes.NewReader([]byte(body)))
+		rec := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(rec, req)
+		return rec
+	}
+	var ret struct {
+		Job *jobs.TranslationJob `json:"job"`
+	}
+
+	rec := createJob(`{"media_path":"/tmp/a.mkv","output_mode":"bilingual_original_first"}`)
+	require.Equal(t, http.StatusCreated, rec.Code)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &ret))
+	require.Equal(t, "bilingual_original_first", ret.Job.Payload.OutputMode)
+
+	rec = createJob(`{"media_path":"/tmp/b.mkv"}`)
+	require.Equal(t, http.StatusCreated, rec.Code)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &ret))
+	require.Equal(t, "bilingual_styled", ret.Job.Payload.OutputMode)
+
+	rec = createJob(`{"media_path":"/tmp/c.mkv","output_mode":"side_by_side"}`)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestServer_CreateJob_SubtitleStream(t *testing.T) {
+	scanner := library.NewScanner(nil, language.Chinese)
+	queue := jobs.NewQueue(1, nil)
+	srv := NewServer(scanner, queue)
+
+	createJob := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/jobs", bytes.NewReader([]byte(body)))
+		rec := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(rec, req)
+		return rec
+	}
+	var ret struct {
+		Job *jobs.TranslationJob `json:"job"`
+	}
+
+	rec := createJob(`{"media_path":"/tmp/a.mkv","stream_index":2}`)
+	require.Equal(t, http.StatusCreated, rec.Code)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &ret))
+	require.NotNil(t, ret.Job.Payload.StreamIndex)
+	require.Equal(t, 2, *ret.Job.Payload.StreamIndex)
+	require.Equal(t, "/tmp/a.mkv|[embedded:s:2]|zh", ret.Job.DedupeKey)
+
+	rec = createJob(`{"media_path":"/tmp/b.mkv","stream_policy":{"language":"en","exclude_forced":true}}`)
+	require.Equal(t, http.StatusCreated, rec.Code)
+	ret.Job = nil
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &ret))
+	require.Nil(t, ret.Job.Payload.StreamIndex)
+	require.Equal(t, &subtitle.StreamPolicy{Language: "en", ExcludeForced: true}, ret.Job.Payload.StreamPolicy)
+	require.Equal(t, "/tmp/b.mkv|[embedded]|zh", ret.Job.DedupeKey)
+
+	rec = createJob(`{"media_path":"/tmp/c.mkv","stream_index":-1}`)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestServer_CreateJob_OneJobPerTargetLanguage(t *testing.T) {
+	scanner := library.NewScanner(nil, language.Chinese, library.WithTargetLanguages(language.SimplifiedChinese, language.TraditionalChinese))
+	queue := jobs.NewQueue(1, nil)
+	srv := NewServer(scanner, queue)
+
+	createJob := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/jobs", bytes.NewReader([]byte(body)))
+		rec := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(rec, req)
+		return rec
+	}
+	var ret struct {
+		Created bool                   `json:"created"`
+		Job     *jobs.TranslationJob   `json:"job"`
+		Jobs    []*jobs.TranslationJob `json:"jobs"`
+	}
+
+	rec := createJob(`{"media_path":"/tmp/a.mkv","subtitle_path":"/tmp/a.srt"}`)
+	require.Equal(t, http.StatusCreated, rec.Code)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &ret))
+	require.Len(t, ret.Jobs, 2)
+	require.Equal(t, "zh-Hans", ret.Jobs[0].Payload.TargetLanguage)
+	require.Equal(t, "/tmp/a.mkv|/tmp/a.srt|zh-Hans", ret.Jobs[0].DedupeKey)
+	require.Equal(t, "zh-Hant", ret.Jobs[1].Payload.TargetLanguage)
+	require.Equal(t, "/tmp/a.mkv|/tmp/a.srt|zh-Hant", ret.Jobs[1].DedupeKey)
+	require.Equal(t, ret.Jobs[0].ID, ret.Job.ID)
+
+	ret.Jobs = nil
+	rec = createJob(`{"media_path":"/tmp/a.mkv","subtitle_path":"/tmp/a.srt","target_language":"zh-Hant"}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &ret))
+	require.False(t, ret.Created)
+	require.Len(t, ret.Jobs, 1)
+	require.Equal(t, "zh-Hant", ret.Job.Payload.TargetLanguage)
+
+	rec = createJob(`{"media_path":"/tmp/a.mkv","target_language":"not a language"}`)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	require.Len(t, queue.List(), 2)
+}
+
+func TestServer_CreateJob_SkipsTargetsTheMediaHas(t *testing.T) {
+	dir := t.TempDir()
+	mediaPath := filepath.Join(dir, "a.mkv")
+	for _, name := range []string{"a.mkv", "a.ja.srt", "a.zh-Hans.srt"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("x"), 0o644))
+	}
+	scanner := library.NewScanner(nil, language.Chinese, library.WithTargetLanguages(language.SimplifiedChinese, language.TraditionalChinese))
+	queue := jobs.NewQueue(1, nil)
+	srv := NewServer(scanner, queue)
+
+	createJob := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/jobs", bytes.NewReader([]byte(`{"media_path":"`+mediaPath+`"}`)))
+		rec := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(rec, req)
+		return rec
+	}
+	var ret struct {
+		Created bool                   `json:"created"`
+		Jobs    []*jobs.TranslationJob `json:"jobs"`
+	}
+
+	rec := createJob()
+	require.Equal(t, http.StatusCreated, rec.Code)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &ret))
+	require.Len(t, ret.Jobs, 1)
+	require.Equal(t, "zh-Hant", ret.Jobs[0].Payload.TargetLanguage)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.zh-Hant.srt"), []byte("x"), 0o644))
+	ret.Jobs = nil
+	rec = createJob()
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &ret))
+	require.False(t, ret.Created)
+	require.Empty(t, ret.Jobs)
+	require.Len(t, queue.List(), 1)
+}
+
 func TestServer_ListEpisodes_IncludesCronInProgress(t *testing.T) {
 	tmp := t.TempDir()
 	showDir := filepath.Join(tmp, "tvshows", "The Show")
@@ -252,6 +398,40 @@ func TestServer_UpdateSettings(t *testing.T) {
 	require.Equal(t, got, store.current)
 }
 
+func TestServer_UpdateSettings_Budget(t *testing.T) {
+	scanner := library.NewScanner(nil, language.Chinese)
+	store := &fakeSettingsStore{
+		current: config.RuntimeSettings{
+			LLMAPIURL:      "https://old.example/v1",
+			LLMAPIKey:      "old-ak",
+			LLMModel:       "old-model",
+			CronExpr:       "0 0 * * *",
+			TargetLanguage: "zh",
+			Budget:         &config.BudgetConfig{Daily: config.BudgetCap{Cost: 5}},
+		},
+	}
+	srv := NewServer(scanner, jobs.NewQueue(1, nil), WithRuntimeSettingsStore(store))
+
+	body := []byte(`{"llm_api_url":"https://old.example/v1","llm_api_key":"old-ak","llm_model":"old-model","cron_expr":"0 0 * * *","target_language":"zh"}`)
+	rec := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/api/settings", bytes.NewReader(body)))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, &config.BudgetConfig{Daily: config.BudgetCap{Cost: 5}}, store.current.Budget, "omitted budget keeps the caps")
+
+	body = []byte(`{"llm_api_url":"https://old.example/v1","llm_api_key":"old-ak","llm_model":"old-model","cron_expr":"0 0 * * *","target_language":"zh",` +
+		`"budget":{"daily":{"tokens":0,"cost":10},"monthly":{"tokens":0,"cost":0},"job":{"tokens":0,"cost":0},"series":{"tokens":0,"cost":0}}}`)
+	rec = httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/api/settings", bytes.NewReader(body)))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, 10.0, store.current.Budget.Daily.Cost)
+
+	body = []byte(`{"llm_api_url":"https://old.example/v1","llm_api_key":"old-ak","llm_model":"old-model","cron_expr":"0 0 * * *","target_language":"zh",` +
+		`"budget":{"daily":{"tokens":-1}}}`)
+	rec = httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/api/settings", bytes.NewReader(body)))
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
 func TestServer_UpdateSettings_StoreFailure(t *testing.T) {
 	tmp := t.TempDir()
 	sourcePath := filepath.Join(tmp, "tvshows")
@@ -408,6 +588,172 @@ func TestServer_GetJobDetail_ReportsCheckpointProgress(t *testing.T) {
 	require.Equal(t, "", resp.Preview[2].TranslatedText)
 }
 
+func TestServer_GetJobDetail_ReportsSourceDiagnostics(t *testing.T) {
+	tmp := t.TempDir()
+	showDir := filepath.Join(tmp, "tvshows", "The Show")
+	require.NoError(t, os.MkdirAll(showDir, 0o755))
+
+	mediaPath := filepath.Join(showDir, "episode01.mkv")
+	subtitlePath := filepath.Join(showDir, "episode01.srt")
+	require.NoError(t, os.WriteFile(mediaPath, []byte("media"), 0o644))
+	require.NoError(t, os.WriteFile(subtitlePath, []byte("00:00:01,000 --> 00:00:02,000\nline one\n"), 0o644))
+
+	scanner := library.NewScanner(
+		[]library.SourceConfig{
+			{ID: "tvshows", Name: "TV Shows", Path: filepath.Join(tmp, "tvshows")},
+		},
+		language.Chinese,
+	)
+
+	queue := jobs.NewQueue(1, nil)
+	job, created := queue.Enqueue(jobs.EnqueueRequest{
+		Source:    "manual",
+		DedupeKey: mediaPath + "|" + subtitlePath + "|zh",
+		Payload: jobs.JobPayload{
+			MediaFile:    mediaPath,
+			SubtitleFile: subtitlePath,
+		},
+	})
+	require.True(t, created)
+
+	srv := NewServer(scanner, queue)
+	req := httptest.NewRequest(http.MethodGet, "/api/jobs/"+job.ID, nil)
+	rec := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	var detail jobDetailResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &detail))
+	require.Len(t, detail.Preview, 1)
+	require.Equal(t, []subtitle.Diagnostic{
+		{Line: 1, Problem: "missing cue number", Action: "numbered 1"},
+	}, detail.SourceDiagnostics)
+}
+
+func TestServer_GetJobDetail_ReportsReadingViolations(t *testing.T) {
+	tmp := t.TempDir()
+	showDir := filepath.Join(tmp, "tvshows", "The Show")
+	require.NoError(t, os.MkdirAll(showDir, 0o755))
+
+	mediaPath := filepath.Join(showDir, "episode01.mkv")
+	subtitlePath := filepath.Join(showDir, "episode01.srt")
+	require.NoError(t, os.WriteFile(mediaPath, []byte("media"), 0o644))
+	require.NoError(t, os.WriteFile(subtitlePath, []byte(sampleSRTThreeLines), 0o644))
+
+	scanner := library.NewScanner(
+		[]library.SourceConfig{
+			{ID: "tvshows", Name: "TV Shows", Path: filepath.Join(tmp, "tvshows")},
+		},
+		language.Chinese,
+	)
+	store, err := persistence.NewSQLiteStore(filepath.Join(tmp, "state.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, store.Close())
+	})
+
+	queue := jobs.NewQueue(1, store)
+	job, created := queue.Enqueue(jobs.EnqueueRequest{
+		Source:    "manual",
+		DedupeKey: mediaPath + "|" + subtitlePath + "|zh",
+		Payload: jobs.JobPayload{
+			MediaFile:    mediaPath,
+			SubtitleFile: subtitlePath,
+		},
+	})
+	require.True(t, created)
+	require.NoError(t, store.SaveBatchCheckpoint(context.Background(), job.ID, 0, 3, []string{
+		"第一行",
+		"我们必须在天亮之前离开这座城市，否则就来不及了",
+		"第三行",
+	}))
+
+	srv := NewServer(scanner, queue, WithJobDataStore(store), WithReadingLimits(reading.DefaultConfig()))
+	req := httptest.NewRequest(http.MethodGet, "/api/jobs/"+job.ID+"?offset=1&limit=1", nil)
+	rec := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	var detail jobDetailResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &detail))
+	require.Equal(t, 1, detail.ReadingViolations)
+	require.Len(t, detail.Preview, 1)
+	require.Equal(t, []reading.Violation{
+		{Kind: reading.KindCPS, Value: 23, Limit: 9},
+		{Kind: reading.KindLineLength, Value: 23, Limit: 16},
+	}, detail.Preview[0].Violations)
+}
+
+func TestServer_GetJobDetail_ShowsReviewIssues(t *testing.T) {
+	tmp := t.TempDir()
+	showDir := filepath.Join(tmp, "tvshows", "The Show")
+	require.NoError(t, os.MkdirAll(showDir, 0o755))
+
+	mediaPath := filepath.Join(showDir, "episode01.mkv")
+	subtitlePath := filepath.Join(showDir, "episode01.srt")
+	require.NoError(t, os.WriteFile(mediaPath, []byte("media"), 0o644))
+	require.NoError(t, os.WriteFile(subtitlePath, []byte(sampleSRTThreeLines), 0o644))
+
+	scanner := library.NewScanner(
+		[]library.SourceConfig{
+			{ID: "tvshows", Name: "TV Shows", Path: filepath.Join(tmp, "tvshows")},
+		},
+		language.Chinese,
+	)
+	store, err := persistence.NewSQLiteStore(filepath.Join(tmp, "state.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, store.Close())
+	})
+
+	queue := jobs.NewQueue(1, store)
+	job, created := queue.Enqueue(jobs.EnqueueRequest{
+		Source:    "manual",
+		DedupeKey: mediaPath + "|" + subtitlePath + "|zh",
+		Payload: jobs.JobPayload{
+			MediaFile:    mediaPath,
+			SubtitleFile: subtitlePath,
+		},
+	})
+	require.True(t, created)
+	ctx := context.Background()
+	require.NoError(t, store.SaveBatchCheckpoint(ctx, job.ID, 0, 2, []string{"第一行", "第二行"}))
+	require.NoError(t, store.SaveBatchCheckpoint(ctx, job.ID, 2, 3, []string{"第三行"}))
+	require.NoError(t, store.PutTranslationReview(ctx, persistence.TranslationReview{
+		JobID:      job.ID,
+		BatchStart: 0,
+		BatchEnd:   2,
+		Score:      70,
+		Issues: []persistence.ReviewIssue{
+			{Line: 2, Category: "omission", Severity: "major", Message: "Drops the warning.", Retranslated: true},
+		},
+	}))
+	require.NoError(t, store.PutTranslationReview(ctx, persistence.TranslationReview{JobID: job.ID, BatchStart: 2, BatchEnd: 3, Score: 90}))
+
+	srv := NewServer(scanner, queue, WithJobDataStore(store), WithTranslationReviewStore(store))
+	req := httptest.NewRequest(http.MethodGet, "/api/jobs/"+job.ID, nil)
+	rec := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	var detail jobDetailResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &detail))
+	require.Equal(t, &jobReviewResponse{Score: 80, Batches: 2, Issues: 1, Retranslated: 1}, detail.Review)
+	require.Len(t, detail.Preview, 3)
+	require.Equal(t, &lineReviewResponse{Score: 70, Issues: []persistence.ReviewIssue{}}, detail.Preview[0].Review)
+	require.Equal(t, &lineReviewResponse{Score: 70, Issues: []persistence.ReviewIssue{
+		{Line: 2, Category: "omission", Severity: "major", Message: "Drops the warning.", Retranslated: true},
+	}}, detail.Preview[1].Review)
+	require.Equal(t, &lineReviewResponse{Score: 90, Issues: []persistence.ReviewIssue{}}, detail.Preview[2].Review)
+
+	// Jobs that were not reviewed carry no review
+	srv = NewServer(scanner, queue, WithJobDataStore(store))
+	rec = httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/jobs/"+job.ID, nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NotContains(t, rec.Body.String(), `"review"`)
+}
+
 func TestServer_GetJobDetail_FallsBackTargetLanguageWhenDedupeSuffixIsNotLanguage(t *testing.T) {
 	tmp := t.TempDir()
 	showDir := filepath.Join(tmp, "tvshows", "The Show")
@@ -525,7 +871,15 @@ func TestServer_UpdateJobLine_UpdatesOutputFileForCompletedJob(t *testing.T) {
 		return ok && got.Status == jobs.StatusSuccess
 	}, time.Second, 20*time.Millisecond)
 
-	srv := NewServer(scanner, queue)
+	var remembered []subtitle.Line
+	var rememberedTarget string
+	srv := NewServer(scanner, queue, WithTranslationMemoryRecorder(
+		func(_ context.Context, got *jobs.TranslationJob, _ string, targetLang string, lines []subtitle.Line) {
+			require.Equal(t, job.ID, got.ID)
+			rememberedTarget = targetLang
+			remembered = lines
+		},
+	))
 	body := []byte(`{"lines":[{"index":1,"translated_text":"第一行已改"},{"index":2,"translated_text":"第二行已改"}]}`)
 	req := httptest.NewRequest(http.MethodPut, "/api/jobs/"+job.ID+"/lines", bytes.NewReader(body))
 	rec := httptest.NewRecorder()
@@ -538,6 +892,69 @@ func TestServer_UpdateJobLine_UpdatesOutputFileForCompletedJob(t *testing.T) {
 	require.NoError(t, err)
 	require.Contains(t, string(data), "第一行已改")
 	require.Contains(t, string(data), "第二行已改")
+
+	// Only the edited lines feed the translation memory
+	require.Equal(t, "zh", rememberedTarget)
+	require.Len(t, remembered, 2)
+	require.Equal(t, "line one", remembered[0].Text)
+	require.Equal(t, "第一行已改", remembered[0].TranslatedText)
+	require.Equal(t, "line two", remembered[1].Text)
+}
+
+func TestServer_UpdateJobLine_KeepsOutputEncoding(t *testing.T) {
+	tmp := t.TempDir()
+	showDir := filepath.Join(tmp, "tvshows", "The Show")
+	require.NoError(t, os.MkdirAll(showDir, 0o755))
+
+	mediaPath := filepath.Join(showDir, "episode01.mkv")
+	subtitlePath := filepath.Join(showDir, "episode01.srt")
+	outputPath := filepath.Join(showDir, "episode01_ctxtrans.zh.srt")
+	require.NoError(t, os.WriteFile(mediaPath, []byte("media"), 0o644))
+	require.NoError(t, os.WriteFile(subtitlePath, []byte(sampleSRTThreeLines), 0o644))
+	gbk, err := simplifiedchinese.GBK.NewEncoder().String("1\n00:00:01,000 --> 00:00:02,000\n我们走吧\n\n2\n00:00:03,000 --> 00:00:04,000\n你说什么\n\n3\n00:00:05,000 --> 00:00:06,000\n这是第三行\n")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(outputPath, []byte(gbk), 0o644))
+
+	scanner := library.NewScanner(
+		[]library.SourceConfig{
+			{ID: "tvshows", Name: "TV Shows", Path: filepath.Join(tmp, "tvshows")},
+		},
+		language.Chinese,
+	)
+
+	queue := jobs.NewQueue(1, nil)
+	queue.Start(func(_ context.Context, _ *jobs.TranslationJob) error { return nil })
+	t.Cleanup(func() {
+		queue.Stop()
+	})
+
+	job, created := queue.Enqueue(jobs.EnqueueRequest{
+		Source:    "manual",
+		DedupeKey: mediaPath + "|" + subtitlePath + "|zh",
+		Payload: jobs.JobPayload{
+			MediaFile:    mediaPath,
+			SubtitleFile: subtitlePath,
+		},
+	})
+	require.True(t, created)
+	require.Eventually(t, func() bool {
+		got, ok := queue.Get(job.ID)
+		return ok && got.Status == jobs.StatusSuccess
+	}, time.Second, 20*time.Millisecond)
+
+	srv := NewServer(scanner, queue)
+	body := []byte(`{"lines":[{"index":2,"translated_text":"你在说什么"}]}`)
+	req := httptest.NewRequest(http.MethodPut, "/api/jobs/"+job.ID+"/lines", bytes.NewReader(body))
+	rec := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	data, err := os.ReadFile(outputPath)
+	require.NoError(t, err)
+	decoded, err := simplifiedchinese.GBK.NewDecoder().Bytes(data)
+	require.NoError(t, err)
+	require.Contains(t, string(decoded), "我们走吧")
+	require.Contains(t, string(decoded), "你在说什么")
 }
 
 const sampleSRTThreeLines = `1
@@ -553,3 +970,452 @@ line two
 line three
 
 `
+
+func TestServer_UpdateJobLine_KeepsBilingualOutput(t *testing.T) {
+	tmp := t.TempDir()
+	showDir := filepath.Join(tmp, "tvshows", "The Show")
+	require.NoError(t, os.MkdirAll(showDir, 0o755))
+
+	mediaPath := filepath.Join(showDir, "episode01.mkv")
+	subtitlePath := filepath.Join(showDir, "episode01.srt")
+	outputPath := filepath.Join(showDir, "episode01_ctxtrans.zh.srt")
+	require.NoError(t, os.WriteFile(mediaPath, []byte("media"), 0o644))
+	require.NoError(t, os.WriteFile(subtitlePath, []byte(sampleSRTThreeLines), 0o644))
+	require.NoError(t, os.WriteFile(outputPath, []byte(`1
+00:00:01,000 --> 00:00:02,000
+第一行
+line one
+
+2
+00:00:03,000 --> 00:00:04,000
+第二行
+line two
+
+3
+00:00:05,000 --> 00:00:06,000
+第三行
+line three
+
+`), 0o644))
+
+	scanner := library.NewScanner(
+		[]library.SourceConfig{
+			{ID: "tvshows", Name: "TV Shows", Path: filepath.Join(tmp, "tvshows")},
+		},
+		language.Chinese,
+	)
+
+	queue := jobs.NewQueue(1, nil)
+	queue.Start(func(_ context.Context, _ *jobs.TranslationJob) error { return nil })
+	t.Cleanup(func() {
+		queue.Stop()
+	})
+
+	job, created := queue.Enqueue(jobs.EnqueueRequest{
+		Source:    "manual",
+		DedupeKey: mediaPath + "|" + subtitlePath + "|zh",
+		Payload: jobs.JobPayload{
+			MediaFile:    mediaPath,
+			SubtitleFile: subtitlePath,
+			OutputMode:   "bilingual",
+		},
+	})
+	require.True(t, created)
+	require.Eventually(t, func() bool {
+		got, ok := queue.Get(job.ID)
+		return ok && got.Status == jobs.StatusSuccess
+	}, time.Second, 20*time.Millisecond)
+
+	srv := NewServer(scanner, queue)
+	body := []byte(`{"lines":[{"index":2,"translated_text":"第二行已改"}]}`)
+	req := httptest.NewRequest(http.MethodPut, "/api/jobs/"+job.ID+"/lines", bytes.NewReader(body))
+	rec := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	var detail jobDetailResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &detail))
+	require.Len(t, detail.Preview, 3)
+	require.Equal(t, "第一行", detail.Preview[0].TranslatedText)
+	require.Equal(t, "第二行已改", detail.Preview[1].TranslatedText)
+
+	data, err := os.ReadFile(outputPath)
+	require.NoError(t, err)
+	require.Contains(t, string(data), "第一行\nline one\n")
+	require.Contains(t, string(data), "第二行已改\nline two\n")
+	require.NotContains(t, string(data), "line two\nline two")
+}
+
+func TestServer_UpdateJobLine_UsesRecordedPivotSubtitle(t *testing.T) {
+	tmp := t.TempDir()
+	showDir := filepath.Join(tmp, "tvshows", "The Show")
+	require.NoError(t, os.MkdirAll(showDir, 0o755))
+
+	mediaPath := filepath.Join(showDir, "episode01.mkv")
+	subtitlePath := filepath.Join(showDir, "episode01.srt")
+	outputPath := filepath.Join(showDir, "episode01_ctxtrans.zh.srt")
+	require.NoError(t, os.WriteFile(mediaPath, []byte("media"), 0o644))
+	require.NoError(t, os.WriteFile(subtitlePath, []byte(sampleSRTThreeLines), 0o644))
+	// The job translated a pivot subtitle with fewer cues and other timings.
+	require.NoError(t, os.WriteFile(outputPath, []byte(`1
+00:00:01,500 --> 00:00:03,500
+第一行
+pivot one
+
+2
+00:00:04,500 --> 00:00:06,500
+第二行
+pivot two
+
+`), 0o644))
+
+	scanner := library.NewScanner(
+		[]library.SourceConfig{
+			{ID: "tvshows", Name: "TV Shows", Path: filepath.Join(tmp, "tvshows")},
+		},
+		language.Chinese,
+	)
+
+	store, err := persistence.NewSQLiteStore(filepath.Join(tmp, "state.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, store.Close())
+	})
+
+	queue := jobs.NewQueue(1, store)
+	queue.Start(func(_ context.Context, _ *jobs.TranslationJob) error { return nil })
+	t.Cleanup(func() {
+		queue.Stop()
+	})
+
+	job, created := queue.Enqueue(jobs.EnqueueRequest{
+		Source:    "manual",
+		DedupeKey: mediaPath + "|" + subtitlePath + "|zh",
+		Payload: jobs.JobPayload{
+			MediaFile:    mediaPath,
+			SubtitleFile: subtitlePath,
+			OutputMode:   "bilingual",
+		},
+	})
+	require.True(t, created)
+	require.Eventually(t, func() bool {
+		got, ok := queue.Get(job.ID)
+		return ok && got.Status == jobs.StatusSuccess
+	}, time.Second, 20*time.Millisecond)
+
+	require.NoError(t, store.PutSubtitleCache(context.Background(), persistence.SubtitleCacheEntry{
+		CacheKey:  jobSourceCacheKey(job.ID),
+		MediaPath: mediaPath,
+		JobID:     job.ID,
+		File: subtitle.File{
+			Lines: []subtitle.Line{
+				{Index: 1, StartTime: 1500 * time.Millisecond, EndTime: 3500 * time.Millisecond, Text: "pivot one"},
+				{Index: 2, StartTime: 4500 * time.Millisecond, EndTime: 6500 * time.Millisecond, Text: "pivot two"},
+			},
+			Language: language.English,
+			Format:   "SRT",
+			Path:     subtitlePath,
+		},
+	}))
+
+	var rememberedSource string
+	var remembered []subtitle.Line
+	srv := NewServer(scanner, queue, WithJobDataStore(store), WithTranslationMemoryRecorder(
+		func(_ context.Context, _ *jobs.TranslationJob, sourceLang string, _ string, lines []subtitle.Line) {
+			rememberedSource = sourceLang
+			remembered = lines
+		},
+	))
+	body := []byte(`{"lines":[{"index":2,"translated_text":"第二行已改"}]}`)
+	req := httptest.NewRequest(http.MethodPut, "/api/jobs/"+job.ID+"/lines", bytes.NewReader(body))
+	rec := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	var detail jobDetailResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &detail))
+	require.Len(t, detail.Preview, 2)
+	require.Equal(t, "pivot one", detail.Preview[0].OriginalText)
+	require.Equal(t, "第一行", detail.Preview[0].TranslatedText)
+	require.Equal(t, "第二行已改", detail.Preview[1].TranslatedText)
+
+	data, err := os.ReadFile(outputPath)
+	require.NoError(t, err)
+	require.Contains(t, string(data), "00:00:04,500 --> 00:00:06,500\n第二行已改\npivot two\n")
+	require.NotContains(t, string(data), "line one")
+	require.NotContains(t, string(data), "\n3\n")
+
+	require.Equal(t, "en", rememberedSource)
+	require.Len(t, remembered, 1)
+	require.Equal(t, "pivot two", remembered[0].Text)
+}
+
+func TestServer_Characters(t *testing.T) {
+	tmp := t.TempDir()
+	scanner := library.NewScanner(nil, language.Chinese)
+	store, err := persistence.NewSQLiteStore(filepath.Join(tmp, "state.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, store.Close())
+	})
+	require.NoError(t, store.AddCharacterProfiles(context.Background(), []persistence.CharacterProfile{
+		{SeriesKey: "/tv/The Show", Name: "Momo", Gender: "male", Formality: "casual"},
+	}))
+	srv := NewServer(scanner, jobs.NewQueue(1, nil), WithCharacterProfileStore(store))
+
+	body := bytes.NewBufferString(`{"series":"/tv/The Show/","name":"Momo","gender":"Female","formality":"casual","nicknames":["Momo-chan"," "]}`)
+	req := httptest.NewRequest(http.MethodPut, "/api/characters", body)
+	rec := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	req = httptest.NewRequest(http.MethodGet, "/api/characters?series="+url.QueryEscape("/tv/The Show"), nil)
+	rec = httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+	var resp struct {
+		Series     string `json:"series"`
+		Characters []struct {
+			Name      string   `json:"name"`
+			Gender    string   `json:"gender"`
+			Formality string   `json:"formality"`
+			Nicknames []string `json:"nicknames"`
+		} `json:"characters"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Equal(t, "/tv/The Show", resp.Series)
+	require.Len(t, resp.Characters, 1)
+	require.Equal(t, "female", resp.Characters[0].Gender)
+	require.Equal(t, []string{"Momo-chan"}, resp.Characters[0].Nicknames)
+
+	req = httptest.NewRequest(http.MethodPut, "/api/characters", bytes.NewBufferString(`{"series":"/tv/The Show","name":"Momo","gender":"robot"}`))
+	rec = httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, req)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+
+	req = httptest.NewRequest(http.MethodDelete, "/api/characters?series="+url.QueryEscape("/tv/The Show")+"&name=Momo", nil)
+	rec = httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, req)
+	require.Equal(t, http.StatusNoContent, rec.Code)
+
+	rec = httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, req)
+	require.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestServer_Songs(t *testing.T) {
+	tmp := t.TempDir()
+	scanner := library.NewScanner(nil, language.Chinese)
+	store, err := persistence.NewSQLiteStore(filepath.Join(tmp, "state.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, store.Close())
+	})
+	srv := NewServer(scanner, jobs.NewQueue(1, nil), WithSongModeStore(store))
+
+	getMode := func() string {
+		req := httptest.NewRequest(http.MethodGet, "/api/songs?series="+url.QueryEscape("/tv/The Show"), nil)
+		rec := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Code)
+		var resp struct {
+			Series string `json:"series"`
+			Mode   string `json:"mode"`
+		}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		require.Equal(t, "/tv/The Show", resp.Series)
+		return resp.Mode
+	}
+	require.Empty(t, getMode())
+
+	req := httptest.NewRequest(http.MethodPut, "/api/songs", bytes.NewBufferString(`{"series":"/tv/The Show/","mode":"Reuse"}`))
+	rec := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.Equal(t, "reuse", getMode())
+
+	req = httptest.NewRequest(http.MethodPut, "/api/songs", bytes.NewBufferString(`{"series":"/tv/The Show","mode":"karaoke"}`))
+	rec = httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, req)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+
+	req = httptest.NewRequest(http.MethodPut, "/api/songs", bytes.NewBufferString(`{"series":"/tv/The Show","mode":""}`))
+	rec = httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Empty(t, getMode())
+}
+
+func TestServer_Usage(t *testing.T) {
+	tmp := t.TempDir()
+	showDir := filepath.Join(tmp, "tvshows", "The Show")
+	require.NoError(t, os.MkdirAll(showDir, 0o755))
+
+	mediaPath := filepath.Join(showDir, "episode01.mkv")
+	subtitlePath := filepath.Join(showDir, "episode01.srt")
+	require.NoError(t, os.WriteFile(mediaPath, []byte("media"), 0o644))
+	require.NoError(t, os.WriteFile(subtitlePath, []byte("1\n00:00:01,000 --> 00:00:02,000\nline one\n"), 0o644))
+
+	scanner := library.NewScanner(
+		[]library.SourceConfig{
+			{ID: "tvshows", Name: "TV Shows", Path: filepath.Join(tmp, "tvshows")},
+		},
+		language.Chinese,
+	)
+	store, err := persistence.NewSQLiteStore(filepath.Join(tmp, "state.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, store.Close())
+	})
+
+	queue := jobs.NewQueue(1, nil)
+	job, created := queue.Enqueue(jobs.EnqueueRequest{
+		Source:    "manual",
+		DedupeKey: mediaPath + "|" + subtitlePath + "|zh",
+		Payload: jobs.JobPayload{
+			MediaFile:    mediaPath,
+			SubtitleFile: subtitlePath,
+		},
+	})
+	require.True(t, created)
+
+	ctx := context.Background()
+	now := time.Now().UTC()
+	for _, usage := range []persistence.LLMUsage{
+		{JobID: job.ID, SeriesKey: showDir, Stage: "translate", Provider: "openai", Model: "gpt-a", PromptTokens: 1000, CompletionTokens: 200, Cost: 0.5, CreatedAt: now},
+		{JobID: job.ID, SeriesKey: showDir, Stage: "terms", Provider: "openai", Model: "gpt-b", PromptTokens: 100, CompletionTokens: 20, Cost: 0.1, CreatedAt: now},
+		{JobID: "old-job", SeriesKey: showDir, Stage: "translate", Provider: "openai", Model: "gpt-a", PromptTokens: 5000, CompletionTokens: 900, Cost: 3, CreatedAt: now.AddDate(0, 0, -10)},
+	} {
+		require.NoError(t, store.AddLLMUsage(ctx, usage))
+	}
+
+	srv := NewServer(scanner, queue, WithUsageStore(store))
+	req := httptest.NewRequest(http.MethodGet, "/api/jobs/"+job.ID, nil)
+	rec := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var detail jobDetailResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &detail))
+	require.NotNil(t, detail.Usage)
+	require.Equal(t, 2, detail.Usage.Calls)
+	require.Equal(t, 1320, detail.Usage.TotalTokens)
+	require.InDelta(t, 0.6, detail.Usage.Cost, 1e-9)
+
+	req = httptest.NewRequest(http.MethodGet, "/api/usage?days=7", nil)
+	rec = httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var resp usageResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Equal(t, now.AddDate(0, 0, -6).Format(time.DateOnly), resp.Since)
+	require.Equal(t, 2, resp.Total.Calls, "usage older than the window is left out")
+	require.Len(t, resp.Daily, 1)
+	require.Equal(t, now.Format(time.DateOnly), resp.Daily[0].Key)
+	require.Len(t, resp.Series, 1)
+	require.Equal(t, showDir, resp.Series[0].Key)
+	require.Len(t, resp.Models, 2)
+	require.Equal(t, "gpt-a", resp.Models[0].Key)
+	require.Len(t, resp.Providers, 1)
+
+	rec = httptest.NewRecorder()
+	NewServer(scanner, queue).Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/usage", nil))
+	require.Equal(t, http.StatusNotImplemented, rec.Code)
+}
+
+func TestServer_TermMaps(t *testing.T) {
+	tmp := t.TempDir()
+	seriesDir := filepath.Join(tmp, "tv", "The Show")
+	require.NoError(t, os.MkdirAll(seriesDir, 0o755))
+	require.NoError(t, termmap.Save(termmap.FilePath(filepath.Join(tmp, "tv"), "ja", "en"), termmap.TermMap{"Momo": "Momo"}))
+
+	store, err := persistence.NewSQLiteStore(filepath.Join(tmp, "state.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, store.Close())
+	})
+	edit := func(path string, edit func(tm termmap.TermMap)) (termmap.TermMap, error) {
+		tm, err := termmap.Load(path)
+		if os.IsNotExist(err) {
+			tm = termmap.TermMap{}
+		} else if err != nil {
+			return nil, err
+		}
+		edit(tm)
+		return tm, termmap.Save(path, tm)
+	}
+	srv := NewServer(library.NewScanner(nil, language.Chinese), jobs.NewQueue(1, nil), WithTermMapStore(store), WithTermMapEditor(edit))
+	zhPath := termmap.FilePath(seriesDir, "ja", "zh")
+
+	body := bytes.NewBufferString(`{"series":"` + seriesDir + `/","source_language":"ja","target_language":"zh-Hans","term":"オカルン","translation":"奥卡伦","locked":true}`)
+	req := httptest.NewRequest(http.MethodPut, "/api/termmaps", body)
+	rec := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	saved, err := termmap.Load(zhPath)
+	require.NoError(t, err)
+	require.Equal(t, termmap.TermMap{"オカルン": "奥卡伦"}, saved)
+	require.NoError(t, store.PutTermUsage(context.Background(), "job-1", zhPath, []string{"オカルン"}))
+
+	req = httptest.NewRequest(http.MethodGet, "/api/termmaps?series="+url.QueryEscape(seriesDir), nil)
+	rec = httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+	var resp struct {
+		Series   string `json:"series"`
+		TermMaps []struct {
+			Path           string `json:"path"`
+			SourceLanguage string `json:"source_language"`
+			TargetLanguage string `json:"target_language"`
+			Inherited      bool   `json:"inherited"`
+			Terms          []struct {
+				Term        string `json:"term"`
+				Translation string `json:"translation"`
+				Locked      bool   `json:"locked"`
+				Jobs        []struct {
+					JobID string `json:"job_id"`
+				} `json:"jobs"`
+			} `json:"terms"`
+		} `json:"term_maps"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Equal(t, seriesDir, resp.Series)
+	require.Len(t, resp.TermMaps, 2)
+	require.Equal(t, "en", resp.TermMaps[0].TargetLanguage)
+	require.True(t, resp.TermMaps[0].Inherited)
+	require.False(t, resp.TermMaps[0].Terms[0].Locked)
+	require.Equal(t, zhPath, resp.TermMaps[1].Path)
+	require.False(t, resp.TermMaps[1].Inherited)
+	require.Len(t, resp.TermMaps[1].Terms, 1)
+	require.True(t, resp.TermMaps[1].Terms[0].Locked)
+	require.Len(t, resp.TermMaps[1].Terms[0].Jobs, 1)
+	require.Equal(t, "job-1", resp.TermMaps[1].Terms[0].Jobs[0].JobID)
+
+	body = bytes.NewBufferString(`{"series":"` + seriesDir + `","source_language":"ja","target_language":"zh","term":"オカルン君","previous_term":"オカルン","translation":"岡伦"}`)
+	req = httptest.NewRequest(http.MethodPut, "/api/termmaps", body)
+	rec = httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	saved, err = termmap.Load(zhPath)
+	require.NoError(t, err)
+	require.Equal(t, termmap.TermMap{"オカルン君": "岡伦"}, saved)
+	locked, err := store.ListLockedTerms(context.Background(), zhPath)
+	require.NoError(t, err)
+	require.Empty(t, locked)
+
+	req = httptest.NewRequest(http.MethodPut, "/api/termmaps", bytes.NewBufferString(`{"series":"`+seriesDir+`","source_language":"??","target_language":"zh","term":"a","translation":"b"}`))
+	rec = httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, req)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+
+	req = httptest.NewRequest(http.MethodDelete, "/api/termmaps?series="+url.QueryEscape(seriesDir)+"&source_language=ja&target_language=zh&term="+url.QueryEscape("オカルン君"), nil)
+	rec = httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, req)
+	require.Equal(t, http.StatusNoContent, rec.Code)
+	saved, err = termmap.Load(zhPath)
+	require.NoError(t, err)
+	require.Empty(t, saved)
+
+	rec = httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, req)
+	require.Equal(t, http.StatusNotFound, rec.Code)
+}
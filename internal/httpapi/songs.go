@@ -0,0 +1,94 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/MimeLyc/contextual-sub-translator/internal/songs"
+)
+
+// songModeStore keeps how the opening, ending and insert songs of a series
+// are translated, keyed by series directory
+type songModeStore interface {
+	GetSongMode(ctx context.Context, seriesKey string) (string, bool, error)
+	PutSongMode(ctx context.Context, seriesKey string, mode string) error
+	DeleteSongMode(ctx context.Context, seriesKey string) (bool, error)
+}
+
+func WithSongModeStore(store songModeStore) Option {
+	return func(s *Server) {
+		s.songModes = store
+	}
+}
+
+// songModeResponse is the song mode of a series; an empty mode means the
+// series follows the configured default
+type songModeResponse struct {
+	Series string `json:"series"`
+	Mode   string `json:"mode"`
+}
+
+type updateSongModeRequest struct {
+	Series string `json:"series"`
+	Mode   string `json:"mode"`
+}
+
+// handleSongs serves the song mode of a series. The series is its directory,
+// e.g. the path of a library item; an empty mode resets it to the default:
+//
+//	GET /api/songs?series={dir}
+//	PUT /api/songs               body: updateSongModeRequest
+func (s *Server) handleSongs(w http.ResponseWriter, r *http.Request) {
+	if s.songModes == nil {
+		writeError(w, http.StatusNotImplemented, "song mode store is not configured")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		series := cleanSeriesKey(r.URL.Query().Get("series"))
+		if series == "" {
+			writeError(w, http.StatusBadRequest, "series is required")
+			return
+		}
+		mode, _, err := s.songModes.GetSongMode(r.Context(), series)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, songModeResponse{Series: series, Mode: mode})
+	case http.MethodPut:
+		var req updateSongModeRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid json body")
+			return
+		}
+		series := cleanSeriesKey(req.Series)
+		if series == "" {
+			writeError(w, http.StatusBadRequest, "series is required")
+			return
+		}
+		if strings.TrimSpace(req.Mode) == "" {
+			if _, err := s.songModes.DeleteSongMode(r.Context(), series); err != nil {
+				writeError(w, http.StatusInternalServerError, err.Error())
+				return
+			}
+			writeJSON(w, http.StatusOK, songModeResponse{Series: series})
+			return
+		}
+		mode, err := songs.ParseMode(req.Mode)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if err := s.songModes.PutSongMode(r.Context(), series, string(mode)); err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, songModeResponse{Series: series, Mode: string(mode)})
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
@@ -0,0 +1,280 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/text/language"
+
+	"github.com/MimeLyc/contextual-sub-translator/internal/persistence"
+	"github.com/MimeLyc/contextual-sub-translator/internal/termmap"
+)
+
+// termMapStore keeps which terms of a term map file are locked against term
+// discovery and which jobs used them, keyed by the path of the file
+type termMapStore interface {
+	ListLockedTerms(ctx context.Context, termMapPath string) ([]string, error)
+	SetTermLocked(ctx context.Context, termMapPath string, term string, locked bool) error
+	ListTermUsage(ctx context.Context, termMapPath string) ([]persistence.TermUsage, error)
+}
+
+// termMapEditor applies an edit to a term map file under the lock the
+// translator saves discovered terms with, and returns the saved term map
+type termMapEditor func(path string, edit func(tm termmap.TermMap)) (termmap.TermMap, error)
+
+func WithTermMapStore(store termMapStore) Option {
+	return func(s *Server) {
+		s.termMaps = store
+	}
+}
+
+func WithTermMapEditor(edit termMapEditor) Option {
+	return func(s *Server) {
+		s.editTermMap = edit
+	}
+}
+
+type termJobResponse struct {
+	JobID     string    `json:"job_id"`
+	MediaFile string    `json:"media_file,omitempty"`
+	UsedAt    time.Time `json:"used_at"`
+}
+
+type termResponse struct {
+	Term        string            `json:"term"`
+	Translation string            `json:"translation"`
+	Locked      bool              `json:"locked"`
+	Jobs        []termJobResponse `json:"jobs"`
+}
+
+// termMapResponse is a term map file the series uses. Inherited is set when
+// the file lies in a parent directory and is shared with other series.
+type termMapResponse struct {
+	Path           string         `json:"path"`
+	SourceLanguage string         `json:"source_language"`
+	TargetLanguage string         `json:"target_language"`
+	Inherited      bool           `json:"inherited"`
+	Terms          []termResponse `json:"terms"`
+}
+
+type termMapListResponse struct {
+	Series   string            `json:"series"`
+	TermMaps []termMapResponse `json:"term_maps"`
+}
+
+type updateTermRequest struct {
+	Series         string `json:"series"`
+	SourceLanguage string `json:"source_language"`
+	TargetLanguage string `json:"target_language"`
+	Term           string `json:"term"`
+	Translation    string `json:"translation"`
+	Locked         bool   `json:"locked"`
+	// PreviousTerm renames a term: it is removed when it differs from Term
+	PreviousTerm string `json:"previous_term"`
+}
+
+// handleTermMaps serves the term maps a series uses: the nearest
+// term_map.<src>-<tgt>.json of each language pair in its directory or a
+// parent. Writes go to that file, or create one in the series directory:
+//
+//	GET    /api/termmaps?series={dir}[&source_language={src}&target_language={tgt}]
+//	PUT    /api/termmaps        body: updateTermRequest
+//	DELETE /api/termmaps?series={dir}&source_language={src}&target_language={tgt}&term={term}
+func (s *Server) handleTermMaps(w http.ResponseWriter, r *http.Request) {
+	if s.termMaps == nil || s.editTermMap == nil {
+		writeError(w, http.StatusNotImplemented, "term map store is not configured")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		query := r.URL.Query()
+		series := cleanSeriesKey(query.Get("series"))
+		if series == "" {
+			writeError(w, http.StatusBadRequest, "series is required")
+			return
+		}
+		paths := termmap.ListInAncestors(series)
+		if query.Get("source_language") != "" || query.Get("target_language") != "" {
+			path, _, ok := termMapPath(w, series, query.Get("source_language"), query.Get("target_language"))
+			if !ok {
+				return
+			}
+			paths = []string{path}
+		}
+		resp := termMapListResponse{Series: series, TermMaps: make([]termMapResponse, 0, len(paths))}
+		for _, path := range paths {
+			if _, err := os.Stat(path); os.IsNotExist(err) {
+				continue
+			}
+			item, err := s.termMapResponse(r.Context(), series, path)
+			if err != nil {
+				writeError(w, http.StatusInternalServerError, err.Error())
+				return
+			}
+			resp.TermMaps = append(resp.TermMaps, item)
+		}
+		writeJSON(w, http.StatusOK, resp)
+	case http.MethodPut:
+		var req updateTermRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid json body")
+			return
+		}
+		series := cleanSeriesKey(req.Series)
+		term := strings.TrimSpace(req.Term)
+		translation := strings.TrimSpace(req.Translation)
+		previous := strings.TrimSpace(req.PreviousTerm)
+		if series == "" || term == "" || translation == "" {
+			writeError(w, http.StatusBadRequest, "series, term and translation are required")
+			return
+		}
+		if info, err := os.Stat(series); err != nil || !info.IsDir() {
+			writeError(w, http.StatusBadRequest, "series directory does not exist")
+			return
+		}
+		path, _, ok := termMapPath(w, series, req.SourceLanguage, req.TargetLanguage)
+		if !ok {
+			return
+		}
+		if _, err := s.editTermMap(path, func(tm termmap.TermMap) {
+			if previous != "" && previous != term {
+				delete(tm, previous)
+			}
+			tm[term] = translation
+		}); err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if previous != "" && previous != term {
+			if err := s.termMaps.SetTermLocked(r.Context(), path, previous, false); err != nil {
+				writeError(w, http.StatusInternalServerError, err.Error())
+				return
+			}
+		}
+		if err := s.termMaps.SetTermLocked(r.Context(), path, term, req.Locked); err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		item, err := s.termMapResponse(r.Context(), series, path)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, item)
+	case http.MethodDelete:
+		query := r.URL.Query()
+		series := cleanSeriesKey(query.Get("series"))
+		term := strings.TrimSpace(query.Get("term"))
+		if series == "" || term == "" {
+			writeError(w, http.StatusBadRequest, "series and term are required")
+			return
+		}
+		path, found, ok := termMapPath(w, series, query.Get("source_language"), query.Get("target_language"))
+		if !ok {
+			return
+		}
+		if !found {
+			writeError(w, http.StatusNotFound, "term map not found")
+			return
+		}
+		deleted := false
+		if _, err := s.editTermMap(path, func(tm termmap.TermMap) {
+			_, deleted = tm[term]
+			delete(tm, term)
+		}); err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if !deleted {
+			writeError(w, http.StatusNotFound, "term not found")
+			return
+		}
+		if err := s.termMaps.SetTermLocked(r.Context(), path, term, false); err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// termMapPath returns the term map file of a language pair the series uses,
+// or where to create it when found is false. It writes the error response
+// and returns ok false for an invalid language.
+func termMapPath(w http.ResponseWriter, series, sourceLang, targetLang string) (path string, found bool, ok bool) {
+	source, err := language.Parse(strings.TrimSpace(sourceLang))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid source_language")
+		return "", false, false
+	}
+	target, err := language.Parse(strings.TrimSpace(targetLang))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid target_language")
+		return "", false, false
+	}
+	if path := termmap.FindInAncestors(series, source.String(), target.String()); path != "" {
+		return path, true, true
+	}
+	return termmap.FilePath(series, source.String(), target.String()), false, true
+}
+
+func (s *Server) termMapResponse(ctx context.Context, series, path string) (termMapResponse, error) {
+	tm, err := termmap.Load(path)
+	if err != nil && !os.IsNotExist(err) {
+		return termMapResponse{}, err
+	}
+	locked, err := s.termMaps.ListLockedTerms(ctx, path)
+	if err != nil {
+		return termMapResponse{}, err
+	}
+	usage, err := s.termMaps.ListTermUsage(ctx, path)
+	if err != nil {
+		return termMapResponse{}, err
+	}
+
+	lockedSet := make(map[string]bool, len(locked))
+	for _, term := range locked {
+		lockedSet[term] = true
+	}
+	jobsByTerm := make(map[string][]termJobResponse)
+	for _, item := range usage {
+		job := termJobResponse{JobID: item.JobID, UsedAt: item.UsedAt}
+		if s.queue != nil {
+			if queued, ok := s.queue.Get(item.JobID); ok {
+				job.MediaFile = queued.Payload.MediaFile
+			}
+		}
+		jobsByTerm[item.Term] = append(jobsByTerm[item.Term], job)
+	}
+
+	sourceLang, targetLang, _ := termmap.ParseFilename(filepath.Base(path))
+	resp := termMapResponse{
+		Path:           path,
+		SourceLanguage: sourceLang,
+		TargetLanguage: targetLang,
+		Inherited:      filepath.Dir(path) != series,
+		Terms:          make([]termResponse, 0, len(tm)),
+	}
+	for term, translation := range tm {
+		jobs := jobsByTerm[term]
+		if jobs == nil {
+			jobs = []termJobResponse{}
+		}
+		resp.Terms = append(resp.Terms, termResponse{
+			Term:        term,
+			Translation: translation,
+			Locked:      lockedSet[term],
+			Jobs:        jobs,
+		})
+	}
+	sort.Slice(resp.Terms, func(i, j int) bool { return resp.Terms[i].Term < resp.Terms[j].Term })
+	return resp, nil
+}
@@ -0,0 +1,123 @@
+package httpapi
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/MimeLyc/contextual-sub-translator/internal/persistence"
+)
+
+const (
+	defaultUsageDays = 30
+	maxUsageDays     = 366
+)
+
+// usageStore keeps the token usage and cost of every LLM call
+type usageStore interface {
+	JobUsage(ctx context.Context, jobID string) (persistence.UsageTotals, error)
+	UsageSince(ctx context.Context, since time.Time) (persistence.UsageTotals, error)
+	UsageBreakdown(ctx context.Context, by persistence.UsageGrouping, since time.Time) ([]persistence.UsageGroup, error)
+}
+
+func WithUsageStore(store usageStore) Option {
+	return func(s *Server) {
+		s.usage = store
+	}
+}
+
+type usageTotalsResponse struct {
+	Calls            int     `json:"calls"`
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	TotalTokens      int     `json:"total_tokens"`
+	Cost             float64 `json:"cost"`
+}
+
+type usageGroupResponse struct {
+	Key string `json:"key"`
+	usageTotalsResponse
+}
+
+type usageResponse struct {
+	Since     string               `json:"since"`
+	Total     usageTotalsResponse  `json:"total"`
+	Daily     []usageGroupResponse `json:"daily"`
+	Series    []usageGroupResponse `json:"series"`
+	Models    []usageGroupResponse `json:"models"`
+	Providers []usageGroupResponse `json:"providers"`
+}
+
+// handleUsage reports LLM usage and cost of the last days, today included:
+//
+//	GET /api/usage?days={n}
+func (s *Server) handleUsage(w http.ResponseWriter, r *http.Request) {
+	if s.usage == nil {
+		writeError(w, http.StatusNotImplemented, "usage store is not configured")
+		return
+	}
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	days := parsePositiveIntWithDefault(r.URL.Query().Get("days"), defaultUsageDays)
+	days = min(max(days, 1), maxUsageDays)
+	since := time.Now().UTC().AddDate(0, 0, 1-days)
+
+	total, err := s.usage.UsageSince(r.Context(), since)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	resp := usageResponse{
+		Since: since.Format(time.DateOnly),
+		Total: newUsageTotalsResponse(total),
+	}
+	for _, breakdown := range []struct {
+		by   persistence.UsageGrouping
+		dest *[]usageGroupResponse
+	}{
+		{persistence.UsageByDay, &resp.Daily},
+		{persistence.UsageBySeries, &resp.Series},
+		{persistence.UsageByModel, &resp.Models},
+		{persistence.UsageByProvider, &resp.Providers},
+	} {
+		groups, err := s.usage.UsageBreakdown(r.Context(), breakdown.by, since)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		*breakdown.dest = make([]usageGroupResponse, 0, len(groups))
+		for _, group := range groups {
+			*breakdown.dest = append(*breakdown.dest, usageGroupResponse{
+				Key:                 group.Key,
+				usageTotalsResponse: newUsageTotalsResponse(group.UsageTotals),
+			})
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// jobUsage returns the usage of a job, nil when usage is not recorded
+func (s *Server) jobUsage(ctx context.Context, jobID string) (*usageTotalsResponse, error) {
+	if s.usage == nil {
+		return nil, nil
+	}
+	totals, err := s.usage.JobUsage(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	resp := newUsageTotalsResponse(totals)
+	return &resp, nil
+}
+
+func newUsageTotalsResponse(totals persistence.UsageTotals) usageTotalsResponse {
+	return usageTotalsResponse{
+		Calls:            totals.Calls,
+		PromptTokens:     totals.PromptTokens,
+		CompletionTokens: totals.CompletionTokens,
+		TotalTokens:      totals.PromptTokens + totals.CompletionTokens,
+		Cost:             totals.Cost,
+	}
+}
@@ -15,12 +15,17 @@ import (
 
 type Executor func(ctx context.Context, job *TranslationJob) error
 
+// Gate decides whether a pending job may start. A non-empty reason holds the
+// job pending until Release is called.
+type Gate func(job *TranslationJob) (reason string)
+
 type Queue struct {
 	workerCount int
 	maxJobs     int
 	store       Store
 
 	mu         sync.RWMutex
+	gate       Gate
 	jobs       map[string]*TranslationJob
 	dedupe     map[string]string
 	idCounter  uint64
@@ -142,6 +147,34 @@ func (q *Queue) Start(exec Executor) {
 	}
 }
 
+// SetGate installs the gate consulted before each job starts.
+func (q *Queue) SetGate(gate Gate) {
+	q.mu.Lock()
+	q.gate = gate
+	q.mu.Unlock()
+}
+
+// Release dispatches held jobs again, each passing the gate once more.
+func (q *Queue) Release() {
+	q.mu.RLock()
+	if !q.started {
+		q.mu.RUnlock()
+		return
+	}
+	held := make([]string, 0)
+	for id, job := range q.jobs {
+		if job.Status == StatusPending && job.HoldReason != "" {
+			held = append(held, id)
+		}
+	}
+	q.mu.RUnlock()
+
+	sort.Strings(held)
+	for _, id := range held {
+		q.enqueuePendingID(id)
+	}
+}
+
 func (q *Queue) Stop() {
 	q.stopOnce.Do(func() {
 		close(q.stopCh)
@@ -181,12 +214,37 @@ func (q *Queue) enqueuePendingID(id string) {
 }
 
 func (q *Queue) markRunning(id string) (*TranslationJob, bool) {
-	q.mu.Lock()
+	q.mu.RLock()
 	job, ok := q.jobs[id]
 	if !ok || job.Status != StatusPending {
+		q.mu.RUnlock()
+		return nil, false
+	}
+	gate := q.gate
+	candidate := cloneJob(job)
+	q.mu.RUnlock()
+
+	// the gate may query the store, so it runs outside the lock
+	reason := ""
+	if gate != nil {
+		reason = gate(candidate)
+	}
+
+	q.mu.Lock()
+	job, ok = q.jobs[id]
+	if !ok || job.Status != StatusPending {
+		q.mu.Unlock()
+		return nil, false
+	}
+	if reason != "" {
+		if job.HoldReason != reason {
+			log.Info("Holding job %s: %s", id, reason)
+		}
+		job.HoldReason = reason
 		q.mu.Unlock()
 		return nil, false
 	}
+	job.HoldReason = ""
 	job.Status = StatusRunning
 	job.UpdatedAt = time.Now()
 	snapshot := cloneJob(job)
@@ -1,6 +1,10 @@
 package jobs
 
-import "time"
+import (
+	"time"
+
+	"github.com/MimeLyc/contextual-sub-translator/internal/subtitle"
+)
 
 type Status string
 
@@ -22,6 +26,14 @@ type JobPayload struct {
 	MediaFile    string `json:"media_file"`
 	SubtitleFile string `json:"subtitle_file"`
 	NFOFile      string `json:"nfo_file"`
+	OutputMode   string `json:"output_mode,omitempty"` // subtitle.OutputMode; empty uses the configured default
+	// TargetLanguage is the language the job translates to; empty uses the
+	// primary configured target language
+	TargetLanguage string `json:"target_language,omitempty"`
+	// StreamIndex picks the embedded subtitle stream (the N in 0:s:N) when no
+	// subtitle file is given; it takes precedence over StreamPolicy
+	StreamIndex  *int                   `json:"stream_index,omitempty"`
+	StreamPolicy *subtitle.StreamPolicy `json:"stream_policy,omitempty"`
 }
 
 type TranslationJob struct {
@@ -31,6 +43,9 @@ type TranslationJob struct {
 	Payload   JobPayload `json:"payload"`
 	Status    Status    `json:"status"`
 	Error     string    `json:"error,omitempty"`
+	// HoldReason says why a pending job is not dispatched, e.g. a budget cap;
+	// it is not persisted, the gate sets it again after a restart
+	HoldReason string `json:"hold_reason,omitempty"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
@@ -2,6 +2,7 @@ package jobs
 
 import (
 	"context"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -26,3 +27,38 @@ func TestQueue_Worker_TransitionsStatus(t *testing.T) {
 		return got.Status == StatusSuccess
 	}, time.Second, 10*time.Millisecond)
 }
+
+func TestQueue_Gate_HoldsJobsUntilReleased(t *testing.T) {
+	q := NewQueue(1, nil)
+	var capReached atomic.Bool
+	capReached.Store(true)
+	q.SetGate(func(_ *TranslationJob) string {
+		if capReached.Load() {
+			return "daily budget reached"
+		}
+		return ""
+	})
+	q.Start(func(_ context.Context, _ *TranslationJob) error { return nil })
+	defer q.Stop()
+
+	job, _ := q.Enqueue(EnqueueRequest{Source: "manual", DedupeKey: "k1"})
+	require.Eventually(t, func() bool {
+		got, _ := q.Get(job.ID)
+		return got.HoldReason == "daily budget reached"
+	}, time.Second, 10*time.Millisecond)
+	got, _ := q.Get(job.ID)
+	require.Equal(t, StatusPending, got.Status)
+
+	q.Release()
+	require.Eventually(t, func() bool {
+		got, _ := q.Get(job.ID)
+		return got.Status == StatusPending && got.HoldReason != ""
+	}, time.Second, 10*time.Millisecond, "still held while the cap is reached")
+
+	capReached.Store(false)
+	q.Release()
+	require.Eventually(t, func() bool {
+		got, _ := q.Get(job.ID)
+		return got.Status == StatusSuccess && got.HoldReason == ""
+	}, time.Second, 10*time.Millisecond)
+}
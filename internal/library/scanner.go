@@ -2,6 +2,7 @@ package library
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -12,12 +13,18 @@ import (
 
 	"golang.org/x/sync/errgroup"
 	"golang.org/x/text/language"
+
+	"github.com/MimeLyc/contextual-sub-translator/internal/subtitle"
 )
 
+// EmbeddedDetector reports the embedded subtitles of a media file.
+// hasEmbeddedTargetSubtitle is about the primary target language; the other
+// targets are looked up in languages.
 type EmbeddedDetector func(mediaPath string) (hasEmbeddedSubtitle bool, hasEmbeddedTargetSubtitle bool, languages []string)
 
 type scannerOptions struct {
 	embeddedDetector EmbeddedDetector
+	targetLanguages  []language.Tag
 	cacheTTL         time.Duration
 	sourcesCacheTTL  time.Duration
 	itemsCacheTTL    time.Duration
@@ -33,6 +40,14 @@ func WithEmbeddedDetector(detector EmbeddedDetector) Option {
 	}
 }
 
+// WithTargetLanguages sets every target language, the first being the
+// primary one. It replaces the target language given to NewScanner.
+func WithTargetLanguages(languages ...language.Tag) Option {
+	return func(o *scannerOptions) {
+		o.targetLanguages = languages
+	}
+}
+
 func WithCacheTTL(ttl time.Duration) Option {
 	return func(o *scannerOptions) {
 		o.cacheTTL = ttl
@@ -89,7 +104,7 @@ type episodesCache struct {
 
 type Scanner struct {
 	sources          []SourceConfig
-	targetLanguage   language.Tag
+	targetLanguages  []language.Tag
 	embeddedDetector EmbeddedDetector
 
 	mu            sync.RWMutex
@@ -123,10 +138,14 @@ func NewScanner(
 	for _, opt := range opts {
 		opt(&options)
 	}
+	targetLanguages := []language.Tag{targetLanguage}
+	if len(options.targetLanguages) > 0 {
+		targetLanguages = slices.Clone(options.targetLanguages)
+	}
 
 	return &Scanner{
 		sources:          sources,
-		targetLanguage:   targetLanguage,
+		targetLanguages:  targetLanguages,
 		embeddedDetector: options.embeddedDetector,
 		cacheTTL:         options.cacheTTL,
 		srcCacheTTL:      options.sourcesCacheTTL,
@@ -138,24 +157,72 @@ func NewScanner(
 	}
 }
 
+// TargetLanguage returns the base of the primary target language
 func (s *Scanner) TargetLanguage() string {
 	s.mu.RLock()
-	target := s.targetLanguage
+	target := s.targetLanguages[0]
 	s.mu.RUnlock()
 
 	base, _ := target.Base()
 	return base.String()
 }
 
-func (s *Scanner) UpdateTargetLanguage(lang string) error {
-	tag, err := language.Parse(lang)
+// TargetLanguages returns every target language, the primary one first
+func (s *Scanner) TargetLanguages() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ret := make([]string, 0, len(s.targetLanguages))
+	for _, tag := range s.targetLanguages {
+		ret = append(ret, tag.String())
+	}
+	return ret
+}
+
+// MissingTargets returns the target languages a media file has neither an
+// external nor an embedded subtitle in, the primary one first
+func (s *Scanner) MissingTargets(mediaPath string) ([]string, error) {
+	s.mu.RLock()
+	targets := slices.Clone(s.targetLanguages)
+	s.mu.RUnlock()
+
+	status, err := detectSubtitles(mediaPath, targets, s.embeddedDetector)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	ret := make([]string, 0, len(status.Targets))
+	for _, target := range status.Targets {
+		if !target.HasSubtitle {
+			ret = append(ret, target.Language)
+		}
+	}
+	return ret, nil
+}
+
+func (s *Scanner) UpdateTargetLanguage(lang string) error {
+	return s.UpdateTargetLanguages([]string{lang})
+}
+
+// UpdateTargetLanguages replaces the target languages, the first being the
+// primary one.
+func (s *Scanner) UpdateTargetLanguages(langs []string) error {
+	tags := make([]language.Tag, 0, len(langs))
+	for _, lang := range langs {
+		tag, err := language.Parse(lang)
+		if err != nil {
+			return err
+		}
+		if !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	if len(tags) == 0 {
+		return fmt.Errorf("no target language")
 	}
 
 	s.mu.Lock()
-	if s.targetLanguage != tag {
-		s.targetLanguage = tag
+	if !slices.Equal(s.targetLanguages, tags) {
+		s.targetLanguages = tags
 		s.invalidateLocked()
 	}
 	s.mu.Unlock()
@@ -252,7 +319,7 @@ func (s *Scanner) Scan(ctx context.Context) (*Library, error) {
 		return cached, nil
 	}
 	sources := append([]SourceConfig(nil), s.sources...)
-	targetLanguage := s.targetLanguage
+	targetLanguages := s.targetLanguages
 	embeddedDetector := s.embeddedDetector
 	s.mu.RUnlock()
 
@@ -307,51 +374,20 @@ func (s *Scanner) Scan(ctx context.Context) (*Library, error) {
 			}
 
 			baseName := strings.TrimSuffix(filepath.Base(mediaPath), filepath.Ext(mediaPath))
-			mediaDir := filepath.Dir(mediaPath)
-			sourceSubs, targetSubs, extLangs, err := findExternalSubtitles(mediaDir, baseName, targetLanguage)
+			subtitles, err := detectSubtitles(mediaPath, targetLanguages, embeddedDetector)
 			if err != nil {
 				return nil, err
 			}
 
-			hasEmbedded, hasEmbeddedTarget, embeddedLangs := embeddedDetector(mediaPath)
-			hasEmbeddedTarget = hasEmbeddedTarget || embeddedLanguagesContainTarget(embeddedLangs, targetLanguage)
-			hasSource := len(sourceSubs) > 0 || hasEmbedded
-			hasTarget := len(targetSubs) > 0 || hasEmbeddedTarget
-
-			// Merge external and embedded languages (deduplicated, normalized)
-			languages := extLangs
-			seen := make(map[string]bool, len(extLangs))
-			for _, l := range extLangs {
-				seen[l] = true
-			}
-			for _, l := range embeddedLangs {
-				normalized := normalizeLangCode(l)
-				if normalized == "" {
-					continue
-				}
-				if !seen[normalized] {
-					seen[normalized] = true
-					languages = append(languages, normalized)
-				}
-			}
-
 			episode := Episode{
-				ID:        mediaPath,
-				SourceID:  sourceCfg.ID,
-				ItemID:    ret.Items[itemIdx].ID,
-				Name:      cleanEpisodeName(baseName),
-				Season:    resolveSeasonName(itemPath, mediaPath),
-				MediaPath: mediaPath,
-				Subtitles: SubtitleStatus{
-					HasSourceSubtitle:         hasSource,
-					HasTargetSubtitle:         hasTarget,
-					HasEmbeddedSubtitle:       hasEmbedded,
-					HasEmbeddedTargetSubtitle: hasEmbeddedTarget,
-					SourceSubtitleFiles:       sourceSubs,
-					TargetSubtitleFiles:       targetSubs,
-					Languages:                 languages,
-				},
-				Translatable: hasSource && !hasTarget,
+				ID:           mediaPath,
+				SourceID:     sourceCfg.ID,
+				ItemID:       ret.Items[itemIdx].ID,
+				Name:         cleanEpisodeName(baseName),
+				Season:       resolveSeasonName(itemPath, mediaPath),
+				MediaPath:    mediaPath,
+				Subtitles:    subtitles,
+				Translatable: subtitles.translatable(),
 			}
 			ret.Episodes = append(ret.Episodes, episode)
 			ret.Items[itemIdx].EpisodeCount++
@@ -526,7 +562,7 @@ func (s *Scanner) ScanEpisodesByItem(ctx context.Context, itemID string) ([]Epis
 		s.mu.RUnlock()
 		return result, nil
 	}
-	targetLanguage := s.targetLanguage
+	targetLanguages := s.targetLanguages
 	embeddedDetector := s.embeddedDetector
 	maxConc := s.maxConcurrency
 	s.mu.RUnlock()
@@ -572,50 +608,20 @@ func (s *Scanner) ScanEpisodesByItem(ctx context.Context, itemID string) ([]Epis
 			}
 
 			baseName := strings.TrimSuffix(filepath.Base(mediaPath), filepath.Ext(mediaPath))
-			mediaDir := filepath.Dir(mediaPath)
-			sourceSubs, targetSubs, extLangs, err := findExternalSubtitles(mediaDir, baseName, targetLanguage)
+			subtitles, err := detectSubtitles(mediaPath, targetLanguages, embeddedDetector)
 			if err != nil {
 				return err
 			}
 
-			hasEmbedded, hasEmbeddedTarget, embeddedLangs := embeddedDetector(mediaPath)
-			hasEmbeddedTarget = hasEmbeddedTarget || embeddedLanguagesContainTarget(embeddedLangs, targetLanguage)
-			hasSource := len(sourceSubs) > 0 || hasEmbedded
-			hasTarget := len(targetSubs) > 0 || hasEmbeddedTarget
-
-			languages := extLangs
-			seen := make(map[string]bool, len(extLangs))
-			for _, l := range extLangs {
-				seen[l] = true
-			}
-			for _, l := range embeddedLangs {
-				normalized := normalizeLangCode(l)
-				if normalized == "" {
-					continue
-				}
-				if !seen[normalized] {
-					seen[normalized] = true
-					languages = append(languages, normalized)
-				}
-			}
-
 			episodes[i] = Episode{
-				ID:        mediaPath,
-				SourceID:  sourceID,
-				ItemID:    itemID,
-				Name:      cleanEpisodeName(baseName),
-				Season:    resolveSeasonName(itemPath, mediaPath),
-				MediaPath: mediaPath,
-				Subtitles: SubtitleStatus{
-					HasSourceSubtitle:         hasSource,
-					HasTargetSubtitle:         hasTarget,
-					HasEmbeddedSubtitle:       hasEmbedded,
-					HasEmbeddedTargetSubtitle: hasEmbeddedTarget,
-					SourceSubtitleFiles:       sourceSubs,
-					TargetSubtitleFiles:       targetSubs,
-					Languages:                 languages,
-				},
-				Translatable: hasSource && !hasTarget,
+				ID:           mediaPath,
+				SourceID:     sourceID,
+				ItemID:       itemID,
+				Name:         cleanEpisodeName(baseName),
+				Season:       resolveSeasonName(itemPath, mediaPath),
+				MediaPath:    mediaPath,
+				Subtitles:    subtitles,
+				Translatable: subtitles.translatable(),
 			}
 			return nil
 		})
@@ -642,15 +648,82 @@ func cloneEpisodes(src []Episode) []Episode {
 	dst := make([]Episode, len(src))
 	copy(dst, src)
 	for i := range dst {
-		dst[i].Subtitles.SourceSubtitleFiles = append([]string(nil), src[i].Subtitles.SourceSubtitleFiles...)
-		dst[i].Subtitles.TargetSubtitleFiles = append([]string(nil), src[i].Subtitles.TargetSubtitleFiles...)
-		dst[i].Subtitles.Languages = append([]string(nil), src[i].Subtitles.Languages...)
+		dst[i].Subtitles = cloneSubtitleStatus(src[i].Subtitles)
+	}
+	return dst
+}
+
+func cloneSubtitleStatus(src SubtitleStatus) SubtitleStatus {
+	dst := src
+	dst.SourceSubtitleFiles = append([]string(nil), src.SourceSubtitleFiles...)
+	dst.TargetSubtitleFiles = append([]string(nil), src.TargetSubtitleFiles...)
+	dst.Languages = append([]string(nil), src.Languages...)
+	dst.Targets = append([]TargetSubtitleStatus(nil), src.Targets...)
+	for i := range dst.Targets {
+		dst.Targets[i].SubtitleFiles = append([]string(nil), src.Targets[i].SubtitleFiles...)
 	}
 	return dst
 }
 
-var subtitleExts = []string{
-	".srt", ".ass", ".ssa", ".vtt", ".sub", ".idx", ".sup", ".txt",
+// detectSubtitles looks up the external and embedded subtitles of a media
+// file and which target languages they cover.
+func detectSubtitles(mediaPath string, targets []language.Tag, embeddedDetector EmbeddedDetector) (SubtitleStatus, error) {
+	baseName := strings.TrimSuffix(filepath.Base(mediaPath), filepath.Ext(mediaPath))
+	sourceSubs, targetSubs, extLangs, err := findExternalSubtitles(filepath.Dir(mediaPath), baseName, targets)
+	if err != nil {
+		return SubtitleStatus{}, err
+	}
+	hasEmbedded, hasEmbeddedPrimary, embeddedLangs := embeddedDetector(mediaPath)
+
+	status := SubtitleStatus{
+		HasSourceSubtitle:         len(sourceSubs) > 0 || hasEmbedded,
+		HasTargetSubtitle:         true,
+		HasEmbeddedSubtitle:       hasEmbedded,
+		HasEmbeddedTargetSubtitle: true,
+		SourceSubtitleFiles:       sourceSubs,
+		TargetSubtitleFiles:       make([]string, 0),
+		Targets:                   make([]TargetSubtitleStatus, 0, len(targets)),
+	}
+	for i, target := range targets {
+		hasEmbeddedTarget := (i == 0 && hasEmbeddedPrimary) || embeddedLanguagesContainTarget(embeddedLangs, target)
+		status.Targets = append(status.Targets, TargetSubtitleStatus{
+			Language:            target.String(),
+			HasSubtitle:         len(targetSubs[i]) > 0 || hasEmbeddedTarget,
+			HasEmbeddedSubtitle: hasEmbeddedTarget,
+			SubtitleFiles:       targetSubs[i],
+		})
+		for _, file := range targetSubs[i] {
+			if !slices.Contains(status.TargetSubtitleFiles, file) {
+				status.TargetSubtitleFiles = append(status.TargetSubtitleFiles, file)
+			}
+		}
+		status.HasTargetSubtitle = status.HasTargetSubtitle && status.Targets[i].HasSubtitle
+		status.HasEmbeddedTargetSubtitle = status.HasEmbeddedTargetSubtitle && hasEmbeddedTarget
+	}
+
+	// Merge external and embedded languages (deduplicated, normalized)
+	status.Languages = extLangs
+	seen := make(map[string]bool, len(extLangs))
+	for _, l := range extLangs {
+		seen[l] = true
+	}
+	for _, l := range embeddedLangs {
+		normalized := normalizeLangCode(l)
+		if normalized == "" {
+			continue
+		}
+		if !seen[normalized] {
+			seen[normalized] = true
+			status.Languages = append(status.Languages, normalized)
+		}
+	}
+	return status, nil
+}
+
+// translatable reports whether the media has a subtitle to translate and
+// lacks at least one target language
+func (s SubtitleStatus) translatable() bool {
+	return s.HasSourceSubtitle && !s.HasTargetSubtitle
 }
 
 var mediaExts = []string{
@@ -669,7 +742,8 @@ func findMediaFiles(root string) ([]string, error) {
 			return nil
 		}
 		ext := strings.ToLower(filepath.Ext(path))
-		if slices.Contains(mediaExts, ext) {
+		// skip media copies with a muxed translation
+		if slices.Contains(mediaExts, ext) && !strings.Contains(d.Name(), "_ctxtrans") {
 			ret = append(ret, path)
 		}
 		return nil
@@ -680,9 +754,16 @@ func findMediaFiles(root string) ([]string, error) {
 	return ret, nil
 }
 
-func findExternalSubtitles(dir string, mediaBase string, target language.Tag) (sourceSubs []string, targetSubs []string, languages []string, err error) {
+// findExternalSubtitles lists the subtitle files next to a media file.
+// targetSubs holds the files of each target, in the order of targets; files
+// in a target language are not source subtitles. Sources are limited to the
+// registered formats, targets count sidecars such as PGS .sup as well.
+func findExternalSubtitles(dir string, mediaBase string, targets []language.Tag) (sourceSubs []string, targetSubs [][]string, languages []string, err error) {
 	sourceSubs = make([]string, 0)
-	targetSubs = make([]string, 0)
+	targetSubs = make([][]string, len(targets))
+	for i := range targetSubs {
+		targetSubs[i] = make([]string, 0)
+	}
 	mediaBases := subtitleMatchMediaBases(mediaBase)
 
 	entries, err := os.ReadDir(dir)
@@ -698,7 +779,7 @@ func findExternalSubtitles(dir string, mediaBase string, target language.Tag) (s
 
 		name := entry.Name()
 		ext := strings.ToLower(filepath.Ext(name))
-		if !slices.Contains(subtitleExts, ext) {
+		if !subtitle.IsSidecarExtension(ext) {
 			continue
 		}
 		stem := strings.TrimSuffix(name, ext)
@@ -720,16 +801,36 @@ func findExternalSubtitles(dir string, mediaBase string, target language.Tag) (s
 		}
 
 		fullPath := filepath.Join(dir, name)
-		if token != "" && isTargetLanguage(token, target) {
-			targetSubs = append(targetSubs, fullPath)
-			continue
+		isTarget := false
+		for i, target := range targets {
+			if token != "" && isTargetLanguage(token, target) {
+				targetSubs[i] = append(targetSubs[i], fullPath)
+				isTarget = true
+			}
+		}
+		// a target in any format is there already, only readable ones are sources
+		if !isTarget && subtitle.IsSupportedExtension(ext) {
+			sourceSubs = append(sourceSubs, fullPath)
 		}
-		sourceSubs = append(sourceSubs, fullPath)
 	}
 
 	return sourceSubs, targetSubs, languages, nil
 }
 
+// FindLanguageSubtitles returns the external subtitles of a media file that are
+// in lang and a registered format reads, e.g. an English fansub to translate
+// through.
+func FindLanguageSubtitles(mediaPath string, lang language.Tag) ([]string, error) {
+	baseName := strings.TrimSuffix(filepath.Base(mediaPath), filepath.Ext(mediaPath))
+	_, found, _, err := findExternalSubtitles(filepath.Dir(mediaPath), baseName, []language.Tag{lang})
+	if err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(found[0], func(path string) bool {
+		return !subtitle.IsSupportedExtension(filepath.Ext(path))
+	}), nil
+}
+
 func subtitleMatchMediaBases(mediaBase string) []string {
 	ret := []string{mediaBase}
 	trimmed := strings.TrimSpace(qualitySuffixPattern.ReplaceAllString(mediaBase, ""))
@@ -776,27 +877,12 @@ func normalizeLangCode(token string) string {
 	return base.String()
 }
 
+// isTargetLanguage reports whether a language token of a subtitle names the
+// target, see subtitle.MatchesTarget: chs and zh-CN count as zh but not as
+// zh-Hant.
 func isTargetLanguage(token string, target language.Tag) bool {
-	token = strings.ToLower(strings.ReplaceAll(token, "_", "-"))
-	if token == "" {
-		return false
-	}
-
-	base, _ := target.Base()
-	targetBase := strings.ToLower(base.String())
-	if token == targetBase || strings.HasPrefix(token, targetBase+"-") {
-		return true
-	}
-
-	// common aliases
-	switch targetBase {
-	case "zh":
-		return token == "chi" || token == "chs" || token == "cht"
-	case "en":
-		return token == "eng"
-	}
-
-	return false
+	tag, ok := subtitle.ParseLanguageToken(token)
+	return ok && subtitle.MatchesTarget(tag, target)
 }
 
 func subtitleMatchesMediaBase(stem, mediaBase string) bool {
@@ -859,9 +945,7 @@ func cloneLibrary(src *Library) *Library {
 	copy(dst.Episodes, src.Episodes)
 
 	for i := range dst.Episodes {
-		dst.Episodes[i].Subtitles.SourceSubtitleFiles = append([]string(nil), src.Episodes[i].Subtitles.SourceSubtitleFiles...)
-		dst.Episodes[i].Subtitles.TargetSubtitleFiles = append([]string(nil), src.Episodes[i].Subtitles.TargetSubtitleFiles...)
-		dst.Episodes[i].Subtitles.Languages = append([]string(nil), src.Episodes[i].Subtitles.Languages...)
+		dst.Episodes[i].Subtitles = cloneSubtitleStatus(src.Episodes[i].Subtitles)
 	}
 	return dst
 }
@@ -332,6 +332,64 @@ func TestScanner_SubtitleMatchRequiresBoundaryAfterMediaBase(t *testing.T) {
 	assert.False(t, ep.Translatable)
 }
 
+func TestScanner_IgnoresSubtitlesWithoutRegisteredFormat(t *testing.T) {
+	tmp := t.TempDir()
+	sourceDir := filepath.Join(tmp, "shows", "Series")
+	require.NoError(t, os.MkdirAll(sourceDir, 0o755))
+
+	require.NoError(t, os.WriteFile(filepath.Join(sourceDir, "ep1.mkv"), []byte("m"), 0o644))
+	for _, name := range []string{"ep1.sup", "ep1.idx", "ep1.sub", "ep1.txt"} {
+		require.NoError(t, os.WriteFile(filepath.Join(sourceDir, name), []byte("s"), 0o644))
+	}
+
+	scanner := NewScanner(
+		[]SourceConfig{{ID: "shows", Name: "Shows", Path: filepath.Join(tmp, "shows")}},
+		language.Chinese,
+	)
+
+	lib, err := scanner.Scan(context.Background())
+	require.NoError(t, err)
+	require.Len(t, lib.Episodes, 1)
+
+	ep := lib.Episodes[0]
+	assert.False(t, ep.Subtitles.HasSourceSubtitle)
+	assert.False(t, ep.Translatable, "no registered format reads these sidecars")
+}
+
+func TestScanner_TargetSubtitleInImageFormatCounts(t *testing.T) {
+	tmp := t.TempDir()
+	sourceDir := filepath.Join(tmp, "shows", "Series")
+	require.NoError(t, os.MkdirAll(sourceDir, 0o755))
+
+	mediaPath := filepath.Join(sourceDir, "ep1.mkv")
+	require.NoError(t, os.WriteFile(mediaPath, []byte("m"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(sourceDir, "ep1.en.srt"), []byte("s"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(sourceDir, "ep1.zh.sup"), []byte("s"), 0o644))
+
+	scanner := NewScanner(
+		[]SourceConfig{{ID: "shows", Name: "Shows", Path: filepath.Join(tmp, "shows")}},
+		language.Chinese,
+	)
+
+	lib, err := scanner.Scan(context.Background())
+	require.NoError(t, err)
+	require.Len(t, lib.Episodes, 1)
+
+	ep := lib.Episodes[0]
+	assert.True(t, ep.Subtitles.HasSourceSubtitle)
+	assert.True(t, ep.Subtitles.HasTargetSubtitle, "a PGS subtitle in the target is a target subtitle")
+	assert.Equal(t, []string{filepath.Join(sourceDir, "ep1.en.srt")}, ep.Subtitles.SourceSubtitleFiles)
+	assert.False(t, ep.Translatable)
+
+	missing, err := scanner.MissingTargets(mediaPath)
+	require.NoError(t, err)
+	assert.Empty(t, missing)
+
+	found, err := FindLanguageSubtitles(mediaPath, language.Chinese)
+	require.NoError(t, err)
+	assert.Empty(t, found, "only readable subtitles are returned")
+}
+
 func TestScanner_SubtitleMatchAllowsMediaSuffixNoise(t *testing.T) {
 	tmp := t.TempDir()
 	sourceDir := filepath.Join(tmp, "shows", "Series")
@@ -651,3 +709,78 @@ func TestScanner_UpdateTargetLanguage_TakesEffectImmediately(t *testing.T) {
 	assert.False(t, lib.Episodes[0].Translatable)
 	assert.True(t, lib.Episodes[0].Subtitles.HasTargetSubtitle)
 }
+
+func TestScanner_MultipleTargetLanguages(t *testing.T) {
+	tmp := t.TempDir()
+	showDir := filepath.Join(tmp, "shows", "Anime")
+	require.NoError(t, os.MkdirAll(showDir, 0o755))
+
+	mediaPath := filepath.Join(showDir, "ep01.mkv")
+	require.NoError(t, os.WriteFile(mediaPath, []byte("m"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(showDir, "ep01.jpn.srt"), []byte("s"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(showDir, "ep01.chs.srt"), []byte("s"), 0o644))
+
+	scanner := NewScanner(
+		[]SourceConfig{{ID: "shows", Name: "Shows", Path: filepath.Join(tmp, "shows")}},
+		language.Chinese,
+		WithTargetLanguages(language.SimplifiedChinese, language.TraditionalChinese, language.English),
+		WithEmbeddedDetector(func(string) (bool, bool, []string) {
+			return true, false, []string{"jpn", "eng"}
+		}),
+	)
+	assert.Equal(t, []string{"zh-Hans", "zh-Hant", "en"}, scanner.TargetLanguages())
+	assert.Equal(t, "zh", scanner.TargetLanguage())
+
+	lib, err := scanner.Scan(context.Background())
+	require.NoError(t, err)
+	require.Len(t, lib.Episodes, 1)
+	subs := lib.Episodes[0].Subtitles
+	assert.Equal(t, []TargetSubtitleStatus{
+		{Language: "zh-Hans", HasSubtitle: true, SubtitleFiles: []string{filepath.Join(showDir, "ep01.chs.srt")}},
+		{Language: "zh-Hant", HasSubtitle: false, SubtitleFiles: []string{}},
+		{Language: "en", HasSubtitle: true, HasEmbeddedSubtitle: true, SubtitleFiles: []string{}},
+	}, subs.Targets)
+	assert.Equal(t, []string{filepath.Join(showDir, "ep01.jpn.srt")}, subs.SourceSubtitleFiles)
+	assert.False(t, subs.HasTargetSubtitle, "zh-Hant is missing")
+	assert.True(t, lib.Episodes[0].Translatable)
+
+	require.NoError(t, os.WriteFile(filepath.Join(showDir, "ep01.zh-TW.srt"), []byte("s"), 0o644))
+	scanner.Invalidate()
+	episodes, err := scanner.ScanEpisodesByItem(context.Background(), "shows|"+showDir)
+	require.NoError(t, err)
+	require.Len(t, episodes, 1)
+	assert.True(t, episodes[0].Subtitles.Targets[1].HasSubtitle)
+	assert.True(t, episodes[0].Subtitles.HasTargetSubtitle)
+	assert.False(t, episodes[0].Translatable)
+
+	require.NoError(t, scanner.UpdateTargetLanguages([]string{"ko", "en"}))
+	assert.Equal(t, []string{"ko", "en"}, scanner.TargetLanguages())
+	require.Error(t, scanner.UpdateTargetLanguages(nil))
+}
+
+func TestIsTargetLanguage_Scripts(t *testing.T) {
+	assert.True(t, isTargetLanguage("cht", language.Chinese), "a bare target takes any script")
+	assert.True(t, isTargetLanguage("chi", language.SimplifiedChinese))
+	assert.True(t, isTargetLanguage("zh-cn", language.SimplifiedChinese))
+	assert.False(t, isTargetLanguage("chs", language.TraditionalChinese))
+	assert.True(t, isTargetLanguage("zh_tw", language.TraditionalChinese))
+	assert.True(t, isTargetLanguage("eng", language.English))
+	assert.False(t, isTargetLanguage("forced", language.English))
+}
+
+func TestFindLanguageSubtitles(t *testing.T) {
+	showDir := t.TempDir()
+	mediaPath := filepath.Join(showDir, "ep01.mkv")
+	require.NoError(t, os.WriteFile(mediaPath, []byte("m"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(showDir, "ep01.ja.srt"), []byte("s"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(showDir, "ep01.eng.srt"), []byte("s"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(showDir, "ep02.en.srt"), []byte("s"), 0o644))
+
+	found, err := FindLanguageSubtitles(mediaPath, language.English)
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(showDir, "ep01.eng.srt")}, found)
+
+	found, err = FindLanguageSubtitles(mediaPath, language.Portuguese)
+	require.NoError(t, err)
+	assert.Empty(t, found)
+}
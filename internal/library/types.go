@@ -22,13 +22,26 @@ type Item struct {
 }
 
 type SubtitleStatus struct {
-	HasSourceSubtitle         bool     `json:"has_source_subtitle"`
+	HasSourceSubtitle bool `json:"has_source_subtitle"`
+	// HasTargetSubtitle and HasEmbeddedTargetSubtitle hold when every target
+	// language is present
 	HasTargetSubtitle         bool     `json:"has_target_subtitle"`
 	HasEmbeddedSubtitle       bool     `json:"has_embedded_subtitle"`
 	HasEmbeddedTargetSubtitle bool     `json:"has_embedded_target_subtitle"`
 	SourceSubtitleFiles       []string `json:"source_subtitle_files"`
 	TargetSubtitleFiles       []string `json:"target_subtitle_files"`
 	Languages                 []string `json:"languages"`
+	// Targets has the status of each target language, in configured order
+	Targets []TargetSubtitleStatus `json:"targets"`
+}
+
+// TargetSubtitleStatus tells whether a media file has subtitles in one target
+// language
+type TargetSubtitleStatus struct {
+	Language            string   `json:"language"`
+	HasSubtitle         bool     `json:"has_subtitle"`
+	HasEmbeddedSubtitle bool     `json:"has_embedded_subtitle"`
+	SubtitleFiles       []string `json:"subtitle_files"`
 }
 
 type Episode struct {
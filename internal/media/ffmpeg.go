@@ -3,6 +3,7 @@ package media
 import (
 	"bufio"
 	"encoding/json"
+	"fmt"
 	"os/exec"
 	"path/filepath"
 	"strings"
@@ -39,18 +40,22 @@ func NewFfmpeg(
 	}
 }
 
-// Extract subtitle from media file and save to target path
+// Extract subtitle stream from media file and save to target path
 func (ff ffmpeg) ExtractSubtitle(
+	stream subtitle.Description,
 	toDir string,
 	name string,
 ) (string, error) {
 	output := filepath.Join(toDir, name)
+	if stream.IsBitmap() {
+		return "", errBitmapStream(stream)
+	}
 
 	cmdPath, err := exec.LookPath(ff.ffmpegCmd)
 	if err != nil {
 		return "", err
 	}
-	cmd := exec.Command(cmdPath, ff.extractSubArgs(output)...)
+	cmd := exec.Command(cmdPath, ff.extractSubArgs(stream, output)...)
 
 	// Redirect stderr to stdout
 	stdout, err := cmd.StdoutPipe()
@@ -86,12 +91,17 @@ func (ff ffmpeg) ExtractSubtitle(
 	return output, err
 }
 
-func (ff ffmpeg) ExtractSubtitleToBytes() ([]byte, error) {
+// ExtractSubtitleToBytes extracts a subtitle stream as ASS content for
+// ASS/SSA streams and as SRT content otherwise, see Description.ExtractFormat
+func (ff ffmpeg) ExtractSubtitleToBytes(stream subtitle.Description) ([]byte, error) {
+	if stream.IsBitmap() {
+		return nil, errBitmapStream(stream)
+	}
 	cmdPath, err := exec.LookPath(ff.ffmpegCmd)
 	if err != nil {
 		return nil, err
 	}
-	cmd := exec.Command(cmdPath, ff.extractSubBytesArgs()...)
+	cmd := exec.Command(cmdPath, ff.extractSubBytesArgs(stream)...)
 	output, err := cmd.Output()
 	if err != nil {
 		log.Error("Execution failed: %v", err)
@@ -100,13 +110,14 @@ func (ff ffmpeg) ExtractSubtitleToBytes() ([]byte, error) {
 	return output, nil
 }
 
-// Extract subtitle from media file and save to target path
-func (ff ffmpeg) DefExtractSubtitle() (string, error) {
+// Extract subtitle stream from media file and save next to it
+func (ff ffmpeg) DefExtractSubtitle(stream subtitle.Description) (string, error) {
 	ext := filepath.Ext(ff.fileName)
 	stem := strings.TrimSuffix(ff.fileName, ext)
 	return ff.ExtractSubtitle(
+		stream,
 		ff.fileDir,
-		stem+"_ctxtrans.srt")
+		stem+"_ctxtrans."+stream.ExtractFormat())
 }
 
 func (ff ffmpeg) ReadSubtitleDescription() (subtitle.Descriptions, error) {
@@ -131,7 +142,9 @@ func (ff ffmpeg) ReadSubtitleDescription() (subtitle.Descriptions, error) {
 				Title    string `json:"title"`
 			} `json:"tags"`
 			Disposition struct {
-				Default int `json:"default"`
+				Default         int `json:"default"`
+				Forced          int `json:"forced"`
+				HearingImpaired int `json:"hearing_impaired"`
 			} `json:"disposition"`
 		} `json:"streams"`
 	}
@@ -152,9 +165,14 @@ func (ff ffmpeg) ReadSubtitleDescription() (subtitle.Descriptions, error) {
 	for _, stream := range probeResult.Streams {
 		if stream.CodecType == "subtitle" {
 			desc := subtitle.Description{
-				Language:    stream.Tags.Language,
-				SubLanguage: stream.Tags.Title,
-				LangTag:     language.All.Make(stream.Tags.Language),
+				Index:           len(descriptions),
+				Language:        stream.Tags.Language,
+				SubLanguage:     stream.Tags.Title,
+				LangTag:         language.All.Make(stream.Tags.Language),
+				Codec:           stream.CodecName,
+				Default:         stream.Disposition.Default == 1,
+				Forced:          stream.Disposition.Forced == 1,
+				HearingImpaired: stream.Disposition.HearingImpaired == 1,
 			}
 			if desc.Language == "" {
 				desc.Language = "und" // undefined
@@ -178,23 +196,34 @@ func (ffmpeg) readProbeArgs(path string) []string {
 	}
 }
 
-func (f ffmpeg) extractSubArgs(targetPath string) []string {
-	return []string{
+func (f ffmpeg) extractSubArgs(stream subtitle.Description, targetPath string) []string {
+	return append([]string{
 		"-i", f.filePath,
-		"-map", "0:s:0", // select first subtitle
-		"-c:s", "srt", // convert to srt
-		"-f", "srt", // output format
-		targetPath,
-	}
+		"-map", streamSpecifier(stream), // select the chosen subtitle
+	}, append(extractCodecArgs(stream), targetPath)...)
 }
 
-func (f ffmpeg) extractSubBytesArgs() []string {
-	return []string{
+func (f ffmpeg) extractSubBytesArgs(stream subtitle.Description) []string {
+	return append([]string{
 		"-v", "error",
 		"-i", f.filePath,
-		"-map", "0:s:0", // select first subtitle
-		"-c:s", "srt", // convert to srt
-		"-f", "srt", // output format
-		"-",
+		"-map", streamSpecifier(stream), // select the chosen subtitle
+	}, append(extractCodecArgs(stream), "-")...)
+}
+
+// extractCodecArgs copies ASS/SSA streams, keeping their styles, and
+// converts other text streams to SRT
+func extractCodecArgs(stream subtitle.Description) []string {
+	if format := stream.ExtractFormat(); format != "srt" {
+		return []string{"-c:s", "copy", "-f", format}
 	}
+	return []string{"-c:s", "srt", "-f", "srt"}
+}
+
+func streamSpecifier(stream subtitle.Description) string {
+	return fmt.Sprintf("0:s:%d", max(0, stream.Index))
+}
+
+func errBitmapStream(stream subtitle.Description) error {
+	return fmt.Errorf("subtitle stream %d (%s) is image-based and cannot be extracted as text", stream.Index, stream.Codec)
 }
@@ -222,6 +222,80 @@ func TestFFmpeg_readProbeArgs(t *testing.T) {
 	assert.Equal(t, expected, args)
 }
 
+// TestFFmpeg_ReadSubtitleDescription_StreamDetails tests index, codec and disposition parsing
+func TestFFmpeg_ReadSubtitleDescription_StreamDetails(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("mock ffprobe script requires a POSIX shell")
+	}
+	mockDir := t.TempDir()
+	output := `{"streams": [
+		{"codec_type": "subtitle", "codec_name": "ass", "tags": {"language": "eng", "title": "Signs"}, "disposition": {"default": 1, "forced": 1}},
+		{"codec_type": "subtitle", "codec_name": "subrip", "tags": {"language": "eng"}, "disposition": {"hearing_impaired": 1}}
+	]}`
+	script := "#!/bin/sh\necho '" + output + "'\n"
+	assert.NoError(t, os.WriteFile(filepath.Join(mockDir, "ffprobe"), []byte(script), 0755))
+	t.Setenv("PATH", mockDir+":"+os.Getenv("PATH"))
+
+	result, err := NewFfmpeg("dummy.mkv").ReadSubtitleDescription()
+	assert.NoError(t, err)
+	if assert.Len(t, result, 2) {
+		assert.Equal(t, 0, result[0].Index)
+		assert.Equal(t, "ass", result[0].Codec)
+		assert.True(t, result[0].Default)
+		assert.True(t, result[0].Forced)
+		assert.Equal(t, 1, result[1].Index)
+		assert.True(t, result[1].HearingImpaired)
+		assert.False(t, result[1].Forced)
+	}
+}
+
+// TestFFmpeg_extractSubArgs tests that the chosen stream is mapped
+func TestFFmpeg_extractSubArgs(t *testing.T) {
+	ff := ffmpeg{filePath: "/path/to/video.mkv"}
+	stream := subtitle.Description{Index: 2, Codec: "subrip"}
+
+	assert.Equal(t, []string{
+		"-v", "error",
+		"-i", "/path/to/video.mkv",
+		"-map", "0:s:2",
+		"-c:s", "srt",
+		"-f", "srt",
+		"-",
+	}, ff.extractSubBytesArgs(stream))
+	assert.Equal(t, []string{
+		"-i", "/path/to/video.mkv",
+		"-map", "0:s:2",
+		"-c:s", "srt",
+		"-f", "srt",
+		"/tmp/out.srt",
+	}, ff.extractSubArgs(stream, "/tmp/out.srt"))
+
+	// styled streams are copied so styles and override tags survive
+	styled := subtitle.Description{Index: 1, Codec: "ssa"}
+	assert.Equal(t, []string{
+		"-v", "error",
+		"-i", "/path/to/video.mkv",
+		"-map", "0:s:1",
+		"-c:s", "copy",
+		"-f", "ass",
+		"-",
+	}, ff.extractSubBytesArgs(styled))
+	assert.Equal(t, []string{
+		"-i", "/path/to/video.mkv",
+		"-map", "0:s:1",
+		"-c:s", "copy",
+		"-f", "ass",
+		"/tmp/out.ass",
+	}, ff.extractSubArgs(styled, "/tmp/out.ass"))
+}
+
+// TestFFmpeg_ExtractBitmapStream tests that image-based streams are rejected before running ffmpeg
+func TestFFmpeg_ExtractBitmapStream(t *testing.T) {
+	ff := NewFfmpeg("video.mkv")
+	_, err := ff.ExtractSubtitleToBytes(subtitle.Description{Index: 1, Codec: "hdmv_pgs_subtitle"})
+	assert.ErrorContains(t, err, "image-based")
+}
+
 // TestNewFfmpeg tests the NewFfmpeg function
 func TestNewFfmpeg(t *testing.T) {
 	ff := NewFfmpeg("")
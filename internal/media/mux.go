@@ -0,0 +1,196 @@
+package media
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/MimeLyc/contextual-sub-translator/internal/subtitle"
+	"github.com/MimeLyc/contextual-sub-translator/pkg/log"
+	"golang.org/x/text/language"
+)
+
+// MuxMode controls whether a translated subtitle is remuxed into the media container
+type MuxMode string
+
+const (
+	// MuxOff keeps the translated subtitle as a sidecar file only
+	MuxOff MuxMode = "off"
+	// MuxCopy writes a copy of the media file with the translated subtitle as a new track
+	MuxCopy MuxMode = "copy"
+	// MuxInPlace replaces the media file with a remuxed copy through a temp file swap
+	MuxInPlace MuxMode = "in_place"
+)
+
+// ParseMuxMode parses a mux mode, an empty value means MuxOff
+func ParseMuxMode(raw string) (MuxMode, error) {
+	mode := MuxMode(strings.ToLower(strings.TrimSpace(raw)))
+	switch mode {
+	case "":
+		return MuxOff, nil
+	case MuxOff, MuxCopy, MuxInPlace:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unsupported mux mode %q", raw)
+	}
+}
+
+// MuxOptions describes the subtitle track added by MuxSubtitle
+type MuxOptions struct {
+	SubtitlePath string
+	Language     language.Tag
+	// Title is written as the track title. Existing subtitle tracks with the
+	// same title are replaced, so muxing the same translation twice keeps one track.
+	Title   string
+	Default bool
+	// OutputPath is the remuxed file, empty replaces the media file in place
+	OutputPath string
+}
+
+// muxSubtitleCodecs maps container extensions to the codec of the added track.
+// Matroska keeps the sidecar format, MP4 family containers only carry mov_text.
+var muxSubtitleCodecs = map[string]string{
+	".mkv": "copy",
+	".mp4": "mov_text",
+	".m4v": "mov_text",
+	".mov": "mov_text",
+}
+
+// CanMux reports whether subtitles can be muxed into the media container
+func CanMux(mediaPath string) bool {
+	_, ok := muxSubtitleCodecs[strings.ToLower(filepath.Ext(mediaPath))]
+	return ok
+}
+
+// DefMuxPath returns the path of the remuxed copy written next to the media file
+func DefMuxPath(mediaPath string, lang language.Tag) string {
+	ext := filepath.Ext(mediaPath)
+	stem := strings.TrimSuffix(filepath.Base(mediaPath), ext)
+	return filepath.Join(filepath.Dir(mediaPath), stem+"_ctxtrans."+lang.String()+ext)
+}
+
+// muxLocks serializes muxing per media file: jobs translating one file into
+// several languages would otherwise each rewrite it from the same original,
+// and the last rename would drop the tracks the others added
+var muxLocks sync.Map
+
+func lockMux(mediaPath string) func() {
+	muAny, _ := muxLocks.LoadOrStore(mediaPath, &sync.Mutex{})
+	mu := muAny.(*sync.Mutex)
+	mu.Lock()
+	return mu.Unlock
+}
+
+// MuxSubtitle adds a subtitle file as a new track of the media file and
+// returns the path of the remuxed media
+func (ff ffmpeg) MuxSubtitle(opts MuxOptions) (string, error) {
+	codec, ok := muxSubtitleCodecs[strings.ToLower(filepath.Ext(ff.fileName))]
+	if !ok {
+		return "", fmt.Errorf("container of %s does not support muxed subtitles", ff.fileName)
+	}
+	if opts.SubtitlePath == "" {
+		return "", fmt.Errorf("subtitle path is required")
+	}
+	cmdPath, err := exec.LookPath(ff.ffmpegCmd)
+	if err != nil {
+		return "", err
+	}
+	defer lockMux(ff.filePath)()
+	existing, err := ff.ReadSubtitleDescription()
+	if err != nil {
+		return "", fmt.Errorf("failed to read subtitle streams of %s: %w", ff.filePath, err)
+	}
+
+	output := opts.OutputPath
+	inPlace := output == "" || filepath.Clean(output) == ff.filePath
+	target := output
+	if inPlace {
+		output = ff.filePath
+		// keep the extension so ffmpeg picks the same muxer
+		tmp, err := os.CreateTemp(ff.fileDir, "."+ff.fileName+".ctxtrans-*"+filepath.Ext(ff.fileName))
+		if err != nil {
+			return "", fmt.Errorf("failed to create temp file for %s: %w", ff.filePath, err)
+		}
+		target = tmp.Name()
+		_ = tmp.Close()
+	}
+
+	cmd := exec.Command(cmdPath, ff.muxSubArgs(existing, opts, codec, target)...)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		_ = os.Remove(target)
+		return "", fmt.Errorf("ffmpeg failed to mux subtitle into %s: %w: %s", ff.filePath, err, strings.TrimSpace(string(out)))
+	}
+	info, err := os.Stat(target)
+	if err != nil || info.Size() == 0 {
+		_ = os.Remove(target)
+		return "", fmt.Errorf("ffmpeg produced no output for %s", ff.filePath)
+	}
+
+	if inPlace {
+		// the temp file has default permissions, keep those of the media file
+		if original, err := os.Stat(output); err == nil {
+			if err := os.Chmod(target, original.Mode().Perm()); err != nil {
+				_ = os.Remove(target)
+				return "", fmt.Errorf("failed to keep the mode of %s: %w", output, err)
+			}
+		}
+		if err := os.Rename(target, output); err != nil {
+			_ = os.Remove(target)
+			return "", fmt.Errorf("failed to replace %s: %w", output, err)
+		}
+	}
+	log.Info("Muxed subtitle %s into %s", opts.SubtitlePath, output)
+	return output, nil
+}
+
+func (f ffmpeg) muxSubArgs(
+	existing subtitle.Descriptions,
+	opts MuxOptions,
+	codec string,
+	targetPath string,
+) []string {
+	args := []string{
+		"-y",
+		"-v", "error",
+		"-i", f.filePath,
+		"-i", opts.SubtitlePath,
+		"-map", "0",
+	}
+	kept := 0
+	for _, desc := range existing {
+		if opts.Title != "" && desc.SubLanguage == opts.Title {
+			// drop the track written by an earlier run
+			args = append(args, "-map", "-"+streamSpecifier(desc))
+			continue
+		}
+		kept++
+	}
+
+	track := "s:" + strconv.Itoa(kept)
+	args = append(args,
+		"-map", "1:0",
+		"-c", "copy",
+		"-c:"+track, codec,
+		"-metadata:s:"+track, "language="+iso639(opts.Language),
+	)
+	if opts.Title != "" {
+		args = append(args, "-metadata:s:"+track, "title="+opts.Title)
+	}
+	if opts.Default {
+		args = append(args, "-disposition:"+track, "default")
+	}
+	return append(args, targetPath)
+}
+
+// iso639 returns the three-letter language code used by container metadata
+func iso639(tag language.Tag) string {
+	base, conf := tag.Base()
+	if tag == language.Und || conf == language.No {
+		return "und"
+	}
+	return base.ISO3()
+}
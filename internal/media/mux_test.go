@@ -0,0 +1,197 @@
+package media
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/MimeLyc/contextual-sub-translator/internal/subtitle"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/language"
+)
+
+// installFakeFFmpeg puts fake ffprobe and ffmpeg binaries on PATH. The fake
+// ffmpeg logs its arguments to args.log and writes "muxed" to its last argument.
+func installFakeFFmpeg(t *testing.T, probeOutput string, ffmpegExit int) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake ffmpeg scripts require a POSIX shell")
+	}
+	binDir := t.TempDir()
+	probe := "#!/bin/sh\necho '" + probeOutput + "'\n"
+	require.NoError(t, os.WriteFile(filepath.Join(binDir, "ffprobe"), []byte(probe), 0755))
+
+	argsLog := filepath.Join(binDir, "args.log")
+	ffmpegScript := "#!/bin/sh\n" +
+		"for arg in \"$@\"; do echo \"$arg\" >> '" + argsLog + "'; last=\"$arg\"; done\n"
+	if ffmpegExit != 0 {
+		ffmpegScript += "echo 'mux failed' >&2\nexit 1\n"
+	} else {
+		ffmpegScript += "printf muxed > \"$last\"\n"
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(binDir, "ffmpeg"), []byte(ffmpegScript), 0755))
+	t.Setenv("PATH", binDir+":"+os.Getenv("PATH"))
+	return argsLog
+}
+
+func TestParseMuxMode(t *testing.T) {
+	mode, err := ParseMuxMode("")
+	require.NoError(t, err)
+	assert.Equal(t, MuxOff, mode)
+
+	mode, err = ParseMuxMode(" In_Place ")
+	require.NoError(t, err)
+	assert.Equal(t, MuxInPlace, mode)
+
+	_, err = ParseMuxMode("replace")
+	assert.Error(t, err)
+}
+
+func TestFFmpeg_muxSubArgs(t *testing.T) {
+	ff := ffmpeg{filePath: "/media/ep01.mkv"}
+	existing := subtitle.Descriptions{
+		{Index: 0, Language: "eng", SubLanguage: "English"},
+		{Index: 1, Language: "chi", SubLanguage: "Chinese (ctxtrans)"},
+	}
+	args := ff.muxSubArgs(existing, MuxOptions{
+		SubtitlePath: "/media/ep01_ctxtrans.zh.srt",
+		Language:     language.Chinese,
+		Title:        "Chinese (ctxtrans)",
+		Default:      true,
+	}, "copy", "/media/out.mkv")
+
+	assert.Equal(t, []string{
+		"-y",
+		"-v", "error",
+		"-i", "/media/ep01.mkv",
+		"-i", "/media/ep01_ctxtrans.zh.srt",
+		"-map", "0",
+		"-map", "-0:s:1",
+		"-map", "1:0",
+		"-c", "copy",
+		"-c:s:1", "copy",
+		"-metadata:s:s:1", "language=zho",
+		"-metadata:s:s:1", "title=Chinese (ctxtrans)",
+		"-disposition:s:1", "default",
+		"/media/out.mkv",
+	}, args)
+}
+
+func TestFFmpeg_MuxSubtitle_InPlace(t *testing.T) {
+	argsLog := installFakeFFmpeg(t, `{"streams": [{"codec_type": "subtitle", "codec_name": "subrip", "tags": {"language": "eng"}}]}`, 0)
+	dir := t.TempDir()
+	mediaPath := filepath.Join(dir, "ep01.mp4")
+	require.NoError(t, os.WriteFile(mediaPath, []byte("original"), 0o640))
+	require.NoError(t, os.Chmod(mediaPath, 0o640))
+
+	output, err := NewFfmpeg(mediaPath).MuxSubtitle(MuxOptions{
+		SubtitlePath: filepath.Join(dir, "ep01_ctxtrans.zh.srt"),
+		Language:     language.Chinese,
+		Title:        "Chinese (ctxtrans)",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, mediaPath, output)
+
+	content, err := os.ReadFile(mediaPath)
+	require.NoError(t, err)
+	assert.Equal(t, "muxed", string(content))
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "temp file must be swapped in, not left behind")
+	info, err := os.Stat(mediaPath)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o640), info.Mode().Perm(), "the media file keeps its mode")
+
+	args, err := os.ReadFile(argsLog)
+	require.NoError(t, err)
+	assert.Contains(t, string(args), "-c:s:1\nmov_text\n")
+	assert.Contains(t, string(args), "language=zho\n")
+}
+
+func TestFFmpeg_MuxSubtitle_InPlaceConcurrent(t *testing.T) {
+	argsLog := installFakeFFmpeg(t, `{"streams": []}`, 0)
+	dir := t.TempDir()
+	mediaPath := filepath.Join(dir, "ep01.mkv")
+	require.NoError(t, os.WriteFile(mediaPath, []byte("original"), 0o644))
+
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+	for i, lang := range []language.Tag{language.SimplifiedChinese, language.TraditionalChinese} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = NewFfmpeg(mediaPath).MuxSubtitle(MuxOptions{
+				SubtitlePath: filepath.Join(dir, "ep01_ctxtrans."+lang.String()+".srt"),
+				Language:     lang,
+			})
+		}()
+	}
+	wg.Wait()
+	require.NoError(t, errs[0])
+	require.NoError(t, errs[1])
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "temp files must be swapped in, not left behind")
+	args, err := os.ReadFile(argsLog)
+	require.NoError(t, err)
+	assert.Equal(t, 2, strings.Count(string(args), ".ctxtrans-"), "each mux writes its own temp file")
+}
+
+func TestFFmpeg_MuxSubtitle_Copy(t *testing.T) {
+	installFakeFFmpeg(t, `{"streams": []}`, 0)
+	dir := t.TempDir()
+	mediaPath := filepath.Join(dir, "ep01.mkv")
+	require.NoError(t, os.WriteFile(mediaPath, []byte("original"), 0o644))
+
+	copyPath := DefMuxPath(mediaPath, language.Chinese)
+	assert.Equal(t, filepath.Join(dir, "ep01_ctxtrans.zh.mkv"), copyPath)
+
+	output, err := NewFfmpeg(mediaPath).MuxSubtitle(MuxOptions{
+		SubtitlePath: filepath.Join(dir, "ep01_ctxtrans.zh.srt"),
+		Language:     language.Chinese,
+		OutputPath:   copyPath,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, copyPath, output)
+
+	original, err := os.ReadFile(mediaPath)
+	require.NoError(t, err)
+	assert.Equal(t, "original", string(original))
+	muxed, err := os.ReadFile(copyPath)
+	require.NoError(t, err)
+	assert.Equal(t, "muxed", string(muxed))
+}
+
+func TestFFmpeg_MuxSubtitle_FailureKeepsOriginal(t *testing.T) {
+	installFakeFFmpeg(t, `{"streams": []}`, 1)
+	dir := t.TempDir()
+	mediaPath := filepath.Join(dir, "ep01.mkv")
+	require.NoError(t, os.WriteFile(mediaPath, []byte("original"), 0o644))
+
+	_, err := NewFfmpeg(mediaPath).MuxSubtitle(MuxOptions{
+		SubtitlePath: filepath.Join(dir, "ep01_ctxtrans.zh.srt"),
+		Language:     language.Chinese,
+	})
+	require.Error(t, err)
+	assert.True(t, strings.Contains(err.Error(), "mux failed"))
+
+	content, err := os.ReadFile(mediaPath)
+	require.NoError(t, err)
+	assert.Equal(t, "original", string(content))
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestFFmpeg_MuxSubtitle_UnsupportedContainer(t *testing.T) {
+	_, err := NewFfmpeg("ep01.avi").MuxSubtitle(MuxOptions{SubtitlePath: "ep01.srt"})
+	assert.ErrorContains(t, err, "does not support")
+	assert.False(t, CanMux("ep01.avi"))
+	assert.True(t, CanMux("ep01.MKV"))
+}
@@ -0,0 +1,128 @@
+package media
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MimeLyc/contextual-sub-translator/internal/subtitle"
+	"github.com/MimeLyc/contextual-sub-translator/pkg/log"
+)
+
+// SubtitlePacket is one demuxed packet of a subtitle stream
+type SubtitlePacket struct {
+	Start    time.Duration
+	Duration time.Duration // zero when the container does not store one
+	Data     []byte
+}
+
+// SubtitlePackets holds the raw packets of an image-based subtitle stream
+type SubtitlePackets struct {
+	Codec     string
+	Extradata []byte // codec private data, e.g. the VobSub idx header with the palette
+	Packets   []SubtitlePacket
+}
+
+// ReadSubtitlePackets demuxes a subtitle stream without decoding it, so
+// image-based streams can be decoded and recognized outside ffmpeg
+func (ff ffmpeg) ReadSubtitlePackets(stream subtitle.Description) (*SubtitlePackets, error) {
+	cmdPath, err := exec.LookPath(ff.ffprobeCmd)
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.Command(cmdPath, ff.readPacketsArgs(stream)...)
+	output, err := cmd.Output()
+	if err != nil {
+		log.Error("Failed to read subtitle packets of %s: %v", ff.filePath, err)
+		return nil, err
+	}
+	return parsePacketsOutput(output)
+}
+
+func (f ffmpeg) readPacketsArgs(stream subtitle.Description) []string {
+	return []string{
+		"-v", "error",
+		"-print_format", "json",
+		"-select_streams", "s:" + strconv.Itoa(max(0, stream.Index)),
+		"-show_streams",
+		"-show_packets",
+		"-show_data",
+		f.filePath,
+	}
+}
+
+func parsePacketsOutput(output []byte) (*SubtitlePackets, error) {
+	var probeResult struct {
+		Streams []struct {
+			CodecName string `json:"codec_name"`
+			Extradata string `json:"extradata"`
+		} `json:"streams"`
+		Packets []struct {
+			PtsTime      string `json:"pts_time"`
+			DurationTime string `json:"duration_time"`
+			Data         string `json:"data"`
+		} `json:"packets"`
+	}
+	if err := json.Unmarshal(output, &probeResult); err != nil {
+		return nil, fmt.Errorf("failed to parse ffprobe packets: %w", err)
+	}
+	if len(probeResult.Streams) == 0 {
+		return nil, fmt.Errorf("subtitle stream not found")
+	}
+
+	extradata, err := parseHexDump(probeResult.Streams[0].Extradata)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse extradata: %w", err)
+	}
+	ret := &SubtitlePackets{
+		Codec:     probeResult.Streams[0].CodecName,
+		Extradata: extradata,
+		Packets:   make([]SubtitlePacket, 0, len(probeResult.Packets)),
+	}
+	for i, packet := range probeResult.Packets {
+		data, err := parseHexDump(packet.Data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse packet %d: %w", i, err)
+		}
+		ret.Packets = append(ret.Packets, SubtitlePacket{
+			Start:    parseSeconds(packet.PtsTime),
+			Duration: parseSeconds(packet.DurationTime),
+			Data:     data,
+		})
+	}
+	return ret, nil
+}
+
+// parseHexDump decodes the hex dump ffprobe prints for -show_data, lines
+// look like "00000000: 1600 0102 0304  ....." with the hex part padded to 41 columns
+func parseHexDump(dump string) ([]byte, error) {
+	var ret []byte
+	for _, line := range strings.Split(dump, "\n") {
+		_, rest, ok := strings.Cut(line, ": ")
+		if !ok {
+			continue
+		}
+		if len(rest) > 41 {
+			rest = rest[:41]
+		}
+		chunk, err := hex.DecodeString(strings.ReplaceAll(rest, " ", ""))
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, chunk...)
+	}
+	return ret, nil
+}
+
+func parseSeconds(raw string) time.Duration {
+	seconds, err := strconv.ParseFloat(raw, 64)
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(math.Round(seconds*1e6)) * time.Microsecond
+}
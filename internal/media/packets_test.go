@@ -0,0 +1,85 @@
+package media
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/MimeLyc/contextual-sub-translator/internal/subtitle"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const packetsOutput = `{
+	"packets": [
+		{
+			"codec_type": "subtitle",
+			"pts_time": "1.001000",
+			"duration_time": "2.500000",
+			"data": "\n00000000: 1600 1307 8004 3810 0001 8000 0001 0000  ........8.......\n00000010: 0000 6403 84                             ..d.."
+		},
+		{
+			"codec_type": "subtitle",
+			"pts_time": "3.500000",
+			"data": "\n00000000: 8000 00                                  ..."
+		}
+	],
+	"streams": [
+		{
+			"codec_name": "hdmv_pgs_subtitle",
+			"extradata": "\n00000000: 7061 6c65 7474 653a 2030 3030 3030 30    palette: 000000"
+		}
+	]
+}`
+
+func TestParsePacketsOutput(t *testing.T) {
+	packets, err := parsePacketsOutput([]byte(packetsOutput))
+	require.NoError(t, err)
+
+	assert.Equal(t, "hdmv_pgs_subtitle", packets.Codec)
+	assert.Equal(t, "palette: 000000", string(packets.Extradata))
+	require.Len(t, packets.Packets, 2)
+	assert.Equal(t, 1001*time.Millisecond, packets.Packets[0].Start)
+	assert.Equal(t, 2500*time.Millisecond, packets.Packets[0].Duration)
+	assert.Equal(t, []byte{
+		0x16, 0x00, 0x13, 0x07, 0x80, 0x04, 0x38, 0x10, 0x00, 0x01, 0x80, 0x00, 0x00, 0x01, 0x00, 0x00,
+		0x00, 0x00, 0x64, 0x03, 0x84,
+	}, packets.Packets[0].Data)
+	assert.Equal(t, time.Duration(0), packets.Packets[1].Duration)
+	assert.Equal(t, []byte{0x80, 0x00, 0x00}, packets.Packets[1].Data)
+}
+
+func TestParsePacketsOutput_NoStream(t *testing.T) {
+	_, err := parsePacketsOutput([]byte(`{"packets": [], "streams": []}`))
+	assert.Error(t, err)
+}
+
+func TestFFmpeg_readPacketsArgs(t *testing.T) {
+	ff := ffmpeg{filePath: "/path/to/video.mkv"}
+	assert.Equal(t, []string{
+		"-v", "error",
+		"-print_format", "json",
+		"-select_streams", "s:3",
+		"-show_streams",
+		"-show_packets",
+		"-show_data",
+		"/path/to/video.mkv",
+	}, ff.readPacketsArgs(subtitle.Description{Index: 3}))
+}
+
+func TestFFmpeg_ReadSubtitlePackets(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("mock ffprobe script requires a POSIX shell")
+	}
+	mockDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(mockDir, "packets.json"), []byte(packetsOutput), 0o644))
+	script := "#!/bin/sh\ncat '" + filepath.Join(mockDir, "packets.json") + "'\n"
+	require.NoError(t, os.WriteFile(filepath.Join(mockDir, "ffprobe"), []byte(script), 0755))
+	t.Setenv("PATH", mockDir+":"+os.Getenv("PATH"))
+
+	packets, err := NewFfmpeg("dummy.mkv").ReadSubtitlePackets(subtitle.Description{Index: 0, Codec: "hdmv_pgs_subtitle"})
+	require.NoError(t, err)
+	assert.Len(t, packets.Packets, 2)
+}
@@ -27,12 +27,15 @@ type Actor struct {
 
 type Operator interface {
 	ReadSubtitleDescription() (subtitle.Descriptions, error)
-	ExtractSubtitleToBytes() ([]byte, error)
+	ExtractSubtitleToBytes(stream subtitle.Description) ([]byte, error)
 	ExtractSubtitle(
+		stream subtitle.Description,
 		toDir string,
 		name string,
 	) (string, error)
-	DefExtractSubtitle() (string, error)
+	DefExtractSubtitle(stream subtitle.Description) (string, error)
+	ReadSubtitlePackets(stream subtitle.Description) (*SubtitlePackets, error)
+	MuxSubtitle(opts MuxOptions) (string, error)
 }
 
 func NewOperator(
@@ -0,0 +1,258 @@
+package ocr
+
+import (
+	"encoding/binary"
+	"fmt"
+	"image"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MimeLyc/contextual-sub-translator/internal/media"
+)
+
+// DVD subpicture control commands
+const (
+	spuForcedStart = 0x00
+	spuStart       = 0x01
+	spuStop        = 0x02
+	spuColors      = 0x03
+	spuAlpha       = 0x04
+	spuCoords      = 0x05
+	spuOffsets     = 0x06
+	spuEnd         = 0xff
+)
+
+// spuDelayUnit is the unit of control sequence delays, 1024 ticks of 90kHz
+const spuDelayUnit = 1024 * time.Second / 90000
+
+// defaultDVDLuma guesses the brightness of the four pixel kinds when the
+// stream has no palette: transparent background, bright fill, dark outline
+var defaultDVDLuma = [4]uint8{0, 255, 0, 128}
+
+// decodeDVDSub decodes DVD subpictures (VobSub). Every packet is one
+// subpicture that carries its own start and stop delays.
+func decodeDVDSub(packets []media.SubtitlePacket, extradata []byte) ([]Event, error) {
+	palette := parseIdxPalette(string(extradata))
+	var events []Event
+	for i, packet := range packets {
+		event, ok, err := decodeSPU(packet, palette)
+		if err != nil {
+			return nil, fmt.Errorf("packet %d: %w", i, err)
+		}
+		if !ok {
+			continue
+		}
+		if n := len(events); n > 0 && (events[n-1].End == 0 || events[n-1].End > event.Start) {
+			events[n-1].End = event.Start
+		}
+		events = append(events, event)
+	}
+	return closeOpenEnds(events), nil
+}
+
+// parseIdxPalette reads the 16 RGB palette entries of a VobSub idx header as
+// luminance, nil when the header has no palette
+func parseIdxPalette(header string) []uint8 {
+	for _, line := range strings.Split(header, "\n") {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), ":")
+		if !ok || !strings.EqualFold(strings.TrimSpace(key), "palette") {
+			continue
+		}
+		var ret []uint8
+		for _, entry := range strings.Split(value, ",") {
+			rgb, err := strconv.ParseUint(strings.TrimSpace(entry), 16, 32)
+			if err != nil {
+				return nil
+			}
+			r, g, b := float64(rgb>>16&0xff), float64(rgb>>8&0xff), float64(rgb&0xff)
+			ret = append(ret, uint8(0.299*r+0.587*g+0.114*b))
+		}
+		return ret
+	}
+	return nil
+}
+
+type spu struct {
+	colors  [4]int // palette entries of the four pixel kinds
+	alpha   [4]uint8
+	rect    image.Rectangle
+	offsets [2]int // top and bottom field
+	start   time.Duration
+	stop    time.Duration
+	started bool
+	stopped bool
+}
+
+func decodeSPU(packet media.SubtitlePacket, palette []uint8) (Event, bool, error) {
+	data := packet.Data
+	if len(data) < 4 {
+		return Event{}, false, fmt.Errorf("truncated subpicture")
+	}
+	size := int(binary.BigEndian.Uint16(data[0:2]))
+	if size > 0 && size < len(data) {
+		data = data[:size]
+	}
+
+	sp := spu{colors: [4]int{0, 1, 2, 3}, alpha: [4]uint8{0, 255, 255, 255}}
+	if err := sp.readControl(data, int(binary.BigEndian.Uint16(data[2:4]))); err != nil {
+		return Event{}, false, err
+	}
+	if !sp.started || sp.rect.Empty() {
+		return Event{}, false, nil
+	}
+
+	canvas := newCanvas(sp.rect)
+	width := sp.rect.Dx()
+	for field := range 2 {
+		offset := sp.offsets[field]
+		if offset <= 0 || offset >= len(data) {
+			continue
+		}
+		nibbles := nibbleReader{data: data, pos: offset * 2}
+		for y := sp.rect.Min.Y + field; y < sp.rect.Max.Y; y += 2 {
+			for x := 0; x < width; {
+				count, kind, ok := nibbles.run()
+				if !ok {
+					break
+				}
+				if count == 0 {
+					count = width - x
+				}
+				luma := sp.luma(kind, palette)
+				for range count {
+					if x >= width {
+						break
+					}
+					paint(canvas, sp.rect.Min.X+x, y, luma, sp.alpha[kind])
+					x++
+				}
+			}
+			nibbles.align()
+		}
+	}
+
+	event := Event{Start: packet.Start + sp.start, Image: withMargin(canvas)}
+	if sp.stopped {
+		event.End = packet.Start + sp.stop
+	} else if packet.Duration > 0 {
+		event.End = packet.Start + packet.Duration
+	}
+	return event, true, nil
+}
+
+func (sp *spu) luma(kind int, palette []uint8) uint8 {
+	if idx := sp.colors[kind]; len(palette) == 16 && idx < len(palette) {
+		return palette[idx]
+	}
+	return defaultDVDLuma[kind]
+}
+
+// readControl walks the chain of control sequences that starts at offset
+func (sp *spu) readControl(data []byte, offset int) error {
+	for seen := 0; seen < 64; seen++ {
+		if offset+4 > len(data) {
+			return fmt.Errorf("control sequence at %d is out of range", offset)
+		}
+		delay := time.Duration(binary.BigEndian.Uint16(data[offset:offset+2])) * spuDelayUnit
+		next := int(binary.BigEndian.Uint16(data[offset+2 : offset+4]))
+		pos := offset + 4
+	commands:
+		for pos < len(data) {
+			cmd := data[pos]
+			pos++
+			switch cmd {
+			case spuForcedStart, spuStart:
+				sp.start, sp.started = delay, true
+			case spuStop:
+				sp.stop, sp.stopped = delay, true
+			case spuColors, spuAlpha:
+				if pos+2 > len(data) {
+					return fmt.Errorf("truncated control command 0x%02x", cmd)
+				}
+				// nibbles are stored for pixel kinds 3, 2, 1, 0
+				nibbles := [4]int{int(data[pos] >> 4), int(data[pos] & 0xf), int(data[pos+1] >> 4), int(data[pos+1] & 0xf)}
+				for kind := range 4 {
+					if cmd == spuColors {
+						sp.colors[3-kind] = nibbles[kind]
+					} else {
+						sp.alpha[3-kind] = uint8(nibbles[kind] * 17)
+					}
+				}
+				pos += 2
+			case spuCoords:
+				if pos+6 > len(data) {
+					return fmt.Errorf("truncated control command 0x%02x", cmd)
+				}
+				c := data[pos : pos+6]
+				x1 := int(c[0])<<4 | int(c[1])>>4
+				x2 := int(c[1]&0xf)<<8 | int(c[2])
+				y1 := int(c[3])<<4 | int(c[4])>>4
+				y2 := int(c[4]&0xf)<<8 | int(c[5])
+				sp.rect = image.Rect(x1, y1, x2+1, y2+1)
+				pos += 6
+			case spuOffsets:
+				if pos+4 > len(data) {
+					return fmt.Errorf("truncated control command 0x%02x", cmd)
+				}
+				sp.offsets = [2]int{
+					int(binary.BigEndian.Uint16(data[pos : pos+2])),
+					int(binary.BigEndian.Uint16(data[pos+2 : pos+4])),
+				}
+				pos += 4
+			case spuEnd:
+				break commands
+			default:
+				return fmt.Errorf("unknown control command 0x%02x", cmd)
+			}
+		}
+		if next == offset {
+			return nil
+		}
+		offset = next
+	}
+	return fmt.Errorf("too many control sequences")
+}
+
+// nibbleReader reads the 4-bit run-length codes of subpicture pixel data
+type nibbleReader struct {
+	data []byte
+	pos  int // in nibbles
+}
+
+func (r *nibbleReader) next() (int, bool) {
+	if r.pos/2 >= len(r.data) {
+		return 0, false
+	}
+	b := r.data[r.pos/2]
+	r.pos++
+	if r.pos%2 == 1 {
+		return int(b >> 4), true
+	}
+	return int(b & 0xf), true
+}
+
+// run returns the length and pixel kind of the next run, a zero length
+// fills the rest of the line
+func (r *nibbleReader) run() (int, int, bool) {
+	v, ok := r.next()
+	if !ok {
+		return 0, 0, false
+	}
+	for _, limit := range []int{0x4, 0x10, 0x40} {
+		if v >= limit {
+			break
+		}
+		n, ok := r.next()
+		if !ok {
+			return 0, 0, false
+		}
+		v = v<<4 | n
+	}
+	return v >> 2, v & 0x3, true
+}
+
+// align skips to the next byte, every line starts on a byte boundary
+func (r *nibbleReader) align() {
+	r.pos += r.pos % 2
+}
@@ -0,0 +1,35 @@
+package ocr
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// imageMargin is the white border around rendered events, OCR engines
+// recognize glyphs that touch the image edge poorly
+const imageMargin = 10
+
+func newCanvas(bounds image.Rectangle) *image.Gray {
+	canvas := image.NewGray(bounds)
+	draw.Draw(canvas, bounds, image.NewUniform(color.White), image.Point{}, draw.Src)
+	return canvas
+}
+
+// paint draws a subtitle pixel as ink: bright, opaque pixels such as the
+// usual white text fill become dark, outlines and transparency stay light
+func paint(canvas *image.Gray, x, y int, luma, alpha uint8) {
+	if !(image.Point{X: x, Y: y}).In(canvas.Rect) {
+		return
+	}
+	ink := int(luma) * int(alpha) / 255
+	canvas.SetGray(x, y, color.Gray{Y: uint8(255 - ink)})
+}
+
+// withMargin returns the canvas moved to the origin with a white margin
+func withMargin(canvas *image.Gray) *image.Gray {
+	size := canvas.Rect.Size()
+	ret := newCanvas(image.Rect(0, 0, size.X+2*imageMargin, size.Y+2*imageMargin))
+	draw.Draw(ret, ret.Rect.Inset(imageMargin), canvas, canvas.Rect.Min, draw.Src)
+	return ret
+}
@@ -0,0 +1,120 @@
+// Package ocr turns image-based subtitle streams (PGS, VobSub) into timed
+// text lines by decoding their bitmaps and running them through an OCR engine.
+package ocr
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"strings"
+	"time"
+
+	"github.com/MimeLyc/contextual-sub-translator/internal/media"
+	"github.com/MimeLyc/contextual-sub-translator/internal/subtitle"
+	"golang.org/x/text/language"
+)
+
+// Engine recognizes the text of one rendered subtitle image
+type Engine interface {
+	Recognize(ctx context.Context, img image.Image, lang language.Tag) (string, error)
+}
+
+// Event is a subtitle image shown between Start and End. Images are dark
+// text on a white background, which is what OCR engines expect.
+type Event struct {
+	Start time.Duration
+	End   time.Duration
+	Image *image.Gray
+}
+
+// defaultEventDuration ends events whose stream never clears them
+const defaultEventDuration = 5 * time.Second
+
+// Decode decodes the bitmaps of an image-based subtitle stream
+func Decode(packets *media.SubtitlePackets) ([]Event, error) {
+	if packets == nil {
+		return nil, fmt.Errorf("no subtitle packets")
+	}
+	switch packets.Codec {
+	case "hdmv_pgs_subtitle":
+		return decodePGS(packets.Packets)
+	case "dvd_subtitle":
+		return decodeDVDSub(packets.Packets, packets.Extradata)
+	default:
+		return nil, fmt.Errorf("unsupported image subtitle codec %q", packets.Codec)
+	}
+}
+
+// ReadLines decodes an image-based subtitle stream and recognizes its events
+func ReadLines(
+	ctx context.Context,
+	engine Engine,
+	packets *media.SubtitlePackets,
+	lang language.Tag,
+) ([]subtitle.Line, error) {
+	events, err := Decode(packets)
+	if err != nil {
+		return nil, err
+	}
+	return Recognize(ctx, engine, events, lang)
+}
+
+// Recognize runs every event through the engine and returns the events with
+// text as numbered lines. An engine error stops recognition, because it
+// usually means the engine is missing or misconfigured.
+func Recognize(ctx context.Context, engine Engine, events []Event, lang language.Tag) ([]subtitle.Line, error) {
+	if engine == nil {
+		return nil, fmt.Errorf("no OCR engine is configured")
+	}
+	lines := make([]subtitle.Line, 0, len(events))
+	for i, event := range events {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		text, err := engine.Recognize(ctx, event.Image, lang)
+		if err != nil {
+			return nil, fmt.Errorf("failed to recognize subtitle event %d at %s: %w", i+1, event.Start, err)
+		}
+		text = cleanText(text)
+		if text == "" {
+			continue
+		}
+		lines = append(lines, subtitle.Line{
+			Index:     len(lines) + 1,
+			StartTime: event.Start,
+			EndTime:   event.End,
+			Text:      text,
+		})
+	}
+	if len(events) > 0 && len(lines) == 0 {
+		return nil, fmt.Errorf("no text recognized in %d subtitle events", len(events))
+	}
+	return lines, nil
+}
+
+// cleanText drops blank lines and surrounding whitespace from OCR output
+func cleanText(text string) string {
+	var kept []string
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "\n")
+}
+
+// closeOpenEnds fills in the end of events that were never cleared
+func closeOpenEnds(events []Event) []Event {
+	for i := range events {
+		if events[i].End > events[i].Start {
+			continue
+		}
+		end := events[i].Start + defaultEventDuration
+		if i+1 < len(events) && events[i+1].Start > events[i].Start {
+			end = min(end, events[i+1].Start)
+		}
+		events[i].End = end
+	}
+	return events
+}
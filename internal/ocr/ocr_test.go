@@ -0,0 +1,229 @@
+package ocr
+
+import (
+	"context"
+	"errors"
+	"image"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/MimeLyc/contextual-sub-translator/internal/media"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/language"
+)
+
+func pgsSegmentBytes(kind byte, payload ...byte) []byte {
+	return append([]byte{kind, byte(len(payload) >> 8), byte(len(payload))}, payload...)
+}
+
+// pgsShow is a display set that shows a 4x2 white object at (100, 900)
+func pgsShow() []byte {
+	rle := []byte{
+		0x00, 0x84, 0x01, 0x00, 0x00, // 4 pixels of color 1, end of line
+		0x00, 0x84, 0x01, 0x00, 0x00,
+	}
+	var data []byte
+	data = append(data, pgsSegmentBytes(pgsComposition,
+		0x07, 0x80, 0x04, 0x38, 0x10, 0x00, 0x01, 0x80, 0x00, 0x00, 0x01,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x64, 0x03, 0x84)...)
+	data = append(data, pgsSegmentBytes(pgsPalette,
+		0x00, 0x00,
+		0x01, 0xeb, 0x80, 0x80, 0xff, // white
+		0x02, 0x10, 0x80, 0x80, 0xff)...) // black
+	data = append(data, pgsSegmentBytes(pgsObject,
+		append([]byte{0x00, 0x00, 0x00, 0xc0, 0x00, 0x00, byte(len(rle) + 4), 0x00, 0x04, 0x00, 0x02}, rle...)...)...)
+	return append(data, pgsSegmentBytes(pgsEnd)...)
+}
+
+// pgsClear is a display set with an empty composition
+func pgsClear() []byte {
+	data := pgsSegmentBytes(pgsComposition, 0x07, 0x80, 0x04, 0x38, 0x10, 0x00, 0x02, 0x00, 0x00, 0x00, 0x00)
+	return append(data, pgsSegmentBytes(pgsEnd)...)
+}
+
+func TestDecodePGS(t *testing.T) {
+	events, err := Decode(&media.SubtitlePackets{
+		Codec: "hdmv_pgs_subtitle",
+		Packets: []media.SubtitlePacket{
+			{Start: time.Second, Data: pgsShow()},
+			{Start: 3 * time.Second, Data: pgsClear()},
+			{Start: 10 * time.Second, Data: pgsShow()},
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+
+	assert.Equal(t, time.Second, events[0].Start)
+	assert.Equal(t, 3*time.Second, events[0].End)
+	assert.Equal(t, 10*time.Second+defaultEventDuration, events[1].End, "events that are never cleared get a default duration")
+
+	img := events[0].Image
+	assert.Equal(t, image.Pt(4+2*imageMargin, 2+2*imageMargin), img.Rect.Size())
+	assert.Less(t, img.GrayAt(imageMargin, imageMargin).Y, uint8(64), "white text becomes dark ink")
+	assert.Equal(t, uint8(255), img.GrayAt(0, 0).Y)
+}
+
+func TestDecodePGS_SupHeaders(t *testing.T) {
+	// .sup files prefix every segment with "PG" and two timestamps
+	var data []byte
+	for rest := pgsShow(); len(rest) > 0; {
+		size := int(rest[1])<<8 | int(rest[2])
+		data = append(data, 'P', 'G', 0, 0, 0, 0, 0, 0, 0, 0)
+		data = append(data, rest[:3+size]...)
+		rest = rest[3+size:]
+	}
+	events, err := decodePGS([]media.SubtitlePacket{{Start: time.Second, Duration: 2 * time.Second, Data: data}})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, 3*time.Second, events[0].End)
+}
+
+func TestDecodePGS_Truncated(t *testing.T) {
+	_, err := decodePGS([]media.SubtitlePacket{{Data: []byte{pgsComposition, 0x00, 0x20, 0x01}}})
+	assert.Error(t, err)
+}
+
+// dvdSubpicture is a 4x2 subpicture at (100, 200) shown for 200 delay units
+func dvdSubpicture() []byte {
+	return []byte{
+		0x00, 0x24, 0x00, 0x06, // size 36, control at 6
+		0x11,       // top field: 4 pixels of kind 1
+		0x11,       // bottom field
+		0x00, 0x00, // delay 0
+		0x00, 0x1e, // next control at 30
+		spuStart,
+		spuColors, 0x00, 0x10, // kind 1 uses palette entry 1
+		spuAlpha, 0xff, 0xf0, // kind 0 is transparent
+		spuCoords, 0x06, 0x40, 0x67, 0x0c, 0x80, 0xc9,
+		spuOffsets, 0x00, 0x04, 0x00, 0x05,
+		spuEnd,
+		0x00, 0xc8, // delay 200
+		0x00, 0x1e, // last control sequence
+		spuStop,
+		spuEnd,
+	}
+}
+
+func TestDecodeDVDSub(t *testing.T) {
+	idx := "size: 720x480\npalette: 000000, ffffff, 808080, 000000, 000000, 000000, 000000, 000000, " +
+		"000000, 000000, 000000, 000000, 000000, 000000, 000000, 000000\n"
+	events, err := Decode(&media.SubtitlePackets{
+		Codec:     "dvd_subtitle",
+		Extradata: []byte(idx),
+		Packets:   []media.SubtitlePacket{{Start: 5 * time.Second, Data: dvdSubpicture()}},
+	})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+
+	assert.Equal(t, 5*time.Second, events[0].Start)
+	assert.Equal(t, 5*time.Second+200*spuDelayUnit, events[0].End)
+	img := events[0].Image
+	assert.Equal(t, image.Pt(4+2*imageMargin, 2+2*imageMargin), img.Rect.Size())
+	assert.Equal(t, uint8(0), img.GrayAt(imageMargin, imageMargin).Y)
+	assert.Equal(t, uint8(0), img.GrayAt(imageMargin+3, imageMargin+1).Y)
+	assert.Equal(t, uint8(255), img.GrayAt(imageMargin+4, imageMargin).Y)
+}
+
+func TestParseIdxPalette(t *testing.T) {
+	palette := parseIdxPalette("# VobSub index file\npalette: 000000, ffffff, ff0000\n")
+	assert.Equal(t, []uint8{0, 255, 76}, palette)
+	assert.Nil(t, parseIdxPalette("size: 720x480"))
+}
+
+func TestDecode_UnsupportedCodec(t *testing.T) {
+	_, err := Decode(&media.SubtitlePackets{Codec: "xsub"})
+	assert.ErrorContains(t, err, "xsub")
+}
+
+type stubEngine struct {
+	texts []string
+	err   error
+	calls int
+}
+
+func (e *stubEngine) Recognize(context.Context, image.Image, language.Tag) (string, error) {
+	if e.err != nil {
+		return "", e.err
+	}
+	text := e.texts[e.calls%len(e.texts)]
+	e.calls++
+	return text, nil
+}
+
+func TestRecognize(t *testing.T) {
+	events := []Event{
+		{Start: time.Second, End: 2 * time.Second},
+		{Start: 3 * time.Second, End: 4 * time.Second},
+		{Start: 5 * time.Second, End: 6 * time.Second},
+	}
+	engine := &stubEngine{texts: []string{" Hello,\n\nworld \n", "  \n", "Bye"}}
+
+	lines, err := Recognize(context.Background(), engine, events, language.English)
+	require.NoError(t, err)
+	require.Len(t, lines, 2)
+	assert.Equal(t, 1, lines[0].Index)
+	assert.Equal(t, "Hello,\nworld", lines[0].Text)
+	assert.Equal(t, time.Second, lines[0].StartTime)
+	assert.Equal(t, 2, lines[1].Index)
+	assert.Equal(t, "Bye", lines[1].Text)
+	assert.Equal(t, 5*time.Second, lines[1].StartTime)
+
+	_, err = Recognize(context.Background(), &stubEngine{err: errors.New("tesseract not found")}, events, language.English)
+	assert.ErrorContains(t, err, "tesseract not found")
+
+	_, err = Recognize(context.Background(), &stubEngine{texts: []string{""}}, events, language.English)
+	assert.ErrorContains(t, err, "no text recognized")
+
+	_, err = Recognize(context.Background(), nil, events, language.English)
+	assert.Error(t, err)
+}
+
+func TestReadLines(t *testing.T) {
+	lines, err := ReadLines(context.Background(), &stubEngine{texts: []string{"Hello"}}, &media.SubtitlePackets{
+		Codec: "hdmv_pgs_subtitle",
+		Packets: []media.SubtitlePacket{
+			{Start: time.Second, Data: pgsShow()},
+			{Start: 3 * time.Second, Data: pgsClear()},
+		},
+	}, language.English)
+	require.NoError(t, err)
+	require.Len(t, lines, 1)
+	assert.Equal(t, "Hello", lines[0].Text)
+	assert.Equal(t, 3*time.Second, lines[0].EndTime)
+}
+
+func TestTesseractLanguage(t *testing.T) {
+	assert.Equal(t, "eng", tesseractLanguage(language.English))
+	assert.Equal(t, "jpn", tesseractLanguage(language.Japanese))
+	assert.Equal(t, "chi_sim", tesseractLanguage(language.Chinese))
+	assert.Equal(t, "chi_tra", tesseractLanguage(language.MustParse("zh-TW")))
+	assert.Equal(t, "eng", tesseractLanguage(language.Und))
+}
+
+func TestTesseract_Recognize(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake tesseract script requires a POSIX shell")
+	}
+	binDir := t.TempDir()
+	argsLog := filepath.Join(binDir, "args.log")
+	script := "#!/bin/sh\necho \"$@\" > '" + argsLog + "'\ntest -s \"$1\" || exit 1\necho 'Hello there'\n"
+	require.NoError(t, os.WriteFile(filepath.Join(binDir, "tesseract"), []byte(script), 0755))
+	t.Setenv("PATH", binDir+":"+os.Getenv("PATH"))
+
+	text, err := NewTesseract("").Recognize(context.Background(), newCanvas(image.Rect(0, 0, 8, 8)), language.Japanese)
+	require.NoError(t, err)
+	assert.Equal(t, "Hello there\n", text)
+
+	args, err := os.ReadFile(argsLog)
+	require.NoError(t, err)
+	assert.Contains(t, string(args), "stdout -l jpn --psm 6")
+}
+
+func TestTesseract_MissingCommand(t *testing.T) {
+	_, err := NewTesseract("ctxtrans-missing-tesseract").Recognize(context.Background(), newCanvas(image.Rect(0, 0, 1, 1)), language.English)
+	assert.Error(t, err)
+}
@@ -0,0 +1,267 @@
+package ocr
+
+import (
+	"encoding/binary"
+	"fmt"
+	"image"
+
+	"github.com/MimeLyc/contextual-sub-translator/internal/media"
+)
+
+// PGS segment types
+const (
+	pgsPalette     = 0x14
+	pgsObject      = 0x15
+	pgsComposition = 0x16
+	pgsWindow      = 0x17
+	pgsEnd         = 0x80
+)
+
+type pgsColor struct {
+	luma  uint8
+	alpha uint8
+}
+
+type pgsObjectData struct {
+	width  int
+	height int
+	rle    []byte
+}
+
+type pgsPlacement struct {
+	objectID int
+	x, y     int
+}
+
+// pgsDecoder keeps the palettes and objects of a PGS stream, later display
+// sets may show objects defined by earlier ones
+type pgsDecoder struct {
+	palettes map[int]map[int]pgsColor
+	objects  map[int]*pgsObjectData
+}
+
+// decodePGS decodes Blu-ray presentation graphics. Every packet holds one
+// display set, a composition without objects clears the screen.
+func decodePGS(packets []media.SubtitlePacket) ([]Event, error) {
+	d := pgsDecoder{
+		palettes: make(map[int]map[int]pgsColor),
+		objects:  make(map[int]*pgsObjectData),
+	}
+	var events []Event
+	for i, packet := range packets {
+		segments, err := splitPGSSegments(packet.Data)
+		if err != nil {
+			return nil, fmt.Errorf("packet %d: %w", i, err)
+		}
+
+		var placements []pgsPlacement
+		paletteID := 0
+		composed := false
+		for _, segment := range segments {
+			switch segment.kind {
+			case pgsPalette:
+				d.readPalette(segment.payload)
+			case pgsObject:
+				d.readObject(segment.payload)
+			case pgsComposition:
+				composed = true
+				placements, paletteID, err = readPGSComposition(segment.payload)
+				if err != nil {
+					return nil, fmt.Errorf("packet %d: %w", i, err)
+				}
+			}
+		}
+		if !composed {
+			continue
+		}
+
+		// any composition replaces what is on screen
+		if n := len(events); n > 0 && events[n-1].End == 0 {
+			events[n-1].End = packet.Start
+		}
+		if len(placements) == 0 {
+			continue
+		}
+		img := d.render(placements, d.palettes[paletteID])
+		if img == nil {
+			continue
+		}
+		event := Event{Start: packet.Start, Image: img}
+		if packet.Duration > 0 {
+			event.End = packet.Start + packet.Duration
+		}
+		events = append(events, event)
+	}
+	return closeOpenEnds(events), nil
+}
+
+type pgsSegment struct {
+	kind    byte
+	payload []byte
+}
+
+// splitPGSSegments splits a display set into segments. Matroska stores bare
+// segments, .sup files prefix every segment with "PG" and two timestamps.
+func splitPGSSegments(data []byte) ([]pgsSegment, error) {
+	var ret []pgsSegment
+	for len(data) > 0 {
+		if len(data) >= 2 && data[0] == 'P' && data[1] == 'G' {
+			if len(data) < 10 {
+				return nil, fmt.Errorf("truncated PGS header")
+			}
+			data = data[10:]
+			continue
+		}
+		if len(data) < 3 {
+			return nil, fmt.Errorf("truncated PGS segment")
+		}
+		size := int(binary.BigEndian.Uint16(data[1:3]))
+		if len(data) < 3+size {
+			return nil, fmt.Errorf("PGS segment 0x%02x is truncated", data[0])
+		}
+		ret = append(ret, pgsSegment{kind: data[0], payload: data[3 : 3+size]})
+		data = data[3+size:]
+	}
+	return ret, nil
+}
+
+func (d *pgsDecoder) readPalette(payload []byte) {
+	if len(payload) < 2 {
+		return
+	}
+	id := int(payload[0])
+	palette := make(map[int]pgsColor)
+	for entry := payload[2:]; len(entry) >= 5; entry = entry[5:] {
+		palette[int(entry[0])] = pgsColor{luma: entry[1], alpha: entry[4]}
+	}
+	d.palettes[id] = palette
+}
+
+func (d *pgsDecoder) readObject(payload []byte) {
+	if len(payload) < 4 {
+		return
+	}
+	id := int(binary.BigEndian.Uint16(payload[0:2]))
+	flags := payload[3]
+	data := payload[4:]
+	if flags&0x80 != 0 {
+		// first fragment: 3 bytes data length, width and height
+		if len(data) < 7 {
+			return
+		}
+		d.objects[id] = &pgsObjectData{
+			width:  int(binary.BigEndian.Uint16(data[3:5])),
+			height: int(binary.BigEndian.Uint16(data[5:7])),
+			rle:    append([]byte(nil), data[7:]...),
+		}
+		return
+	}
+	if object, ok := d.objects[id]; ok {
+		object.rle = append(object.rle, data...)
+	}
+}
+
+func readPGSComposition(payload []byte) ([]pgsPlacement, int, error) {
+	if len(payload) < 11 {
+		return nil, 0, fmt.Errorf("truncated PGS composition")
+	}
+	paletteID := int(payload[9])
+	count := int(payload[10])
+	placements := make([]pgsPlacement, 0, count)
+	rest := payload[11:]
+	for range count {
+		if len(rest) < 8 {
+			return nil, 0, fmt.Errorf("truncated PGS composition object")
+		}
+		placement := pgsPlacement{
+			objectID: int(binary.BigEndian.Uint16(rest[0:2])),
+			x:        int(binary.BigEndian.Uint16(rest[4:6])),
+			y:        int(binary.BigEndian.Uint16(rest[6:8])),
+		}
+		cropped := rest[3]&0x40 != 0
+		rest = rest[8:]
+		if cropped {
+			if len(rest) < 8 {
+				return nil, 0, fmt.Errorf("truncated PGS composition crop")
+			}
+			rest = rest[8:]
+		}
+		placements = append(placements, placement)
+	}
+	return placements, paletteID, nil
+}
+
+// render draws the placed objects into one image that covers all of them
+func (d *pgsDecoder) render(placements []pgsPlacement, palette map[int]pgsColor) *image.Gray {
+	var bounds image.Rectangle
+	for _, placement := range placements {
+		object, ok := d.objects[placement.objectID]
+		if !ok || object.width == 0 || object.height == 0 {
+			continue
+		}
+		bounds = bounds.Union(image.Rect(placement.x, placement.y, placement.x+object.width, placement.y+object.height))
+	}
+	if bounds.Empty() {
+		return nil
+	}
+
+	canvas := newCanvas(bounds)
+	for _, placement := range placements {
+		object, ok := d.objects[placement.objectID]
+		if !ok {
+			continue
+		}
+		x, y := placement.x, placement.y
+		decodePGSRLE(object.rle, func(color, count int) {
+			c := palette[color]
+			for range count {
+				if x < placement.x+object.width {
+					paint(canvas, x, y, c.luma, c.alpha)
+				}
+				x++
+			}
+		}, func() {
+			x = placement.x
+			y++
+		})
+	}
+	return withMargin(canvas)
+}
+
+// decodePGSRLE walks the run-length encoded pixels of a PGS object
+func decodePGSRLE(data []byte, run func(color, count int), endOfLine func()) {
+	for i := 0; i < len(data); {
+		b := data[i]
+		i++
+		if b != 0 {
+			run(int(b), 1)
+			continue
+		}
+		if i >= len(data) {
+			return
+		}
+		flags := data[i]
+		i++
+		if flags == 0 {
+			endOfLine()
+			continue
+		}
+		count := int(flags & 0x3f)
+		if flags&0x40 != 0 {
+			if i >= len(data) {
+				return
+			}
+			count = count<<8 | int(data[i])
+			i++
+		}
+		color := 0
+		if flags&0x80 != 0 {
+			if i >= len(data) {
+				return
+			}
+			color = int(data[i])
+			i++
+		}
+		run(color, count)
+	}
+}
@@ -0,0 +1,80 @@
+package ocr
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"os/exec"
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+// Tesseract recognizes text with the tesseract command line tool
+type Tesseract struct {
+	command string
+}
+
+// NewTesseract creates an engine that runs command, "tesseract" when empty
+func NewTesseract(command string) *Tesseract {
+	if strings.TrimSpace(command) == "" {
+		command = "tesseract"
+	}
+	return &Tesseract{command: command}
+}
+
+func (t *Tesseract) Recognize(ctx context.Context, img image.Image, lang language.Tag) (string, error) {
+	cmdPath, err := exec.LookPath(t.command)
+	if err != nil {
+		return "", err
+	}
+
+	input, err := os.CreateTemp("", "ctxtrans-ocr-*.png")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(input.Name())
+	if err := png.Encode(input, img); err != nil {
+		input.Close()
+		return "", fmt.Errorf("failed to encode subtitle image: %w", err)
+	}
+	if err := input.Close(); err != nil {
+		return "", err
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, cmdPath, t.args(input.Name(), lang)...)
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return string(output), nil
+}
+
+func (Tesseract) args(imagePath string, lang language.Tag) []string {
+	return []string{
+		imagePath,
+		"stdout",
+		"-l", tesseractLanguage(lang),
+		"--psm", "6", // a single uniform block of text
+	}
+}
+
+// tesseractLanguage maps a language to the name of its tesseract model
+func tesseractLanguage(lang language.Tag) string {
+	base, conf := lang.Base()
+	if conf == language.No {
+		return "eng"
+	}
+	if base.String() == "zh" {
+		if script, _ := lang.Script(); script.String() == "Hant" {
+			return "chi_tra"
+		}
+		return "chi_sim"
+	}
+	return base.ISO3()
+}
@@ -0,0 +1 @@
+ALTER TABLE jobs ADD COLUMN output_mode TEXT NOT NULL DEFAULT '';
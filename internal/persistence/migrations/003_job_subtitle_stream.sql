@@ -0,0 +1,3 @@
+-- stream_index is NULL when the stream is chosen by policy or automatically.
+ALTER TABLE jobs ADD COLUMN stream_index INTEGER;
+ALTER TABLE jobs ADD COLUMN stream_policy_json TEXT NOT NULL DEFAULT '';
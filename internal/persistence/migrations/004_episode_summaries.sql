@@ -0,0 +1,11 @@
+-- series_key identifies a show, usually the directory of its tvshow.nfo.
+-- relationships holds the character notes of the series up to this episode.
+CREATE TABLE IF NOT EXISTS episode_summaries (
+    series_key TEXT NOT NULL,
+    media_path TEXT NOT NULL,
+    episode TEXT NOT NULL DEFAULT '',
+    synopsis TEXT NOT NULL,
+    relationships TEXT NOT NULL DEFAULT '',
+    updated_at DATETIME NOT NULL,
+    PRIMARY KEY (series_key, media_path)
+);
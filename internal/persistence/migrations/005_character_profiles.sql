@@ -0,0 +1,11 @@
+-- Profiles are added by the speaker pre-pass and edited through the API;
+-- the pre-pass never overwrites an existing row.
+CREATE TABLE IF NOT EXISTS character_profiles (
+    series_key TEXT NOT NULL,
+    name TEXT NOT NULL COLLATE NOCASE,
+    gender TEXT NOT NULL DEFAULT '',
+    formality TEXT NOT NULL DEFAULT '',
+    nicknames_json TEXT NOT NULL DEFAULT '[]',
+    updated_at DATETIME NOT NULL,
+    PRIMARY KEY (series_key, name)
+);
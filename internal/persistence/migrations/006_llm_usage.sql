@@ -0,0 +1,20 @@
+-- One row per LLM call; batch_start/batch_end are -1 for calls outside a
+-- translation batch. Rows outlive their job so cost history stays complete.
+CREATE TABLE IF NOT EXISTS llm_usage (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    job_id TEXT NOT NULL DEFAULT '',
+    series_key TEXT NOT NULL DEFAULT '',
+    stage TEXT NOT NULL DEFAULT '',
+    batch_start INTEGER NOT NULL DEFAULT -1,
+    batch_end INTEGER NOT NULL DEFAULT -1,
+    provider TEXT NOT NULL DEFAULT '',
+    model TEXT NOT NULL DEFAULT '',
+    prompt_tokens INTEGER NOT NULL DEFAULT 0,
+    completion_tokens INTEGER NOT NULL DEFAULT 0,
+    cost REAL NOT NULL DEFAULT 0,
+    day TEXT NOT NULL,
+    created_at DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_llm_usage_job ON llm_usage(job_id);
+CREATE INDEX IF NOT EXISTS idx_llm_usage_day ON llm_usage(day);
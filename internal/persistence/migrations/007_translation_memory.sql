@@ -0,0 +1,14 @@
+-- Approved line translations, reused across the episodes of a series.
+-- source_key is the normalised source text; manual edits are never replaced
+-- by translations of the model.
+CREATE TABLE IF NOT EXISTS translation_memory (
+    series_key TEXT NOT NULL,
+    source_lang TEXT NOT NULL,
+    target_lang TEXT NOT NULL,
+    source_key TEXT NOT NULL,
+    source_text TEXT NOT NULL,
+    translation TEXT NOT NULL,
+    manual INTEGER NOT NULL DEFAULT 0,
+    updated_at DATETIME NOT NULL,
+    PRIMARY KEY (series_key, source_lang, target_lang, source_key)
+);
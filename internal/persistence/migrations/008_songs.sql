@@ -0,0 +1,16 @@
+-- How the songs of a series are translated, when it differs from the
+-- default, and the lyrics detected in its episodes so later episodes
+-- recognise them without styling. source_key is the normalised text.
+CREATE TABLE IF NOT EXISTS series_song_modes (
+    series_key TEXT PRIMARY KEY,
+    mode TEXT NOT NULL,
+    updated_at DATETIME NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS song_lines (
+    series_key TEXT NOT NULL,
+    source_key TEXT NOT NULL,
+    source_text TEXT NOT NULL,
+    updated_at DATETIME NOT NULL,
+    PRIMARY KEY (series_key, source_key)
+);
@@ -0,0 +1,11 @@
+-- The verdict of the reviewer on each translated batch of a job: the score of
+-- the batch and the issues it found per line, as JSON.
+CREATE TABLE IF NOT EXISTS translation_reviews (
+    job_id TEXT NOT NULL,
+    batch_start INTEGER NOT NULL,
+    batch_end INTEGER NOT NULL,
+    score REAL NOT NULL,
+    issues_json TEXT NOT NULL,
+    updated_at DATETIME NOT NULL,
+    PRIMARY KEY (job_id, batch_start)
+);
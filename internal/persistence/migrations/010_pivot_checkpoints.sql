@@ -0,0 +1,10 @@
+-- Checkpoints of the first pass of jobs translated through a pivot language,
+-- kept apart from job_batch_checkpoints which hold the pass to the target.
+CREATE TABLE IF NOT EXISTS job_pivot_checkpoints (
+    job_id TEXT NOT NULL,
+    batch_start INTEGER NOT NULL,
+    batch_end INTEGER NOT NULL,
+    translated_json TEXT NOT NULL,
+    updated_at DATETIME NOT NULL,
+    PRIMARY KEY (job_id, batch_start, batch_end)
+);
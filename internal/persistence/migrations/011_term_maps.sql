@@ -0,0 +1,19 @@
+-- Terms of a term map file edited by hand that term discovery must not
+-- overwrite, and the terms of the file each job found in its subtitle. The
+-- terms themselves stay in the file.
+CREATE TABLE IF NOT EXISTS term_locks (
+    term_map_path TEXT NOT NULL,
+    term TEXT NOT NULL,
+    updated_at DATETIME NOT NULL,
+    PRIMARY KEY (term_map_path, term)
+);
+
+CREATE TABLE IF NOT EXISTS job_term_usage (
+    job_id TEXT NOT NULL,
+    term_map_path TEXT NOT NULL,
+    term TEXT NOT NULL,
+    used_at DATETIME NOT NULL,
+    PRIMARY KEY (job_id, term_map_path, term)
+);
+
+CREATE INDEX IF NOT EXISTS idx_job_term_usage_term ON job_term_usage (term_map_path, term);
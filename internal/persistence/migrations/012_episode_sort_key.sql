@@ -0,0 +1,7 @@
+-- episode_key orders the episodes of a series by season and then by file
+-- name with its numbers compared by value, so "Season 10" sorts after
+-- "Season 2" and "Show - 10" after "Show - 9". Rows stored before it are
+-- keyed when the summaries of their series are next listed.
+ALTER TABLE episode_summaries ADD COLUMN episode_key TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_episode_summaries_key ON episode_summaries (series_key, episode_key);
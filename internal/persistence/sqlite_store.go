@@ -8,6 +8,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -127,7 +129,7 @@ func migrationVersion(name string) int {
 func (s *SQLiteStore) LoadJobs(ctx context.Context) ([]*jobs.TranslationJob, error) {
 	rows, err := s.db.QueryContext(
 		ctx,
-		`SELECT id, source, dedupe_key, media_file, subtitle_file, nfo_file, status, error, created_at, updated_at
+		`SELECT id, source, dedupe_key, media_file, subtitle_file, nfo_file, output_mode, stream_index, stream_policy_json, status, error, created_at, updated_at
 		 FROM jobs
 		 ORDER BY created_at ASC`,
 	)
@@ -140,6 +142,8 @@ func (s *SQLiteStore) LoadJobs(ctx context.Context) ([]*jobs.TranslationJob, err
 	for rows.Next() {
 		var item jobs.TranslationJob
 		var status string
+		var streamIndex sql.NullInt64
+		var streamPolicyJSON string
 		if err := rows.Scan(
 			&item.ID,
 			&item.Source,
@@ -147,6 +151,9 @@ func (s *SQLiteStore) LoadJobs(ctx context.Context) ([]*jobs.TranslationJob, err
 			&item.Payload.MediaFile,
 			&item.Payload.SubtitleFile,
 			&item.Payload.NFOFile,
+			&item.Payload.OutputMode,
+			&streamIndex,
+			&streamPolicyJSON,
 			&status,
 			&item.Error,
 			&item.CreatedAt,
@@ -155,6 +162,17 @@ func (s *SQLiteStore) LoadJobs(ctx context.Context) ([]*jobs.TranslationJob, err
 			return nil, err
 		}
 		item.Status = jobs.Status(status)
+		if streamIndex.Valid {
+			index := int(streamIndex.Int64)
+			item.Payload.StreamIndex = &index
+		}
+		if streamPolicyJSON != "" {
+			var policy subtitle.StreamPolicy
+			if err := json.Unmarshal([]byte(streamPolicyJSON), &policy); err != nil {
+				return nil, fmt.Errorf("invalid stream policy of job %s: %w", item.ID, err)
+			}
+			item.Payload.StreamPolicy = &policy
+		}
 		ret = append(ret, &item)
 	}
 	if err := rows.Err(); err != nil {
@@ -172,17 +190,32 @@ func (s *SQLiteStore) UpsertJob(ctx context.Context, job *jobs.TranslationJob) e
 	if job == nil {
 		return fmt.Errorf("job is nil")
 	}
+	var streamIndex sql.NullInt64
+	if job.Payload.StreamIndex != nil {
+		streamIndex = sql.NullInt64{Int64: int64(*job.Payload.StreamIndex), Valid: true}
+	}
+	streamPolicyJSON := ""
+	if job.Payload.StreamPolicy != nil {
+		data, err := json.Marshal(job.Payload.StreamPolicy)
+		if err != nil {
+			return err
+		}
+		streamPolicyJSON = string(data)
+	}
 	_, err := s.db.ExecContext(
 		ctx,
 		`INSERT INTO jobs (
-			id, source, dedupe_key, media_file, subtitle_file, nfo_file, status, error, created_at, updated_at
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			id, source, dedupe_key, media_file, subtitle_file, nfo_file, output_mode, stream_index, stream_policy_json, status, error, created_at, updated_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		ON CONFLICT(id) DO UPDATE SET
 			source=excluded.source,
 			dedupe_key=excluded.dedupe_key,
 			media_file=excluded.media_file,
 			subtitle_file=excluded.subtitle_file,
 			nfo_file=excluded.nfo_file,
+			output_mode=excluded.output_mode,
+			stream_index=excluded.stream_index,
+			stream_policy_json=excluded.stream_policy_json,
 			status=excluded.status,
 			error=excluded.error,
 			updated_at=excluded.updated_at`,
@@ -192,6 +225,9 @@ func (s *SQLiteStore) UpsertJob(ctx context.Context, job *jobs.TranslationJob) e
 		job.Payload.MediaFile,
 		job.Payload.SubtitleFile,
 		job.Payload.NFOFile,
+		job.Payload.OutputMode,
+		streamIndex,
+		streamPolicyJSON,
 		string(job.Status),
 		job.Error,
 		job.CreatedAt,
@@ -201,13 +237,33 @@ func (s *SQLiteStore) UpsertJob(ctx context.Context, job *jobs.TranslationJob) e
 }
 
 func (s *SQLiteStore) SaveBatchCheckpoint(ctx context.Context, jobID string, batchStart int, batchEnd int, translatedLines []string) error {
+	return s.saveCheckpoint(ctx, "job_batch_checkpoints", jobID, batchStart, batchEnd, translatedLines)
+}
+
+func (s *SQLiteStore) LoadBatchCheckpoints(ctx context.Context, jobID string) ([]BatchCheckpoint, error) {
+	return s.loadCheckpoints(ctx, "job_batch_checkpoints", jobID)
+}
+
+// SavePivotCheckpoint stores a batch of the pass of a job to its pivot
+// language, apart from the batches of the pass to the target.
+func (s *SQLiteStore) SavePivotCheckpoint(ctx context.Context, jobID string, batchStart int, batchEnd int, translatedLines []string) error {
+	return s.saveCheckpoint(ctx, "job_pivot_checkpoints", jobID, batchStart, batchEnd, translatedLines)
+}
+
+// LoadPivotCheckpoints returns the batches of the pass of a job to its pivot
+// language in line order.
+func (s *SQLiteStore) LoadPivotCheckpoints(ctx context.Context, jobID string) ([]BatchCheckpoint, error) {
+	return s.loadCheckpoints(ctx, "job_pivot_checkpoints", jobID)
+}
+
+func (s *SQLiteStore) saveCheckpoint(ctx context.Context, table string, jobID string, batchStart int, batchEnd int, translatedLines []string) error {
 	payload, err := json.Marshal(translatedLines)
 	if err != nil {
 		return err
 	}
 	_, err = s.db.ExecContext(
 		ctx,
-		`INSERT INTO job_batch_checkpoints (job_id, batch_start, batch_end, translated_json, updated_at)
+		`INSERT INTO `+table+` (job_id, batch_start, batch_end, translated_json, updated_at)
 		 VALUES (?, ?, ?, ?, ?)
 		 ON CONFLICT(job_id, batch_start, batch_end) DO UPDATE SET
 			translated_json=excluded.translated_json,
@@ -221,11 +277,11 @@ func (s *SQLiteStore) SaveBatchCheckpoint(ctx context.Context, jobID string, bat
 	return err
 }
 
-func (s *SQLiteStore) LoadBatchCheckpoints(ctx context.Context, jobID string) ([]BatchCheckpoint, error) {
+func (s *SQLiteStore) loadCheckpoints(ctx context.Context, table string, jobID string) ([]BatchCheckpoint, error) {
 	rows, err := s.db.QueryContext(
 		ctx,
 		`SELECT job_id, batch_start, batch_end, translated_json, updated_at
-		 FROM job_batch_checkpoints
+		 FROM `+table+`
 		 WHERE job_id = ?
 		 ORDER BY batch_start ASC`,
 		jobID,
@@ -254,18 +310,26 @@ func (s *SQLiteStore) LoadBatchCheckpoints(ctx context.Context, jobID string) ([
 }
 
 type subtitlePayload struct {
-	Lines    []subtitle.Line `json:"lines"`
-	Language string          `json:"language"`
-	Format   string          `json:"format"`
-	Path     string          `json:"path"`
+	Lines       []subtitle.Line       `json:"lines"`
+	Language    string                `json:"language"`
+	Format      string                `json:"format"`
+	Path        string                `json:"path"`
+	Encoding    string                `json:"encoding,omitempty"`
+	Diagnostics []subtitle.Diagnostic `json:"diagnostics,omitempty"`
+	ASS         *subtitle.ASSScript   `json:"ass,omitempty"`
+	VTT         *subtitle.VTTDocument `json:"vtt,omitempty"`
 }
 
 func (s *SQLiteStore) PutSubtitleCache(ctx context.Context, entry SubtitleCacheEntry) error {
 	payload := subtitlePayload{
-		Lines:    entry.File.Lines,
-		Language: entry.File.Language.String(),
-		Format:   entry.File.Format,
-		Path:     entry.File.Path,
+		Lines:       entry.File.Lines,
+		Language:    entry.File.Language.String(),
+		Format:      entry.File.Format,
+		Path:        entry.File.Path,
+		Encoding:    entry.File.Encoding,
+		Diagnostics: entry.File.Diagnostics,
+		ASS:         entry.File.ASS,
+		VTT:         entry.File.VTT,
 	}
 	jsonPayload, err := json.Marshal(payload)
 	if err != nil {
@@ -310,6 +374,10 @@ func (s *SQLiteStore) GetSubtitleCache(ctx context.Context, cacheKey string) (su
 		 WHERE cache_key = ?`,
 		cacheKey,
 	)
+	return scanSubtitleCache(row)
+}
+
+func scanSubtitleCache(row *sql.Row) (subtitle.File, bool, error) {
 	var payloadJSON string
 	if err := row.Scan(&payloadJSON); err != nil {
 		if err == sql.ErrNoRows {
@@ -326,14 +394,32 @@ func (s *SQLiteStore) GetSubtitleCache(ctx context.Context, cacheKey string) (su
 		langTag = language.Und
 	}
 	ret := subtitle.File{
-		Lines:    payload.Lines,
-		Language: langTag,
-		Format:   payload.Format,
-		Path:     payload.Path,
+		Lines:       payload.Lines,
+		Language:    langTag,
+		Format:      payload.Format,
+		Path:        payload.Path,
+		Encoding:    payload.Encoding,
+		Diagnostics: payload.Diagnostics,
+		ASS:         payload.ASS,
+		VTT:         payload.VTT,
 	}
 	return ret, true, nil
 }
 
+// GetSubtitleCacheForJob returns the subtitle most recently cached by a job
+func (s *SQLiteStore) GetSubtitleCacheForJob(ctx context.Context, jobID string) (subtitle.File, bool, error) {
+	row := s.db.QueryRowContext(
+		ctx,
+		`SELECT payload_json
+		 FROM subtitle_cache
+		 WHERE job_id = ?
+		 ORDER BY updated_at DESC
+		 LIMIT 1`,
+		jobID,
+	)
+	return scanSubtitleCache(row)
+}
+
 func (s *SQLiteStore) PutMediaMetaCache(ctx context.Context, meta MediaMetaCache) error {
 	externalJSON, err := json.Marshal(meta.ExternalLanguages)
 	if err != nil {
@@ -431,6 +517,9 @@ func (s *SQLiteStore) ClearJobTemp(ctx context.Context, jobID string) error {
 	if _, err = tx.ExecContext(ctx, `DELETE FROM job_batch_checkpoints WHERE job_id = ?`, jobID); err != nil {
 		return err
 	}
+	if _, err = tx.ExecContext(ctx, `DELETE FROM job_pivot_checkpoints WHERE job_id = ?`, jobID); err != nil {
+		return err
+	}
 	if _, err = tx.ExecContext(ctx, `DELETE FROM subtitle_cache WHERE job_id = ? AND is_temp = 1`, jobID); err != nil {
 		return err
 	}
@@ -446,7 +535,8 @@ func (s *SQLiteStore) DeleteExpiredMediaMetaCache(ctx context.Context, now time.
 	return res.RowsAffected()
 }
 
-// DeleteJobData removes all data associated with a job (checkpoints + temp subtitle cache).
+// DeleteJobData removes all data associated with a job (checkpoints of both
+// passes, temp subtitle cache, translation reviews and term usage).
 func (s *SQLiteStore) DeleteJobData(ctx context.Context, jobID string) error {
 	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
@@ -461,15 +551,737 @@ func (s *SQLiteStore) DeleteJobData(ctx context.Context, jobID string) error {
 	if _, err = tx.ExecContext(ctx, `DELETE FROM job_batch_checkpoints WHERE job_id = ?`, jobID); err != nil {
 		return err
 	}
+	if _, err = tx.ExecContext(ctx, `DELETE FROM job_pivot_checkpoints WHERE job_id = ?`, jobID); err != nil {
+		return err
+	}
 	if _, err = tx.ExecContext(ctx, `DELETE FROM subtitle_cache WHERE job_id = ?`, jobID); err != nil {
 		return err
 	}
+	if _, err = tx.ExecContext(ctx, `DELETE FROM translation_reviews WHERE job_id = ?`, jobID); err != nil {
+		return err
+	}
+	if _, err = tx.ExecContext(ctx, `DELETE FROM job_term_usage WHERE job_id = ?`, jobID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// PutEpisodeSummary stores the summary of an episode, replacing an earlier
+// one of the same media file.
+func (s *SQLiteStore) PutEpisodeSummary(ctx context.Context, summary EpisodeSummary) error {
+	updatedAt := summary.UpdatedAt.UTC()
+	if updatedAt.IsZero() {
+		updatedAt = time.Now().UTC()
+	}
+	_, err := s.db.ExecContext(
+		ctx,
+		`INSERT INTO episode_summaries (
+			series_key, media_path, episode_key, episode, synopsis, relationships, updated_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(series_key, media_path) DO UPDATE SET
+			episode_key=excluded.episode_key,
+			episode=excluded.episode,
+			synopsis=excluded.synopsis,
+			relationships=excluded.relationships,
+			updated_at=excluded.updated_at`,
+		summary.SeriesKey,
+		summary.MediaPath,
+		episodeSortKey(summary.MediaPath),
+		summary.Episode,
+		summary.Synopsis,
+		summary.Relationships,
+		updatedAt,
+	)
+	return err
+}
+
+// ListEpisodeSummariesBefore returns up to limit summaries of the series whose
+// episodes come before mediaPath, oldest first. Episodes are ordered by
+// episodeSortKey, so these are the episodes right before mediaPath.
+func (s *SQLiteStore) ListEpisodeSummariesBefore(ctx context.Context, seriesKey string, mediaPath string, limit int) ([]EpisodeSummary, error) {
+	if err := s.keyEpisodeSummaries(ctx, seriesKey); err != nil {
+		return nil, err
+	}
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT series_key, media_path, episode, synopsis, relationships, updated_at
+		 FROM episode_summaries
+		 WHERE series_key = ? AND episode_key < ?
+		 ORDER BY episode_key DESC
+		 LIMIT ?`,
+		seriesKey,
+		episodeSortKey(mediaPath),
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ret []EpisodeSummary
+	for rows.Next() {
+		var item EpisodeSummary
+		if err := rows.Scan(
+			&item.SeriesKey,
+			&item.MediaPath,
+			&item.Episode,
+			&item.Synopsis,
+			&item.Relationships,
+			&item.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		ret = append(ret, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	slices.Reverse(ret)
+	return ret, nil
+}
+
+// keyEpisodeSummaries sets the episode key of the summaries of a series
+// stored before episode_key was added
+func (s *SQLiteStore) keyEpisodeSummaries(ctx context.Context, seriesKey string) error {
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT media_path FROM episode_summaries WHERE series_key = ? AND episode_key = ''`,
+		seriesKey,
+	)
+	if err != nil {
+		return err
+	}
+	var paths []string
+	for rows.Next() {
+		var path string
+		if err := rows.Scan(&path); err != nil {
+			rows.Close()
+			return err
+		}
+		paths = append(paths, path)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for _, path := range paths {
+		if _, err := s.db.ExecContext(
+			ctx,
+			`UPDATE episode_summaries SET episode_key = ? WHERE series_key = ? AND media_path = ?`,
+			episodeSortKey(path),
+			seriesKey,
+			path,
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var (
+	// episodeSeasonPattern matches the season of names like Show.S02E10
+	episodeSeasonPattern = regexp.MustCompile(`(?i)(?:^|[^a-z0-9])s(\d{1,4})[ ._-]?e\d{1,4}`)
+	// seasonDirNumberPattern matches season directories like "Season 10" or "S10"
+	seasonDirNumberPattern = regexp.MustCompile(`(?i)^(?:season[ ._-]*|s)(\d{1,4})$`)
+	digitsPattern          = regexp.MustCompile(`\d+`)
+)
+
+// episodeSortKey orders the episodes of a series: by the season of the file
+// name or of its directory, "Specials" first, then by the file name with
+// its numbers zero-padded so they compare by value.
+func episodeSortKey(mediaPath string) string {
+	name := strings.TrimSuffix(filepath.Base(mediaPath), filepath.Ext(mediaPath))
+	dir := filepath.Base(filepath.Dir(mediaPath))
+
+	season := 1
+	if m := episodeSeasonPattern.FindStringSubmatch(name); m != nil {
+		season, _ = strconv.Atoi(m[1])
+	} else if m := seasonDirNumberPattern.FindStringSubmatch(dir); m != nil {
+		season, _ = strconv.Atoi(m[1])
+	} else if strings.EqualFold(dir, "specials") {
+		season = 0
+	}
+
+	natural := digitsPattern.ReplaceAllStringFunc(strings.ToLower(name), func(digits string) string {
+		digits = strings.TrimLeft(digits, "0")
+		if len(digits) >= 10 {
+			return digits
+		}
+		return strings.Repeat("0", 10-len(digits)) + digits
+	})
+	return fmt.Sprintf("%04d/%s", season, natural)
+}
+
+// ListCharacterProfiles returns the character profiles of a series by name.
+func (s *SQLiteStore) ListCharacterProfiles(ctx context.Context, seriesKey string) ([]CharacterProfile, error) {
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT series_key, name, gender, formality, nicknames_json, updated_at
+		 FROM character_profiles
+		 WHERE series_key = ?
+		 ORDER BY name ASC`,
+		seriesKey,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ret := make([]CharacterProfile, 0)
+	for rows.Next() {
+		var item CharacterProfile
+		var nicknamesJSON string
+		if err := rows.Scan(&item.SeriesKey, &item.Name, &item.Gender, &item.Formality, &nicknamesJSON, &item.UpdatedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(nicknamesJSON), &item.Nicknames); err != nil {
+			return nil, err
+		}
+		ret = append(ret, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// AddCharacterProfiles stores profiles of characters the series has no
+// profile for yet; existing profiles are kept as they are.
+func (s *SQLiteStore) AddCharacterProfiles(ctx context.Context, profiles []CharacterProfile) error {
+	return s.putCharacterProfiles(ctx, profiles, `ON CONFLICT(series_key, name) DO NOTHING`)
+}
+
+// PutCharacterProfile creates or replaces the profile of a character.
+func (s *SQLiteStore) PutCharacterProfile(ctx context.Context, profile CharacterProfile) error {
+	return s.putCharacterProfiles(ctx, []CharacterProfile{profile}, `ON CONFLICT(series_key, name) DO UPDATE SET
+			name=excluded.name,
+			gender=excluded.gender,
+			formality=excluded.formality,
+			nicknames_json=excluded.nicknames_json,
+			updated_at=excluded.updated_at`)
+}
+
+func (s *SQLiteStore) putCharacterProfiles(ctx context.Context, profiles []CharacterProfile, onConflict string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	for _, profile := range profiles {
+		nicknames := profile.Nicknames
+		if nicknames == nil {
+			nicknames = []string{}
+		}
+		var nicknamesJSON []byte
+		nicknamesJSON, err = json.Marshal(nicknames)
+		if err != nil {
+			return err
+		}
+		updatedAt := profile.UpdatedAt.UTC()
+		if updatedAt.IsZero() {
+			updatedAt = time.Now().UTC()
+		}
+		if _, err = tx.ExecContext(
+			ctx,
+			`INSERT INTO character_profiles (
+				series_key, name, gender, formality, nicknames_json, updated_at
+			) VALUES (?, ?, ?, ?, ?, ?)
+			`+onConflict,
+			profile.SeriesKey,
+			profile.Name,
+			profile.Gender,
+			profile.Formality,
+			string(nicknamesJSON),
+			updatedAt,
+		); err != nil {
+			return err
+		}
+	}
 	return tx.Commit()
 }
 
+// DeleteCharacterProfile removes the profile of a character and reports
+// whether it existed.
+func (s *SQLiteStore) DeleteCharacterProfile(ctx context.Context, seriesKey string, name string) (bool, error) {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM character_profiles WHERE series_key = ? AND name = ?`, seriesKey, name)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
 func boolToInt(v bool) int {
 	if v {
 		return 1
 	}
 	return 0
 }
+
+// usageGroupColumns maps a grouping to its llm_usage column
+var usageGroupColumns = map[UsageGrouping]string{
+	UsageByDay:      "day",
+	UsageBySeries:   "series_key",
+	UsageByModel:    "model",
+	UsageByProvider: "provider",
+}
+
+// AddLLMUsage records the usage of one LLM call.
+func (s *SQLiteStore) AddLLMUsage(ctx context.Context, usage LLMUsage) error {
+	createdAt := usage.CreatedAt.UTC()
+	if createdAt.IsZero() {
+		createdAt = time.Now().UTC()
+	}
+	_, err := s.db.ExecContext(
+		ctx,
+		`INSERT INTO llm_usage (
+			job_id, series_key, stage, batch_start, batch_end, provider, model,
+			prompt_tokens, completion_tokens, cost, day, created_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		usage.JobID,
+		usage.SeriesKey,
+		usage.Stage,
+		usage.BatchStart,
+		usage.BatchEnd,
+		usage.Provider,
+		usage.Model,
+		usage.PromptTokens,
+		usage.CompletionTokens,
+		usage.Cost,
+		createdAt.Format(time.DateOnly),
+		createdAt,
+	)
+	return err
+}
+
+// JobUsage sums the usage of the LLM calls made for a job.
+func (s *SQLiteStore) JobUsage(ctx context.Context, jobID string) (UsageTotals, error) {
+	return s.usageTotals(ctx, `WHERE job_id = ?`, jobID)
+}
+
+// SeriesUsage sums the usage of the LLM calls made for a series.
+func (s *SQLiteStore) SeriesUsage(ctx context.Context, seriesKey string) (UsageTotals, error) {
+	return s.usageTotals(ctx, `WHERE series_key = ?`, seriesKey)
+}
+
+// UsageSince sums the usage of the LLM calls made on or after the day of since.
+func (s *SQLiteStore) UsageSince(ctx context.Context, since time.Time) (UsageTotals, error) {
+	return s.usageTotals(ctx, `WHERE day >= ?`, since.UTC().Format(time.DateOnly))
+}
+
+func (s *SQLiteStore) usageTotals(ctx context.Context, where string, args ...any) (UsageTotals, error) {
+	var ret UsageTotals
+	err := s.db.QueryRowContext(
+		ctx,
+		`SELECT COUNT(*), COALESCE(SUM(prompt_tokens), 0), COALESCE(SUM(completion_tokens), 0), COALESCE(SUM(cost), 0)
+		 FROM llm_usage `+where,
+		args...,
+	).Scan(&ret.Calls, &ret.PromptTokens, &ret.CompletionTokens, &ret.Cost)
+	return ret, err
+}
+
+// UsageBreakdown groups the usage of the LLM calls made on or after the day
+// of since. Days are listed in order, other groups by descending cost.
+func (s *SQLiteStore) UsageBreakdown(ctx context.Context, by UsageGrouping, since time.Time) ([]UsageGroup, error) {
+	column, ok := usageGroupColumns[by]
+	if !ok {
+		return nil, fmt.Errorf("unsupported usage grouping %q", by)
+	}
+	order := `cost DESC, key ASC`
+	if by == UsageByDay {
+		order = `key ASC`
+	}
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT `+column+` AS key, COUNT(*), SUM(prompt_tokens), SUM(completion_tokens), SUM(cost) AS cost
+		 FROM llm_usage
+		 WHERE day >= ?
+		 GROUP BY key
+		 ORDER BY `+order,
+		since.UTC().Format(time.DateOnly),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ret := make([]UsageGroup, 0)
+	for rows.Next() {
+		var item UsageGroup
+		if err := rows.Scan(&item.Key, &item.Calls, &item.PromptTokens, &item.CompletionTokens, &item.Cost); err != nil {
+			return nil, err
+		}
+		ret = append(ret, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// NormalizeMemorySource returns the translation memory key of a source line:
+// the text in lower case with the whitespace of each line collapsed and blank
+// lines dropped.
+func NormalizeMemorySource(text string) string {
+	var lines []string
+	for _, line := range strings.Split(strings.ToLower(text), "\n") {
+		if fields := strings.Fields(line); len(fields) > 0 {
+			lines = append(lines, strings.Join(fields, " "))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// PutTranslationMemory stores approved line translations, replacing the
+// earlier translation of the same source. Translations of the model never
+// replace manual ones; entries without text are skipped.
+func (s *SQLiteStore) PutTranslationMemory(ctx context.Context, entries []TranslationMemoryEntry) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	for _, entry := range entries {
+		sourceKey := NormalizeMemorySource(entry.SourceText)
+		if sourceKey == "" || strings.TrimSpace(entry.Translation) == "" {
+			continue
+		}
+		updatedAt := entry.UpdatedAt.UTC()
+		if updatedAt.IsZero() {
+			updatedAt = time.Now().UTC()
+		}
+		if _, err = tx.ExecContext(
+			ctx,
+			`INSERT INTO translation_memory (
+				series_key, source_lang, target_lang, source_key, source_text, translation, manual, updated_at
+			) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT(series_key, source_lang, target_lang, source_key) DO UPDATE SET
+				source_text=excluded.source_text,
+				translation=excluded.translation,
+				manual=excluded.manual,
+				updated_at=excluded.updated_at
+			WHERE excluded.manual = 1 OR translation_memory.manual = 0`,
+			entry.SeriesKey,
+			entry.SourceLang,
+			entry.TargetLang,
+			sourceKey,
+			entry.SourceText,
+			entry.Translation,
+			boolToInt(entry.Manual),
+			updatedAt,
+		); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// ListTranslationMemory returns the translation memory of a series for a
+// language pair, most recently approved first.
+func (s *SQLiteStore) ListTranslationMemory(ctx context.Context, seriesKey string, sourceLang string, targetLang string) ([]TranslationMemoryEntry, error) {
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT series_key, source_lang, target_lang, source_key, source_text, translation, manual, updated_at
+		 FROM translation_memory
+		 WHERE series_key = ? AND source_lang = ? AND target_lang = ?
+		 ORDER BY updated_at DESC, source_key ASC`,
+		seriesKey,
+		sourceLang,
+		targetLang,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ret := make([]TranslationMemoryEntry, 0)
+	for rows.Next() {
+		var item TranslationMemoryEntry
+		var manual int
+		if err := rows.Scan(&item.SeriesKey, &item.SourceLang, &item.TargetLang, &item.SourceKey, &item.SourceText, &item.Translation, &manual, &item.UpdatedAt); err != nil {
+			return nil, err
+		}
+		item.Manual = manual != 0
+		ret = append(ret, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// GetSongMode returns the song mode set for a series.
+func (s *SQLiteStore) GetSongMode(ctx context.Context, seriesKey string) (string, bool, error) {
+	var mode string
+	err := s.db.QueryRowContext(ctx, `SELECT mode FROM series_song_modes WHERE series_key = ?`, seriesKey).Scan(&mode)
+	if err == sql.ErrNoRows {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return mode, true, nil
+}
+
+// PutSongMode sets the song mode of a series.
+func (s *SQLiteStore) PutSongMode(ctx context.Context, seriesKey string, mode string) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		`INSERT INTO series_song_modes (series_key, mode, updated_at) VALUES (?, ?, ?)
+		ON CONFLICT(series_key) DO UPDATE SET
+			mode=excluded.mode,
+			updated_at=excluded.updated_at`,
+		seriesKey,
+		mode,
+		time.Now().UTC(),
+	)
+	return err
+}
+
+// DeleteSongMode removes the song mode of a series, so it falls back to the
+// default, and reports whether one was set.
+func (s *SQLiteStore) DeleteSongMode(ctx context.Context, seriesKey string) (bool, error) {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM series_song_modes WHERE series_key = ?`, seriesKey)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// AddSongLines remembers lines of a series as song lyrics.
+func (s *SQLiteStore) AddSongLines(ctx context.Context, seriesKey string, texts []string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	now := time.Now().UTC()
+	for _, text := range texts {
+		sourceKey := NormalizeMemorySource(text)
+		if sourceKey == "" {
+			continue
+		}
+		if _, err = tx.ExecContext(
+			ctx,
+			`INSERT INTO song_lines (series_key, source_key, source_text, updated_at) VALUES (?, ?, ?, ?)
+			ON CONFLICT(series_key, source_key) DO UPDATE SET
+				source_text=excluded.source_text,
+				updated_at=excluded.updated_at`,
+			seriesKey,
+			sourceKey,
+			text,
+			now,
+		); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// ListSongLines returns the normalised lyrics remembered for a series.
+func (s *SQLiteStore) ListSongLines(ctx context.Context, seriesKey string) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT source_key FROM song_lines WHERE series_key = ? ORDER BY source_key ASC`, seriesKey)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ret := make([]string, 0)
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		ret = append(ret, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// PutTranslationReview stores the review of a batch, replacing an earlier
+// review of the batch.
+func (s *SQLiteStore) PutTranslationReview(ctx context.Context, review TranslationReview) error {
+	issues := review.Issues
+	if issues == nil {
+		issues = []ReviewIssue{}
+	}
+	payload, err := json.Marshal(issues)
+	if err != nil {
+		return err
+	}
+	updatedAt := review.UpdatedAt.UTC()
+	if review.UpdatedAt.IsZero() {
+		updatedAt = time.Now().UTC()
+	}
+	_, err = s.db.ExecContext(
+		ctx,
+		`INSERT INTO translation_reviews (job_id, batch_start, batch_end, score, issues_json, updated_at)
+		 VALUES (?, ?, ?, ?, ?, ?)
+		 ON CONFLICT(job_id, batch_start) DO UPDATE SET
+			batch_end=excluded.batch_end,
+			score=excluded.score,
+			issues_json=excluded.issues_json,
+			updated_at=excluded.updated_at`,
+		review.JobID,
+		review.BatchStart,
+		review.BatchEnd,
+		review.Score,
+		string(payload),
+		updatedAt,
+	)
+	return err
+}
+
+// ListTranslationReviews returns the reviews of the batches of a job in line order.
+func (s *SQLiteStore) ListTranslationReviews(ctx context.Context, jobID string) ([]TranslationReview, error) {
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT job_id, batch_start, batch_end, score, issues_json, updated_at
+		 FROM translation_reviews
+		 WHERE job_id = ?
+		 ORDER BY batch_start ASC`,
+		jobID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ret := make([]TranslationReview, 0)
+	for rows.Next() {
+		var item TranslationReview
+		var issuesJSON string
+		if err := rows.Scan(&item.JobID, &item.BatchStart, &item.BatchEnd, &item.Score, &issuesJSON, &item.UpdatedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(issuesJSON), &item.Issues); err != nil {
+			return nil, err
+		}
+		ret = append(ret, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// ListLockedTerms returns the locked terms of a term map file.
+func (s *SQLiteStore) ListLockedTerms(ctx context.Context, termMapPath string) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT term FROM term_locks WHERE term_map_path = ? ORDER BY term ASC`, termMapPath)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ret := make([]string, 0)
+	for rows.Next() {
+		var term string
+		if err := rows.Scan(&term); err != nil {
+			return nil, err
+		}
+		ret = append(ret, term)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// SetTermLocked locks or unlocks a term of a term map file.
+func (s *SQLiteStore) SetTermLocked(ctx context.Context, termMapPath string, term string, locked bool) error {
+	if !locked {
+		_, err := s.db.ExecContext(ctx, `DELETE FROM term_locks WHERE term_map_path = ? AND term = ?`, termMapPath, term)
+		return err
+	}
+	_, err := s.db.ExecContext(
+		ctx,
+		`INSERT INTO term_locks (term_map_path, term, updated_at) VALUES (?, ?, ?)
+		ON CONFLICT(term_map_path, term) DO UPDATE SET
+			updated_at=excluded.updated_at`,
+		termMapPath,
+		term,
+		time.Now().UTC(),
+	)
+	return err
+}
+
+// PutTermUsage records the terms of a term map file a job used, replacing
+// what an earlier run of the job recorded for the file.
+func (s *SQLiteStore) PutTermUsage(ctx context.Context, jobID string, termMapPath string, terms []string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if _, err = tx.ExecContext(ctx, `DELETE FROM job_term_usage WHERE job_id = ? AND term_map_path = ?`, jobID, termMapPath); err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	for _, term := range terms {
+		if _, err = tx.ExecContext(
+			ctx,
+			`INSERT INTO job_term_usage (job_id, term_map_path, term, used_at) VALUES (?, ?, ?, ?)
+			ON CONFLICT(job_id, term_map_path, term) DO NOTHING`,
+			jobID,
+			termMapPath,
+			term,
+			now,
+		); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// ListTermUsage returns the jobs that used the terms of a term map file,
+// latest first.
+func (s *SQLiteStore) ListTermUsage(ctx context.Context, termMapPath string) ([]TermUsage, error) {
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT job_id, term_map_path, term, used_at
+		 FROM job_term_usage
+		 WHERE term_map_path = ?
+		 ORDER BY used_at DESC, job_id ASC, term ASC`,
+		termMapPath,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ret := make([]TermUsage, 0)
+	for rows.Next() {
+		var item TermUsage
+		if err := rows.Scan(&item.JobID, &item.TermMapPath, &item.Term, &item.UsedAt); err != nil {
+			return nil, err
+		}
+		ret = append(ret, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
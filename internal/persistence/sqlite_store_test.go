@@ -42,6 +42,43 @@ func TestSQLiteStore_JobsRoundTrip(t *testing.T) {
 	assert.Equal(t, job.ID, all[0].ID)
 	assert.Equal(t, job.Status, all[0].Status)
 	assert.Equal(t, job.Payload.MediaFile, all[0].Payload.MediaFile)
+	assert.Nil(t, all[0].Payload.StreamIndex)
+	assert.Nil(t, all[0].Payload.StreamPolicy)
+}
+
+func TestSQLiteStore_JobsRoundTripSubtitleStream(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	store, err := NewSQLiteStore(filepath.Join(dir, "ctxtrans.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = store.Close() })
+
+	ctx := context.Background()
+	streamIndex := 2
+	job := &jobs.TranslationJob{
+		ID:        "job-1",
+		Source:    "manual",
+		DedupeKey: "m|[embedded:s:2]",
+		Payload: jobs.JobPayload{
+			MediaFile:    "/media/a.mkv",
+			OutputMode:   "bilingual",
+			StreamIndex:  &streamIndex,
+			StreamPolicy: &subtitle.StreamPolicy{Language: "en", ExcludeForced: true, Codecs: []string{"ass"}},
+		},
+		Status:    jobs.StatusPending,
+		CreatedAt: time.Now().UTC().Truncate(time.Millisecond),
+		UpdatedAt: time.Now().UTC().Truncate(time.Millisecond),
+	}
+	require.NoError(t, store.UpsertJob(ctx, job))
+
+	all, err := store.LoadJobs(ctx)
+	require.NoError(t, err)
+	require.Len(t, all, 1)
+	assert.Equal(t, "bilingual", all[0].Payload.OutputMode)
+	require.NotNil(t, all[0].Payload.StreamIndex)
+	assert.Equal(t, 2, *all[0].Payload.StreamIndex)
+	assert.Equal(t, job.Payload.StreamPolicy, all[0].Payload.StreamPolicy)
 }
 
 func TestSQLiteStore_CheckpointAndCleanup(t *testing.T) {
@@ -69,6 +106,40 @@ func TestSQLiteStore_CheckpointAndCleanup(t *testing.T) {
 	assert.Empty(t, cps)
 }
 
+func TestSQLiteStore_PivotCheckpointsKeptApart(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	store, err := NewSQLiteStore(filepath.Join(dir, "ctxtrans.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = store.Close() })
+
+	ctx := context.Background()
+	jobID := "job-1"
+	require.NoError(t, store.SavePivotCheckpoint(ctx, jobID, 0, 2, []string{"hello", "bye"}))
+	require.NoError(t, store.SaveBatchCheckpoint(ctx, jobID, 0, 2, []string{"olá", "tchau"}))
+
+	cps, err := store.LoadPivotCheckpoints(ctx, jobID)
+	require.NoError(t, err)
+	require.Len(t, cps, 1)
+	assert.Equal(t, []string{"hello", "bye"}, cps[0].TranslatedLines)
+	cps, err = store.LoadBatchCheckpoints(ctx, jobID)
+	require.NoError(t, err)
+	require.Len(t, cps, 1)
+	assert.Equal(t, []string{"olá", "tchau"}, cps[0].TranslatedLines)
+
+	require.NoError(t, store.ClearJobTemp(ctx, jobID))
+	cps, err = store.LoadPivotCheckpoints(ctx, jobID)
+	require.NoError(t, err)
+	assert.Empty(t, cps)
+
+	require.NoError(t, store.SavePivotCheckpoint(ctx, jobID, 0, 2, []string{"hello", "bye"}))
+	require.NoError(t, store.DeleteJobData(ctx, jobID))
+	cps, err = store.LoadPivotCheckpoints(ctx, jobID)
+	require.NoError(t, err)
+	assert.Empty(t, cps)
+}
+
 func TestSQLiteStore_SubtitleCacheRoundTrip(t *testing.T) {
 	t.Parallel()
 
@@ -89,6 +160,9 @@ func TestSQLiteStore_SubtitleCacheRoundTrip(t *testing.T) {
 			Lines: []subtitle.Line{
 				{Index: 1, Text: "hello"},
 			},
+			Diagnostics: []subtitle.Diagnostic{
+				{Line: 1, Problem: "missing cue number", Action: "numbered 1"},
+			},
 		},
 		IsTemp: true,
 	}
@@ -100,6 +174,12 @@ func TestSQLiteStore_SubtitleCacheRoundTrip(t *testing.T) {
 	assert.Equal(t, entry.File.Format, cached.Format)
 	require.Len(t, cached.Lines, 1)
 	assert.Equal(t, "hello", cached.Lines[0].Text)
+	assert.Equal(t, entry.File.Diagnostics, cached.Diagnostics)
+
+	byJob, ok, err := store.GetSubtitleCacheForJob(ctx, "job-1")
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, cached.Lines, byJob.Lines)
 
 	require.NoError(t, store.ClearJobTemp(ctx, "job-1"))
 	_, ok, err = store.GetSubtitleCache(ctx, entry.CacheKey)
@@ -107,6 +187,45 @@ func TestSQLiteStore_SubtitleCacheRoundTrip(t *testing.T) {
 	assert.False(t, ok)
 }
 
+func TestSQLiteStore_SubtitleCacheKeepsASSAndVTTDocuments(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	store, err := NewSQLiteStore(filepath.Join(dir, "ctxtrans.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = store.Close() })
+
+	ctx := context.Background()
+	ass, err := subtitle.ReadASSBytes([]byte("[Script Info]\nScriptType: v4.00+\n\n"+
+		"[V4+ Styles]\nFormat: Name, Fontname, Fontsize\nStyle: Signs,Arial,18\n\n"+
+		"[Events]\nFormat: Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text\n"+
+		"Dialogue: 0,0:00:01.00,0:00:02.00,Default,Momo,0,0,0,,Hello\n"+
+		"Dialogue: 1,0:00:03.00,0:00:04.00,Signs,,0,0,0,,{\\an8\\pos(320,50)}Exit\n"), "embedded.ass")
+	require.NoError(t, err)
+	require.NoError(t, store.PutSubtitleCache(ctx, SubtitleCacheEntry{CacheKey: "media|s:0", MediaPath: "/media/a.mkv", JobID: "job-1", File: *ass}))
+
+	cached, ok, err := store.GetSubtitleCache(ctx, "media|s:0")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.NotNil(t, cached.ASS)
+	assert.Equal(t, ass.ASS, cached.ASS)
+	assert.Contains(t, cached.ASS.Header, "Style: Signs,Arial,18")
+	assert.Equal(t, "Momo", cached.ASS.Events[0].Fields[4])
+	assert.Equal(t, `{\an8\pos(320,50)}`, cached.ASS.Events[1].Override)
+
+	vtt, err := subtitle.ReadVTTBytes([]byte("WEBVTT\n\nSTYLE\n::cue { color: yellow }\n\n"+
+		"intro\n00:00:01.000 --> 00:00:02.000 align:start\n<v Momo>Hello\n"), "embedded.vtt")
+	require.NoError(t, err)
+	require.NoError(t, store.PutSubtitleCache(ctx, SubtitleCacheEntry{CacheKey: "media|s:1", MediaPath: "/media/a.mkv", JobID: "job-1", File: *vtt}))
+
+	cached, ok, err = store.GetSubtitleCache(ctx, "media|s:1")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.NotNil(t, cached.VTT)
+	assert.Equal(t, vtt.VTT, cached.VTT)
+	assert.Nil(t, cached.ASS)
+}
+
 func TestSQLiteStore_MediaMetaCacheTTL(t *testing.T) {
 	t.Parallel()
 
@@ -137,3 +256,377 @@ func TestSQLiteStore_MediaMetaCacheTTL(t *testing.T) {
 	require.NoError(t, err)
 	assert.False(t, ok)
 }
+
+func TestSQLiteStore_EpisodeSummaries(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	store, err := NewSQLiteStore(filepath.Join(dir, "ctxtrans.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = store.Close() })
+
+	ctx := context.Background()
+	for _, episode := range []string{"S01E01", "S01E02", "S01E03", "S01E04"} {
+		require.NoError(t, store.PutEpisodeSummary(ctx, EpisodeSummary{
+			SeriesKey: "/tv/show",
+			MediaPath: "/tv/show/Season 1/show." + episode + ".mkv",
+			Episode:   episode,
+			Synopsis:  "synopsis of " + episode,
+		}))
+	}
+	require.NoError(t, store.PutEpisodeSummary(ctx, EpisodeSummary{
+		SeriesKey:     "/tv/show",
+		MediaPath:     "/tv/show/Season 1/show.S01E02.mkv",
+		Episode:       "S01E02",
+		Synopsis:      "updated synopsis",
+		Relationships: "A trusts B",
+	}))
+	require.NoError(t, store.PutEpisodeSummary(ctx, EpisodeSummary{
+		SeriesKey: "/tv/other",
+		MediaPath: "/tv/other/other.S01E01.mkv",
+		Synopsis:  "other show",
+	}))
+
+	summaries, err := store.ListEpisodeSummariesBefore(ctx, "/tv/show", "/tv/show/Season 1/show.S01E04.mkv", 2)
+	require.NoError(t, err)
+	require.Len(t, summaries, 2)
+	assert.Equal(t, "S01E02", summaries[0].Episode)
+	assert.Equal(t, "updated synopsis", summaries[0].Synopsis)
+	assert.Equal(t, "A trusts B", summaries[0].Relationships)
+	assert.Equal(t, "S01E03", summaries[1].Episode)
+
+	summaries, err = store.ListEpisodeSummariesBefore(ctx, "/tv/show", "/tv/show/Season 1/show.S01E01.mkv", 2)
+	require.NoError(t, err)
+	assert.Empty(t, summaries)
+}
+
+func TestSQLiteStore_EpisodeSummaries_OrdersNumbersByValue(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	store, err := NewSQLiteStore(filepath.Join(dir, "ctxtrans.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = store.Close() })
+
+	ctx := context.Background()
+	for _, path := range []string{
+		"/tv/show/Season 10/Show - 1.mkv",
+		"/tv/show/Season 2/Show - 10.mkv",
+		"/tv/show/Season 2/Show - 9.mkv",
+		"/tv/show/Season 2/Show - 1.mkv",
+	} {
+		require.NoError(t, store.PutEpisodeSummary(ctx, EpisodeSummary{SeriesKey: "/tv/show", MediaPath: path, Episode: path, Synopsis: "synopsis"}))
+	}
+	// a summary stored before episodes were keyed
+	_, err = store.db.ExecContext(ctx,
+		`INSERT INTO episode_summaries (series_key, media_path, episode, synopsis, updated_at) VALUES (?, ?, ?, ?, ?)`,
+		"/tv/show", "/tv/show/Season 1/Show - 12.mkv", "/tv/show/Season 1/Show - 12.mkv", "synopsis", time.Now().UTC())
+	require.NoError(t, err)
+
+	episodes := func(mediaPath string, limit int) []string {
+		summaries, err := store.ListEpisodeSummariesBefore(ctx, "/tv/show", mediaPath, limit)
+		require.NoError(t, err)
+		ret := make([]string, 0, len(summaries))
+		for _, item := range summaries {
+			ret = append(ret, item.Episode)
+		}
+		return ret
+	}
+
+	assert.Equal(t, []string{
+		"/tv/show/Season 1/Show - 12.mkv",
+		"/tv/show/Season 2/Show - 1.mkv",
+		"/tv/show/Season 2/Show - 9.mkv",
+	}, episodes("/tv/show/Season 2/Show - 10.mkv", 5))
+	assert.Equal(t, []string{
+		"/tv/show/Season 2/Show - 9.mkv",
+		"/tv/show/Season 2/Show - 10.mkv",
+	}, episodes("/tv/show/Season 10/Show - 1.mkv", 2))
+	assert.Equal(t, []string{"/tv/show/Season 1/Show - 12.mkv"}, episodes("/tv/show/Season 2/Show - 1.mkv", 5))
+}
+
+func TestEpisodeSortKey(t *testing.T) {
+	assert.Less(t, episodeSortKey("/tv/show/Show.S02E10.mkv"), episodeSortKey("/tv/show/Show.S10E01.mkv"))
+	assert.Less(t, episodeSortKey("/tv/show/Show - 9.mkv"), episodeSortKey("/tv/show/Show - 10.mkv"))
+	assert.Less(t, episodeSortKey("/tv/show/Specials/Show - 5.mkv"), episodeSortKey("/tv/show/Season 1/Show - 1.mkv"))
+	assert.Less(t, episodeSortKey("/tv/show/S2/Show - 20.mkv"), episodeSortKey("/tv/show/S11/Show - 3.mkv"))
+	assert.Equal(t, episodeSortKey("/tv/show/Season 1/Show - 01.mkv"), episodeSortKey("/tv/show/Season 1/Show - 1.mkv"))
+}
+
+func TestSQLiteStore_CharacterProfiles(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	store, err := NewSQLiteStore(filepath.Join(dir, "ctxtrans.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = store.Close() })
+
+	ctx := context.Background()
+	require.NoError(t, store.PutCharacterProfile(ctx, CharacterProfile{
+		SeriesKey: "/tv/show",
+		Name:      "Momo",
+		Gender:    "female",
+		Formality: "casual",
+		Nicknames: []string{"Momo-chan"},
+	}))
+	require.NoError(t, store.AddCharacterProfiles(ctx, []CharacterProfile{
+		{SeriesKey: "/tv/show", Name: "momo", Gender: "male"},
+		{SeriesKey: "/tv/show", Name: "Okarun", Gender: "male", Formality: "polite"},
+		{SeriesKey: "/tv/other", Name: "Momo"},
+	}))
+
+	profiles, err := store.ListCharacterProfiles(ctx, "/tv/show")
+	require.NoError(t, err)
+	require.Len(t, profiles, 2)
+	assert.Equal(t, "Momo", profiles[0].Name)
+	assert.Equal(t, "female", profiles[0].Gender, "added profiles never replace existing ones")
+	assert.Equal(t, []string{"Momo-chan"}, profiles[0].Nicknames)
+	assert.Equal(t, "Okarun", profiles[1].Name)
+	assert.Empty(t, profiles[1].Nicknames)
+
+	require.NoError(t, store.PutCharacterProfile(ctx, CharacterProfile{SeriesKey: "/tv/show", Name: "OKARUN", Gender: "male", Formality: "shy"}))
+	profiles, err = store.ListCharacterProfiles(ctx, "/tv/show")
+	require.NoError(t, err)
+	require.Len(t, profiles, 2)
+	assert.Equal(t, "OKARUN", profiles[1].Name)
+	assert.Equal(t, "shy", profiles[1].Formality)
+
+	deleted, err := store.DeleteCharacterProfile(ctx, "/tv/show", "momo")
+	require.NoError(t, err)
+	assert.True(t, deleted)
+	deleted, err = store.DeleteCharacterProfile(ctx, "/tv/show", "momo")
+	require.NoError(t, err)
+	assert.False(t, deleted)
+}
+
+func TestSQLiteStore_LLMUsage(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	store, err := NewSQLiteStore(filepath.Join(dir, "ctxtrans.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = store.Close() })
+
+	ctx := context.Background()
+	day1 := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	day2 := day1.AddDate(0, 0, 1)
+	for _, usage := range []LLMUsage{
+		{JobID: "job-1", SeriesKey: "/tv/show", Stage: "translate", BatchStart: 0, BatchEnd: 40, Provider: "openai", Model: "gpt-a", PromptTokens: 1000, CompletionTokens: 200, Cost: 0.5, CreatedAt: day1},
+		{JobID: "job-1", SeriesKey: "/tv/show", Stage: "terms", BatchStart: -1, BatchEnd: -1, Provider: "openai", Model: "gpt-b", PromptTokens: 300, CompletionTokens: 50, Cost: 0.1, CreatedAt: day1},
+		{JobID: "job-2", SeriesKey: "/tv/other", Stage: "translate", BatchStart: 0, BatchEnd: 40, Provider: "anthropic", Model: "claude-a", PromptTokens: 2000, CompletionTokens: 400, Cost: 2, CreatedAt: day2},
+	} {
+		require.NoError(t, store.AddLLMUsage(ctx, usage))
+	}
+
+	job, err := store.JobUsage(ctx, "job-1")
+	require.NoError(t, err)
+	assert.Equal(t, 2, job.Calls)
+	assert.Equal(t, 1300, job.PromptTokens)
+	assert.Equal(t, 250, job.CompletionTokens)
+	assert.InDelta(t, 0.6, job.Cost, 1e-9)
+
+	missing, err := store.JobUsage(ctx, "nope")
+	require.NoError(t, err)
+	assert.Equal(t, UsageTotals{}, missing)
+
+	series1, err := store.SeriesUsage(ctx, "/tv/show")
+	require.NoError(t, err)
+	assert.Equal(t, job, series1)
+
+	total, err := store.UsageSince(ctx, day2)
+	require.NoError(t, err)
+	assert.Equal(t, 1, total.Calls)
+
+	daily, err := store.UsageBreakdown(ctx, UsageByDay, day1)
+	require.NoError(t, err)
+	require.Len(t, daily, 2)
+	assert.Equal(t, "2026-03-01", daily[0].Key)
+	assert.Equal(t, 2, daily[0].Calls)
+	assert.Equal(t, "2026-03-02", daily[1].Key)
+
+	series, err := store.UsageBreakdown(ctx, UsageBySeries, day1)
+	require.NoError(t, err)
+	require.Len(t, series, 2)
+	assert.Equal(t, "/tv/other", series[0].Key, "most expensive first")
+	assert.InDelta(t, 0.6, series[1].Cost, 1e-9)
+
+	models, err := store.UsageBreakdown(ctx, UsageByModel, day1)
+	require.NoError(t, err)
+	assert.Len(t, models, 3)
+
+	_, err = store.UsageBreakdown(ctx, UsageGrouping("job"), day1)
+	require.Error(t, err)
+}
+
+func TestSQLiteStore_TranslationMemory(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	store, err := NewSQLiteStore(filepath.Join(dir, "ctxtrans.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = store.Close() })
+
+	ctx := context.Background()
+	day1 := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	require.NoError(t, store.PutTranslationMemory(ctx, []TranslationMemoryEntry{
+		{SeriesKey: "/tv/show", SourceLang: "en", TargetLang: "zh", SourceText: "Previously on  the show...", Translation: "前情提要……", UpdatedAt: day1},
+		{SeriesKey: "/tv/show", SourceLang: "en", TargetLang: "zh", SourceText: "Believe it!", Translation: "我说到做到！", Manual: true, UpdatedAt: day1},
+		{SeriesKey: "/tv/show", SourceLang: "en", TargetLang: "ja", SourceText: "Believe it!", Translation: "だってばよ！", UpdatedAt: day1},
+		{SeriesKey: "/tv/show", SourceLang: "en", TargetLang: "zh", SourceText: "   ", Translation: "空"},
+		{SeriesKey: "/tv/show", SourceLang: "en", TargetLang: "zh", SourceText: "Untranslated", Translation: ""},
+	}))
+
+	// The model never replaces a manual translation; a manual edit replaces anything
+	require.NoError(t, store.PutTranslationMemory(ctx, []TranslationMemoryEntry{
+		{SeriesKey: "/tv/show", SourceLang: "en", TargetLang: "zh", SourceText: "BELIEVE IT!", Translation: "相信我！"},
+		{SeriesKey: "/tv/show", SourceLang: "en", TargetLang: "zh", SourceText: "Previously on the show...", Translation: "上回说到……", Manual: true},
+	}))
+
+	entries, err := store.ListTranslationMemory(ctx, "/tv/show", "en", "zh")
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "previously on the show...", entries[0].SourceKey)
+	assert.Equal(t, "上回说到……", entries[0].Translation)
+	assert.True(t, entries[0].Manual)
+	assert.Equal(t, "believe it!", entries[1].SourceKey)
+	assert.Equal(t, "我说到做到！", entries[1].Translation)
+	assert.Equal(t, "Believe it!", entries[1].SourceText)
+
+	other, err := store.ListTranslationMemory(ctx, "/tv/other", "en", "zh")
+	require.NoError(t, err)
+	assert.Empty(t, other)
+}
+
+func TestNormalizeMemorySource(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "hello there\nhow are you?", NormalizeMemorySource("  Hello   there\n\n  How are\tyou? "))
+	assert.Empty(t, NormalizeMemorySource(" \n "))
+}
+
+func TestSQLiteStore_Songs(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	store, err := NewSQLiteStore(filepath.Join(dir, "ctxtrans.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = store.Close() })
+
+	ctx := context.Background()
+	_, ok, err := store.GetSongMode(ctx, "/tv/show")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	require.NoError(t, store.PutSongMode(ctx, "/tv/show", "skip"))
+	require.NoError(t, store.PutSongMode(ctx, "/tv/show", "reuse"))
+	mode, ok, err := store.GetSongMode(ctx, "/tv/show")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "reuse", mode)
+
+	deleted, err := store.DeleteSongMode(ctx, "/tv/show")
+	require.NoError(t, err)
+	assert.True(t, deleted)
+	_, ok, err = store.GetSongMode(ctx, "/tv/show")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	require.NoError(t, store.AddSongLines(ctx, "/tv/show", []string{"Kimi no  koe ga", "Sora ni todoke", " "}))
+	require.NoError(t, store.AddSongLines(ctx, "/tv/show", []string{"KIMI NO KOE GA"}))
+	require.NoError(t, store.AddSongLines(ctx, "/tv/other", []string{"Another song"}))
+	lines, err := store.ListSongLines(ctx, "/tv/show")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"kimi no koe ga", "sora ni todoke"}, lines)
+}
+
+func TestSQLiteStore_TranslationReviews(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	store, err := NewSQLiteStore(filepath.Join(dir, "ctxtrans.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = store.Close() })
+
+	ctx := context.Background()
+	require.NoError(t, store.PutTranslationReview(ctx, TranslationReview{JobID: "job-1", BatchStart: 50, BatchEnd: 100, Score: 95}))
+	require.NoError(t, store.PutTranslationReview(ctx, TranslationReview{JobID: "job-1", BatchStart: 0, BatchEnd: 50, Score: 40}))
+	require.NoError(t, store.PutTranslationReview(ctx, TranslationReview{
+		JobID:      "job-1",
+		BatchStart: 0,
+		BatchEnd:   50,
+		Score:      72.5,
+		Issues: []ReviewIssue{
+			{Line: 3, Category: "omission", Severity: "major", Message: "Drops the warning.", Retranslated: true},
+		},
+	}))
+	require.NoError(t, store.PutTranslationReview(ctx, TranslationReview{JobID: "job-2", BatchStart: 0, BatchEnd: 50, Score: 80}))
+
+	reviews, err := store.ListTranslationReviews(ctx, "job-1")
+	require.NoError(t, err)
+	require.Len(t, reviews, 2)
+	assert.Equal(t, 72.5, reviews[0].Score)
+	assert.Equal(t, []ReviewIssue{
+		{Line: 3, Category: "omission", Severity: "major", Message: "Drops the warning.", Retranslated: true},
+	}, reviews[0].Issues)
+	assert.Equal(t, 50, reviews[1].BatchStart)
+	assert.Empty(t, reviews[1].Issues)
+
+	require.NoError(t, store.ClearJobTemp(ctx, "job-1"))
+	reviews, err = store.ListTranslationReviews(ctx, "job-1")
+	require.NoError(t, err)
+	assert.Len(t, reviews, 2, "reviews outlive the temporary data of a job")
+
+	require.NoError(t, store.DeleteJobData(ctx, "job-1"))
+	reviews, err = store.ListTranslationReviews(ctx, "job-1")
+	require.NoError(t, err)
+	assert.Empty(t, reviews)
+	reviews, err = store.ListTranslationReviews(ctx, "job-2")
+	require.NoError(t, err)
+	assert.Len(t, reviews, 1)
+}
+
+func TestSQLiteStore_TermLocksAndUsage(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	store, err := NewSQLiteStore(filepath.Join(dir, "ctxtrans.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = store.Close() })
+
+	ctx := context.Background()
+	path := "/media/tv/Show/term_map.ja-zh.json"
+	require.NoError(t, store.SetTermLocked(ctx, path, "Okarun", true))
+	require.NoError(t, store.SetTermLocked(ctx, path, "Momo", true))
+	require.NoError(t, store.SetTermLocked(ctx, path, "Momo", true))
+	require.NoError(t, store.SetTermLocked(ctx, "/media/tv/Other/term_map.ja-zh.json", "Turbo", true))
+
+	locked, err := store.ListLockedTerms(ctx, path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Momo", "Okarun"}, locked)
+
+	require.NoError(t, store.SetTermLocked(ctx, path, "Momo", false))
+	locked, err = store.ListLockedTerms(ctx, path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Okarun"}, locked)
+
+	require.NoError(t, store.PutTermUsage(ctx, "job-1", path, []string{"Okarun", "Momo"}))
+	require.NoError(t, store.PutTermUsage(ctx, "job-1", path, []string{"Okarun"}))
+	require.NoError(t, store.PutTermUsage(ctx, "job-2", path, []string{"Momo"}))
+
+	usage, err := store.ListTermUsage(ctx, path)
+	require.NoError(t, err)
+	require.Len(t, usage, 2, "a rerun replaces the usage of the job")
+	terms := map[string]string{}
+	for _, item := range usage {
+		terms[item.JobID] = item.Term
+		assert.Equal(t, path, item.TermMapPath)
+		assert.False(t, item.UsedAt.IsZero())
+	}
+	assert.Equal(t, map[string]string{"job-1": "Okarun", "job-2": "Momo"}, terms)
+
+	require.NoError(t, store.DeleteJobData(ctx, "job-1"))
+	usage, err = store.ListTermUsage(ctx, path)
+	require.NoError(t, err)
+	require.Len(t, usage, 1)
+	assert.Equal(t, "job-2", usage[0].JobID)
+}
@@ -33,3 +33,110 @@ type MediaMetaCache struct {
 	ExpiresAt         time.Time
 	UpdatedAt         time.Time
 }
+
+// EpisodeSummary is the story memory of a translated episode
+type EpisodeSummary struct {
+	SeriesKey string
+	MediaPath string
+	Episode   string
+	Synopsis  string
+	// Relationships are the character notes of the series up to this episode
+	Relationships string
+	UpdatedAt     time.Time
+}
+
+// CharacterProfile describes how a character of a series speaks
+type CharacterProfile struct {
+	SeriesKey string
+	Name      string
+	Gender    string
+	Formality string
+	Nicknames []string
+	UpdatedAt time.Time
+}
+
+// LLMUsage is the token usage and cost of one LLM call
+type LLMUsage struct {
+	JobID     string
+	SeriesKey string
+	Stage     string
+	// BatchStart and BatchEnd bound the translation batch, -1 outside batches
+	BatchStart       int
+	BatchEnd         int
+	Provider         string
+	Model            string
+	PromptTokens     int
+	CompletionTokens int
+	Cost             float64
+	CreatedAt        time.Time
+}
+
+// UsageTotals sums the usage of a set of LLM calls
+type UsageTotals struct {
+	Calls            int
+	PromptTokens     int
+	CompletionTokens int
+	Cost             float64
+}
+
+// UsageGroup is the usage of the LLM calls sharing a key
+type UsageGroup struct {
+	Key string
+	UsageTotals
+}
+
+// UsageGrouping selects the key of a usage breakdown
+type UsageGrouping string
+
+const (
+	UsageByDay      UsageGrouping = "day"
+	UsageBySeries   UsageGrouping = "series"
+	UsageByModel    UsageGrouping = "model"
+	UsageByProvider UsageGrouping = "provider"
+)
+
+// TranslationMemoryEntry is an approved translation of a subtitle line
+type TranslationMemoryEntry struct {
+	SeriesKey  string
+	SourceLang string
+	TargetLang string
+	// SourceKey is the normalised source text, see NormalizeMemorySource
+	SourceKey   string
+	SourceText  string
+	Translation string
+	// Manual is set for translations edited by hand
+	Manual    bool
+	UpdatedAt time.Time
+}
+
+// TranslationReview is the verdict of the reviewer on a translated batch of
+// a job, lines BatchStart to BatchEnd (exclusive) counted from 0
+type TranslationReview struct {
+	JobID      string
+	BatchStart int
+	BatchEnd   int
+	// Score rates the batch from 0 to 100
+	Score     float64
+	Issues    []ReviewIssue
+	UpdatedAt time.Time
+}
+
+// ReviewIssue is a problem the reviewer found in a translated line
+type ReviewIssue struct {
+	// Line is the position of the line in the subtitle, from 1
+	Line     int    `json:"line"`
+	Category string `json:"category"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+	// Retranslated is set when the line was translated again for the issue
+	Retranslated bool `json:"retranslated"`
+}
+
+// TermUsage records that a job translated a subtitle containing a term of a
+// term map file
+type TermUsage struct {
+	JobID       string
+	TermMapPath string
+	Term        string
+	UsedAt      time.Time
+}
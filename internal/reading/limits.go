@@ -0,0 +1,116 @@
+package reading
+
+import (
+	"math"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/text/language"
+)
+
+// Limits are the reading speed limits of one script; zero disables a check
+type Limits struct {
+	// MaxCPS is the most characters per second a cue may ask viewers to read
+	MaxCPS float64 `json:"max_cps"`
+	// MaxLineLength is the most characters of one line of a cue
+	MaxLineLength int `json:"max_line_length"`
+}
+
+// Config holds the limits of scripts read at different speeds
+type Config struct {
+	Latin Limits `json:"latin"`
+	CJK   Limits `json:"cjk"`
+}
+
+// DefaultConfig returns the limits common broadcast guidelines set for adult
+// programmes
+func DefaultConfig() Config {
+	return Config{
+		Latin: Limits{MaxCPS: 17, MaxLineLength: 42},
+		CJK:   Limits{MaxCPS: 9, MaxLineLength: 16},
+	}
+}
+
+// For returns the limits of the script lang is written in
+func (c Config) For(lang language.Tag) Limits {
+	base, _ := lang.Base()
+	switch base.String() {
+	case "zh", "ja", "ko", "yue":
+		return c.CJK
+	default:
+		return c.Latin
+	}
+}
+
+// Kind names the limit a line breaks
+type Kind string
+
+const (
+	KindCPS        Kind = "cps"
+	KindLineLength Kind = "line_length"
+)
+
+// Violation is a limit a translated line breaks
+type Violation struct {
+	Kind  Kind    `json:"kind"`
+	Value float64 `json:"value"`
+	Limit float64 `json:"limit"`
+}
+
+// formattingPattern matches ASS override blocks and HTML-style tags, which
+// are not read
+var formattingPattern = regexp.MustCompile(`\{[^}]*\}|<[^<>]*>`)
+
+// Lines returns the text of each line of a cue as shown, without formatting
+func Lines(text string) []string {
+	text = strings.ReplaceAll(formattingPattern.ReplaceAllString(text, ""), `\N`, "\n")
+	var ret []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			ret = append(ret, line)
+		}
+	}
+	return ret
+}
+
+// Chars counts the characters viewers read in text, spaces included and line
+// breaks left out
+func Chars(text string) int {
+	count := 0
+	for _, line := range Lines(text) {
+		count += utf8.RuneCountInString(line)
+	}
+	return count
+}
+
+// MaxChars is the most characters a cue shown for duration may hold, or 0
+// when limits do not bound it
+func MaxChars(duration time.Duration, limits Limits) int {
+	if limits.MaxCPS <= 0 || duration <= 0 {
+		return 0
+	}
+	return max(int(limits.MaxCPS*duration.Seconds()), 1)
+}
+
+// Check returns the limits text breaks when shown for duration. Cues without
+// a duration are only checked for line length.
+func Check(text string, duration time.Duration, limits Limits) []Violation {
+	var ret []Violation
+	if chars := Chars(text); chars > 0 && limits.MaxCPS > 0 && duration > 0 {
+		if cps := float64(chars) / duration.Seconds(); cps > limits.MaxCPS {
+			ret = append(ret, Violation{Kind: KindCPS, Value: math.Round(cps*10) / 10, Limit: limits.MaxCPS})
+		}
+	}
+	if limits.MaxLineLength > 0 {
+		longest := 0
+		for _, line := range Lines(text) {
+			longest = max(longest, utf8.RuneCountInString(line))
+		}
+		if longest > limits.MaxLineLength {
+			ret = append(ret, Violation{Kind: KindLineLength, Value: float64(longest), Limit: float64(limits.MaxLineLength)})
+		}
+	}
+	return ret
+}
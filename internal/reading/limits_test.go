@@ -0,0 +1,52 @@
+package reading
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/text/language"
+)
+
+func TestConfig_For(t *testing.T) {
+	t.Parallel()
+
+	cfg := DefaultConfig()
+	assert.Equal(t, cfg.CJK, cfg.For(language.Chinese))
+	assert.Equal(t, cfg.CJK, cfg.For(language.MustParse("zh-Hant")))
+	assert.Equal(t, cfg.CJK, cfg.For(language.Japanese))
+	assert.Equal(t, cfg.Latin, cfg.For(language.French))
+	assert.Equal(t, cfg.Latin, cfg.For(language.Und))
+}
+
+func TestChars(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, 10, Chars("{\\i1}Hello\\N<b>there</b>"))
+	assert.Equal(t, 5, Chars("你好\n我是谁"))
+	assert.Equal(t, 0, Chars("  "))
+}
+
+func TestCheck(t *testing.T) {
+	t.Parallel()
+
+	limits := Limits{MaxCPS: 9, MaxLineLength: 16}
+	assert.Empty(t, Check("你好，我是谁", 2*time.Second, limits))
+
+	violations := Check("我们必须在天亮之前离开这座城市，否则就来不及了", 2*time.Second, limits)
+	assert.Equal(t, []Violation{
+		{Kind: KindCPS, Value: 11.5, Limit: 9},
+		{Kind: KindLineLength, Value: 23, Limit: 16},
+	}, violations)
+
+	assert.Empty(t, Check("我们必须在天亮之前离开\n这座城市", 0, limits), "cues without timing only check line length")
+	assert.Empty(t, Check("Anything goes here at all", time.Second, Limits{}), "zero limits check nothing")
+}
+
+func TestMaxChars(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, 22, MaxChars(2500*time.Millisecond, Limits{MaxCPS: 9}))
+	assert.Equal(t, 1, MaxChars(50*time.Millisecond, Limits{MaxCPS: 9}))
+	assert.Equal(t, 0, MaxChars(2*time.Second, Limits{}))
+}
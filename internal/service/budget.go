@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/MimeLyc/contextual-sub-translator/internal/config"
+	"github.com/MimeLyc/contextual-sub-translator/internal/jobs"
+	"github.com/MimeLyc/contextual-sub-translator/internal/media"
+	"github.com/MimeLyc/contextual-sub-translator/pkg/log"
+)
+
+// errBudgetExceeded stops a job whose LLM usage reached the job budget
+var errBudgetExceeded = errors.New("budget exceeded")
+
+// globalBudgetReason describes the daily or monthly budget usage has reached,
+// empty while under both or when usage is not recorded.
+func (s *transService) globalBudgetReason(ctx context.Context) string {
+	if s.store == nil {
+		return ""
+	}
+	budget := s.configSnapshot().Budget
+	now := time.Now().UTC()
+	for _, period := range []struct {
+		name  string
+		limit config.BudgetCap
+		since time.Time
+	}{
+		{"daily", budget.Daily, now},
+		{"monthly", budget.Monthly, time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)},
+	} {
+		if period.limit == (config.BudgetCap{}) {
+			continue
+		}
+		totals, err := s.store.UsageSince(ctx, period.since)
+		if err != nil {
+			log.Error("Failed to check %s budget: %v", period.name, err)
+			continue
+		}
+		if reached := period.limit.Reached(totals.PromptTokens+totals.CompletionTokens, totals.Cost); reached != "" {
+			return fmt.Sprintf("%s budget reached: %s", period.name, reached)
+		}
+	}
+	return ""
+}
+
+// budgetHold is the gate of the job queue. Every job is held once the daily or
+// monthly budget is reached, the jobs of a series once its budget is reached.
+func (s *transService) budgetHold(job *jobs.TranslationJob) string {
+	ctx := context.Background()
+	if reason := s.globalBudgetReason(ctx); reason != "" {
+		return reason
+	}
+	limit := s.configSnapshot().Budget.Series
+	if s.store == nil || limit == (config.BudgetCap{}) {
+		return ""
+	}
+	seriesKey := jobSeriesKey(job.Payload)
+	totals, err := s.store.SeriesUsage(ctx, seriesKey)
+	if err != nil {
+		log.Error("Failed to check budget of series %s: %v", seriesKey, err)
+		return ""
+	}
+	if reached := limit.Reached(totals.PromptTokens+totals.CompletionTokens, totals.Cost); reached != "" {
+		return fmt.Sprintf("series budget of %s reached: %s", seriesKey, reached)
+	}
+	return ""
+}
+
+// jobSeriesKey returns the series a job belongs to, keyed like processBundle keys it
+func jobSeriesKey(payload jobs.JobPayload) string {
+	var nfoFiles []media.TVShowInfo
+	if payload.NFOFile != "" {
+		nfoFiles = []media.TVShowInfo{{Path: payload.NFOFile}}
+	}
+	return findSeriesDir(nfoFiles, filepath.Dir(payload.MediaFile))
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/MimeLyc/contextual-sub-translator/internal/agent"
+	"github.com/MimeLyc/contextual-sub-translator/internal/config"
+	"github.com/MimeLyc/contextual-sub-translator/internal/jobs"
+	"github.com/MimeLyc/contextual-sub-translator/internal/persistence"
+)
+
+func TestTransService_BudgetHold(t *testing.T) {
+	store, err := persistence.NewSQLiteStore(filepath.Join(t.TempDir(), "ctxtrans.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = store.Close() })
+
+	ctx := context.Background()
+	require.NoError(t, store.AddLLMUsage(ctx, persistence.LLMUsage{
+		JobID: "job-1", SeriesKey: "/tv/show", PromptTokens: 800, CompletionTokens: 200, Cost: 1.5,
+	}))
+	require.NoError(t, store.AddLLMUsage(ctx, persistence.LLMUsage{
+		JobID: "job-0", SeriesKey: "/tv/show", PromptTokens: 5000, Cost: 9, CreatedAt: time.Now().AddDate(0, -2, 0),
+	}))
+
+	s := &transService{store: store}
+	showJob := &jobs.TranslationJob{Payload: jobs.JobPayload{MediaFile: "/tv/show/episode02.mkv"}}
+	otherJob := &jobs.TranslationJob{Payload: jobs.JobPayload{MediaFile: "/tv/other/episode01.mkv"}}
+	assert.Empty(t, s.budgetHold(showJob), "no caps, no holds")
+
+	s.cfg.Budget = config.BudgetConfig{Daily: config.BudgetCap{Cost: 2}, Monthly: config.BudgetCap{Tokens: 2000}}
+	assert.Empty(t, s.budgetHold(showJob), "older usage does not count")
+
+	s.cfg.Budget.Daily.Cost = 1.5
+	assert.Equal(t, "daily budget reached: $1.50 of $1.50 spent", s.budgetHold(otherJob))
+	assert.NotEmpty(t, s.globalBudgetReason(ctx))
+
+	s.cfg.Budget = config.BudgetConfig{Series: config.BudgetCap{Tokens: 6000}}
+	assert.Equal(t, "series budget of /tv/show reached: 6000 of 6000 tokens used", s.budgetHold(showJob))
+	assert.Empty(t, s.budgetHold(otherJob))
+	assert.Empty(t, s.globalBudgetReason(ctx))
+}
+
+func TestUsageRecorder_StopsJobAtJobBudget(t *testing.T) {
+	store, err := persistence.NewSQLiteStore(filepath.Join(t.TempDir(), "ctxtrans.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = store.Close() })
+
+	// the job was resumed after spending 600 tokens
+	require.NoError(t, store.AddLLMUsage(context.Background(), persistence.LLMUsage{JobID: "job-1", PromptTokens: 600}))
+
+	s := &transService{store: store}
+	ctx, release := s.withUsageRecording(context.Background(), "job-1", "/tv/show", config.Config{
+		Budget: config.BudgetConfig{Job: config.BudgetCap{Tokens: 1000}},
+	})
+	defer release()
+
+	llmAgent := newUsageAgent(t, 300, 0)
+	_, err = llmAgent.Execute(ctx, agent.AgentRequest{UserMessage: "batch 1"})
+	require.NoError(t, err)
+	require.NoError(t, ctx.Err())
+
+	_, err = llmAgent.Execute(ctx, agent.AgentRequest{UserMessage: "batch 2"})
+	require.NoError(t, err)
+	require.Error(t, ctx.Err(), "1200 tokens reach the job budget")
+	assert.True(t, errors.Is(context.Cause(ctx), errBudgetExceeded))
+	assert.Contains(t, context.Cause(ctx).Error(), "1200 of 1000 tokens used")
+}
@@ -33,12 +33,24 @@ func batchCheckpointStoreFromContext(ctx context.Context) batchCheckpointStore {
 type persistentBatchCheckpointStore struct {
 	store *persistence.SQLiteStore
 	jobID string
+	// pivot keeps the batches of the pass to the pivot language
+	pivot bool
 
 	mu     sync.RWMutex
 	cached map[string][]string
 }
 
 func newPersistentBatchCheckpointStore(ctx context.Context, store *persistence.SQLiteStore, jobID string) (*persistentBatchCheckpointStore, error) {
+	return loadPersistentCheckpointStore(ctx, store, jobID, false)
+}
+
+// newPersistentPivotCheckpointStore checkpoints the pass of a job to its
+// pivot language, see persistence.SQLiteStore.SavePivotCheckpoint
+func newPersistentPivotCheckpointStore(ctx context.Context, store *persistence.SQLiteStore, jobID string) (*persistentBatchCheckpointStore, error) {
+	return loadPersistentCheckpointStore(ctx, store, jobID, true)
+}
+
+func loadPersistentCheckpointStore(ctx context.Context, store *persistence.SQLiteStore, jobID string, pivot bool) (*persistentBatchCheckpointStore, error) {
 	if store == nil {
 		return nil, fmt.Errorf("store is nil")
 	}
@@ -46,7 +58,11 @@ func newPersistentBatchCheckpointStore(ctx context.Context, store *persistence.S
 		return nil, fmt.Errorf("job id is empty")
 	}
 
-	checkpoints, err := store.LoadBatchCheckpoints(ctx, jobID)
+	load := store.LoadBatchCheckpoints
+	if pivot {
+		load = store.LoadPivotCheckpoints
+	}
+	checkpoints, err := load(ctx, jobID)
 	if err != nil {
 		return nil, err
 	}
@@ -59,6 +75,7 @@ func newPersistentBatchCheckpointStore(ctx context.Context, store *persistence.S
 	return &persistentBatchCheckpointStore{
 		store:  store,
 		jobID:  jobID,
+		pivot:  pivot,
 		cached: cached,
 	}, nil
 }
@@ -81,7 +98,11 @@ func (s *persistentBatchCheckpointStore) Save(ctx context.Context, start, end in
 		return nil
 	}
 	copyData := append([]string(nil), translated...)
-	if err := s.store.SaveBatchCheckpoint(ctx, s.jobID, start, end, copyData); err != nil {
+	save := s.store.SaveBatchCheckpoint
+	if s.pivot {
+		save = s.store.SavePivotCheckpoint
+	}
+	if err := save(ctx, s.jobID, start, end, copyData); err != nil {
 		return err
 	}
 	s.mu.Lock()
@@ -0,0 +1,49 @@
+package service
+
+import (
+	"context"
+
+	"github.com/MimeLyc/contextual-sub-translator/internal/config"
+	"github.com/MimeLyc/contextual-sub-translator/internal/reading"
+	"github.com/MimeLyc/contextual-sub-translator/internal/subtitle"
+	"github.com/MimeLyc/contextual-sub-translator/internal/translator"
+	"github.com/MimeLyc/contextual-sub-translator/pkg/log"
+)
+
+// condenseLines shortens the translated lines over the reading limits in
+// place. Songs kept in the original are left alone; lines the condenser
+// returns unchanged are not counted as shortened.
+func (t *SubTranslator) condenseLines(ctx context.Context, media translator.MediaMeta, lines []subtitle.Line) {
+	if t.config.Condenser == nil {
+		return
+	}
+	limits := t.config.ReadingLimits
+	var positions []int
+	var offending []subtitle.Line
+	for i, line := range lines {
+		if line.Song && t.config.SongMode != "" && !t.config.SongMode.Translates() {
+			continue
+		}
+		if len(reading.Check(line.TranslatedText, line.EndTime-line.StartTime, limits)) > 0 {
+			positions = append(positions, i)
+			offending = append(offending, line)
+		}
+	}
+	if len(offending) == 0 {
+		return
+	}
+
+	condensed, err := t.config.Condenser.Condense(withUsageStage(ctx, config.StageCondense), media, offending, t.config.TargetLanguage.String(), limits)
+	if err != nil {
+		log.Error("Failed to condense %d lines over the reading limits, keeping their translation: %v", len(offending), err)
+		return
+	}
+	shortened := 0
+	for k, i := range positions {
+		if condensed[k] != lines[i].TranslatedText {
+			lines[i].TranslatedText = condensed[k]
+			shortened++
+		}
+	}
+	log.Info("Condensed %d of %d lines over the reading limits", shortened, len(offending))
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/language"
+
+	"github.com/MimeLyc/contextual-sub-translator/internal/agent"
+	"github.com/MimeLyc/contextual-sub-translator/internal/reading"
+	"github.com/MimeLyc/contextual-sub-translator/internal/songs"
+	"github.com/MimeLyc/contextual-sub-translator/internal/subtitle"
+	"github.com/MimeLyc/contextual-sub-translator/internal/tools"
+	"github.com/MimeLyc/contextual-sub-translator/internal/translator"
+)
+
+func TestSubTranslator_CondenseLines(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, string(body))
+		encoded, _ := json.Marshal(`[{"index":1,"text":"天亮前必须走"}]`)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"chatcmpl-1","object":"chat.completion","created":1,"model":"test-model",` +
+			`"choices":[{"index":0,"finish_reason":"stop","message":{"role":"assistant","content":` + string(encoded) + `}}],` +
+			`"usage":{"prompt_tokens":1,"completion_tokens":1,"total_tokens":2}}`))
+	}))
+	t.Cleanup(server.Close)
+	llmAgent, err := agent.NewLLMAgent(agent.LLMConfig{APIKey: "test-key", APIURL: server.URL, Model: "test-model", Timeout: 10}, tools.NewRegistry(), 1)
+	require.NoError(t, err)
+
+	lines := []subtitle.Line{
+		{Index: 1, EndTime: 2 * time.Second, Text: "We have to leave before dawn.", TranslatedText: "我们必须在天亮之前离开这座城市，否则就来不及了"},
+		{Index: 2, StartTime: 3 * time.Second, EndTime: 5 * time.Second, Text: "Okay.", TranslatedText: "好的"},
+		{Index: 3, StartTime: 6 * time.Second, EndTime: 7 * time.Second, Text: "Flying over the morning sky", TranslatedText: "Flying over the morning sky", Song: true},
+	}
+	subTrans := &SubTranslator{config: TranslatorConfig{
+		TargetLanguage: language.Chinese,
+		SongMode:       songs.ModeOriginal,
+		ReadingLimits:  reading.DefaultConfig().CJK,
+		Condenser:      translator.NewCondenser(llmAgent),
+	}}
+	subTrans.condenseLines(context.Background(), translator.MediaMeta{}, lines)
+
+	require.Len(t, requests, 1, "only the first line is over the limits, the song keeps the original")
+	assert.NotContains(t, requests[0], "Okay.")
+	assert.Equal(t, "天亮前必须走", lines[0].TranslatedText)
+	assert.Equal(t, "好的", lines[1].TranslatedText)
+	assert.Equal(t, "Flying over the morning sky", lines[2].TranslatedText)
+
+	// Without a condenser the lines stay as translated
+	lines[0].TranslatedText = "我们必须在天亮之前离开这座城市，否则就来不及了"
+	(&SubTranslator{config: TranslatorConfig{ReadingLimits: reading.DefaultConfig().CJK}}).condenseLines(context.Background(), translator.MediaMeta{}, lines)
+	assert.Equal(t, "我们必须在天亮之前离开这座城市，否则就来不及了", lines[0].TranslatedText)
+	assert.Len(t, requests, 1)
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"path/filepath"
+	"regexp"
+
+	"github.com/MimeLyc/contextual-sub-translator/internal/agent"
+	"github.com/MimeLyc/contextual-sub-translator/internal/media"
+	"github.com/MimeLyc/contextual-sub-translator/internal/persistence"
+	"github.com/MimeLyc/contextual-sub-translator/internal/subtitle"
+	"github.com/MimeLyc/contextual-sub-translator/internal/summary"
+	"github.com/MimeLyc/contextual-sub-translator/internal/translator"
+	"github.com/MimeLyc/contextual-sub-translator/pkg/log"
+)
+
+// previousEpisodeLimit is the number of earlier episode synopses sent along
+// with a translation
+const previousEpisodeLimit = 3
+
+// storyMemory is what the translator remembers of the earlier episodes of a series
+type storyMemory struct {
+	seriesKey        string
+	previousEpisodes []translator.EpisodeSummary
+	// relationships are the character notes up to the previous episode
+	relationships string
+}
+
+// loadStoryMemory loads the summaries of the episodes before mediaFile; the
+// relationships are the ones noted with the latest of them.
+func (s *transService) loadStoryMemory(ctx context.Context, bundle MediaBundle) storyMemory {
+	memory := storyMemory{seriesKey: findSeriesDir(bundle.NFOFiles, filepath.Dir(bundle.MediaFile))}
+	if s.store == nil {
+		return memory
+	}
+	summaries, err := s.store.ListEpisodeSummariesBefore(ctx, memory.seriesKey, bundle.MediaFile, previousEpisodeLimit)
+	if err != nil {
+		log.Error("Failed to load episode summaries of %s: %v", memory.seriesKey, err)
+		return memory
+	}
+	for _, item := range summaries {
+		memory.previousEpisodes = append(memory.previousEpisodes, translator.EpisodeSummary{
+			Episode:  item.Episode,
+			Synopsis: item.Synopsis,
+		})
+		if item.Relationships != "" {
+			memory.relationships = item.Relationships
+		}
+	}
+	if len(summaries) > 0 {
+		log.Info("Loaded %d earlier episode summaries of %s", len(summaries), memory.seriesKey)
+	}
+	return memory
+}
+
+// saveEpisodeSummary summarizes a translated episode for the episodes after
+// it, replacing the summary of an earlier run of the same episode.
+func (s *transService) saveEpisodeSummary(
+	ctx context.Context,
+	llmAgent agent.Agent,
+	bundle MediaBundle,
+	memory storyMemory,
+	lines []subtitle.Line,
+) {
+	if s.store == nil || llmAgent == nil {
+		return
+	}
+	var showInfo media.TVShowInfo
+	if len(bundle.NFOFiles) > 0 {
+		showInfo = bundle.NFOFiles[0]
+	}
+	episode := getBaseName(bundle.MediaFile)
+
+	generated, err := summary.NewGenerator(llmAgent).Summarize(ctx, showInfo, episode, lines, memory.relationships)
+	if err != nil {
+		log.Error("Failed to summarize episode %s: %v", bundle.MediaFile, err)
+		return
+	}
+	if err := s.store.PutEpisodeSummary(ctx, persistence.EpisodeSummary{
+		SeriesKey:     memory.seriesKey,
+		MediaPath:     bundle.MediaFile,
+		Episode:       episode,
+		Synopsis:      generated.Synopsis,
+		Relationships: generated.Relationships,
+	}); err != nil {
+		log.Error("Failed to save episode summary of %s: %v", bundle.MediaFile, err)
+		return
+	}
+	log.Info("Saved episode summary of %s", bundle.MediaFile)
+}
+
+// seasonDirPattern matches season directories like "Season 1", "S01" or "Specials"
+var seasonDirPattern = regexp.MustCompile(`(?i)^(?:season[ ._-]*\d+|s\d+|specials)$`)
+
+// findSeriesDir finds the directory that identifies the series of an episode:
+// the directory of tvshow.nfo, looked up from the bundle NFOs and then the
+// ancestors of mediaDir, falling back to mediaDir or the series directory
+// above it when mediaDir is a season directory.
+func findSeriesDir(nfoFiles []media.TVShowInfo, mediaDir string) string {
+	for _, nfo := range nfoFiles {
+		if filepath.Base(nfo.Path) == "tvshow.nfo" {
+			return filepath.Dir(nfo.Path)
+		}
+	}
+	for _, nfoPath := range findNFOFiles(mediaDir) {
+		if filepath.Base(nfoPath) == "tvshow.nfo" {
+			return filepath.Dir(nfoPath)
+		}
+	}
+	if seasonDirPattern.MatchString(filepath.Base(mediaDir)) {
+		return filepath.Dir(mediaDir)
+	}
+	return mediaDir
+}
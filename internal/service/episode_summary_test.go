@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/language"
+
+	"github.com/MimeLyc/contextual-sub-translator/internal/media"
+	"github.com/MimeLyc/contextual-sub-translator/internal/persistence"
+	"github.com/MimeLyc/contextual-sub-translator/internal/subtitle"
+	"github.com/MimeLyc/contextual-sub-translator/internal/translator"
+)
+
+func TestFindSeriesDir(t *testing.T) {
+	root := t.TempDir()
+	seasonDir := filepath.Join(root, "Show", "Season 1")
+	require.NoError(t, os.MkdirAll(seasonDir, 0o755))
+
+	assert.Equal(t, filepath.Join(root, "Show"), findSeriesDir(nil, seasonDir))
+	assert.Equal(t, filepath.Join(root, "Show"), findSeriesDir(nil, filepath.Join(root, "Show")))
+
+	require.NoError(t, os.WriteFile(filepath.Join(root, "Show", "tvshow.nfo"), []byte("<tvshow/>"), 0o644))
+	assert.Equal(t, filepath.Join(root, "Show"), findSeriesDir([]media.TVShowInfo{
+		{Path: filepath.Join(seasonDir, "Show.S01E02.nfo")},
+	}, seasonDir))
+
+	assert.Equal(t, "/elsewhere", findSeriesDir([]media.TVShowInfo{{Path: "/elsewhere/tvshow.nfo"}}, seasonDir))
+}
+
+func TestTransService_LoadStoryMemory(t *testing.T) {
+	root := t.TempDir()
+	store, err := persistence.NewSQLiteStore(filepath.Join(root, "ctxtrans.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = store.Close() })
+
+	ctx := context.Background()
+	showDir := filepath.Join(root, "Show")
+	for _, item := range []persistence.EpisodeSummary{
+		{MediaPath: filepath.Join(showDir, "Show.S01E01.mkv"), Episode: "Show.S01E01", Synopsis: "first", Relationships: "A meets B"},
+		{MediaPath: filepath.Join(showDir, "Show.S01E02.mkv"), Episode: "Show.S01E02", Synopsis: "second", Relationships: "A trusts B"},
+		{MediaPath: filepath.Join(showDir, "Show.S01E04.mkv"), Episode: "Show.S01E04", Synopsis: "later"},
+	} {
+		item.SeriesKey = showDir
+		require.NoError(t, store.PutEpisodeSummary(ctx, item))
+	}
+
+	s := &transService{store: store}
+	memory := s.loadStoryMemory(ctx, MediaBundle{
+		MediaFile: filepath.Join(showDir, "Show.S01E03.mkv"),
+		NFOFiles:  []media.TVShowInfo{{Path: filepath.Join(showDir, "tvshow.nfo")}},
+	})
+	assert.Equal(t, showDir, memory.seriesKey)
+	assert.Equal(t, []translator.EpisodeSummary{
+		{Episode: "Show.S01E01", Synopsis: "first"},
+		{Episode: "Show.S01E02", Synopsis: "second"},
+	}, memory.previousEpisodes)
+	assert.Equal(t, "A trusts B", memory.relationships)
+}
+
+func TestSubTranslator_Translate_SendsStoryMemory(t *testing.T) {
+	mockTrans := &mockTranslator{}
+	mockWriter := &mockSubtitleWriter{}
+	previous := []translator.EpisodeSummary{{Episode: "Show.S01E01", Synopsis: "first"}}
+	lines := []subtitle.Line{{Index: 1, Text: "Hello"}}
+
+	subTrans := &SubTranslator{
+		subtitleWriter: mockWriter,
+		translator:     mockTrans,
+		config: TranslatorConfig{
+			TargetLanguage:   language.Chinese,
+			ContextEnabled:   true,
+			InputPath:        "/tmp/show.srt",
+			PreviousEpisodes: previous,
+			Relationships:    "A trusts B",
+		},
+		file: &subtitle.File{Lines: lines},
+	}
+	mockTrans.On(
+		"BatchTranslate",
+		mock.Anything,
+		mock.MatchedBy(func(media translator.MediaMeta) bool {
+			return assert.ObjectsAreEqual(previous, media.PreviousEpisodes) && media.Relationships == "A trusts B"
+		}),
+		lines,
+		mock.Anything,
+		mock.Anything,
+		mock.Anything,
+	).Return([]subtitle.Line{{Index: 1, Text: "Hello", TranslatedText: "你好"}}, nil)
+	mockWriter.On("Write", mock.Anything, mock.AnythingOfType("*subtitle.File")).Return(nil)
+
+	_, err := subTrans.Translate(context.Background(), "")
+	require.NoError(t, err)
+	mockTrans.AssertExpectations(t)
+}
@@ -41,3 +41,38 @@ func TestManualAndCron_UseSameEnqueueDedup(t *testing.T) {
 	assert.Equal(t, jobFromCron.ID, jobFromManual.ID)
 	assert.Len(t, q.List(), 1)
 }
+
+func TestEnqueueBundle_OneJobPerTargetLanguage(t *testing.T) {
+	q := jobs.NewQueue(1, nil)
+	svc := transService{
+		cfg: config.Config{
+			Translate: config.TranslateConfig{
+				TargetLanguage:  language.SimplifiedChinese,
+				TargetLanguages: []language.Tag{language.SimplifiedChinese, language.TraditionalChinese},
+			},
+		},
+		jobQueue: q,
+	}
+
+	bundle := MediaPathBundle{
+		MediaFile:     "/media/episode01.mkv",
+		SubtitleFiles: []string{"/media/episode01.srt"},
+	}
+	primary, created, err := svc.enqueueCronBundle(bundle)
+	require.NoError(t, err)
+	require.True(t, created)
+	assert.Equal(t, "zh-Hans", primary.Payload.TargetLanguage)
+	assert.Equal(t, "/media/episode01.mkv|/media/episode01.srt|zh-Hans", primary.DedupeKey)
+
+	bundle.TargetLanguage = language.TraditionalChinese
+	traditional, created, err := svc.enqueueCronBundle(bundle)
+	require.NoError(t, err)
+	require.True(t, created, "another language is another job")
+	assert.Equal(t, "zh-Hant", traditional.Payload.TargetLanguage)
+	assert.NotEqual(t, primary.ID, traditional.ID)
+
+	_, created, err = svc.enqueueManualBundle(bundle)
+	require.NoError(t, err)
+	assert.False(t, created)
+	assert.Len(t, q.List(), 2)
+}
@@ -0,0 +1,35 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/MimeLyc/contextual-sub-translator/internal/media"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/language"
+)
+
+func TestMuxTranslatedSubtitle(t *testing.T) {
+	var muxed []media.MuxOptions
+	operator := fakeMediaOperator{muxed: &muxed}
+
+	muxTranslatedSubtitle(operator, "/tv/ep01.mkv", "/tv/ep01_ctxtrans.zh.srt", language.Chinese, media.MuxOff)
+	muxTranslatedSubtitle(operator, "/tv/ep01.avi", "/tv/ep01_ctxtrans.zh.srt", language.Chinese, media.MuxInPlace)
+	assert.Empty(t, muxed, "off mode and unsupported containers must not mux")
+
+	muxTranslatedSubtitle(operator, "/tv/ep01.mkv", "/tv/ep01_ctxtrans.zh.srt", language.Chinese, media.MuxCopy)
+	muxTranslatedSubtitle(operator, "/tv/ep01.mkv", "/tv/ep01_ctxtrans.zh.srt", language.Chinese, media.MuxInPlace)
+	require.Len(t, muxed, 2)
+	assert.Equal(t, media.MuxOptions{
+		SubtitlePath: "/tv/ep01_ctxtrans.zh.srt",
+		Language:     language.Chinese,
+		Title:        "Chinese (ctxtrans)",
+		OutputPath:   "/tv/ep01_ctxtrans.zh.mkv",
+	}, muxed[0])
+	assert.Empty(t, muxed[1].OutputPath)
+}
+
+func TestIsMuxedCopy(t *testing.T) {
+	assert.True(t, isMuxedCopy("/tv/ep01_ctxtrans.zh.mkv"))
+	assert.False(t, isMuxedCopy("/tv/ep01.mkv"))
+}
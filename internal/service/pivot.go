@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"golang.org/x/text/language"
+
+	"github.com/MimeLyc/contextual-sub-translator/internal/config"
+	"github.com/MimeLyc/contextual-sub-translator/internal/library"
+	"github.com/MimeLyc/contextual-sub-translator/internal/subtitle"
+	"github.com/MimeLyc/contextual-sub-translator/internal/termmap"
+	"github.com/MimeLyc/contextual-sub-translator/internal/translator"
+	"github.com/MimeLyc/contextual-sub-translator/pkg/log"
+)
+
+// usesPivot reports whether subtitles in source are translated to target
+// through the configured pivot language: not when either side already is in
+// it.
+func usesPivot(source, target language.Tag, cfg config.Config) bool {
+	pivot := cfg.Translate.Pivot
+	if pivot == language.Und {
+		return false
+	}
+	return !languageMatches(source, pivot) && !languageMatches(target, pivot)
+}
+
+// pivotSubtitle returns the subtitle the target is translated from. With a
+// pivot language that is a subtitle in it found next to the media, or else
+// the source translated to it first, checkpointed apart from the pass to the
+// target. The pivot translation is only written out when PivotOutput is set.
+func (s *transService) pivotSubtitle(
+	ctx context.Context,
+	bundle MediaBundle,
+	jobID string,
+	target language.Tag,
+	outputMode subtitle.OutputMode,
+	cli translator.Translator,
+	cfg config.Config,
+) (subtitle.File, error) {
+	source := bundle.SubtitleFiles[0]
+	if !usesPivot(source.Language, target, cfg) {
+		return source, nil
+	}
+	pivot := cfg.Translate.Pivot
+
+	if found := findPivotSubtitle(bundle.MediaFile, pivot); found != nil {
+		log.Info("Translating subtitle media %s through pivot subtitle %s", bundle.MediaFile, found.Path)
+		found.Path = source.Path
+		return *found, nil
+	}
+
+	if s.store != nil && jobID != "" {
+		checkpointStore, err := newPersistentPivotCheckpointStore(ctx, s.store, jobID)
+		if err != nil {
+			log.Error("Failed to load pivot checkpoints for job %s: %v", jobID, err)
+		} else {
+			ctx = withBatchCheckpointStore(ctx, checkpointStore)
+		}
+	}
+
+	var termMapData map[string]string
+	if tmPath := termmap.FindInAncestors(filepath.Dir(bundle.MediaFile), source.Language.String(), pivot.String()); tmPath != "" {
+		tm, err := termmap.Load(tmPath)
+		if err != nil {
+			log.Error("Failed to load term map from %s: %v", tmPath, err)
+		} else {
+			termMapData = map[string]string(tm)
+		}
+	}
+
+	log.Info("Translating subtitle media %s from %s to pivot %s", bundle.MediaFile, source.Language, pivot)
+	transLator, err := NewTranslator(TranslatorConfig{
+		TargetLanguage: pivot,
+		ContextEnabled: true,
+		SubtitleFile:   &source,
+		OutputDir:      filepath.Dir(bundle.MediaFile),
+		InputPath:      source.Path,
+		TermMap:        termMapData,
+		OutputMode:     outputMode,
+		OutputEncoding: cfg.Translate.OutputEncoding,
+		DiscardOutput:  !cfg.Translate.PivotOutput,
+	}, cli)
+	if err != nil {
+		return subtitle.File{}, err
+	}
+	nfoPath := ""
+	if len(bundle.NFOFiles) > 0 {
+		nfoPath = bundle.NFOFiles[0].Path
+	}
+	result, err := transLator.Translate(ctx, nfoPath)
+	if err != nil {
+		return subtitle.File{}, fmt.Errorf("failed to translate to pivot %s: %w", pivot, err)
+	}
+
+	pivoted := result.TranslatedFile
+	pivoted.Path = source.Path
+	pivoted.Lines = make([]subtitle.Line, len(result.TranslatedFile.Lines))
+	for i, line := range result.TranslatedFile.Lines {
+		line.Text = line.TranslatedText
+		line.TranslatedText = ""
+		pivoted.Lines[i] = line
+	}
+	return pivoted, nil
+}
+
+// findPivotSubtitle reads the first readable subtitle in the pivot language
+// next to a media file, nil when there is none. Files that fail to parse
+// are skipped.
+func findPivotSubtitle(mediaPath string, pivot language.Tag) *subtitle.File {
+	paths, err := library.FindLanguageSubtitles(mediaPath, pivot)
+	if err != nil {
+		log.Warn("Failed to look up %s subtitles of %s: %v", pivot, mediaPath, err)
+		return nil
+	}
+	for _, path := range paths {
+		found, err := subtitle.NewReader(path).Read()
+		if err != nil {
+			log.Warn("Skipping pivot subtitle %s: %v", path, err)
+			continue
+		}
+		found.Language = pivot
+		return found
+	}
+	return nil
+}
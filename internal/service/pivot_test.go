@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/language"
+
+	"github.com/MimeLyc/contextual-sub-translator/internal/config"
+	"github.com/MimeLyc/contextual-sub-translator/internal/persistence"
+	"github.com/MimeLyc/contextual-sub-translator/internal/subtitle"
+	"github.com/MimeLyc/contextual-sub-translator/internal/translator"
+)
+
+const pivotTestSRT = "1\n00:00:01,000 --> 00:00:02,000\nこんにちは\n\n2\n00:00:03,000 --> 00:00:04,000\nさようなら\n"
+
+func pivotTestBundle(t *testing.T) MediaBundle {
+	t.Helper()
+	dir := t.TempDir()
+	mediaPath := filepath.Join(dir, "ep01.mkv")
+	sourcePath := filepath.Join(dir, "ep01.ja.srt")
+	require.NoError(t, os.WriteFile(mediaPath, []byte("m"), 0o644))
+	require.NoError(t, os.WriteFile(sourcePath, []byte(pivotTestSRT), 0o644))
+	source, err := subtitle.NewReader(sourcePath).Read()
+	require.NoError(t, err)
+	source.Language = language.Japanese
+	return MediaBundle{MediaFile: mediaPath, SubtitleFiles: []subtitle.File{*source}}
+}
+
+func pivotTestConfig(output bool) config.Config {
+	return config.Config{Translate: config.TranslateConfig{Pivot: language.English, PivotOutput: output}}
+}
+
+func TestUsesPivot(t *testing.T) {
+	cfg := pivotTestConfig(false)
+	assert.True(t, usesPivot(language.Japanese, language.Portuguese, cfg))
+	assert.True(t, usesPivot(language.Und, language.Portuguese, cfg))
+	assert.False(t, usesPivot(language.English, language.Portuguese, cfg), "the source already is in the pivot")
+	assert.False(t, usesPivot(language.Japanese, language.BritishEnglish, cfg), "the target is the pivot")
+	assert.False(t, usesPivot(language.Japanese, language.Portuguese, config.Config{}))
+}
+
+func TestTransService_PivotSubtitle_TranslatesThroughPivot(t *testing.T) {
+	store, err := persistence.NewSQLiteStore(filepath.Join(t.TempDir(), "ctxtrans.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = store.Close() })
+
+	bundle := pivotTestBundle(t)
+	source := bundle.SubtitleFiles[0]
+	translated := make([]subtitle.Line, len(source.Lines))
+	copy(translated, source.Lines)
+	translated[0].TranslatedText = "Hello"
+	translated[1].TranslatedText = "Goodbye"
+
+	mockTrans := &mockTranslator{}
+	mockTrans.On("BatchTranslate", mock.Anything, mock.AnythingOfType("translator.MediaMeta"), source.Lines, "ja", "en", 2).
+		Return(translated, nil).Once()
+
+	s := &transService{store: store}
+	pivoted, err := s.pivotSubtitle(context.Background(), bundle, "job-1", language.Portuguese, subtitle.OutputTranslated, mockTrans, pivotTestConfig(false))
+	require.NoError(t, err)
+	mockTrans.AssertExpectations(t)
+
+	assert.Equal(t, language.English, pivoted.Language)
+	assert.Equal(t, source.Path, pivoted.Path)
+	require.Len(t, pivoted.Lines, 2)
+	assert.Equal(t, "Hello", pivoted.Lines[0].Text)
+	assert.Empty(t, pivoted.Lines[0].TranslatedText)
+	assert.Equal(t, source.Lines[1].StartTime, pivoted.Lines[1].StartTime)
+
+	cps, err := store.LoadPivotCheckpoints(context.Background(), "job-1")
+	require.NoError(t, err)
+	require.Len(t, cps, 1)
+	assert.Equal(t, []string{"Hello", "Goodbye"}, cps[0].TranslatedLines)
+	cps, err = store.LoadBatchCheckpoints(context.Background(), "job-1")
+	require.NoError(t, err)
+	assert.Empty(t, cps, "the pass to the target keeps its own checkpoints")
+
+	_, err = os.Stat(filepath.Join(filepath.Dir(bundle.MediaFile), "ep01.ja_ctxtrans.en.srt"))
+	assert.True(t, os.IsNotExist(err), "the pivot translation is not written by default")
+
+	// A retry resumes from the checkpoints without another model call.
+	_, err = s.pivotSubtitle(context.Background(), bundle, "job-1", language.Portuguese, subtitle.OutputTranslated, mockTrans, pivotTestConfig(true))
+	require.NoError(t, err)
+	mockTrans.AssertNumberOfCalls(t, "BatchTranslate", 1)
+	_, err = os.Stat(filepath.Join(filepath.Dir(bundle.MediaFile), "ep01.ja_ctxtrans.en.srt"))
+	assert.NoError(t, err, "PivotOutput writes the pivot translation")
+}
+
+func TestTransService_PivotSubtitle_ReusesPivotSubtitleOnDisk(t *testing.T) {
+	bundle := pivotTestBundle(t)
+	pivotPath := filepath.Join(filepath.Dir(bundle.MediaFile), "ep01.eng.srt")
+	require.NoError(t, os.WriteFile(pivotPath, []byte("1\n00:00:01,000 --> 00:00:02,000\nHi there\n"), 0o644))
+
+	s := &transService{}
+	var cli translator.Translator = &mockTranslator{}
+	pivoted, err := s.pivotSubtitle(context.Background(), bundle, "", language.Portuguese, subtitle.OutputTranslated, cli, pivotTestConfig(false))
+	require.NoError(t, err)
+
+	assert.Equal(t, language.English, pivoted.Language)
+	assert.Equal(t, bundle.SubtitleFiles[0].Path, pivoted.Path, "outputs stay named after the source")
+	require.Len(t, pivoted.Lines, 1)
+	assert.Equal(t, "Hi there", pivoted.Lines[0].Text)
+
+	direct, err := s.pivotSubtitle(context.Background(), bundle, "", language.English, subtitle.OutputTranslated, cli, pivotTestConfig(false))
+	require.NoError(t, err)
+	assert.Equal(t, bundle.SubtitleFiles[0].Lines, direct.Lines, "no pivot when translating to it")
+}
+
+func TestTransService_RecordJobSource_KeepsPivotSubtitleForJobDetail(t *testing.T) {
+	store, err := persistence.NewSQLiteStore(filepath.Join(t.TempDir(), "ctxtrans.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = store.Close() })
+
+	bundle := pivotTestBundle(t)
+	pivotPath := filepath.Join(filepath.Dir(bundle.MediaFile), "ep01.eng.srt")
+	require.NoError(t, os.WriteFile(pivotPath, []byte("1\n00:00:01,500 --> 00:00:04,000\nHi there\n"), 0o644))
+
+	s := &transService{store: store}
+	pivoted, err := s.pivotSubtitle(context.Background(), bundle, "job-1", language.Portuguese, subtitle.OutputTranslated, &mockTranslator{}, pivotTestConfig(false))
+	require.NoError(t, err)
+	s.recordJobSource(context.Background(), bundle, "job-1", pivoted)
+	require.NoError(t, store.ClearJobTemp(context.Background(), "job-1"))
+
+	recorded, ok, err := store.GetSubtitleCache(context.Background(), jobSourceCacheKey("job-1"))
+	require.NoError(t, err)
+	require.True(t, ok, "the translated subtitle outlives the temporary job data")
+	assert.Equal(t, language.English, recorded.Language)
+	assert.Equal(t, bundle.SubtitleFiles[0].Path, recorded.Path)
+	require.Len(t, recorded.Lines, 1)
+	assert.Equal(t, "Hi there", recorded.Lines[0].Text)
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+
+	"github.com/MimeLyc/contextual-sub-translator/internal/config"
+	"github.com/MimeLyc/contextual-sub-translator/internal/persistence"
+	"github.com/MimeLyc/contextual-sub-translator/internal/subtitle"
+	"github.com/MimeLyc/contextual-sub-translator/internal/translator"
+	"github.com/MimeLyc/contextual-sub-translator/pkg/log"
+)
+
+// reviewBatch has the reviewer check the translated lines of a batch starting
+// at line start and translates the lines with issues at or above the review
+// severity again, in place. Songs kept in the original are not reviewed,
+// and a batch the reviewer fails on keeps its first translation.
+func (t *SubTranslator) reviewBatch(ctx context.Context, media translator.MediaMeta, lines []subtitle.Line, start int) {
+	if t.config.Reviewer == nil || len(lines) == 0 {
+		return
+	}
+	var positions []int
+	var reviewed []subtitle.Line
+	for i, line := range lines {
+		if line.Song && t.config.SongMode != "" && !t.config.SongMode.Translates() {
+			continue
+		}
+		if line.Text == "" {
+			continue
+		}
+		positions = append(positions, i)
+		reviewed = append(reviewed, line)
+	}
+	if len(reviewed) == 0 {
+		return
+	}
+
+	sourceLanguage := t.file.Language.String()
+	targetLanguage := t.config.TargetLanguage.String()
+	review, err := t.config.Reviewer.Review(withUsageStage(ctx, config.StageReview), media, reviewed, sourceLanguage, targetLanguage)
+	if err != nil {
+		log.Error("Failed to review lines %d-%d, keeping their translation: %v", start+1, start+len(lines), err)
+		return
+	}
+
+	threshold := t.config.ReviewSeverity
+	if threshold == "" {
+		threshold = translator.SeverityMajor
+	}
+	var retryPositions []int
+	var corrections []translator.ReviewNote
+	seen := make(map[int]bool)
+	for _, issue := range review.Issues {
+		if !issue.Severity.AtLeast(threshold) || seen[issue.Index] {
+			continue
+		}
+		seen[issue.Index] = true
+		line := reviewed[issue.Index]
+		retryPositions = append(retryPositions, positions[issue.Index])
+		corrections = append(corrections, translator.ReviewNote{
+			Source:      line.Text,
+			Translation: line.TranslatedText,
+			Note:        issue.Message,
+		})
+	}
+
+	retranslated := make(map[int]bool)
+	if len(retryPositions) > 0 {
+		retryLines := make([]subtitle.Line, 0, len(retryPositions))
+		for _, i := range retryPositions {
+			retryLines = append(retryLines, lines[i])
+		}
+		retryMedia := media
+		// The series memory would hand back the rejected translation
+		retryMedia.Memory = nil
+		retryMedia.Corrections = corrections
+		translated, err := t.translator.BatchTranslate(ctx, retryMedia, retryLines, sourceLanguage, targetLanguage, len(retryLines))
+		if err != nil || len(translated) != len(retryLines) {
+			log.Error("Failed to translate %d reviewed lines of lines %d-%d again, keeping their translation: %v", len(retryLines), start+1, start+len(lines), err)
+		} else {
+			for k, i := range retryPositions {
+				if translated[k].TranslatedText == "" {
+					continue
+				}
+				lines[i].TranslatedText = translated[k].TranslatedText
+				retranslated[i] = true
+			}
+		}
+	}
+	log.Info("Reviewed lines %d-%d: score %.0f, %d issues, %d lines translated again",
+		start+1, start+len(lines), review.Score, len(review.Issues), len(retranslated))
+
+	if t.config.RecordReview == nil {
+		return
+	}
+	record := persistence.TranslationReview{
+		BatchStart: start,
+		BatchEnd:   start + len(lines),
+		Score:      review.Score,
+	}
+	for _, issue := range review.Issues {
+		i := positions[issue.Index]
+		record.Issues = append(record.Issues, persistence.ReviewIssue{
+			Line:         start + i + 1,
+			Category:     issue.Category,
+			Severity:     string(issue.Severity),
+			Message:      issue.Message,
+			Retranslated: retranslated[i] && issue.Severity.AtLeast(threshold),
+		})
+	}
+	t.config.RecordReview(ctx, record)
+}
+
+// reviewRecorder stores the reviews of the batches of a job, nil when there
+// is no store or job to keep them for.
+func (s *transService) reviewRecorder(jobID string) func(ctx context.Context, review persistence.TranslationReview) {
+	if s.store == nil || jobID == "" {
+		return nil
+	}
+	return func(ctx context.Context, review persistence.TranslationReview) {
+		review.JobID = jobID
+		if err := s.store.PutTranslationReview(ctx, review); err != nil {
+			log.Warn("Failed to save review of lines %d-%d for job %s: %v", review.BatchStart+1, review.BatchEnd, jobID, err)
+		}
+	}
+}
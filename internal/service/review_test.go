@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/language"
+
+	"github.com/MimeLyc/contextual-sub-translator/internal/agent"
+	"github.com/MimeLyc/contextual-sub-translator/internal/persistence"
+	"github.com/MimeLyc/contextual-sub-translator/internal/subtitle"
+	"github.com/MimeLyc/contextual-sub-translator/internal/tools"
+	"github.com/MimeLyc/contextual-sub-translator/internal/translator"
+)
+
+func TestSubTranslator_ReviewRetranslatesSeriousIssues(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encoded, _ := json.Marshal(`{"score": 60, "issues": [` +
+			`{"index": 1, "category": "tone", "severity": "minor", "message": "A bit stiff."},` +
+			`{"index": 2, "category": "omission", "severity": "major", "message": "Drops the warning."}]}`)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"chatcmpl-1","object":"chat.completion","created":1,"model":"test-model",` +
+			`"choices":[{"index":0,"finish_reason":"stop","message":{"role":"assistant","content":` + string(encoded) + `}}],` +
+			`"usage":{"prompt_tokens":1,"completion_tokens":1,"total_tokens":2}}`))
+	}))
+	t.Cleanup(server.Close)
+	llmAgent, err := agent.NewLLMAgent(agent.LLMConfig{APIKey: "test-key", APIURL: server.URL, Model: "test-model", Timeout: 10}, tools.NewRegistry(), 1)
+	require.NoError(t, err)
+
+	lines := []subtitle.Line{
+		{Index: 1, Text: "Hello."},
+		{Index: 2, Text: "Watch out, it's a trap!"},
+	}
+	mockTrans := &mockTranslator{}
+	mockTrans.On("BatchTranslate", mock.Anything, mock.Anything, lines, "en", "zh", 2).Return([]subtitle.Line{
+		{Index: 1, Text: "Hello.", TranslatedText: "你好。"},
+		{Index: 2, Text: "Watch out, it's a trap!", TranslatedText: "小心！"},
+	}, nil).Once()
+	mockTrans.On(
+		"BatchTranslate",
+		mock.Anything,
+		mock.MatchedBy(func(media translator.MediaMeta) bool {
+			return media.Memory == nil && assert.ObjectsAreEqual([]translator.ReviewNote{
+				{Source: "Watch out, it's a trap!", Translation: "小心！", Note: "Drops the warning."},
+			}, media.Corrections)
+		}),
+		[]subtitle.Line{{Index: 2, Text: "Watch out, it's a trap!", TranslatedText: "小心！"}},
+		"en",
+		"zh",
+		1,
+	).Return([]subtitle.Line{
+		{Index: 2, Text: "Watch out, it's a trap!", TranslatedText: "小心，是陷阱！"},
+	}, nil).Once()
+
+	var recorded []persistence.TranslationReview
+	subTrans := &SubTranslator{
+		translator: mockTrans,
+		config: TranslatorConfig{
+			TargetLanguage: language.Chinese,
+			BatchSize:      2,
+			Reviewer:       translator.NewReviewer(llmAgent),
+			ReviewSeverity: translator.SeverityMajor,
+			RecordReview: func(_ context.Context, review persistence.TranslationReview) {
+				recorded = append(recorded, review)
+			},
+		},
+		file: &subtitle.File{Language: language.English},
+	}
+	cp := &inMemoryCheckpointStore{}
+	ctx := withBatchCheckpointStore(context.Background(), cp)
+
+	ret, err := subTrans.translateSubtitleLines(ctx, translator.MediaMeta{Memory: fakeServiceMemory{}}, lines)
+	require.NoError(t, err)
+	assert.Equal(t, "你好。", ret[0].TranslatedText)
+	assert.Equal(t, "小心，是陷阱！", ret[1].TranslatedText)
+	assert.Equal(t, []string{"你好。", "小心，是陷阱！"}, cp.saved[batchKey(0, 2)], "checkpoints hold the reviewed translation")
+
+	require.Len(t, recorded, 1)
+	assert.Equal(t, persistence.TranslationReview{
+		BatchStart: 0,
+		BatchEnd:   2,
+		Score:      60,
+		Issues: []persistence.ReviewIssue{
+			{Line: 1, Category: "tone", Severity: "minor", Message: "A bit stiff."},
+			{Line: 2, Category: "omission", Severity: "major", Message: "Drops the warning.", Retranslated: true},
+		},
+	}, recorded[0])
+	mockTrans.AssertExpectations(t)
+}
+
+// fakeServiceMemory recalls nothing; it only has to be cleared for retries
+type fakeServiceMemory struct{}
+
+func (fakeServiceMemory) Exact(string) (string, bool)             { return "", false }
+func (fakeServiceMemory) Similar(string) []translator.MemoryMatch { return nil }
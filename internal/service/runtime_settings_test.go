@@ -2,9 +2,14 @@ package service
 
 import (
 	"context"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/MimeLyc/contextual-sub-translator/internal/config"
+	"github.com/MimeLyc/contextual-sub-translator/internal/jobs"
+	"github.com/MimeLyc/contextual-sub-translator/internal/persistence"
+	"github.com/MimeLyc/contextual-sub-translator/internal/subtitle"
 	"github.com/robfig/cron/v3"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -40,6 +45,7 @@ func TestTransService_ApplyRuntimeSettings_ReschedulesCronAndUpdatesConfig(t *te
 		LLMModel:       "new-model",
 		CronExpr:       "*/10 * * * *",
 		TargetLanguage: "en",
+		OutputMode:     "bilingual_styled",
 	})
 	require.NoError(t, err)
 
@@ -48,5 +54,66 @@ func TestTransService_ApplyRuntimeSettings_ReschedulesCronAndUpdatesConfig(t *te
 	assert.Equal(t, "https://new.example/v1", svc.cfg.LLM.APIURL)
 	assert.Equal(t, "new-model", svc.cfg.LLM.Model)
 	assert.Equal(t, language.English, svc.cfg.Translate.TargetLanguage)
+	assert.Equal(t, subtitle.OutputBilingualStyled, svc.cfg.Translate.OutputMode)
 	require.Len(t, cronEngine.Entries(), 1)
+
+	require.NoError(t, svc.ApplyRuntimeSettings(config.RuntimeSettings{
+		LLMAPIURL:       "https://new.example/v1",
+		LLMAPIKey:       "new-ak",
+		LLMModel:        "new-model",
+		CronExpr:        "*/10 * * * *",
+		TargetLanguage:  "en",
+		TargetLanguages: []string{"zh-Hant", "en"},
+	}))
+	assert.Equal(t, language.TraditionalChinese, svc.cfg.Translate.TargetLanguage)
+	assert.Equal(t, []language.Tag{language.TraditionalChinese, language.English}, svc.cfg.Translate.TargetLanguages)
+}
+
+func TestTransService_ApplyRuntimeSettings_RaisedBudgetReleasesHeldJobs(t *testing.T) {
+	store, err := persistence.NewSQLiteStore(filepath.Join(t.TempDir(), "ctxtrans.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = store.Close() })
+	require.NoError(t, store.AddLLMUsage(context.Background(), persistence.LLMUsage{JobID: "job-0", Cost: 5}))
+
+	queue := jobs.NewQueue(1, nil)
+	svc := NewRunnableTransServiceWithQueueAndStore(
+		config.Config{
+			LLM: config.LLMConfig{APIKey: "ak", APIURL: "https://example.test/v1", Model: "model"},
+			Translate: config.TranslateConfig{
+				TargetLanguage: language.Chinese,
+				CronExpr:       "0 0 * * *",
+			},
+			Budget: config.BudgetConfig{Daily: config.BudgetCap{Cost: 5}},
+		},
+		cron.New(),
+		queue,
+		store,
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, svc.Schedule(ctx))
+
+	job, _ := queue.Enqueue(jobs.EnqueueRequest{
+		Source:    "manual",
+		DedupeKey: "missing",
+		Payload:   jobs.JobPayload{MediaFile: filepath.Join(t.TempDir(), "missing.mkv")},
+	})
+	require.Eventually(t, func() bool {
+		got, _ := queue.Get(job.ID)
+		return got.HoldReason == "daily budget reached: $5.00 of $5.00 spent"
+	}, time.Second, 10*time.Millisecond)
+
+	require.NoError(t, svc.ApplyRuntimeSettings(config.RuntimeSettings{
+		LLMAPIURL:      "https://example.test/v1",
+		LLMAPIKey:      "ak",
+		LLMModel:       "model",
+		CronExpr:       "0 0 * * *",
+		TargetLanguage: "zh",
+		Budget:         &config.BudgetConfig{Daily: config.BudgetCap{Cost: 10}},
+	}))
+	require.Eventually(t, func() bool {
+		got, _ := queue.Get(job.ID)
+		return got.Status != jobs.StatusPending
+	}, time.Second, 10*time.Millisecond, "the raised budget lets the job run")
 }
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"path/filepath"
+
+	"golang.org/x/text/language"
+
+	"github.com/MimeLyc/contextual-sub-translator/internal/config"
+	"github.com/MimeLyc/contextual-sub-translator/internal/library"
+	"github.com/MimeLyc/contextual-sub-translator/internal/media"
+	"github.com/MimeLyc/contextual-sub-translator/internal/subtitle"
+	"github.com/MimeLyc/contextual-sub-translator/internal/translator"
+	"github.com/MimeLyc/contextual-sub-translator/internal/zhconv"
+	"github.com/MimeLyc/contextual-sub-translator/pkg/log"
+)
+
+// scriptSource returns the subtitle a Chinese target can be converted from
+// without the model: the source of the job when it is in another variant of
+// Chinese, or else such a subtitle next to the media, e.g. the output of the
+// zh-Hans job for the zh-Hant one. The original text a bilingual output
+// mode stacked into such a subtitle is stripped again, in the layout found
+// in the file since OUTPUT_MODE may have changed since it was written.
+func scriptSource(bundle MediaBundle, target language.Tag) (subtitle.File, bool) {
+	to, ok := zhconv.VariantOf(target)
+	if !ok {
+		return subtitle.File{}, false
+	}
+
+	source := bundle.SubtitleFiles[0]
+	if source.Language == language.Und {
+		if lang, ok := subtitlePathLanguage(source.Path); ok {
+			source.Language = lang
+		}
+	}
+	if from, ok := zhconv.VariantOf(source.Language); ok && from != to {
+		return source, true
+	}
+
+	paths, err := library.FindLanguageSubtitles(bundle.MediaFile, language.Chinese)
+	if err != nil {
+		log.Warn("Failed to look up Chinese subtitles of %s: %v", bundle.MediaFile, err)
+		return subtitle.File{}, false
+	}
+	for _, path := range paths {
+		lang, ok := subtitlePathLanguage(path)
+		if !ok {
+			continue
+		}
+		if from, ok := zhconv.VariantOf(lang); !ok || from == to {
+			continue
+		}
+		found, err := subtitle.NewReader(path).Read()
+		if err != nil {
+			log.Warn("Skipping subtitle %s to convert: %v", path, err)
+			continue
+		}
+		original := bundle.SubtitleFiles[0].Lines
+		found = subtitle.StripOriginal(found, original, subtitle.DetectOutputMode(found, original))
+		found.Language = lang
+		return *found, true
+	}
+	return subtitle.File{}, false
+}
+
+// convertScript writes the target of a job converted from source with
+// translator.NewScriptConverter. The other stages of a translation are
+// skipped, so the job makes no model calls. The source is recorded for the
+// job detail, it can be another subtitle than the one of the job.
+func (s *transService) convertScript(
+	ctx context.Context,
+	bundle MediaBundle,
+	jobID string,
+	source subtitle.File,
+	target language.Tag,
+	outputMode subtitle.OutputMode,
+	cfg config.Config,
+) error {
+	log.Info("Converting subtitle media %s from %s to %s", bundle.MediaFile, source.Language, target)
+	s.recordJobSource(ctx, bundle, jobID, source)
+	translatorConfig := TranslatorConfig{
+		TargetLanguage: target,
+		SubtitleFile:   &source,
+		OutputDir:      filepath.Dir(bundle.MediaFile),
+		InputPath:      bundle.SubtitleFiles[0].Path,
+		OutputMode:     outputMode,
+		OutputEncoding: cfg.Translate.OutputEncoding,
+	}
+	transLator, err := NewTranslator(translatorConfig, translator.NewScriptConverter())
+	if err != nil {
+		return err
+	}
+	if _, err := transLator.Translate(ctx, ""); err != nil {
+		log.Error("Failed to convert subtitle media %s: %v", bundle.MediaFile, err)
+		return err
+	}
+	log.Info("Converted subtitle media %s", bundle.MediaFile)
+	muxTranslatedSubtitle(
+		media.NewOperator(bundle.MediaFile),
+		bundle.MediaFile,
+		translatorConfig.OutputPath(),
+		target,
+		cfg.Media.MuxModeFor(bundle.MediaFile),
+	)
+	if s.store != nil && jobID != "" {
+		if err := s.store.ClearJobTemp(ctx, jobID); err != nil {
+			log.Warn("Failed to clear temporary data for job %s: %v", jobID, err)
+		}
+	}
+	return nil
+}
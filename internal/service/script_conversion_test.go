@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/language"
+
+	"github.com/MimeLyc/contextual-sub-translator/internal/config"
+	"github.com/MimeLyc/contextual-sub-translator/internal/persistence"
+	"github.com/MimeLyc/contextual-sub-translator/internal/subtitle"
+)
+
+func TestScriptSource(t *testing.T) {
+	bundle := pivotTestBundle(t)
+	_, ok := scriptSource(bundle, language.TraditionalChinese)
+	assert.False(t, ok, "no Chinese subtitle to convert from yet")
+
+	hansPath := filepath.Join(filepath.Dir(bundle.MediaFile), "ep01.ja_ctxtrans.zh-Hans.srt")
+	require.NoError(t, os.WriteFile(hansPath, []byte("1\n00:00:01,000 --> 00:00:02,000\n你好\n\n2\n00:00:03,000 --> 00:00:04,000\n再见\n"), 0o644))
+
+	source, ok := scriptSource(bundle, language.TraditionalChinese)
+	require.True(t, ok)
+	assert.Equal(t, hansPath, source.Path)
+	assert.Equal(t, language.SimplifiedChinese, source.Language)
+
+	_, ok = scriptSource(bundle, language.SimplifiedChinese)
+	assert.False(t, ok, "the target's own variant is not converted")
+	_, ok = scriptSource(bundle, language.English)
+	assert.False(t, ok)
+
+	bundle.SubtitleFiles[0].Language = language.MustParse("zh-TW")
+	source, ok = scriptSource(bundle, language.SimplifiedChinese)
+	require.True(t, ok)
+	assert.Equal(t, bundle.SubtitleFiles[0].Path, source.Path, "a Chinese source is converted directly")
+}
+
+func TestScriptSource_StripsBilingualOriginal(t *testing.T) {
+	// the layout is read from the sidecar, whatever the output mode is now
+	for name, content := range map[string]string{
+		"translated first": "1\n00:00:01,000 --> 00:00:02,000\n你好\nこんにちは\n\n2\n00:00:03,000 --> 00:00:04,000\n再见\nさようなら\n",
+		"original first":   "1\n00:00:01,000 --> 00:00:02,000\nこんにちは\n你好\n\n2\n00:00:03,000 --> 00:00:04,000\nさようなら\n再见\n",
+		"translated only":  "1\n00:00:01,000 --> 00:00:02,000\n你好\n\n2\n00:00:03,000 --> 00:00:04,000\n再见\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			bundle := pivotTestBundle(t)
+			hansPath := filepath.Join(filepath.Dir(bundle.MediaFile), "ep01.ja_ctxtrans.zh-Hans.srt")
+			require.NoError(t, os.WriteFile(hansPath, []byte(content), 0o644))
+
+			source, ok := scriptSource(bundle, language.TraditionalChinese)
+			require.True(t, ok)
+			require.Len(t, source.Lines, 2)
+			assert.Equal(t, "你好", source.Lines[0].Text)
+			assert.Equal(t, "再见", source.Lines[1].Text)
+		})
+	}
+}
+
+func TestTransService_ConvertScript(t *testing.T) {
+	bundle := pivotTestBundle(t)
+	dir := filepath.Dir(bundle.MediaFile)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "ep01.ja_ctxtrans.zh-Hans.srt"),
+		[]byte("1\n00:00:01,000 --> 00:00:02,000\n头发干净了\n\n2\n00:00:03,000 --> 00:00:04,000\n没关系\n"), 0o644))
+	source, ok := scriptSource(bundle, language.TraditionalChinese)
+	require.True(t, ok)
+
+	store, err := persistence.NewSQLiteStore(filepath.Join(t.TempDir(), "ctxtrans.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = store.Close() })
+
+	s := &transService{store: store}
+	cfg := config.Config{Translate: config.TranslateConfig{ScriptConversion: true}}
+	require.NoError(t, s.convertScript(context.Background(), bundle, "job-1", source, language.TraditionalChinese, subtitle.OutputTranslated, cfg))
+
+	converted, err := subtitle.NewReader(filepath.Join(dir, "ep01.ja_ctxtrans.zh-Hant.srt")).Read()
+	require.NoError(t, err)
+	require.Len(t, converted.Lines, 2)
+	assert.Equal(t, "頭髮乾淨了", converted.Lines[0].Text)
+	assert.Equal(t, "沒關係", converted.Lines[1].Text)
+	assert.Equal(t, source.Lines[1].StartTime, converted.Lines[1].StartTime)
+
+	// The job detail pairs the output with the subtitle it was converted from
+	recorded, ok, err := store.GetSubtitleCache(context.Background(), jobSourceCacheKey("job-1"))
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, language.SimplifiedChinese, recorded.Language)
+	assert.Equal(t, bundle.SubtitleFiles[0].Path, recorded.Path)
+	require.Len(t, recorded.Lines, 2)
+	assert.Equal(t, "头发干净了", recorded.Lines[0].Text)
+}
@@ -2,7 +2,9 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"slices"
@@ -13,11 +15,13 @@ import (
 	"golang.org/x/sync/errgroup"
 	"golang.org/x/sync/singleflight"
 	"golang.org/x/text/language"
+	"golang.org/x/text/language/display"
 
 	"github.com/MimeLyc/contextual-sub-translator/internal/agent"
 	"github.com/MimeLyc/contextual-sub-translator/internal/config"
 	"github.com/MimeLyc/contextual-sub-translator/internal/jobs"
 	"github.com/MimeLyc/contextual-sub-translator/internal/media"
+	"github.com/MimeLyc/contextual-sub-translator/internal/ocr"
 	"github.com/MimeLyc/contextual-sub-translator/internal/persistence"
 	"github.com/MimeLyc/contextual-sub-translator/internal/subtitle"
 	"github.com/MimeLyc/contextual-sub-translator/internal/termmap"
@@ -38,6 +42,7 @@ type transService struct {
 	store          *persistence.SQLiteStore
 	runFunc        func()
 	cronEntryID    cron.EntryID
+	ocrEngine      ocr.Engine
 }
 
 func NewRunnableTransService(
@@ -45,9 +50,10 @@ func NewRunnableTransService(
 	cron *cron.Cron,
 ) transService {
 	return transService{
-		cfg:      cfg,
-		cronExpr: cfg.Translate.CronExpr,
-		cron:     cron,
+		cfg:       cfg,
+		cronExpr:  cfg.Translate.CronExpr,
+		cron:      cron,
+		ocrEngine: ocr.NewTesseract(cfg.OCR.Command),
 	}
 }
 
@@ -83,9 +89,12 @@ func (s *transService) enqueueBundle(source string, bundle MediaPathBundle) (*jo
 	if s.jobQueue == nil {
 		return nil, false, fmt.Errorf("job queue is not configured")
 	}
+	cfg := s.configSnapshot()
 	dedupeKey := s.bundleDedupeKey(bundle)
 	payload := jobs.JobPayload{
-		MediaFile: bundle.MediaFile,
+		MediaFile:      bundle.MediaFile,
+		OutputMode:     string(cfg.Translate.OutputMode),
+		TargetLanguage: bundleTarget(bundle.TargetLanguage, cfg).String(),
 	}
 	if len(bundle.SubtitleFiles) > 0 {
 		payload.SubtitleFile = bundle.SubtitleFiles[0]
@@ -111,10 +120,19 @@ func (s *transService) bundleDedupeKey(bundle MediaPathBundle) string {
 		"%s|%s|%s",
 		bundle.MediaFile,
 		subPath,
-		cfg.Translate.TargetLanguage.String(),
+		bundleTarget(bundle.TargetLanguage, cfg).String(),
 	)
 }
 
+// bundleTarget returns the language a bundle translates to, the primary
+// configured target language when the bundle has none
+func bundleTarget(target language.Tag, cfg config.Config) language.Tag {
+	if target == language.Und {
+		return cfg.Translate.TargetLanguage
+	}
+	return target
+}
+
 var singleflightGroup singleflight.Group
 var termMapFileLocks sync.Map
 
@@ -135,12 +153,13 @@ func (s *transService) Schedule(
 ) error {
 	log.Info("Run TransService")
 	if s.jobQueue != nil {
+		s.jobQueue.SetGate(s.budgetHold)
 		s.jobQueue.Start(func(execCtx context.Context, job *jobs.TranslationJob) error {
-			llmAgent, searchEnabled, err := s.buildAgent()
+			agents, searchEnabled, err := s.buildAgents()
 			if err != nil {
 				return err
 			}
-			return s.processJob(execCtx, job, llmAgent, searchEnabled)
+			return s.processJob(execCtx, job, agents, searchEnabled)
 		})
 		go func() {
 			<-ctx.Done()
@@ -150,6 +169,10 @@ func (s *transService) Schedule(
 
 	runFunc := func() {
 		_, _, _ = singleflightGroup.Do("run", func() (any, error) {
+			if s.jobQueue != nil {
+				// jobs held by the daily budget may run on a new day
+				s.jobQueue.Release()
+			}
 			cfg := s.configSnapshot()
 			for _, dir := range cfg.Media.MediaPaths() {
 				log.Info("Run in dir %s", dir)
@@ -181,9 +204,13 @@ func (s *transService) ApplyRuntimeSettings(next config.RuntimeSettings) error {
 	if err := next.Validate(); err != nil {
 		return err
 	}
-	targetTag, err := language.Parse(next.TargetLanguage)
+	targetTags, err := next.Languages()
 	if err != nil {
-		return fmt.Errorf("invalid target_language: %w", err)
+		return err
+	}
+	outputMode, err := subtitle.ParseOutputMode(next.OutputMode)
+	if err != nil {
+		return fmt.Errorf("invalid output_mode: %w", err)
 	}
 
 	oldEntryID, oldCronExpr, runFunc := s.scheduleSnapshot()
@@ -200,14 +227,25 @@ func (s *transService) ApplyRuntimeSettings(next config.RuntimeSettings) error {
 	}
 
 	s.mu.Lock()
+	s.cfg.LLM.Provider = next.LLMProvider
 	s.cfg.LLM.APIURL = next.LLMAPIURL
 	s.cfg.LLM.APIKey = next.LLMAPIKey
 	s.cfg.LLM.Model = next.LLMModel
 	s.cfg.Translate.CronExpr = next.CronExpr
-	s.cfg.Translate.TargetLanguage = targetTag
+	s.cfg.Translate.TargetLanguage = targetTags[0]
+	s.cfg.Translate.TargetLanguages = targetTags
+	s.cfg.Translate.OutputMode = outputMode
+	if next.Budget != nil {
+		s.cfg.Budget = *next.Budget
+	}
 	s.cronExpr = next.CronExpr
 	s.cronEntryID = newEntryID
 	s.mu.Unlock()
+
+	if s.jobQueue != nil {
+		// a raised budget lets held jobs run
+		s.jobQueue.Release()
+	}
 	return nil
 }
 
@@ -217,6 +255,11 @@ func (s *transService) run(
 ) error {
 	s.cleanupExpiredCaches(ctx)
 
+	if reason := s.globalBudgetReason(ctx); reason != "" {
+		log.Warn("Skipping scheduled translation in dir %s: %s", dir, reason)
+		return nil
+	}
+
 	toTrans, err := s.findTargetMediaTuplesInDir(ctx, dir)
 	if err != nil {
 		log.Error("Failed to find target media tuples in dir %s: %v", dir, err)
@@ -233,7 +276,7 @@ func (s *transService) run(
 		return nil
 	}
 
-	llmAgent, searchEnabled, err := s.buildAgent()
+	agents, searchEnabled, err := s.buildAgents()
 	if err != nil {
 		return err
 	}
@@ -242,7 +285,7 @@ func (s *transService) run(
 	bundleConcurrency := max(1, cfg.Agent.BundleConcurrency)
 	if bundleConcurrency == 1 {
 		for _, bundle := range toTrans {
-			if err := s.processBundle(ctx, bundle, "", llmAgent, searchEnabled); err != nil {
+			if err := s.processBundle(ctx, bundle, "", agents, searchEnabled); err != nil {
 				return err
 			}
 		}
@@ -262,16 +305,28 @@ func (s *transService) run(
 				return groupCtx.Err()
 			}
 			defer func() { <-sem }()
-			return s.processBundle(groupCtx, bundle, "", llmAgent, searchEnabled)
+			return s.processBundle(groupCtx, bundle, "", agents, searchEnabled)
 		})
 	}
 
 	return group.Wait()
 }
 
-func (s *transService) buildAgent() (*agent.LLMAgent, bool, error) {
+// stageAgents are the model routes of the LLM stages of a job
+type stageAgents struct {
+	translate agent.Route
+	// repair is empty when the repair attempt runs on the translate models
+	repair   agent.Route
+	terms    agent.Route
+	context  agent.Route
+	condense agent.Route
+	review   agent.Route
+}
+
+func (s *transService) buildAgents() (stageAgents, bool, error) {
 	cfg := s.configSnapshot()
 	llmConfig := agent.LLMConfig{
+		Provider:    cfg.LLM.Provider,
 		APIKey:      cfg.LLM.APIKey,
 		APIURL:      cfg.LLM.APIURL,
 		Model:       cfg.LLM.Model,
@@ -293,12 +348,32 @@ func (s *transService) buildAgent() (*agent.LLMAgent, bool, error) {
 		}
 	}
 
-	llmAgent, err := agent.NewLLMAgent(llmConfig, registry, cfg.Agent.MaxIterations)
-	if err != nil {
-		log.Error("Failed to create agent-core-go agent: %v", err)
-		return nil, false, err
+	var agents stageAgents
+	for _, stage := range []struct {
+		name  string
+		route *agent.Route
+	}{
+		{config.StageTranslate, &agents.translate},
+		{config.StageRepair, &agents.repair},
+		{config.StageTerms, &agents.terms},
+		{config.StageContext, &agents.context},
+		{config.StageCondense, &agents.condense},
+		{config.StageReview, &agents.review},
+	} {
+		models := cfg.LLM.ModelsFor(stage.name)
+		if len(models) == 0 {
+			continue
+		}
+		route, err := agent.NewRoute(llmConfig, models, registry, cfg.Agent.MaxIterations)
+		if err != nil {
+			log.Error("Failed to create %s agent for stage %s: %v", cfg.LLM.Provider, stage.name, err)
+			return stageAgents{}, false, err
+		}
+		*stage.route = route
 	}
-	return llmAgent, searchEnabled, nil
+	log.Debug("LLM stage models: translate=%v repair=%v terms=%v context=%v condense=%v review=%v",
+		agents.translate.Models(), agents.repair.Models(), agents.terms.Models(), agents.context.Models(), agents.condense.Models(), agents.review.Models())
+	return agents, searchEnabled, nil
 }
 
 func (s *transService) enqueueCronMediaBundle(bundle MediaBundle) error {
@@ -308,8 +383,9 @@ func (s *transService) enqueueCronMediaBundle(bundle MediaBundle) error {
 	}
 
 	pathBundle := MediaPathBundle{
-		MediaFile:     bundle.MediaFile,
-		SubtitleFiles: []string{bundle.SubtitleFiles[0].Path},
+		MediaFile:      bundle.MediaFile,
+		SubtitleFiles:  []string{bundle.SubtitleFiles[0].Path},
+		TargetLanguage: bundle.TargetLanguage,
 	}
 	if len(bundle.NFOFiles) > 0 {
 		pathBundle.NFOFiles = []string{bundle.NFOFiles[0].Path}
@@ -319,10 +395,11 @@ func (s *transService) enqueueCronMediaBundle(bundle MediaBundle) error {
 	if err != nil {
 		return err
 	}
+	target := bundleTarget(bundle.TargetLanguage, s.configSnapshot())
 	if created {
-		log.Info("Queued cron job %s for media %s", job.ID, bundle.MediaFile)
+		log.Info("Queued cron job %s for media %s to %s", job.ID, bundle.MediaFile, target)
 	} else {
-		log.Info("Skipped duplicated cron job %s for media %s", job.ID, bundle.MediaFile)
+		log.Info("Skipped duplicated cron job %s for media %s to %s", job.ID, bundle.MediaFile, target)
 	}
 	return nil
 }
@@ -330,7 +407,7 @@ func (s *transService) enqueueCronMediaBundle(bundle MediaBundle) error {
 func (s *transService) processJob(
 	ctx context.Context,
 	job *jobs.TranslationJob,
-	llmAgent *agent.LLMAgent,
+	agents stageAgents,
 	searchEnabled bool,
 ) error {
 	if job == nil {
@@ -345,10 +422,23 @@ func (s *transService) processJob(
 		return err
 	}
 
+	outputMode, err := subtitle.ParseOutputMode(job.Payload.OutputMode)
+	if err != nil {
+		return err
+	}
 	bundle := MediaBundle{
 		MediaFile:     job.Payload.MediaFile,
 		SubtitleFiles: []subtitle.File{*subFile},
 	}
+	if strings.TrimSpace(job.Payload.OutputMode) != "" {
+		bundle.OutputMode = outputMode
+	}
+	if strings.TrimSpace(job.Payload.TargetLanguage) != "" {
+		bundle.TargetLanguage, err = language.Parse(job.Payload.TargetLanguage)
+		if err != nil {
+			return fmt.Errorf("invalid target_language: %w", err)
+		}
+	}
 	if job.Payload.NFOFile != "" {
 		nfoInfo, err := NewNFOReader().ReadTVShowInfo(job.Payload.NFOFile)
 		if err != nil {
@@ -358,21 +448,52 @@ func (s *transService) processJob(
 		}
 	}
 
-	return s.processBundle(ctx, bundle, job.ID, llmAgent, searchEnabled)
+	return s.processBundle(ctx, bundle, job.ID, agents, searchEnabled)
 }
 
+// processBundle translates the first subtitle of a bundle to its target.
+// Only reading and translating the subtitle fail the job. The stages around
+// them, such as story memory, speakers, songs, translation memory, review
+// and muxing, are best effort: failures are only logged and the job goes on
+// without their result.
 func (s *transService) processBundle(
 	ctx context.Context,
 	bundle MediaBundle,
 	jobID string,
-	llmAgent *agent.LLMAgent,
+	agents stageAgents,
 	searchEnabled bool,
 ) error {
 	if len(bundle.SubtitleFiles) == 0 {
 		log.Info("Skipping media %s: no subtitle files available", bundle.MediaFile)
 		return nil
 	}
-	translateCtx := ctx
+	cfg := s.configSnapshot()
+	target := bundleTarget(bundle.TargetLanguage, cfg)
+	seriesKey := findSeriesDir(bundle.NFOFiles, filepath.Dir(bundle.MediaFile))
+	ctx, releaseUsage := s.withUsageRecording(ctx, jobID, seriesKey, cfg)
+	defer releaseUsage()
+	translateCtx := withUsageStage(ctx, config.StageTranslate)
+	agentTranslator := translator.NewRoutedTranslator(agents.translate, agents.repair, searchEnabled)
+
+	outputMode := bundle.OutputMode
+	if outputMode == "" {
+		outputMode = cfg.Translate.OutputMode
+	}
+	if cfg.Translate.ScriptConversion {
+		if source, ok := scriptSource(bundle, target); ok {
+			return s.convertScript(ctx, bundle, jobID, source, target, outputMode, cfg)
+		}
+	}
+
+	targetSub, err := s.pivotSubtitle(translateCtx, bundle, jobID, target, outputMode, agentTranslator, cfg)
+	if err != nil {
+		if cause := context.Cause(ctx); errors.Is(cause, errBudgetExceeded) {
+			err = cause
+		}
+		log.Error("Failed to translate subtitle media %s: %v", bundle.MediaFile, err)
+		return err
+	}
+	s.recordJobSource(ctx, bundle, jobID, targetSub)
 	if s.store != nil && jobID != "" {
 		checkpointStore, err := newPersistentBatchCheckpointStore(translateCtx, s.store, jobID)
 		if err != nil {
@@ -381,16 +502,14 @@ func (s *transService) processBundle(
 			translateCtx = withBatchCheckpointStore(translateCtx, checkpointStore)
 		}
 	}
-	targetSub := bundle.SubtitleFiles[0]
-	agentTranslator := translator.NewAgentTranslator(llmAgent, searchEnabled)
-	cfg := s.configSnapshot()
 
 	var termMapData map[string]string
 	srcLang := targetSub.Language.String()
-	tgtLang := cfg.Translate.TargetLanguage.String()
+	tgtLang := target.String()
 	mediaDir := filepath.Dir(bundle.MediaFile)
 
 	tmPath := termmap.FindInAncestors(mediaDir, srcLang, tgtLang)
+	termMapPath := tmPath
 	if tmPath != "" {
 		tm, err := termmap.Load(tmPath)
 		if err != nil {
@@ -400,35 +519,56 @@ func (s *transService) processBundle(
 			log.Info("Loaded term map from %s (%d terms)", tmPath, len(tm))
 		}
 	} else if searchEnabled && len(bundle.NFOFiles) > 0 {
-		gen := termmap.NewGenerator(llmAgent)
-		tm, err := gen.Generate(ctx, bundle.NFOFiles[0], srcLang, tgtLang)
+		gen := termmap.NewGenerator(agents.terms)
+		tm, err := gen.Generate(withUsageStage(ctx, config.StageTerms), bundle.NFOFiles[0], srcLang, tgtLang)
 		if err != nil {
 			log.Error("Failed to generate term map: %v", err)
 		} else {
 			saveDir := findTermMapSaveDir(bundle.NFOFiles, mediaDir)
 			savePath := termmap.FilePath(saveDir, srcLang, tgtLang)
-			merged, err := saveMergedTermMap(savePath, termMapData, tm)
+			merged, err := saveMergedTermMap(savePath, termMapData, s.withoutLockedTerms(ctx, savePath, tm))
 			if err != nil {
 				log.Error("Failed to save term map to %s: %v", savePath, err)
 			} else {
 				termMapData = merged
+				termMapPath = savePath
 				log.Info("Generated and saved term map to %s (%d terms)", savePath, len(tm))
 			}
 		}
 	}
 
-	log.Info("Translating subtitle media %s from %s to %s", bundle.MediaFile, targetSub.Language, cfg.Translate.TargetLanguage)
-	transLator, err := NewTranslator(
-		TranslatorConfig{
-			TargetLanguage: cfg.Translate.TargetLanguage,
-			ContextEnabled: true,
-			SubtitleFile:   &targetSub,
-			OutputDir:      filepath.Dir(bundle.MediaFile),
-			InputPath:      targetSub.Path,
-			TermMap:        termMapData,
-		},
-		agentTranslator,
-	)
+	memory := s.loadStoryMemory(ctx, bundle)
+	characters := s.attributeSpeakers(withUsageStage(ctx, config.StageContext), agents.context, bundle, memory.seriesKey, &targetSub)
+	s.markSongs(ctx, seriesKey, &targetSub)
+	songMode := s.seriesSongMode(ctx, seriesKey, cfg)
+
+	log.Info("Translating subtitle media %s from %s to %s", bundle.MediaFile, targetSub.Language, target)
+	translatorConfig := TranslatorConfig{
+		TargetLanguage: target,
+		ContextEnabled: true,
+		SubtitleFile:   &targetSub,
+		OutputDir:      filepath.Dir(bundle.MediaFile),
+		InputPath:      targetSub.Path,
+		TermMap:        termMapData,
+		OutputMode:     outputMode,
+		OutputEncoding: cfg.Translate.OutputEncoding,
+
+		PreviousEpisodes: memory.previousEpisodes,
+		Relationships:    memory.relationships,
+		Characters:       characters,
+		Memory:           s.loadTranslationMemory(ctx, seriesKey, srcLang, tgtLang),
+		SongMode:         songMode,
+		ReadingLimits:    cfg.Translate.Reading.For(target),
+	}
+	if cfg.Translate.Condense && len(agents.condense) > 0 {
+		translatorConfig.Condenser = translator.NewCondenser(agents.condense)
+	}
+	if cfg.Translate.Review && len(agents.review) > 0 {
+		translatorConfig.Reviewer = translator.NewReviewer(agents.review)
+		translatorConfig.ReviewSeverity = cfg.Translate.ReviewSeverity
+		translatorConfig.RecordReview = s.reviewRecorder(jobID)
+	}
+	transLator, err := NewTranslator(translatorConfig, agentTranslator)
 	if err != nil {
 		log.Error("Failed to create translator: %v", err)
 		return err
@@ -438,31 +578,45 @@ func (s *transService) processBundle(
 	if len(bundle.NFOFiles) > 0 {
 		nfoPath = bundle.NFOFiles[0].Path
 	}
-	if _, err := transLator.Translate(translateCtx, nfoPath); err != nil {
+	result, err := transLator.Translate(translateCtx, nfoPath)
+	if err != nil {
+		if cause := context.Cause(ctx); errors.Is(cause, errBudgetExceeded) {
+			err = cause
+		}
 		log.Error("Failed to translate subtitle media %s: %v", bundle.MediaFile, err)
 		return err
 	}
 	log.Info("Translated subtitle media %s", bundle.MediaFile)
+	s.rememberTranslations(ctx, seriesKey, srcLang, tgtLang, memoryLines(result.TranslatedFile.Lines, songMode), false)
+	s.recordTermUsage(ctx, jobID, termMapPath, termMapData, result.OriginalFile.Lines)
+	muxTranslatedSubtitle(
+		media.NewOperator(bundle.MediaFile),
+		bundle.MediaFile,
+		translatorConfig.OutputPath(),
+		target,
+		cfg.Media.MuxModeFor(bundle.MediaFile),
+	)
 	if s.store != nil && jobID != "" {
 		if err := s.store.ClearJobTemp(ctx, jobID); err != nil {
 			log.Warn("Failed to clear temporary data for job %s: %v", jobID, err)
 		}
 	}
+	s.saveEpisodeSummary(withUsageStage(ctx, config.StageContext), agents.context, bundle, memory, result.OriginalFile.Lines)
 
 	if discoverer, ok := agentTranslator.(translator.TermDiscoverer); ok {
 		toolCalls := discoverer.CollectedToolCalls()
 		discoverer.ResetCollectedToolCalls()
 
 		if len(toolCalls) > 0 && searchEnabled && len(bundle.NFOFiles) > 0 {
-			gen := termmap.NewGenerator(llmAgent)
-			newTerms, err := gen.ExtractNewTerms(ctx, toolCalls, termmap.TermMap(termMapData), bundle.NFOFiles[0].Title, srcLang, tgtLang)
+			gen := termmap.NewGenerator(agents.terms)
+			newTerms, err := gen.ExtractNewTerms(withUsageStage(ctx, config.StageTerms), toolCalls, termmap.TermMap(termMapData), bundle.NFOFiles[0].Title, srcLang, tgtLang)
 			if err != nil {
 				log.Error("Failed to extract new terms from tool calls: %v", err)
 			} else if len(newTerms) > 0 {
 				saveDir := findTermMapSaveDir(bundle.NFOFiles, mediaDir)
 				savePath := termmap.FilePath(saveDir, srcLang, tgtLang)
 
-				merged, err := saveMergedTermMap(savePath, termMapData, newTerms)
+				merged, err := saveMergedTermMap(savePath, termMapData, s.withoutLockedTerms(ctx, savePath, newTerms))
 				if err != nil {
 					log.Error("Failed to save updated term map to %s: %v", savePath, err)
 				} else {
@@ -476,6 +630,41 @@ func (s *transService) processBundle(
 	return nil
 }
 
+// muxTranslatedSubtitle adds the translated subtitle to the media container
+// according to the mux mode of its source. The sidecar file stays next to
+// the media whether muxing works or not.
+func muxTranslatedSubtitle(
+	operator media.Operator,
+	mediaPath string,
+	subtitlePath string,
+	target language.Tag,
+	mode media.MuxMode,
+) {
+	if mode == "" || mode == media.MuxOff {
+		return
+	}
+	if !media.CanMux(mediaPath) {
+		log.Warn("Skip muxing subtitle into %s: container does not support it", mediaPath)
+		return
+	}
+	opts := media.MuxOptions{
+		SubtitlePath: subtitlePath,
+		Language:     target,
+		Title:        muxTrackTitle(target),
+	}
+	if mode == media.MuxCopy {
+		opts.OutputPath = media.DefMuxPath(mediaPath, target)
+	}
+	if _, err := operator.MuxSubtitle(opts); err != nil {
+		log.Error("Failed to mux subtitle %s into %s: %v", subtitlePath, mediaPath, err)
+	}
+}
+
+// muxTrackTitle names the muxed track, e.g. "Chinese (ctxtrans)"
+func muxTrackTitle(target language.Tag) string {
+	return display.English.Languages().Name(target) + " (ctxtrans)"
+}
+
 func (s *transService) loadSubtitleForJob(ctx context.Context, job *jobs.TranslationJob) (*subtitle.File, error) {
 	if job == nil {
 		return nil, fmt.Errorf("job is nil")
@@ -490,21 +679,37 @@ func (s *transService) loadSubtitleForJob(ctx context.Context, job *jobs.Transla
 		return subFile, nil
 	}
 
-	cacheKey := subtitleCacheKey(job.Payload.MediaFile)
+	operator := media.NewOperator(job.Payload.MediaFile)
+	stream, err := selectSubtitleStream(operator, job.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select subtitle stream of media file %s: %w", job.Payload.MediaFile, err)
+	}
+
+	cacheKey := subtitleCacheKey(job.Payload.MediaFile, stream.Index)
 	if s.store != nil {
 		cached, ok, err := s.store.GetSubtitleCache(ctx, cacheKey)
 		if err != nil {
 			log.Error("Failed to load subtitle cache %s: %v", cacheKey, err)
 		} else if ok {
+			// Re-save under this job so the job detail API can find the stream it used.
+			s.putSubtitleCache(ctx, cacheKey, job, &cached)
 			return &cached, nil
 		}
 	}
 
-	operator := media.NewOperator(job.Payload.MediaFile)
-	payload, err := operator.ExtractSubtitleToBytes()
+	if stream.IsBitmap() {
+		subFile, err := s.recognizeSubtitle(ctx, operator, job.Payload.MediaFile, stream)
+		if err != nil {
+			return nil, fmt.Errorf("failed to recognize image subtitle of media file %s: %w", job.Payload.MediaFile, err)
+		}
+		s.putSubtitleCache(ctx, cacheKey, job, subFile)
+		return subFile, nil
+	}
+
+	payload, err := operator.ExtractSubtitleToBytes(stream)
 	if err != nil {
 		// Fallback to file extraction for environments where stdout extraction is unavailable.
-		extracted, fileErr := operator.DefExtractSubtitle()
+		extracted, fileErr := operator.DefExtractSubtitle(stream)
 		if fileErr != nil {
 			return nil, fmt.Errorf("failed to extract subtitle from media file %s: %w", job.Payload.MediaFile, err)
 		}
@@ -512,45 +717,132 @@ func (s *transService) loadSubtitleForJob(ctx context.Context, job *jobs.Transla
 		if readErr != nil {
 			return nil, fmt.Errorf("failed to read subtitle file %s: %w", extracted, readErr)
 		}
-		if s.store != nil {
-			if cacheErr := s.store.PutSubtitleCache(ctx, persistence.SubtitleCacheEntry{
-				CacheKey:  cacheKey,
-				MediaPath: job.Payload.MediaFile,
-				JobID:     job.ID,
-				File:      *subFile,
-				IsTemp:    true,
-			}); cacheErr != nil {
-				log.Error("Failed to save subtitle cache %s: %v", cacheKey, cacheErr)
-			}
-		}
+		s.putSubtitleCache(ctx, cacheKey, job, subFile)
 		return subFile, nil
 	}
-	subFile, err := subtitle.ReadSRTBytes(payload, syntheticSubtitlePath(job.Payload.MediaFile))
+	subFile, err := subtitle.ReadBytes(payload, syntheticSubtitlePath(job.Payload.MediaFile, stream.ExtractFormat()))
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse extracted subtitle of media %s: %w", job.Payload.MediaFile, err)
 	}
-	if s.store != nil {
-		if err := s.store.PutSubtitleCache(ctx, persistence.SubtitleCacheEntry{
-			CacheKey:  cacheKey,
-			MediaPath: job.Payload.MediaFile,
-			JobID:     job.ID,
-			File:      *subFile,
-			IsTemp:    true,
-		}); err != nil {
-			log.Error("Failed to save subtitle cache %s: %v", cacheKey, err)
+	s.putSubtitleCache(ctx, cacheKey, job, subFile)
+	return subFile, nil
+}
+
+// recognizeSubtitle turns an image-based subtitle stream into timed text lines with OCR
+func (s *transService) recognizeSubtitle(
+	ctx context.Context,
+	operator media.Operator,
+	mediaPath string,
+	stream subtitle.Description,
+) (*subtitle.File, error) {
+	packets, err := operator.ReadSubtitlePackets(stream)
+	if err != nil {
+		return nil, err
+	}
+	log.Info("Recognizing image subtitle stream %d (%s, %d packets) of %s", stream.Index, stream.Codec, len(packets.Packets), mediaPath)
+	lines, err := ocr.ReadLines(ctx, s.ocrEngine, packets, stream.LangTag)
+	if err != nil {
+		return nil, err
+	}
+	return &subtitle.File{
+		Lines:    lines,
+		Language: stream.LangTag,
+		Format:   "SRT",
+		Path:     syntheticSubtitlePath(mediaPath, "srt"),
+	}, nil
+}
+
+func (s *transService) putSubtitleCache(ctx context.Context, cacheKey string, job *jobs.TranslationJob, subFile *subtitle.File) {
+	if s.store == nil {
+		return
+	}
+	if err := s.store.PutSubtitleCache(ctx, persistence.SubtitleCacheEntry{
+		CacheKey:  cacheKey,
+		MediaPath: job.Payload.MediaFile,
+		JobID:     job.ID,
+		File:      *subFile,
+		IsTemp:    true,
+	}); err != nil {
+		log.Error("Failed to save subtitle cache %s: %v", cacheKey, err)
+	}
+}
+
+// recordJobSource saves the subtitle a job translated under the job's own
+// cache key, so the job detail API pairs the output with its cues even when
+// it is not the subtitle of the payload, e.g. a pivot subtitle found on disk.
+// It outlives ClearJobTemp and goes with DeleteJobData.
+func (s *transService) recordJobSource(ctx context.Context, bundle MediaBundle, jobID string, subFile subtitle.File) {
+	if s.store == nil || jobID == "" {
+		return
+	}
+	// Outputs are named after the source of the bundle, keep the job detail in line.
+	subFile.Path = bundle.SubtitleFiles[0].Path
+	cacheKey := jobSourceCacheKey(jobID)
+	if err := s.store.PutSubtitleCache(ctx, persistence.SubtitleCacheEntry{
+		CacheKey:  cacheKey,
+		MediaPath: bundle.MediaFile,
+		JobID:     jobID,
+		File:      subFile,
+	}); err != nil {
+		log.Error("Failed to save subtitle cache %s: %v", cacheKey, err)
+	}
+}
+
+// selectSubtitleStream picks the embedded subtitle stream a job translates:
+// an explicit stream index first, then the job's stream policy, otherwise the
+// best text stream that is neither forced nor SDH.
+func selectSubtitleStream(operator media.Operator, payload jobs.JobPayload) (subtitle.Description, error) {
+	descriptions, err := operator.ReadSubtitleDescription()
+	if err != nil {
+		if payload.StreamIndex != nil {
+			log.Warn("Failed to probe subtitle streams of %s, using stream %d: %v", payload.MediaFile, *payload.StreamIndex, err)
+			return subtitle.Description{Index: *payload.StreamIndex}, nil
 		}
+		if payload.StreamPolicy != nil {
+			return subtitle.Description{}, err
+		}
+		log.Warn("Failed to probe subtitle streams of %s, using the first stream: %v", payload.MediaFile, err)
+		return subtitle.Description{}, nil
 	}
-	return subFile, nil
+	if len(descriptions) == 0 {
+		return subtitle.Description{}, fmt.Errorf("no embedded subtitle streams")
+	}
+
+	if payload.StreamIndex != nil {
+		stream, ok := descriptions.ByIndex(*payload.StreamIndex)
+		if !ok {
+			return subtitle.Description{}, fmt.Errorf("subtitle stream %d not found, media has %d subtitle streams", *payload.StreamIndex, len(descriptions))
+		}
+		return stream, nil
+	}
+
+	policy := subtitle.StreamPolicy{}
+	if payload.StreamPolicy != nil {
+		policy = *payload.StreamPolicy
+	}
+	stream, ok := descriptions.Select(policy)
+	if !ok {
+		return subtitle.Description{}, fmt.Errorf("no subtitle stream matches the stream policy")
+	}
+	log.Info("Selected subtitle stream %d (%s, %q, %s) of %s", stream.Index, stream.Language, stream.SubLanguage, stream.Codec, payload.MediaFile)
+	return stream, nil
 }
 
-func subtitleCacheKey(mediaPath string) string {
-	return mediaPath + "|s:0"
+func subtitleCacheKey(mediaPath string, streamIndex int) string {
+	return fmt.Sprintf("%s|s:%d", mediaPath, streamIndex)
 }
 
-func syntheticSubtitlePath(mediaPath string) string {
+func jobSourceCacheKey(jobID string) string {
+	return "job:" + jobID + "|source"
+}
+
+// syntheticSubtitlePath names a subtitle read from a stream of a media file,
+// with the extension of the format it was extracted in, e.g. "ass" for
+// subtitle.Description.ExtractFormat, so it is parsed and written as such
+func syntheticSubtitlePath(mediaPath string, format string) string {
 	ext := filepath.Ext(mediaPath)
 	stem := strings.TrimSuffix(filepath.Base(mediaPath), ext)
-	return filepath.Join(filepath.Dir(mediaPath), stem+"_ctxtrans_embedded.srt")
+	return filepath.Join(filepath.Dir(mediaPath), stem+"_ctxtrans_embedded."+format)
 }
 
 func (s *transService) findTargetMediaTuplesInDir(
@@ -567,18 +859,24 @@ func (s *transService) findTargetMediaTuplesInDir(
 	for _, bundle := range all {
 		mediaPath := bundle.MediaFile
 		now := time.Now().UTC()
-		var cachedMeta persistence.MediaMetaCache
-		cachedHit := false
-		if s.store != nil && mediaPath != "" {
-			meta, ok, err := s.store.GetMediaMetaCache(ctx, mediaPath, cfg.Translate.TargetLanguage.String(), now)
-			if err != nil {
-				log.Error("Failed to load media metadata cache for %s: %v", mediaPath, err)
-			} else if ok {
-				cachedMeta = meta
-				cachedHit = true
+		// targets still to check, with their cached metadata if any
+		var missing []language.Tag
+		cachedMetas := make(map[language.Tag]persistence.MediaMetaCache)
+		for _, target := range cfg.Translate.Targets() {
+			if s.store != nil && mediaPath != "" {
+				meta, ok, err := s.store.GetMediaMetaCache(ctx, mediaPath, target.String(), now)
+				if err != nil {
+					log.Error("Failed to load media metadata cache for %s: %v", mediaPath, err)
+				} else if ok {
+					if meta.HasTargetExternal || meta.HasTargetEmbedded {
+						continue
+					}
+					cachedMetas[target] = meta
+				}
 			}
+			missing = append(missing, target)
 		}
-		if cachedHit && (cachedMeta.HasTargetExternal || cachedMeta.HasTargetEmbedded) {
+		if len(missing) == 0 {
 			continue
 		}
 
@@ -589,14 +887,21 @@ func (s *transService) findTargetMediaTuplesInDir(
 		}
 
 		// If target subtitle exists, skip
-		if containTargetSubtitle(subtitles, cfg.Translate.TargetLanguage) {
+		missing = slices.DeleteFunc(missing, func(target language.Tag) bool {
+			return containTargetSubtitle(subtitles, target) || hasTargetSidecar(bundle.MediaFile, target)
+		})
+		if len(missing) == 0 {
 			continue
 		}
 
 		mediaReader := media.NewOperator(bundle.MediaFile)
 		var subDescs subtitle.Descriptions
+		cachedHit := len(cachedMetas) > 0
 		if cachedHit {
-			subDescs = descriptionsFromLanguageCodes(cachedMeta.EmbeddedLanguages)
+			for _, meta := range cachedMetas {
+				subDescs = descriptionsFromLanguageCodes(meta.EmbeddedLanguages)
+				break
+			}
 		} else {
 			subDescs, err = mediaReader.ReadSubtitleDescription()
 			if err != nil {
@@ -604,14 +909,18 @@ func (s *transService) findTargetMediaTuplesInDir(
 				// Keep processing with external subtitle signals even if ffprobe is unavailable.
 				subDescs = nil
 			}
-			if s.store != nil && mediaPath != "" {
+		}
+		if s.store != nil && mediaPath != "" {
+			for _, target := range missing {
+				if _, ok := cachedMetas[target]; ok {
+					continue
+				}
 				if err := s.store.PutMediaMetaCache(ctx, persistence.MediaMetaCache{
 					MediaPath:         mediaPath,
-					TargetLanguage:    cfg.Translate.TargetLanguage.String(),
+					TargetLanguage:    target.String(),
 					ExternalLanguages: subtitleLanguages(subtitles),
 					EmbeddedLanguages: descriptionLanguages(subDescs),
-					HasTargetExternal: containTargetSubtitle(subtitles, cfg.Translate.TargetLanguage),
-					HasTargetEmbedded: subDescs.HasLanguage(cfg.Translate.TargetLanguage),
+					HasTargetEmbedded: subDescs.HasLanguage(target),
 					ExpiresAt:         now.Add(10 * time.Minute),
 					UpdatedAt:         now,
 				}); err != nil {
@@ -619,8 +928,14 @@ func (s *transService) findTargetMediaTuplesInDir(
 				}
 			}
 		}
-		if subDescs.HasLanguage(cfg.Translate.TargetLanguage) {
-			log.Info("Target subtitle already exists in media file %s", bundle.MediaFile)
+		missing = slices.DeleteFunc(missing, func(target language.Tag) bool {
+			if subDescs.HasLanguage(target) {
+				log.Info("Target subtitle %s already exists in media file %s", target, bundle.MediaFile)
+				return true
+			}
+			return false
+		})
+		if len(missing) == 0 {
 			continue
 		}
 
@@ -637,27 +952,47 @@ func (s *transService) findTargetMediaTuplesInDir(
 
 		// There is no target subtitle, extract one from media file
 		if len(subtitles) == 0 && len(subDescs) > 0 {
-			output, err := mediaReader.DefExtractSubtitle()
-			if err != nil {
-				log.Error("Failed to extract subtitle from media file %s: %v", bundle.MediaFile, err)
-				continue
+			if cachedHit {
+				// Cached metadata only keeps languages; probe again to select the stream.
+				if probed, err := mediaReader.ReadSubtitleDescription(); err == nil {
+					subDescs = probed
+				}
 			}
-			sub, err := subtitle.NewReader(output).Read()
-			if err != nil {
-				log.Error("Failed to read subtitle file %s: %v", output, err)
+			stream, ok := subDescs.Select(subtitle.StreamPolicy{})
+			if !ok {
 				continue
 			}
+			var sub *subtitle.File
+			if stream.IsBitmap() {
+				sub, err = s.recognizeSubtitle(ctx, mediaReader, bundle.MediaFile, stream)
+				if err != nil {
+					log.Error("Failed to recognize image subtitle of media file %s: %v", bundle.MediaFile, err)
+					continue
+				}
+			} else {
+				output, err := mediaReader.DefExtractSubtitle(stream)
+				if err != nil {
+					log.Error("Failed to extract subtitle from media file %s: %v", bundle.MediaFile, err)
+					continue
+				}
+				sub, err = subtitle.NewReader(output).Read()
+				if err != nil {
+					log.Error("Failed to read subtitle file %s: %v", output, err)
+					continue
+				}
+			}
 
-			ret = append(ret, MediaBundle{
-				MediaFile:     bundle.MediaFile,
-				SubtitleFiles: []subtitle.File{*sub},
-				NFOFiles:      nfos,
-			})
+			subtitles = []subtitle.File{*sub}
 		} else {
+			subtitles = sourceSubtitlesFirst(subtitles, cfg.Translate.Targets())
+		}
+		// one bundle, and so one job, per missing language
+		for _, target := range missing {
 			ret = append(ret, MediaBundle{
-				MediaFile:     bundle.MediaFile,
-				SubtitleFiles: subtitles,
-				NFOFiles:      nfos,
+				MediaFile:      bundle.MediaFile,
+				SubtitleFiles:  subtitles,
+				NFOFiles:       nfos,
+				TargetLanguage: target,
 			})
 		}
 	}
@@ -665,6 +1000,24 @@ func (s *transService) findTargetMediaTuplesInDir(
 	return
 }
 
+// sourceSubtitlesFirst moves the subtitles in none of the target languages
+// to the front, so jobs translate from the source rather than from another
+// target
+func sourceSubtitlesFirst(subtitles []subtitle.File, targets []language.Tag) []subtitle.File {
+	sources := make([]subtitle.File, 0, len(subtitles))
+	var others []subtitle.File
+	for _, sub := range subtitles {
+		if slices.ContainsFunc(targets, func(target language.Tag) bool {
+			return containTargetSubtitle([]subtitle.File{sub}, target)
+		}) {
+			others = append(others, sub)
+			continue
+		}
+		sources = append(sources, sub)
+	}
+	return append(sources, others...)
+}
+
 // containTargetSubtitle checks if any subtitle file has the target language
 func containTargetSubtitle(subtitles []subtitle.File, targetLanguage language.Tag) bool {
 	for _, sub := range subtitles {
@@ -678,37 +1031,58 @@ func containTargetSubtitle(subtitles []subtitle.File, targetLanguage language.Ta
 	return false
 }
 
-func languageMatches(a, b language.Tag) bool {
-	if a == b {
-		return true
+// hasTargetSidecar reports whether a subtitle named with the target language
+// sits next to a media file, also in formats that are not read such as PGS
+// .sup or VobSub .idx/.sub
+func hasTargetSidecar(mediaPath string, target language.Tag) bool {
+	if mediaPath == "" {
+		return false
+	}
+	baseName := getBaseName(mediaPath)
+	entries, err := os.ReadDir(filepath.Dir(mediaPath))
+	if err != nil {
+		return false
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, baseName) {
+			continue
+		}
+		if subtitlePathMatchesLanguage(name, target) {
+			return true
+		}
 	}
-	ab, _ := a.Base()
-	bb, _ := b.Base()
-	return ab.String() != "und" && ab == bb
+	return false
+}
+
+func languageMatches(a, b language.Tag) bool {
+	return a == b || subtitle.MatchesTarget(a, b)
 }
 
 func subtitlePathMatchesLanguage(path string, target language.Tag) bool {
+	tag, ok := subtitlePathLanguage(path)
+	return ok && subtitle.MatchesTarget(tag, target)
+}
+
+// subtitlePathLanguage returns the language a subtitle file is named with,
+// e.g. zh-Hant for ep01.cht.srt
+func subtitlePathLanguage(path string) (language.Tag, bool) {
 	if path == "" {
-		return false
+		return language.Und, false
 	}
 
 	fileName := strings.ToLower(filepath.Base(path))
 	ext := strings.ToLower(filepath.Ext(fileName))
-	if !isSubtitleFile(ext) {
-		return false
+	if !subtitle.IsSidecarExtension(ext) {
+		return language.Und, false
 	}
 
 	base := strings.TrimSuffix(fileName, ext)
 	idx := strings.LastIndex(base, ".")
 	if idx < 0 || idx == len(base)-1 {
-		return false
+		return language.Und, false
 	}
-	suffix := strings.ReplaceAll(base[idx+1:], "_", "-")
-	targetStr := strings.ToLower(strings.ReplaceAll(target.String(), "_", "-"))
-	targetBase, _ := target.Base()
-	targetBaseStr := strings.ToLower(targetBase.String())
-
-	return suffix == targetStr || suffix == targetBaseStr || strings.HasPrefix(suffix, targetBaseStr+"-")
+	return subtitle.ParseLanguageToken(base[idx+1:])
 }
 
 func (s *transService) readSubtitleFiles(
@@ -756,7 +1130,7 @@ func (s *transService) findSourceBundlesInDir(
 		}
 
 		ext := strings.ToLower(filepath.Ext(path))
-		if isSubtitleFile(ext) || isMediaFile(ext) {
+		if isSubtitleFile(ext) || (isMediaFile(ext) && !isMuxedCopy(path)) {
 			targetFiles = append(targetFiles, path)
 		}
 		return nil
@@ -881,7 +1255,7 @@ func findMatchingSubtitleFiles(dir, baseName string) []string {
 		}
 
 		fileName := file.Name()
-		for _, ext := range subtitleExts {
+		for _, ext := range subtitle.SupportedExtensions() {
 			// Check if file starts with baseName and ends with the subtitle extension
 			if strings.HasPrefix(fileName, baseName) && strings.HasSuffix(fileName, ext) {
 				subtitleFiles = append(subtitleFiles, filepath.Join(dir, fileName))
@@ -1066,9 +1440,9 @@ func descriptionsFromLanguageCodes(langs []string) subtitle.Descriptions {
 	return ret
 }
 
-// isSubtitleFile checks if the file extension is a subtitle format
+// isSubtitleFile checks if the file extension is a subtitle format the pipeline can read
 func isSubtitleFile(ext string) bool {
-	return slices.Contains(subtitleExts, ext)
+	return subtitle.IsSupportedExtension(ext)
 }
 
 // isMediaFile checks if the file extension is a media format that supports embedded subtitles
@@ -1076,6 +1450,11 @@ func isMediaFile(ext string) bool {
 	return slices.Contains(mediaExts, ext)
 }
 
+// isMuxedCopy reports whether a media file is a copy written by the mux step
+func isMuxedCopy(path string) bool {
+	return strings.Contains(filepath.Base(path), "_ctxtrans")
+}
+
 // findTermMapSaveDir finds the best directory to save a term map.
 // Prefers the directory containing tvshow.nfo for show-level coverage,
 // falling back to the first NFO's directory or the given fallback.
@@ -1091,16 +1470,26 @@ func findTermMapSaveDir(nfoFiles []media.TVShowInfo, fallbackDir string) string
 	return fallbackDir
 }
 
+// saveMergedTermMap adds new terms to the term map file at savePath. The
+// file is read again under its lock, so terms edited through the API since
+// existing was loaded are kept; existing is only the base when there is no
+// file yet.
 func saveMergedTermMap(savePath string, existing map[string]string, newTerms termmap.TermMap) (map[string]string, error) {
-	merged := make(map[string]string, len(existing)+len(newTerms))
-	for key, value := range existing {
-		merged[key] = value
-	}
-	for key, value := range newTerms {
-		merged[key] = value
-	}
-
+	var merged map[string]string
 	if err := withTermMapFileLock(savePath, func() error {
+		base := existing
+		if onDisk, err := termmap.Load(savePath); err == nil {
+			base = onDisk
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		merged = make(map[string]string, len(base)+len(newTerms))
+		for key, value := range base {
+			merged[key] = value
+		}
+		for key, value := range newTerms {
+			merged[key] = value
+		}
 		return termmap.Save(savePath, termmap.TermMap(merged))
 	}); err != nil {
 		return nil, err
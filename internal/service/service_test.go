@@ -297,13 +297,12 @@ func TestFindMatchingSubtitleFiles(t *testing.T) {
 
 	subtitleFiles := findMatchingSubtitleFiles(tempDir, "movie")
 
-	assert.Len(t, subtitleFiles, 4) // movie.srt, movie.ass, movie.vtt, movie.txt
+	assert.Len(t, subtitleFiles, 3) // movie.srt, movie.ass, movie.vtt; movie.txt is not a readable format
 
 	expectedFiles := []string{
 		filepath.Join(tempDir, "movie.srt"),
 		filepath.Join(tempDir, "movie.ass"),
 		filepath.Join(tempDir, "movie.vtt"),
-		filepath.Join(tempDir, "movie.txt"),
 	}
 
 	for _, expectedFile := range expectedFiles {
@@ -396,8 +395,9 @@ func TestIsSubtitleFile(t *testing.T) {
 		{".srt", true},
 		{".ass", true},
 		{".vtt", true},
-		{".sub", true},
-		{".txt", true},
+		{".ssa", true},
+		{".sub", false},
+		{".txt", false},
 		{".mkv", false},
 		{".mp4", false},
 		{".nfo", false},
@@ -535,6 +535,53 @@ func TestFindTargetMediaTuplesInDir(t *testing.T) {
 				assert.Empty(t, bundles)
 			},
 		},
+		{
+			name: "skip media whose target subtitle is an image sidecar",
+			setupFiles: func(t *testing.T, rootDir string) {
+				require.NoError(t, os.WriteFile(filepath.Join(rootDir, "test_movie.mkv"), []byte("mock mkv content"), 0644))
+				require.NoError(t, os.WriteFile(filepath.Join(rootDir, "test_movie.eng.srt"), []byte(mockSubtitleContent), 0644))
+				require.NoError(t, os.WriteFile(filepath.Join(rootDir, "test_movie.zh.sup"), []byte("PG"), 0644))
+				require.NoError(t, os.WriteFile(filepath.Join(rootDir, "tvshow.nfo"), []byte(tvshowNFO), 0644))
+			},
+			service: transService{
+				cfg: config.Config{
+					Translate: config.TranslateConfig{
+						TargetLanguage: language.Chinese,
+					},
+				},
+				lastTrigerTime: time.Now().Add(-24 * time.Hour),
+			},
+			expectedCount: 0,
+			validateContent: func(t *testing.T, bundles []MediaBundle) {
+				assert.Empty(t, bundles)
+			},
+		},
+		{
+			name: "fan out one bundle per missing target language",
+			setupFiles: func(t *testing.T, rootDir string) {
+				require.NoError(t, os.WriteFile(filepath.Join(rootDir, "test_movie.mkv"), []byte("mock mkv content"), 0644))
+				require.NoError(t, os.WriteFile(filepath.Join(rootDir, "test_movie.chs.srt"), []byte(mockCNSubtitleContent), 0644))
+				require.NoError(t, os.WriteFile(filepath.Join(rootDir, "test_movie.eng.srt"), []byte(mockSubtitleContent), 0644))
+				require.NoError(t, os.WriteFile(filepath.Join(rootDir, "tvshow.nfo"), []byte(tvshowNFO), 0644))
+			},
+			service: transService{
+				cfg: config.Config{
+					Translate: config.TranslateConfig{
+						TargetLanguage:  language.SimplifiedChinese,
+						TargetLanguages: []language.Tag{language.SimplifiedChinese, language.TraditionalChinese, language.Japanese},
+					},
+				},
+				lastTrigerTime: time.Now().Add(-24 * time.Hour),
+			},
+			expectedCount: 2,
+			validateContent: func(t *testing.T, bundles []MediaBundle) {
+				assert.Equal(t, language.TraditionalChinese, bundles[0].TargetLanguage)
+				assert.Equal(t, language.Japanese, bundles[1].TargetLanguage)
+				for _, bundle := range bundles {
+					assert.Equal(t, "test_movie.eng.srt", filepath.Base(bundle.SubtitleFiles[0].Path), "translates from the source, not from another target")
+				}
+			},
+		},
 		{
 			name: "skip media with target subtitle in file",
 			setupFiles: func(t *testing.T, rootDir string) {
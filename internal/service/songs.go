@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/MimeLyc/contextual-sub-translator/internal/config"
+	"github.com/MimeLyc/contextual-sub-translator/internal/persistence"
+	"github.com/MimeLyc/contextual-sub-translator/internal/songs"
+	"github.com/MimeLyc/contextual-sub-translator/internal/subtitle"
+	"github.com/MimeLyc/contextual-sub-translator/pkg/log"
+)
+
+// seriesSongMode returns how the songs of a series are translated: its own
+// mode when one was set and is valid, the configured default otherwise.
+func (s *transService) seriesSongMode(ctx context.Context, seriesKey string, cfg config.Config) songs.Mode {
+	defaultMode := cfg.Translate.SongMode
+	if defaultMode == "" {
+		defaultMode = songs.ModeLyrics
+	}
+	if s.store == nil {
+		return defaultMode
+	}
+	raw, ok, err := s.store.GetSongMode(ctx, seriesKey)
+	if err != nil {
+		log.Error("Failed to load song mode of %s: %v", seriesKey, err)
+		return defaultMode
+	}
+	if !ok {
+		return defaultMode
+	}
+	mode, err := songs.ParseMode(raw)
+	if err != nil {
+		log.Warn("Ignoring song mode of %s: %v", seriesKey, err)
+		return defaultMode
+	}
+	return mode
+}
+
+// markSongs flags the lines of sub that belong to openings, endings and
+// insert songs. Only the lines the release marks as songs are stored for
+// later episodes to recognise by their lyrics: a guess from italics, such as
+// a narrated cold open, would otherwise be a song in every episode after.
+func (s *transService) markSongs(ctx context.Context, seriesKey string, sub *subtitle.File) {
+	known := make(map[string]bool)
+	if s.store != nil {
+		lyrics, err := s.store.ListSongLines(ctx, seriesKey)
+		if err != nil {
+			log.Error("Failed to load song lines of %s: %v", seriesKey, err)
+		}
+		for _, text := range lyrics {
+			known[text] = true
+		}
+	}
+
+	detected := songs.Detect(sub, func(text string) bool {
+		return known[persistence.NormalizeMemorySource(songText(text))]
+	})
+	marked := songs.Marked(sub)
+	var count int
+	var texts []string
+	for i, song := range detected {
+		sub.Lines[i].Song = song
+		if !song {
+			continue
+		}
+		count++
+		if !marked[i] {
+			continue
+		}
+		// Short lines like "Yeah!" are sung and said alike
+		if text := songText(sub.Lines[i].Text); utf8.RuneCountInString(persistence.NormalizeMemorySource(text)) >= minMemoryRunes {
+			texts = append(texts, text)
+		}
+	}
+	if count == 0 {
+		return
+	}
+	log.Info("Detected %d song lines in %s", count, sub.Path)
+	if s.store == nil || len(texts) == 0 {
+		return
+	}
+	if err := s.store.AddSongLines(ctx, seriesKey, texts); err != nil {
+		log.Error("Failed to save song lines of %s: %v", seriesKey, err)
+	}
+}
+
+// musicNotes strips the marks some releases put around lyrics, so lines
+// compare alike with and without them
+var musicNotes = strings.NewReplacer("♪", "", "♫", "", "♬", "")
+
+func songText(text string) string {
+	return musicNotes.Replace(text)
+}
+
+// memoryLines drops the song lines that mode does not translate; their
+// original text or blank translation is no use to later episodes
+func memoryLines(lines []subtitle.Line, mode songs.Mode) []subtitle.Line {
+	if mode.Translates() {
+		return lines
+	}
+	ret := make([]subtitle.Line, 0, len(lines))
+	for _, line := range lines {
+		if !line.Song {
+			ret = append(ret, line)
+		}
+	}
+	return ret
+}
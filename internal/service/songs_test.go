@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/MimeLyc/contextual-sub-translator/internal/config"
+	"github.com/MimeLyc/contextual-sub-translator/internal/persistence"
+	"github.com/MimeLyc/contextual-sub-translator/internal/songs"
+	"github.com/MimeLyc/contextual-sub-translator/internal/subtitle"
+)
+
+func TestTransService_SeriesSongMode(t *testing.T) {
+	store, err := persistence.NewSQLiteStore(filepath.Join(t.TempDir(), "ctxtrans.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = store.Close() })
+
+	ctx := context.Background()
+	s := &transService{store: store}
+	cfg := config.Config{Translate: config.TranslateConfig{SongMode: songs.ModeOriginal}}
+	assert.Equal(t, songs.ModeOriginal, s.seriesSongMode(ctx, "/tv/show", cfg))
+	assert.Equal(t, songs.ModeLyrics, s.seriesSongMode(ctx, "/tv/show", config.Config{}))
+
+	require.NoError(t, store.PutSongMode(ctx, "/tv/show", "skip"))
+	assert.Equal(t, songs.ModeSkip, s.seriesSongMode(ctx, "/tv/show", cfg))
+
+	require.NoError(t, store.PutSongMode(ctx, "/tv/show", "bogus"))
+	assert.Equal(t, songs.ModeOriginal, s.seriesSongMode(ctx, "/tv/show", cfg))
+}
+
+func TestTransService_MarkSongsRecognisesEarlierLyrics(t *testing.T) {
+	store, err := persistence.NewSQLiteStore(filepath.Join(t.TempDir(), "ctxtrans.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = store.Close() })
+
+	line := func(index int, start time.Duration, text string) subtitle.Line {
+		return subtitle.Line{Index: index, StartTime: start, EndTime: start + 2*time.Second, Text: text}
+	}
+	ctx := context.Background()
+	s := &transService{store: store}
+
+	first := &subtitle.File{Lines: []subtitle.Line{
+		line(1, 0, "♪ Flying over the morning sky ♪"),
+		line(2, 10*time.Minute, "Where were you last night?"),
+	}}
+	s.markSongs(ctx, "/tv/show", first)
+	assert.True(t, first.Lines[0].Song)
+	assert.False(t, first.Lines[1].Song)
+
+	// The next episode drops the music notes
+	second := &subtitle.File{Lines: []subtitle.Line{
+		line(1, 10*time.Minute, "Where were you last night?"),
+		line(2, 11*time.Minute, "Flying over the morning sky"),
+	}}
+	s.markSongs(ctx, "/tv/show", second)
+	assert.False(t, second.Lines[0].Song)
+	assert.True(t, second.Lines[1].Song)
+}
+
+func TestTransService_MarkSongsOnlyRemembersMarkedLyrics(t *testing.T) {
+	store, err := persistence.NewSQLiteStore(filepath.Join(t.TempDir(), "ctxtrans.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = store.Close() })
+
+	ctx := context.Background()
+	s := &transService{store: store}
+
+	// an italic cold-open narration looks like an opening
+	var lines []subtitle.Line
+	for i, text := range []string{"<i>Long ago, the island sank.</i>", "<i>Only the lighthouse remained.</i>", "<i>Its keeper never left.</i>", "<i>Until tonight.</i>"} {
+		start := time.Duration(i) * 4 * time.Second
+		lines = append(lines, subtitle.Line{Index: i + 1, StartTime: start, EndTime: start + 3*time.Second, Text: text})
+	}
+	lines = append(lines,
+		subtitle.Line{Index: 5, StartTime: 5 * time.Minute, EndTime: 5*time.Minute + 2*time.Second, Text: "♪ Flying over the morning sky ♪"},
+		subtitle.Line{Index: 6, StartTime: 20 * time.Minute, EndTime: 20*time.Minute + 2*time.Second, Text: "Goodnight."},
+	)
+	sub := &subtitle.File{Lines: lines}
+	s.markSongs(ctx, "/tv/show", sub)
+	assert.True(t, sub.Lines[0].Song, "still translated as a song in this episode")
+	assert.True(t, sub.Lines[4].Song)
+
+	known, err := store.ListSongLines(ctx, "/tv/show")
+	require.NoError(t, err)
+	require.Len(t, known, 1)
+	assert.Contains(t, known[0], "flying over the morning sky")
+}
+
+func TestMemoryLines(t *testing.T) {
+	t.Parallel()
+
+	lines := []subtitle.Line{
+		{Text: "Flying over the morning sky", TranslatedText: "Flying over the morning sky", Song: true},
+		{Text: "Where were you last night?", TranslatedText: "你昨晚去哪了？"},
+	}
+	assert.Len(t, memoryLines(lines, songs.ModeReuse), 2)
+	kept := memoryLines(lines, songs.ModeOriginal)
+	require.Len(t, kept, 1)
+	assert.Equal(t, "Where were you last night?", kept[0].Text)
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"slices"
+
+	"github.com/MimeLyc/contextual-sub-translator/internal/agent"
+	"github.com/MimeLyc/contextual-sub-translator/internal/media"
+	"github.com/MimeLyc/contextual-sub-translator/internal/persistence"
+	"github.com/MimeLyc/contextual-sub-translator/internal/speaker"
+	"github.com/MimeLyc/contextual-sub-translator/internal/subtitle"
+	"github.com/MimeLyc/contextual-sub-translator/pkg/log"
+)
+
+// attributeSpeakers sets the likely speaker on every line of sub and returns
+// the character profiles of the series, including the ones found now. When
+// attribution fails, the lines are left as they are and only the stored
+// profiles are returned.
+func (s *transService) attributeSpeakers(
+	ctx context.Context,
+	llmAgent agent.Agent,
+	bundle MediaBundle,
+	seriesKey string,
+	sub *subtitle.File,
+) []speaker.Profile {
+	var profiles []speaker.Profile
+	if s.store != nil {
+		stored, err := s.store.ListCharacterProfiles(ctx, seriesKey)
+		if err != nil {
+			log.Error("Failed to load character profiles of %s: %v", seriesKey, err)
+		}
+		for _, item := range stored {
+			profiles = append(profiles, speakerProfile(item))
+		}
+	}
+
+	var showInfo media.TVShowInfo
+	if len(bundle.NFOFiles) > 0 {
+		showInfo = bundle.NFOFiles[0]
+	}
+	var attribution speaker.Attribution
+	if llmAgent != nil {
+		var err error
+		attribution, err = speaker.NewAttributor(llmAgent).Attribute(ctx, showInfo, sub, profiles)
+		if err != nil {
+			log.Error("Failed to attribute speakers of %s: %v", bundle.MediaFile, err)
+		}
+	}
+
+	// the lines may be shared with the subtitle cache, set speakers on a copy
+	lines := slices.Clone(sub.Lines)
+	for i := range lines {
+		if i < len(attribution.Speakers) {
+			lines[i].Speaker = attribution.Speakers[i]
+		}
+	}
+	sub.Lines = lines
+
+	if len(attribution.Profiles) == 0 {
+		return profiles
+	}
+	if s.store != nil {
+		added := make([]persistence.CharacterProfile, 0, len(attribution.Profiles))
+		for _, profile := range attribution.Profiles {
+			added = append(added, persistence.CharacterProfile{
+				SeriesKey: seriesKey,
+				Name:      profile.Name,
+				Gender:    profile.Gender,
+				Formality: profile.Formality,
+				Nicknames: profile.Nicknames,
+			})
+		}
+		if err := s.store.AddCharacterProfiles(ctx, added); err != nil {
+			log.Error("Failed to save character profiles of %s: %v", seriesKey, err)
+		} else {
+			log.Info("Added %d character profiles to %s", len(added), seriesKey)
+		}
+	}
+	return append(profiles, attribution.Profiles...)
+}
+
+func speakerProfile(item persistence.CharacterProfile) speaker.Profile {
+	return speaker.Profile{
+		Name:      item.Name,
+		Gender:    item.Gender,
+		Formality: item.Formality,
+		Nicknames: item.Nicknames,
+	}
+}
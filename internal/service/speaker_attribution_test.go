@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/MimeLyc/contextual-sub-translator/internal/agent"
+	"github.com/MimeLyc/contextual-sub-translator/internal/persistence"
+	"github.com/MimeLyc/contextual-sub-translator/internal/speaker"
+	"github.com/MimeLyc/contextual-sub-translator/internal/subtitle"
+	"github.com/MimeLyc/contextual-sub-translator/internal/tools"
+)
+
+func TestTransService_AttributeSpeakers(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encoded, _ := json.Marshal(`{"speakers":[{"index":1,"speaker":"Okarun"},{"index":2,"speaker":"Momo"}],` +
+			`"characters":[{"name":"Okarun","gender":"male","formality":"polite"},{"name":"Momo","gender":"male"}]}`)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"chatcmpl-1","object":"chat.completion","created":1,"model":"test-model",` +
+			`"choices":[{"index":0,"finish_reason":"stop","message":{"role":"assistant","content":` + string(encoded) + `}}],` +
+			`"usage":{"prompt_tokens":1,"completion_tokens":1,"total_tokens":2}}`))
+	}))
+	t.Cleanup(server.Close)
+	llmAgent, err := agent.NewLLMAgent(agent.LLMConfig{APIKey: "test-key", APIURL: server.URL, Model: "test-model", Timeout: 10}, tools.NewRegistry(), 1)
+	require.NoError(t, err)
+
+	store, err := persistence.NewSQLiteStore(filepath.Join(t.TempDir(), "ctxtrans.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = store.Close() })
+	ctx := context.Background()
+	require.NoError(t, store.PutCharacterProfile(ctx, persistence.CharacterProfile{SeriesKey: "/tv/show", Name: "Momo", Gender: "female"}))
+
+	cached := []subtitle.Line{{Index: 1, Text: "Morning."}, {Index: 2, Text: "Hey."}}
+	sub := &subtitle.File{Lines: cached}
+	s := &transService{store: store}
+	profiles := s.attributeSpeakers(ctx, llmAgent, MediaBundle{MediaFile: "/tv/show/show.S01E01.mkv"}, "/tv/show", sub)
+
+	assert.Equal(t, "Okarun", sub.Lines[0].Speaker)
+	assert.Equal(t, "Momo", sub.Lines[1].Speaker)
+	assert.Empty(t, cached[0].Speaker, "cached lines are not modified")
+	assert.Equal(t, []speaker.Profile{
+		{Name: "Momo", Gender: "female", Nicknames: []string{}},
+		{Name: "Okarun", Gender: "male", Formality: "polite"},
+	}, profiles)
+
+	stored, err := store.ListCharacterProfiles(ctx, "/tv/show")
+	require.NoError(t, err)
+	require.Len(t, stored, 2)
+	assert.Equal(t, "female", stored[0].Gender, "edited profiles are kept")
+	assert.Equal(t, "Okarun", stored[1].Name)
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"image"
+	"testing"
+	"time"
+
+	"github.com/MimeLyc/contextual-sub-translator/internal/media"
+	"github.com/MimeLyc/contextual-sub-translator/internal/subtitle"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/language"
+)
+
+type stubOCREngine struct {
+	text string
+}
+
+func (e stubOCREngine) Recognize(context.Context, image.Image, language.Tag) (string, error) {
+	return e.text, nil
+}
+
+func TestRecognizeSubtitle(t *testing.T) {
+	// one PGS display set showing a 1x1 object, then one clearing it
+	show := []byte{
+		0x16, 0x00, 0x13, 0x07, 0x80, 0x04, 0x38, 0x10, 0x00, 0x01, 0x80, 0x00, 0x00, 0x01,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x10, 0x00, 0x10,
+		0x14, 0x00, 0x07, 0x00, 0x00, 0x01, 0xeb, 0x80, 0x80, 0xff,
+		0x15, 0x00, 0x0e, 0x00, 0x00, 0x00, 0xc0, 0x00, 0x00, 0x07, 0x00, 0x01, 0x00, 0x01, 0x01, 0x00, 0x00,
+		0x80, 0x00, 0x00,
+	}
+	clear := []byte{
+		0x16, 0x00, 0x0b, 0x07, 0x80, 0x04, 0x38, 0x10, 0x00, 0x02, 0x00, 0x00, 0x00, 0x00,
+		0x80, 0x00, 0x00,
+	}
+	operator := fakeMediaOperator{packets: &media.SubtitlePackets{
+		Codec: "hdmv_pgs_subtitle",
+		Packets: []media.SubtitlePacket{
+			{Start: 2 * time.Second, Data: show},
+			{Start: 4 * time.Second, Data: clear},
+		},
+	}}
+	svc := transService{ocrEngine: stubOCREngine{text: "Where are we?\n"}}
+
+	sub, err := svc.recognizeSubtitle(context.Background(), operator, "/tv/ep01.mkv", subtitle.Description{
+		Index:    1,
+		Codec:    "hdmv_pgs_subtitle",
+		Language: "eng",
+		LangTag:  language.English,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, language.English, sub.Language)
+	assert.Equal(t, "SRT", sub.Format)
+	assert.Equal(t, "/tv/ep01_ctxtrans_embedded.srt", sub.Path)
+	assert.Equal(t, []subtitle.Line{{
+		Index:     1,
+		StartTime: 2 * time.Second,
+		EndTime:   4 * time.Second,
+		Text:      "Where are we?",
+	}}, sub.Lines)
+}
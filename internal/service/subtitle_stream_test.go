@@ -0,0 +1,112 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/MimeLyc/contextual-sub-translator/internal/jobs"
+	"github.com/MimeLyc/contextual-sub-translator/internal/media"
+	"github.com/MimeLyc/contextual-sub-translator/internal/subtitle"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/language"
+)
+
+type fakeMediaOperator struct {
+	descriptions subtitle.Descriptions
+	probeErr     error
+	muxed        *[]media.MuxOptions
+	packets      *media.SubtitlePackets
+}
+
+func (f fakeMediaOperator) ReadSubtitleDescription() (subtitle.Descriptions, error) {
+	return f.descriptions, f.probeErr
+}
+
+func (f fakeMediaOperator) ExtractSubtitleToBytes(subtitle.Description) ([]byte, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (f fakeMediaOperator) ExtractSubtitle(subtitle.Description, string, string) (string, error) {
+	return "", errors.New("not implemented")
+}
+
+func (f fakeMediaOperator) DefExtractSubtitle(subtitle.Description) (string, error) {
+	return "", errors.New("not implemented")
+}
+
+func (f fakeMediaOperator) ReadSubtitlePackets(subtitle.Description) (*media.SubtitlePackets, error) {
+	if f.packets == nil {
+		return nil, errors.New("not implemented")
+	}
+	return f.packets, nil
+}
+
+func (f fakeMediaOperator) MuxSubtitle(opts media.MuxOptions) (string, error) {
+	if f.muxed == nil {
+		return "", errors.New("not implemented")
+	}
+	*f.muxed = append(*f.muxed, opts)
+	return opts.OutputPath, nil
+}
+
+func TestSelectSubtitleStream(t *testing.T) {
+	operator := fakeMediaOperator{descriptions: subtitle.Descriptions{
+		{Index: 0, Language: "eng", SubLanguage: "Forced", LangTag: language.English, Codec: "subrip", Forced: true},
+		{Index: 1, Language: "eng", SubLanguage: "English", LangTag: language.English, Codec: "subrip"},
+		{Index: 2, Language: "jpn", LangTag: language.Japanese, Codec: "ass"},
+	}}
+	index := func(n int) *int { return &n }
+
+	stream, err := selectSubtitleStream(operator, jobs.JobPayload{MediaFile: "a.mkv"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, stream.Index, "forced track first must not be picked automatically")
+
+	stream, err = selectSubtitleStream(operator, jobs.JobPayload{MediaFile: "a.mkv", StreamIndex: index(0)})
+	require.NoError(t, err)
+	assert.Equal(t, 0, stream.Index)
+
+	stream, err = selectSubtitleStream(operator, jobs.JobPayload{MediaFile: "a.mkv", StreamPolicy: &subtitle.StreamPolicy{Language: "ja"}})
+	require.NoError(t, err)
+	assert.Equal(t, 2, stream.Index)
+
+	_, err = selectSubtitleStream(operator, jobs.JobPayload{MediaFile: "a.mkv", StreamIndex: index(5)})
+	require.Error(t, err)
+
+	_, err = selectSubtitleStream(operator, jobs.JobPayload{MediaFile: "a.mkv", StreamPolicy: &subtitle.StreamPolicy{Language: "fr"}})
+	require.Error(t, err)
+}
+
+func TestSelectSubtitleStream_ProbeFailure(t *testing.T) {
+	operator := fakeMediaOperator{probeErr: errors.New("ffprobe not found")}
+	index := 3
+
+	stream, err := selectSubtitleStream(operator, jobs.JobPayload{MediaFile: "a.mkv", StreamIndex: &index})
+	require.NoError(t, err)
+	assert.Equal(t, 3, stream.Index)
+
+	stream, err = selectSubtitleStream(operator, jobs.JobPayload{MediaFile: "a.mkv"})
+	require.NoError(t, err)
+	assert.Equal(t, 0, stream.Index)
+
+	_, err = selectSubtitleStream(operator, jobs.JobPayload{MediaFile: "a.mkv", StreamPolicy: &subtitle.StreamPolicy{Language: "en"}})
+	require.Error(t, err)
+}
+
+func TestSyntheticSubtitlePath(t *testing.T) {
+	assert.Equal(t, "/tv/ep01_ctxtrans_embedded.ass", syntheticSubtitlePath("/tv/ep01.mkv", subtitle.Description{Codec: "ssa"}.ExtractFormat()))
+	assert.Equal(t, "/tv/ep01_ctxtrans_embedded.srt", syntheticSubtitlePath("/tv/ep01.mkv", subtitle.Description{Codec: "subrip"}.ExtractFormat()))
+
+	file, err := subtitle.ReadBytes([]byte(testASSStream), syntheticSubtitlePath("/tv/ep01.mkv", "ass"))
+	require.NoError(t, err)
+	assert.Equal(t, "ASS", file.Format)
+	require.Len(t, file.Lines, 1)
+	assert.Equal(t, "Hello", file.Lines[0].Text)
+}
+
+const testASSStream = "[Script Info]\nScriptType: v4.00+\n\n[V4+ Styles]\nFormat: Name, Fontname, Fontsize\nStyle: Default,Arial,20\n\n[Events]\nFormat: Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text\nDialogue: 0,0:00:01.00,0:00:02.00,Default,,0,0,0,,Hello\n"
+
+func TestSubtitleCacheKey(t *testing.T) {
+	assert.Equal(t, "/media/a.mkv|s:0", subtitleCacheKey("/media/a.mkv", 0))
+	assert.Equal(t, "/media/a.mkv|s:2", subtitleCacheKey("/media/a.mkv", 2))
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"sort"
+
+	"github.com/MimeLyc/contextual-sub-translator/internal/subtitle"
+	"github.com/MimeLyc/contextual-sub-translator/internal/termmap"
+	"github.com/MimeLyc/contextual-sub-translator/pkg/log"
+)
+
+// EditTermMap applies edit to the term map file at path, an empty one when
+// it does not exist yet, and saves it. It holds the lock term discovery
+// saves the file with, so edits made while a job runs are not lost.
+func (s *transService) EditTermMap(path string, edit func(tm termmap.TermMap)) (termmap.TermMap, error) {
+	var ret termmap.TermMap
+	err := withTermMapFileLock(path, func() error {
+		tm, err := loadTermMapFile(path)
+		if err != nil {
+			return err
+		}
+		edit(tm)
+		ret = tm
+		return termmap.Save(path, tm)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// loadTermMapFile reads a term map, an empty one when the file does not exist
+func loadTermMapFile(path string) (termmap.TermMap, error) {
+	tm, err := termmap.Load(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return termmap.TermMap{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if tm == nil {
+		tm = termmap.TermMap{}
+	}
+	return tm, nil
+}
+
+// withoutLockedTerms drops the terms locked in the term map file at path
+// from terms found by term discovery. All terms are kept when the locks
+// cannot be loaded.
+func (s *transService) withoutLockedTerms(ctx context.Context, path string, terms termmap.TermMap) termmap.TermMap {
+	if s.store == nil || len(terms) == 0 {
+		return terms
+	}
+	locked, err := s.store.ListLockedTerms(ctx, path)
+	if err != nil {
+		log.Warn("Failed to load locked terms of %s: %v", path, err)
+		return terms
+	}
+	if len(locked) == 0 {
+		return terms
+	}
+	ret := make(termmap.TermMap, len(terms))
+	for key, value := range terms {
+		ret[key] = value
+	}
+	for _, term := range locked {
+		delete(ret, term)
+	}
+	return ret
+}
+
+// recordTermUsage remembers which terms of the term map file at path
+// appear in the lines a job translated, so the term map API can list the
+// jobs each term was used in.
+func (s *transService) recordTermUsage(ctx context.Context, jobID string, path string, tm map[string]string, lines []subtitle.Line) {
+	if s.store == nil || jobID == "" || path == "" || len(tm) == 0 {
+		return
+	}
+	texts := make([]string, 0, len(lines))
+	for _, line := range lines {
+		texts = append(texts, line.Text)
+	}
+	matched := termmap.Match(termmap.TermMap(tm), texts).Matched
+	terms := make([]string, 0, len(matched))
+	for term := range matched {
+		terms = append(terms, term)
+	}
+	sort.Strings(terms)
+	if err := s.store.PutTermUsage(ctx, jobID, path, terms); err != nil {
+		log.Warn("Failed to record term usage of job %s: %v", jobID, err)
+	}
+}
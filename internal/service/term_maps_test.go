@@ -0,0 +1,76 @@
+package service
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/MimeLyc/contextual-sub-translator/internal/persistence"
+	"github.com/MimeLyc/contextual-sub-translator/internal/subtitle"
+	"github.com/MimeLyc/contextual-sub-translator/internal/termmap"
+)
+
+func TestTransService_EditTermMap(t *testing.T) {
+	t.Parallel()
+
+	tmPath := termmap.FilePath(t.TempDir(), "ja", "zh")
+	s := &transService{}
+
+	tm, err := s.EditTermMap(tmPath, func(tm termmap.TermMap) { tm["Okarun"] = "奥卡伦" })
+	require.NoError(t, err)
+	assert.Equal(t, termmap.TermMap{"Okarun": "奥卡伦"}, tm)
+
+	_, err = s.EditTermMap(tmPath, func(tm termmap.TermMap) {
+		tm["Momo"] = "桃"
+		delete(tm, "Okarun")
+	})
+	require.NoError(t, err)
+	loaded, err := termmap.Load(tmPath)
+	require.NoError(t, err)
+	assert.Equal(t, termmap.TermMap{"Momo": "桃"}, loaded)
+}
+
+func TestSaveMergedTermMap_KeepsEditsMadeSinceLoad(t *testing.T) {
+	t.Parallel()
+
+	tmPath := termmap.FilePath(t.TempDir(), "ja", "zh")
+	require.NoError(t, termmap.Save(tmPath, termmap.TermMap{"Okarun": "奥卡伦", "Momo": "桃"}))
+	loadedByJob := map[string]string{"Okarun": "奥卡伦", "Momo": "桃"}
+
+	_, err := (&transService{}).EditTermMap(tmPath, func(tm termmap.TermMap) {
+		tm["Okarun"] = "岡伦"
+		delete(tm, "Momo")
+	})
+	require.NoError(t, err)
+
+	merged, err := saveMergedTermMap(tmPath, loadedByJob, termmap.TermMap{"Turbo Granny": "涡轮婆婆"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"Okarun": "岡伦", "Turbo Granny": "涡轮婆婆"}, merged)
+}
+
+func TestTransService_LockedTermsAndUsage(t *testing.T) {
+	t.Parallel()
+
+	store, err := persistence.NewSQLiteStore(filepath.Join(t.TempDir(), "ctxtrans.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = store.Close() })
+	ctx := context.Background()
+	s := &transService{store: store}
+	tmPath := "/media/tv/Show/term_map.ja-zh.json"
+
+	require.NoError(t, store.SetTermLocked(ctx, tmPath, "Okarun", true))
+	found := termmap.TermMap{"Okarun": "欧卡伦", "Momo": "桃"}
+	assert.Equal(t, termmap.TermMap{"Momo": "桃"}, s.withoutLockedTerms(ctx, tmPath, found))
+	assert.Len(t, found, 2, "the discovered terms are not modified")
+
+	lines := []subtitle.Line{{Text: "Momo, wait!"}, {Text: "Where is Turbo Granny?"}}
+	s.recordTermUsage(ctx, "job-1", tmPath, map[string]string{"Okarun": "奥卡伦", "Momo": "桃"}, lines)
+	usage, err := store.ListTermUsage(ctx, tmPath)
+	require.NoError(t, err)
+	require.Len(t, usage, 1)
+	assert.Equal(t, "job-1", usage[0].JobID)
+	assert.Equal(t, "Momo", usage[0].Term)
+}
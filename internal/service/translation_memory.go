@@ -0,0 +1,191 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/MimeLyc/contextual-sub-translator/internal/jobs"
+	"github.com/MimeLyc/contextual-sub-translator/internal/persistence"
+	"github.com/MimeLyc/contextual-sub-translator/internal/subtitle"
+	"github.com/MimeLyc/contextual-sub-translator/internal/translator"
+	"github.com/MimeLyc/contextual-sub-translator/pkg/log"
+)
+
+const (
+	// minMemoryRunes is the length below which lines are neither remembered
+	// nor reused; short lines like "Yes." or "What?" read differently
+	// depending on who says them to whom
+	minMemoryRunes = 6
+	// memorySimilarity is the bigram similarity from which an earlier line
+	// is offered to the model as a suggestion
+	memorySimilarity = 0.7
+	// maxSimilarMatches caps the suggestions per line
+	maxSimilarMatches = 3
+)
+
+// seriesMemory is the translation memory of one series and language pair,
+// loaded once per job.
+type seriesMemory struct {
+	byKey   map[string]string
+	entries []memoryEntry
+}
+
+type memoryEntry struct {
+	match   translator.MemoryMatch
+	key     string
+	bigrams map[string]struct{}
+}
+
+// loadTranslationMemory loads the translation memory of a series for a
+// language pair, nil when it is empty or cannot be loaded.
+func (s *transService) loadTranslationMemory(ctx context.Context, seriesKey string, sourceLang string, targetLang string) translator.Memory {
+	if s.store == nil {
+		return nil
+	}
+	entries, err := s.store.ListTranslationMemory(ctx, seriesKey, sourceLang, targetLang)
+	if err != nil {
+		log.Error("Failed to load translation memory of %s: %v", seriesKey, err)
+		return nil
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+	log.Info("Loaded %d translation memory entries of %s", len(entries), seriesKey)
+	return newSeriesMemory(entries)
+}
+
+func newSeriesMemory(entries []persistence.TranslationMemoryEntry) *seriesMemory {
+	memory := &seriesMemory{byKey: make(map[string]string, len(entries))}
+	for _, entry := range entries {
+		if utf8.RuneCountInString(entry.SourceKey) < minMemoryRunes {
+			continue
+		}
+		memory.byKey[entry.SourceKey] = entry.Translation
+		memory.entries = append(memory.entries, memoryEntry{
+			match:   translator.MemoryMatch{Source: entry.SourceText, Translation: entry.Translation},
+			key:     entry.SourceKey,
+			bigrams: runeBigrams(entry.SourceKey),
+		})
+	}
+	return memory
+}
+
+func (m *seriesMemory) Exact(text string) (string, bool) {
+	translation, ok := m.byKey[persistence.NormalizeMemorySource(text)]
+	return translation, ok
+}
+
+func (m *seriesMemory) Similar(text string) []translator.MemoryMatch {
+	key := persistence.NormalizeMemorySource(text)
+	if utf8.RuneCountInString(key) < minMemoryRunes {
+		return nil
+	}
+	bigrams := runeBigrams(key)
+
+	type scored struct {
+		match translator.MemoryMatch
+		score float64
+	}
+	var candidates []scored
+	for _, entry := range m.entries {
+		if entry.key == key {
+			continue
+		}
+		if score := diceSimilarity(bigrams, entry.bigrams); score >= memorySimilarity {
+			candidates = append(candidates, scored{match: entry.match, score: score})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].score > candidates[j].score
+	})
+
+	ret := make([]translator.MemoryMatch, 0, min(len(candidates), maxSimilarMatches))
+	for _, candidate := range candidates[:min(len(candidates), maxSimilarMatches)] {
+		ret = append(ret, candidate.match)
+	}
+	return ret
+}
+
+// runeBigrams returns the pairs of adjacent runes of text, which compare
+// alike for spaced and unspaced scripts
+func runeBigrams(text string) map[string]struct{} {
+	runes := []rune(text)
+	ret := make(map[string]struct{}, len(runes))
+	for i := 0; i+1 < len(runes); i++ {
+		ret[string(runes[i:i+2])] = struct{}{}
+	}
+	return ret
+}
+
+// diceSimilarity is the Sørensen–Dice coefficient of two bigram sets
+func diceSimilarity(a, b map[string]struct{}) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	// Sets this far apart in size cannot reach the threshold
+	if 2*float64(len(a))/float64(len(a)+len(b)) < memorySimilarity {
+		return 0
+	}
+	shared := 0
+	for bigram := range a {
+		if _, ok := b[bigram]; ok {
+			shared++
+		}
+	}
+	return 2 * float64(shared) / float64(len(a)+len(b))
+}
+
+// rememberTranslations adds the translated lines to the translation memory of
+// a series. Lines too short to be matched again are left out; manual marks
+// edits made by hand.
+func (s *transService) rememberTranslations(
+	ctx context.Context,
+	seriesKey string,
+	sourceLang string,
+	targetLang string,
+	lines []subtitle.Line,
+	manual bool,
+) {
+	if s.store == nil {
+		return
+	}
+	entries := make([]persistence.TranslationMemoryEntry, 0, len(lines))
+	for _, line := range lines {
+		if utf8.RuneCountInString(persistence.NormalizeMemorySource(line.Text)) < minMemoryRunes {
+			continue
+		}
+		entries = append(entries, persistence.TranslationMemoryEntry{
+			SeriesKey:   seriesKey,
+			SourceLang:  sourceLang,
+			TargetLang:  targetLang,
+			SourceText:  line.Text,
+			Translation: line.TranslatedText,
+			Manual:      manual,
+		})
+	}
+	if len(entries) == 0 {
+		return
+	}
+	if err := s.store.PutTranslationMemory(ctx, entries); err != nil {
+		log.Error("Failed to update translation memory of %s: %v", seriesKey, err)
+	}
+}
+
+// RememberEditedLines adds lines of a job edited by hand to the translation
+// memory of its series; they take precedence over translations of the model.
+func (s *transService) RememberEditedLines(
+	ctx context.Context,
+	job *jobs.TranslationJob,
+	sourceLang string,
+	targetLang string,
+	lines []subtitle.Line,
+) {
+	if job == nil {
+		return
+	}
+	s.rememberTranslations(ctx, jobSeriesKey(job.Payload), sourceLang, targetLang, lines, true)
+}
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/MimeLyc/contextual-sub-translator/internal/jobs"
+	"github.com/MimeLyc/contextual-sub-translator/internal/persistence"
+	"github.com/MimeLyc/contextual-sub-translator/internal/subtitle"
+	"github.com/MimeLyc/contextual-sub-translator/internal/translator"
+)
+
+func TestSeriesMemory_ExactAndSimilar(t *testing.T) {
+	t.Parallel()
+
+	memory := newSeriesMemory([]persistence.TranslationMemoryEntry{
+		{SourceKey: "previously on the show...", SourceText: "Previously on the show...", Translation: "前情提要……"},
+		{SourceKey: "i will become the hokage!", SourceText: "I will become the Hokage!", Translation: "我要成为火影！"},
+		{SourceKey: "what?", SourceText: "What?", Translation: "什么？"},
+	})
+
+	translation, ok := memory.Exact("  PREVIOUSLY on the show... ")
+	require.True(t, ok)
+	assert.Equal(t, "前情提要……", translation)
+
+	_, ok = memory.Exact("What?")
+	assert.False(t, ok, "short lines are not reused")
+
+	similar := memory.Similar("I'll become the Hokage!")
+	require.Len(t, similar, 1)
+	assert.Equal(t, translator.MemoryMatch{Source: "I will become the Hokage!", Translation: "我要成为火影！"}, similar[0])
+
+	assert.Empty(t, memory.Similar("Previously on the show..."), "exact matches are not suggestions")
+	assert.Empty(t, memory.Similar("Something else entirely."))
+}
+
+func TestTransService_RememberTranslations(t *testing.T) {
+	store, err := persistence.NewSQLiteStore(filepath.Join(t.TempDir(), "ctxtrans.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = store.Close() })
+
+	ctx := context.Background()
+	s := &transService{store: store}
+	assert.Nil(t, s.loadTranslationMemory(ctx, "/tv/show", "en", "zh"))
+
+	s.rememberTranslations(ctx, "/tv/show", "en", "zh", []subtitle.Line{
+		{Text: "Previously on the show...", TranslatedText: "前情提要……"},
+		{Text: "Believe it!", TranslatedText: "相信我！"},
+		{Text: "Yes.", TranslatedText: "是。"},
+	}, false)
+	job := &jobs.TranslationJob{Payload: jobs.JobPayload{MediaFile: "/tv/show/episode02.mkv"}}
+	s.RememberEditedLines(ctx, job, "en", "zh", []subtitle.Line{
+		{Text: "Believe it!", TranslatedText: "我说到做到！"},
+	})
+	// A later run of the model keeps the edit
+	s.rememberTranslations(ctx, "/tv/show", "en", "zh", []subtitle.Line{
+		{Text: "Believe it!", TranslatedText: "相信我！"},
+	}, false)
+
+	entries, err := store.ListTranslationMemory(ctx, "/tv/show", "en", "zh")
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+
+	memory := s.loadTranslationMemory(ctx, "/tv/show", "en", "zh")
+	require.NotNil(t, memory)
+	translation, ok := memory.Exact("Believe it!")
+	require.True(t, ok)
+	assert.Equal(t, "我说到做到！", translation)
+}
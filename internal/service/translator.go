@@ -7,6 +7,10 @@ import (
 	"strings"
 
 	"github.com/MimeLyc/contextual-sub-translator/internal/media"
+	"github.com/MimeLyc/contextual-sub-translator/internal/persistence"
+	"github.com/MimeLyc/contextual-sub-translator/internal/reading"
+	"github.com/MimeLyc/contextual-sub-translator/internal/songs"
+	"github.com/MimeLyc/contextual-sub-translator/internal/speaker"
 	"github.com/MimeLyc/contextual-sub-translator/internal/subtitle"
 	"github.com/MimeLyc/contextual-sub-translator/internal/translator"
 	"github.com/MimeLyc/contextual-sub-translator/pkg/log"
@@ -26,6 +30,35 @@ type TranslatorConfig struct {
 	// BackupOriginal bool
 	Verbose bool
 	TermMap map[string]string
+	// OutputMode selects translated-only or bilingual output
+	OutputMode subtitle.OutputMode
+	// OutputEncoding is the character encoding of the output, see subtitle.ParseEncoding
+	OutputEncoding string
+	// DiscardOutput only returns the translation without writing it, e.g. the
+	// pass to a pivot language
+	DiscardOutput bool
+	// PreviousEpisodes and Relationships are the story memory of the series,
+	// sent along when context is enabled
+	PreviousEpisodes []translator.EpisodeSummary
+	Relationships    string
+	// Characters are the profiles of the characters of the series, sent
+	// along when context is enabled
+	Characters []speaker.Profile
+	// Memory recalls how earlier episodes translated the same lines
+	Memory translator.Memory
+	// SongMode is how lines flagged as songs are translated
+	SongMode songs.Mode
+	// ReadingLimits are the reading speed limits of the target language
+	ReadingLimits reading.Limits
+	// Condenser shortens the lines over ReadingLimits before the output is
+	// written; nil leaves them as translated
+	Condenser *translator.Condenser
+	// Reviewer checks each translated batch; lines with issues at or above
+	// ReviewSeverity are translated again. nil skips the review
+	Reviewer       *translator.Reviewer
+	ReviewSeverity translator.Severity
+	// RecordReview stores the review of a batch; nil drops it
+	RecordReview func(ctx context.Context, review persistence.TranslationReview)
 }
 
 func (c TranslatorConfig) OutputPath() string {
@@ -74,25 +107,37 @@ func (t *SubTranslator) Translate(
 			contextInfo = *tvShowInfo
 		}
 	}
+	mediaMeta := translator.MediaMeta{
+		TVShowInfo:    contextInfo,
+		TermMap:       t.config.TermMap,
+		Memory:        t.config.Memory,
+		SongMode:      t.config.SongMode,
+		ReadingLimits: t.config.ReadingLimits,
+	}
+	if t.config.ContextEnabled {
+		mediaMeta.PreviousEpisodes = t.config.PreviousEpisodes
+		mediaMeta.Relationships = t.config.Relationships
+		mediaMeta.Characters = t.config.Characters
+	}
 	// Perform translation
-	translations, err := t.translateSubtitleLines(
-		ctx, translator.MediaMeta{
-			TVShowInfo: contextInfo,
-			TermMap:    t.config.TermMap,
-		}, t.file.Lines)
+	translations, err := t.translateSubtitleLines(ctx, mediaMeta, t.file.Lines)
 	if err != nil {
 		return nil, fmt.Errorf("failed to translate subtitles: %w", err)
 	}
+	t.condenseLines(ctx, mediaMeta, translations)
 
 	// Update translation results
 	translatedFile := &subtitle.File{
 		Lines:    translations,
 		Language: t.config.TargetLanguage,
 		Format:   t.file.Format,
+		ASS:      t.file.ASS,
+		VTT:      t.file.VTT,
+		Encoding: t.file.Encoding,
 	}
 
 	// Save translation results if output path is specified
-	if outputPath != "" {
+	if outputPath != "" && !t.config.DiscardOutput {
 		if err := t.subtitleWriter.Write(outputPath, translatedFile); err != nil {
 			return nil, fmt.Errorf("failed to save translation results: %w", err)
 		}
@@ -128,20 +173,30 @@ func (t *SubTranslator) translateSubtitleLines(
 		return nil, nil
 	}
 
+	batchSize := t.config.BatchSize
+	if batchSize <= 0 {
+		batchSize = 50
+	}
+
 	checkpointStore := batchCheckpointStoreFromContext(ctx)
 	if checkpointStore == nil {
-		return t.translator.BatchTranslate(
+		translated, err := t.translator.BatchTranslate(
 			ctx,
 			media,
 			lines,
 			t.file.Language.String(),
 			t.config.TargetLanguage.String(),
 			t.config.BatchSize)
-	}
-
-	batchSize := t.config.BatchSize
-	if batchSize <= 0 {
-		batchSize = 50
+		if err != nil {
+			return nil, err
+		}
+		for start := 0; start < len(translated); start += batchSize {
+			end := min(start+batchSize, len(translated))
+			batchMedia := media
+			batchMedia.Window = translator.SurroundingLines(translated, start, end)
+			t.reviewBatch(withUsageBatch(ctx, start, end), batchMedia, translated[start:end], start)
+		}
+		return translated, nil
 	}
 
 	result := make([]subtitle.Line, len(lines))
@@ -151,6 +206,8 @@ func (t *SubTranslator) translateSubtitleLines(
 			StartTime: line.StartTime,
 			EndTime:   line.EndTime,
 			Text:      line.Text,
+			Speaker:   line.Speaker,
+			Song:      line.Song,
 		}
 	}
 
@@ -165,9 +222,12 @@ func (t *SubTranslator) translateSubtitleLines(
 
 		batchLines := make([]subtitle.Line, end-start)
 		copy(batchLines, lines[start:end])
+		// result holds the translations of earlier batches, resumed or not
+		batchMedia := media
+		batchMedia.Window = translator.SurroundingLines(result, start, end)
 		translated, err := t.translator.BatchTranslate(
-			ctx,
-			media,
+			withUsageBatch(ctx, start, end),
+			batchMedia,
 			batchLines,
 			t.file.Language.String(),
 			t.config.TargetLanguage.String(),
@@ -179,6 +239,7 @@ func (t *SubTranslator) translateSubtitleLines(
 		if len(translated) != (end - start) {
 			return nil, fmt.Errorf("translation count mismatch for lines %d-%d: expected %d, got %d", start+1, end, end-start, len(translated))
 		}
+		t.reviewBatch(withUsageBatch(ctx, start, end), batchMedia, translated, start)
 		translatedTexts := make([]string, 0, len(translated))
 		for i := start; i < end; i++ {
 			result[i] = translated[i-start]
@@ -271,7 +332,7 @@ func NewTranslator(
 	if config.SubtitleFile != nil {
 		return &SubTranslator{
 			nfoReader:      NewNFOReader(),
-			subtitleWriter: subtitle.NewWriter(),
+			subtitleWriter: subtitle.NewWriterWithEncoding(config.OutputMode, config.OutputEncoding),
 			config:         config,
 			translator:     cli,
 			file:           config.SubtitleFile,
@@ -280,7 +341,7 @@ func NewTranslator(
 	return &FileTranslator{
 		nfoReader:      NewNFOReader(),
 		subtitleReader: subtitle.NewReader(config.InputPath),
-		subtitleWriter: subtitle.NewWriter(),
+		subtitleWriter: subtitle.NewWriterWithEncoding(config.OutputMode, config.OutputEncoding),
 		config:         config,
 		translator:     cli,
 	}, nil
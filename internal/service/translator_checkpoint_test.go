@@ -85,3 +85,71 @@ func TestSubTranslator_TranslateSubtitleLines_ResumesFromCheckpoints(t *testing.
 
 	mockTrans.AssertExpectations(t)
 }
+
+func TestSubTranslator_TranslateSubtitleLines_SendsResumedLinesAsContext(t *testing.T) {
+	lines := []subtitle.Line{
+		{Index: 1, Text: "hello"},
+		{Index: 2, Text: "world"},
+		{Index: 3, Text: "bye"},
+		{Index: 4, Text: "see you"},
+		{Index: 5, Text: "later"},
+	}
+
+	mockTrans := &mockTranslator{}
+	mockTrans.On(
+		"BatchTranslate",
+		mock.Anything,
+		mock.MatchedBy(func(media translator.MediaMeta) bool {
+			return assert.ObjectsAreEqual(translator.ContextWindow{
+				Before: []translator.ContextLine{
+					{Text: "hello", TranslatedText: "你好"},
+					{Text: "world", TranslatedText: "世界"},
+				},
+				After: []translator.ContextLine{{Text: "later"}},
+			}, media.Window)
+		}),
+		lines[2:4],
+		"en",
+		"zh",
+		2,
+	).Return([]subtitle.Line{
+		{Index: 3, Text: "bye", TranslatedText: "再见"},
+		{Index: 4, Text: "see you", TranslatedText: "回见"},
+	}, nil).Once()
+	mockTrans.On(
+		"BatchTranslate",
+		mock.Anything,
+		mock.MatchedBy(func(media translator.MediaMeta) bool {
+			before := media.Window.Before
+			return len(before) == 4 && before[3].TranslatedText == "回见" && len(media.Window.After) == 0
+		}),
+		lines[4:5],
+		"en",
+		"zh",
+		1,
+	).Return([]subtitle.Line{
+		{Index: 5, Text: "later", TranslatedText: "待会儿"},
+	}, nil).Once()
+
+	subTrans := &SubTranslator{
+		translator: mockTrans,
+		config: TranslatorConfig{
+			TargetLanguage: language.Chinese,
+			BatchSize:      2,
+		},
+		file: &subtitle.File{Language: language.English},
+	}
+
+	cp := &inMemoryCheckpointStore{
+		cached: map[string][]string{
+			batchKey(0, 2): {"你好", "世界"},
+		},
+	}
+	ctx := withBatchCheckpointStore(context.Background(), cp)
+
+	ret, err := subTrans.translateSubtitleLines(ctx, translator.MediaMeta{}, lines)
+	require.NoError(t, err)
+	require.Len(t, ret, 5)
+	assert.Equal(t, "待会儿", ret[4].TranslatedText)
+	mockTrans.AssertExpectations(t)
+}
@@ -76,12 +76,19 @@ type MediaBundle struct {
 	MediaFile     string
 	NFOFiles      []media.TVShowInfo
 	SubtitleFiles []subtitle.File
+	OutputMode    subtitle.OutputMode // empty uses the configured output mode
+	// TargetLanguage is the language to translate to; language.Und uses the
+	// primary configured target language
+	TargetLanguage language.Tag
 }
 
 type MediaPathBundle struct {
 	MediaFile     string
 	NFOFiles      []string
 	SubtitleFiles []string
+	// TargetLanguage is the language to translate to; language.Und uses the
+	// primary configured target language
+	TargetLanguage language.Tag
 }
 
 func (b MediaPathBundle) ExistTargetSubtitle(lang string) int {
@@ -96,25 +103,6 @@ func (b MediaPathBundle) ExistTargetSubtitle(lang string) int {
 
 type SourceBundles []MediaPathBundle
 
-var subtitleExts = []string{
-	".srt",  // SubRip
-	".ass",  // Advanced SubStation Alpha
-	".ssa",  // SubStation Alpha
-	".vtt",  // WebVTT
-	".sub",  // MicroDVD/SubViewer
-	".idx",  // VobSub index
-	".sup",  // Blu-ray PGS
-	".usf",  // Universal Subtitle Format
-	".ttml", // Timed Text Markup Language
-	".dfxp", // Distribution Format Exchange Profile
-	".sbv",  // YouTube
-	".lrc",  // LyRiCs
-	".rt",   // RealText
-	".smi",  // SAMI
-	".stl",  // Spruce subtitle format
-	".txt",  // Plain text (sometimes used for subtitles)
-}
-
 var mediaExts = []string{
 	// Container formats that support embedded subtitles
 	".mkv",  // Matroska Video
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/MimeLyc/contextual-sub-translator/internal/agent"
+	"github.com/MimeLyc/contextual-sub-translator/internal/config"
+	"github.com/MimeLyc/contextual-sub-translator/internal/persistence"
+	"github.com/MimeLyc/contextual-sub-translator/pkg/log"
+)
+
+// usageLabels tag the LLM calls made under a context with what they were made for
+type usageLabels struct {
+	jobID     string
+	seriesKey string
+	stage     string
+	// batchStart and batchEnd bound the translation batch, -1 outside batches
+	batchStart int
+	batchEnd   int
+}
+
+type usageLabelsContextKey struct{}
+
+func withUsageLabels(ctx context.Context, labels usageLabels) context.Context {
+	return context.WithValue(ctx, usageLabelsContextKey{}, labels)
+}
+
+func usageLabelsFromContext(ctx context.Context) usageLabels {
+	if ctx != nil {
+		if labels, ok := ctx.Value(usageLabelsContextKey{}).(usageLabels); ok {
+			return labels
+		}
+	}
+	return usageLabels{batchStart: -1, batchEnd: -1}
+}
+
+// withUsageStage labels the LLM calls made under ctx with a stage
+func withUsageStage(ctx context.Context, stage string) context.Context {
+	labels := usageLabelsFromContext(ctx)
+	labels.stage = stage
+	labels.batchStart, labels.batchEnd = -1, -1
+	return withUsageLabels(ctx, labels)
+}
+
+// withUsageBatch labels the LLM calls made under ctx with a translation batch
+func withUsageBatch(ctx context.Context, start, end int) context.Context {
+	labels := usageLabelsFromContext(ctx)
+	labels.batchStart, labels.batchEnd = start, end
+	return withUsageLabels(ctx, labels)
+}
+
+// usageRecorder persists the usage of LLM calls with their labels and cost,
+// and stops the job once its usage reaches the job budget.
+type usageRecorder struct {
+	store  *persistence.SQLiteStore
+	llm    config.LLMConfig
+	jobCap config.BudgetCap
+	stop   context.CancelCauseFunc
+
+	mu        sync.Mutex
+	jobTokens int
+	jobCost   float64
+}
+
+// withUsageRecording returns a context under which the LLM calls made for a
+// job are recorded, or ctx as is when there is no store to record them in.
+// The context is canceled with errBudgetExceeded once the job budget is
+// reached; release frees it when the job is done.
+func (s *transService) withUsageRecording(
+	ctx context.Context,
+	jobID string,
+	seriesKey string,
+	cfg config.Config,
+) (recordCtx context.Context, release func()) {
+	if s.store == nil {
+		return ctx, func() {}
+	}
+	ctx, cancel := context.WithCancelCause(ctx)
+	recorder := &usageRecorder{store: s.store, llm: cfg.LLM, jobCap: cfg.Budget.Job, stop: cancel}
+	if jobID != "" && recorder.jobCap != (config.BudgetCap{}) {
+		// a resumed job already spent part of its budget
+		totals, err := s.store.JobUsage(ctx, jobID)
+		if err != nil {
+			log.Warn("Failed to load LLM usage of job %s: %v", jobID, err)
+		}
+		recorder.jobTokens = totals.PromptTokens + totals.CompletionTokens
+		recorder.jobCost = totals.Cost
+	}
+	ctx = withUsageLabels(ctx, usageLabels{jobID: jobID, seriesKey: seriesKey, batchStart: -1, batchEnd: -1})
+	return agent.WithUsageRecorder(ctx, recorder), func() { cancel(nil) }
+}
+
+// RecordUsage stores one call. The call was paid for even when the job is
+// being cancelled, so the row is written regardless.
+func (r *usageRecorder) RecordUsage(ctx context.Context, record agent.UsageRecord) {
+	labels := usageLabelsFromContext(ctx)
+	cost := r.llm.CostOf(record.Model, record.Usage.PromptTokens, record.Usage.CompletionTokens)
+	if err := r.store.AddLLMUsage(context.WithoutCancel(ctx), persistence.LLMUsage{
+		JobID:            labels.jobID,
+		SeriesKey:        labels.seriesKey,
+		Stage:            labels.stage,
+		BatchStart:       labels.batchStart,
+		BatchEnd:         labels.batchEnd,
+		Provider:         record.Provider,
+		Model:            record.Model,
+		PromptTokens:     record.Usage.PromptTokens,
+		CompletionTokens: record.Usage.CompletionTokens,
+		Cost:             cost,
+	}); err != nil {
+		log.Warn("Failed to record LLM usage of job %s: %v", labels.jobID, err)
+	}
+
+	r.mu.Lock()
+	r.jobTokens += record.Usage.PromptTokens + record.Usage.CompletionTokens
+	r.jobCost += cost
+	reached := r.jobCap.Reached(r.jobTokens, r.jobCost)
+	r.mu.Unlock()
+	if reached != "" {
+		r.stop(fmt.Errorf("%w: job budget reached: %s", errBudgetExceeded, reached))
+	}
+}
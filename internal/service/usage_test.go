@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/language"
+
+	"github.com/MimeLyc/contextual-sub-translator/internal/agent"
+	"github.com/MimeLyc/contextual-sub-translator/internal/config"
+	"github.com/MimeLyc/contextual-sub-translator/internal/persistence"
+	"github.com/MimeLyc/contextual-sub-translator/internal/subtitle"
+	"github.com/MimeLyc/contextual-sub-translator/internal/translator"
+)
+
+// newUsageAgent returns an agent whose every call reports the given usage
+func newUsageAgent(t *testing.T, promptTokens int, completionTokens int) *agent.LLMAgent {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"choices":[{"index":0,"finish_reason":"stop","message":{"role":"assistant","content":"ok"}}],`+
+			`"usage":{"prompt_tokens":%d,"completion_tokens":%d}}`, promptTokens, completionTokens)
+	}))
+	t.Cleanup(server.Close)
+	llmAgent, err := agent.NewLLMAgent(agent.LLMConfig{APIKey: "test-key", APIURL: server.URL, Model: "gpt-a", Timeout: 10}, nil, 1)
+	require.NoError(t, err)
+	return llmAgent
+}
+
+func TestTransService_RecordsLabelledUsage(t *testing.T) {
+	store, err := persistence.NewSQLiteStore(filepath.Join(t.TempDir(), "ctxtrans.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = store.Close() })
+
+	s := &transService{store: store}
+	cfg := config.Config{LLM: config.LLMConfig{Prices: map[string]config.ModelPrice{"gpt-a": {Prompt: 2, Completion: 8}}}}
+	ctx, release := s.withUsageRecording(context.Background(), "job-1", "/tv/show", cfg)
+	defer release()
+
+	llmAgent := newUsageAgent(t, 1000, 200)
+	_, err = llmAgent.Execute(withUsageBatch(withUsageStage(ctx, config.StageTranslate), 0, 40), agent.AgentRequest{UserMessage: "hello"})
+	require.NoError(t, err)
+
+	totals, err := store.JobUsage(context.Background(), "job-1")
+	require.NoError(t, err)
+	assert.Equal(t, 1, totals.Calls)
+	assert.Equal(t, 1000, totals.PromptTokens)
+	assert.InDelta(t, 0.0036, totals.Cost, 1e-12)
+
+	series, err := store.UsageBreakdown(context.Background(), persistence.UsageBySeries, time.Now())
+	require.NoError(t, err)
+	require.Len(t, series, 1)
+	assert.Equal(t, "/tv/show", series[0].Key)
+
+	plain, _ := (&transService{}).withUsageRecording(ctx, "job-1", "/tv/show", cfg)
+	assert.Equal(t, ctx, plain, "no store, nothing recorded")
+}
+
+func TestSubTranslator_TranslateSubtitleLines_LabelsBatchUsage(t *testing.T) {
+	lines := []subtitle.Line{{Index: 1, Text: "hello"}, {Index: 2, Text: "world"}, {Index: 3, Text: "bye"}}
+
+	var batches [][2]int
+	mockTrans := &mockTranslator{}
+	recordBatch := func(args mock.Arguments) {
+		labels := usageLabelsFromContext(args.Get(0).(context.Context))
+		assert.Equal(t, config.StageTranslate, labels.stage)
+		batches = append(batches, [2]int{labels.batchStart, labels.batchEnd})
+	}
+	mockTrans.On("BatchTranslate", mock.Anything, mock.Anything, lines[0:2], "en", "zh", 2).
+		Run(recordBatch).Return(lines[0:2], nil).Once()
+	mockTrans.On("BatchTranslate", mock.Anything, mock.Anything, lines[2:3], "en", "zh", 1).
+		Run(recordBatch).Return(lines[2:3], nil).Once()
+
+	subTrans := &SubTranslator{
+		translator: mockTrans,
+		config:     TranslatorConfig{TargetLanguage: language.Chinese, BatchSize: 2},
+		file:       &subtitle.File{Language: language.English},
+	}
+	ctx := withBatchCheckpointStore(withUsageStage(context.Background(), config.StageTranslate), &inMemoryCheckpointStore{})
+
+	_, err := subTrans.translateSubtitleLines(ctx, translator.MediaMeta{}, lines)
+	require.NoError(t, err)
+	assert.Equal(t, [][2]int{{0, 2}, {2, 3}}, batches)
+}
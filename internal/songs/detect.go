@@ -0,0 +1,158 @@
+package songs
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/MimeLyc/contextual-sub-translator/internal/subtitle"
+)
+
+// Mode selects how the lines of opening, ending and insert songs are translated
+type Mode string
+
+const (
+	// ModeLyrics translates songs with a prompt meant for lyrics
+	ModeLyrics Mode = "lyrics"
+	// ModeReuse reuses the translation an earlier episode stored for a song
+	// line, and translates the lines it has none for as lyrics
+	ModeReuse Mode = "reuse"
+	// ModeOriginal keeps the original lyrics as their translation
+	ModeOriginal Mode = "original"
+	// ModeSkip leaves songs untranslated; nothing is sent to the model
+	ModeSkip Mode = "skip"
+)
+
+// ParseMode parses a song mode; empty selects ModeLyrics.
+func ParseMode(raw string) (Mode, error) {
+	switch mode := Mode(strings.ToLower(strings.TrimSpace(raw))); mode {
+	case "":
+		return ModeLyrics, nil
+	case ModeLyrics, ModeReuse, ModeOriginal, ModeSkip:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unsupported song mode %q (want lyrics, reuse, original or skip)", raw)
+	}
+}
+
+// Translates reports whether song lines are sent to the model in this mode
+func (m Mode) Translates() bool {
+	return m == ModeLyrics || m == ModeReuse
+}
+
+const (
+	// minRunLines is the number of consecutive styled lines that make a song
+	minRunLines = 4
+	// maxRunGap is the longest pause between two lines of one song
+	maxRunGap = 5 * time.Second
+	// edgeShare bounds the start and end of an episode where openings and
+	// endings play
+	edgeShare = 0.25
+)
+
+var (
+	// songStylePattern matches ASS style names of song lines,
+	// e.g. "OP", "ED_Romaji", "Insert Song" or "Lyrics-JP"
+	songStylePattern = regexp.MustCompile(`(?i)(?:^|[^a-z])(?:op|ed|opening|ending|song|songs|lyric|lyrics|karaoke|insert)(?:$|[^a-z])`)
+	// italicPattern matches text italicised as a whole by an ASS override or HTML tag
+	italicPattern = regexp.MustCompile(`^(?:\{[^}]*\\i1[^}]*\}|<i>)`)
+)
+
+// Detect returns whether each line of file belongs to a song. Lines with a
+// song style, a music note or known lyrics are songs wherever they are;
+// italicised runs are songs when they play at the start or the end of the
+// episode. known reports the lines earlier episodes detected as lyrics and
+// may be nil.
+func Detect(file *subtitle.File, known func(text string) bool) []bool {
+	ret := Marked(file)
+	if len(file.Lines) == 0 {
+		return ret
+	}
+	italic := italicLines(file)
+
+	for i, line := range file.Lines {
+		if known != nil && known(line.Text) {
+			ret[i] = true
+		}
+	}
+
+	end := file.Lines[len(file.Lines)-1].EndTime
+	for start := 0; start < len(file.Lines); {
+		if !italic[file.Lines[start].Index] && !ret[start] {
+			start++
+			continue
+		}
+		stop := start + 1
+		for stop < len(file.Lines) &&
+			(italic[file.Lines[stop].Index] || ret[stop]) &&
+			file.Lines[stop].StartTime-file.Lines[stop-1].EndTime <= maxRunGap {
+			stop++
+		}
+		first, last := file.Lines[start], file.Lines[stop-1]
+		atEdge := float64(first.StartTime) <= edgeShare*float64(end) || float64(last.EndTime) >= (1-edgeShare)*float64(end)
+		if stop-start >= minRunLines && atEdge {
+			for i := start; i < stop; i++ {
+				ret[i] = true
+			}
+		}
+		start = stop
+	}
+	return ret
+}
+
+// Marked returns whether each line of file is marked as a song by the
+// release itself, with a song style or a music note. Unlike the lines Detect
+// only guesses from italics, these are safe to remember as lyrics.
+func Marked(file *subtitle.File) []bool {
+	ret := make([]bool, len(file.Lines))
+	styled := songStyledLines(file)
+	for i, line := range file.Lines {
+		ret[i] = styled[line.Index] || strings.ContainsAny(line.Text, "♪♫♬")
+	}
+	return ret
+}
+
+// songStyledLines returns the lines of ASS events whose style marks them as
+// a song, by line index. The Name field is left alone, characters may well be
+// called Ed.
+func songStyledLines(file *subtitle.File) map[int]bool {
+	ret := make(map[int]bool)
+	if file.ASS == nil {
+		return ret
+	}
+	styleIdx := -1
+	for i, field := range file.ASS.EventFormat {
+		if strings.EqualFold(strings.TrimSpace(field), "Style") {
+			styleIdx = i
+		}
+	}
+	if styleIdx < 0 {
+		return ret
+	}
+	for _, event := range file.ASS.Events {
+		if event.LineIndex > 0 && styleIdx < len(event.Fields) && songStylePattern.MatchString(strings.TrimSpace(event.Fields[styleIdx])) {
+			ret[event.LineIndex] = true
+		}
+	}
+	return ret
+}
+
+// italicLines returns the lines italicised as a whole, by line index. ASS
+// keeps the leading override of an event outside the line text.
+func italicLines(file *subtitle.File) map[int]bool {
+	ret := make(map[int]bool)
+	for _, line := range file.Lines {
+		if italicPattern.MatchString(strings.TrimSpace(line.Text)) {
+			ret[line.Index] = true
+		}
+	}
+	if file.ASS != nil {
+		for _, event := range file.ASS.Events {
+			if event.LineIndex > 0 && italicPattern.MatchString(event.Override) {
+				ret[event.LineIndex] = true
+			}
+		}
+	}
+	return ret
+}
@@ -0,0 +1,97 @@
+package songs
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/MimeLyc/contextual-sub-translator/internal/subtitle"
+)
+
+func TestParseMode(t *testing.T) {
+	t.Parallel()
+
+	for raw, want := range map[string]Mode{
+		"":         ModeLyrics,
+		"lyrics":   ModeLyrics,
+		" Reuse ":  ModeReuse,
+		"ORIGINAL": ModeOriginal,
+		"skip":     ModeSkip,
+	} {
+		got, err := ParseMode(raw)
+		require.NoError(t, err, raw)
+		assert.Equal(t, want, got, raw)
+	}
+
+	_, err := ParseMode("karaoke")
+	require.Error(t, err)
+	assert.True(t, ModeReuse.Translates())
+	assert.False(t, ModeOriginal.Translates())
+}
+
+func TestDetect_ASSStyles(t *testing.T) {
+	t.Parallel()
+
+	data := []byte("[Script Info]\nScriptType: v4.00+\n\n[Events]\n" +
+		"Format: Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text\n" +
+		"Dialogue: 0,0:00:01.00,0:00:04.00,OP_Romaji,,0,0,0,,Kimi no koe ga\n" +
+		"Dialogue: 0,0:00:05.00,0:00:08.00,Default,Ed,0,0,0,,Brother, wait!\n" +
+		"Dialogue: 0,0:20:01.00,0:20:04.00,Insert Song,,0,0,0,,Hold my hand\n" +
+		"Dialogue: 0,0:21:00.00,0:21:04.00,Default,,0,0,0,,♪ La la la ♪\n")
+	file, err := subtitle.ReadASSBytes(data, "episode.ass")
+	require.NoError(t, err)
+
+	assert.Equal(t, []bool{true, false, true, true}, Detect(file, nil))
+	assert.Equal(t, []bool{true, false, true, true}, Marked(file))
+}
+
+func TestDetect_ItalicRunsAtTheEdges(t *testing.T) {
+	t.Parallel()
+
+	var lines []subtitle.Line
+	add := func(at time.Duration, text string) {
+		lines = append(lines, subtitle.Line{Index: len(lines) + 1, StartTime: at, EndTime: at + 3*time.Second, Text: text})
+	}
+	// an italic opening, a short italic flashback mid-episode, a long
+	// italic narration mid-episode and an italic ending
+	for i := range 4 {
+		add(time.Duration(i)*4*time.Second, fmt.Sprintf("<i>Opening line %d</i>", i))
+	}
+	add(5*time.Minute, "Dialogue")
+	add(10*time.Minute, "<i>Flashback</i>")
+	add(10*time.Minute+4*time.Second, "<i>Flashback again</i>")
+	for i := range 5 {
+		add(12*time.Minute+time.Duration(i)*4*time.Second, fmt.Sprintf("<i>Narration %d</i>", i))
+	}
+	add(15*time.Minute, "Dialogue")
+	for i := range 4 {
+		add(22*time.Minute+time.Duration(i)*4*time.Second, fmt.Sprintf("{\\i1}Ending line %d", i))
+	}
+
+	detected := Detect(&subtitle.File{Lines: lines}, nil)
+	var got []string
+	for i, song := range detected {
+		if song {
+			got = append(got, lines[i].Text)
+		}
+	}
+	require.Len(t, got, 8)
+	assert.True(t, strings.HasPrefix(got[0], "<i>Opening"))
+	assert.True(t, strings.HasPrefix(got[7], "{\\i1}Ending"))
+	assert.NotContains(t, Marked(&subtitle.File{Lines: lines}), true, "italics are only a guess")
+}
+
+func TestDetect_KnownLyrics(t *testing.T) {
+	t.Parallel()
+
+	file := &subtitle.File{Lines: []subtitle.Line{
+		{Index: 1, StartTime: 12 * time.Minute, EndTime: 12*time.Minute + time.Second, Text: "Kimi no koe ga"},
+		{Index: 2, StartTime: 13 * time.Minute, EndTime: 13*time.Minute + time.Second, Text: "Hello"},
+	}}
+	detected := Detect(file, func(text string) bool { return text == "Kimi no koe ga" })
+	assert.Equal(t, []bool{true, false}, detected)
+}
@@ -0,0 +1,269 @@
+package speaker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/MimeLyc/contextual-sub-translator/internal/agent"
+	"github.com/MimeLyc/contextual-sub-translator/internal/media"
+	"github.com/MimeLyc/contextual-sub-translator/internal/subtitle"
+	"github.com/MimeLyc/contextual-sub-translator/pkg/log"
+)
+
+// attributionBatchSize is the number of lines sent per attribution call
+const attributionBatchSize = 200
+
+// Profile describes a character, so translations get pronouns, gendered
+// forms and politeness levels right
+type Profile struct {
+	Name      string   `json:"name"`
+	Gender    string   `json:"gender"`    // male, female or unknown
+	Formality string   `json:"formality"` // how the character speaks, e.g. polite, casual, rough
+	Nicknames []string `json:"nicknames"`
+}
+
+// Attribution is the result of the speaker pre-pass
+type Attribution struct {
+	// Speakers holds the likely speaker per line, empty when unknown.
+	// Lines with several speakers list them separated by " / ".
+	Speakers []string
+	// Profiles are the characters found in the dialogue
+	Profiles []Profile
+}
+
+// Attributor infers the speakers of subtitle lines using an LLM agent.
+type Attributor struct {
+	agent agent.Agent
+}
+
+// NewAttributor creates a new speaker attributor.
+func NewAttributor(a agent.Agent) *Attributor {
+	return &Attributor{agent: a}
+}
+
+type attributionLine struct {
+	Index    int    `json:"index"`
+	Text     string `json:"text"`
+	Speaker  string `json:"speaker,omitempty"`
+	Dialogue bool   `json:"dialogue,omitempty"`
+}
+
+type attributionResponse struct {
+	Speakers []struct {
+		Index   int    `json:"index"`
+		Speaker string `json:"speaker"`
+	} `json:"speakers"`
+	Characters []Profile `json:"characters"`
+}
+
+// Attribute infers the likely speaker of every line of file and profiles
+// the characters. Speakers named by the file itself always win. known are the
+// profiles of the series so far; they are sent along so names stay stable.
+// A batch that fails keeps only the speakers named by the file; an error is
+// returned when every batch failed.
+func (a *Attributor) Attribute(
+	ctx context.Context,
+	showInfo media.TVShowInfo,
+	file *subtitle.File,
+	known []Profile,
+) (Attribution, error) {
+	hints := Hints(file)
+	ret := Attribution{Speakers: make([]string, len(file.Lines))}
+	for i, hint := range hints {
+		ret.Speakers[i] = strings.Join(hint.Speakers, " / ")
+	}
+	if len(file.Lines) == 0 {
+		return ret, nil
+	}
+
+	systemPrompt := buildAttributionPrompt(showInfo, known)
+	profiles := newProfileSet(known)
+	var lastErr error
+	failed := 0
+	batches := 0
+	for start := 0; start < len(file.Lines); start += attributionBatchSize {
+		end := min(start+attributionBatchSize, len(file.Lines))
+		batches++
+
+		response, err := a.attributeBatch(ctx, systemPrompt, file.Lines[start:end], hints[start:end])
+		if err != nil {
+			log.Warn("Speaker attribution failed for lines %d-%d: %v", start+1, end, err)
+			lastErr = err
+			failed++
+			continue
+		}
+		for _, item := range response.Speakers {
+			i := start + item.Index - 1
+			if item.Index < 1 || i >= end || ret.Speakers[i] != "" {
+				continue
+			}
+			ret.Speakers[i] = strings.TrimSpace(item.Speaker)
+		}
+		for _, profile := range response.Characters {
+			profiles.add(profile)
+		}
+	}
+	ret.Profiles = profiles.discovered()
+	if failed == batches {
+		return ret, fmt.Errorf("speaker attribution failed: %w", lastErr)
+	}
+	return ret, nil
+}
+
+func (a *Attributor) attributeBatch(ctx context.Context, systemPrompt string, lines []subtitle.Line, hints []Hint) (attributionResponse, error) {
+	payload := make([]attributionLine, 0, len(lines))
+	for i, line := range lines {
+		payload = append(payload, attributionLine{
+			Index:    i + 1,
+			Text:     line.PlainText(),
+			Speaker:  strings.Join(hints[i].Speakers, " / "),
+			Dialogue: hints[i].Dialogue,
+		})
+	}
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return attributionResponse{}, err
+	}
+
+	result, err := a.agent.Execute(ctx, agent.AgentRequest{
+		SystemPrompt:  systemPrompt,
+		UserMessage:   string(encoded),
+		MaxIterations: 1,
+		JSONMode:      true,
+	})
+	if err != nil {
+		return attributionResponse{}, fmt.Errorf("agent execution failed: %w", err)
+	}
+	return parseAttributionResponse(result.Content)
+}
+
+func buildAttributionPrompt(showInfo media.TVShowInfo, known []Profile) string {
+	var prompt strings.Builder
+
+	prompt.WriteString("You attribute subtitle lines to the characters who speak them, so a translator can pick the right pronouns and politeness levels. Output ONLY a JSON object.\n\n")
+
+	prompt.WriteString("=== SHOW INFORMATION ===\n")
+	if showInfo.Title != "" {
+		prompt.WriteString(fmt.Sprintf("Title: %s\n", showInfo.Title))
+	}
+	if showInfo.Plot != "" {
+		prompt.WriteString(fmt.Sprintf("Plot: %s\n", showInfo.Plot))
+	}
+	var roles []string
+	for _, actor := range showInfo.Actors {
+		if actor.Role != "" {
+			roles = append(roles, actor.Role)
+		}
+	}
+	if len(roles) > 0 {
+		prompt.WriteString(fmt.Sprintf("Characters: %s\n", strings.Join(roles, ", ")))
+	}
+	if len(known) > 0 {
+		prompt.WriteString("\n=== KNOWN CHARACTERS ===\n")
+		for _, profile := range known {
+			prompt.WriteString("  " + FormatProfile(profile) + "\n")
+		}
+	}
+
+	prompt.WriteString("\n=== TASK ===\n")
+	prompt.WriteString("The input is a JSON array of lines. speaker is set when the subtitle file names the speaker; keep it.\n")
+	prompt.WriteString("dialogue marks lines where dashes put two speakers in one line; name both as \"A / B\".\n")
+	prompt.WriteString("Infer the speaker of the other lines from who is addressed, who answers and the flow of the conversation.\n")
+	prompt.WriteString("Leave speaker empty when you cannot tell. Use the names above for characters that appear there.\n")
+	prompt.WriteString("List every character who speaks with gender (male, female or unknown), formality (how they speak, e.g. polite, casual, rough, childish) and the nicknames they are called by.\n\n")
+
+	prompt.WriteString("=== RESPONSE FORMAT (MANDATORY) ===\n")
+	prompt.WriteString("{\"speakers\": [{\"index\": 1, \"speaker\": \"Name\"}], \"characters\": [{\"name\": \"Name\", \"gender\": \"female\", \"formality\": \"polite\", \"nicknames\": [\"Nick\"]}]}\n")
+	prompt.WriteString("NO markdown, NO explanations. The response must start with { and end with }.\n")
+
+	return prompt.String()
+}
+
+// parseAttributionResponse parses the LLM response into speakers and profiles.
+// Handles clean JSON, markdown code fences, and JSON embedded in prose.
+func parseAttributionResponse(content string) (attributionResponse, error) {
+	content = strings.TrimSpace(content)
+	if start, end := strings.Index(content, "{"), strings.LastIndex(content, "}"); start >= 0 && end > start {
+		content = content[start : end+1]
+	}
+
+	var response attributionResponse
+	if err := json.Unmarshal([]byte(content), &response); err != nil {
+		return attributionResponse{}, fmt.Errorf("failed to parse speaker attribution JSON from response: %w", err)
+	}
+	return response, nil
+}
+
+// FormatProfile renders a profile on one line for prompts,
+// e.g. "Momo (female, casual; called Momo-chan, Ayase)"
+func FormatProfile(profile Profile) string {
+	var details []string
+	if profile.Gender != "" {
+		details = append(details, profile.Gender)
+	}
+	if profile.Formality != "" {
+		details = append(details, profile.Formality)
+	}
+	detail := strings.Join(details, ", ")
+	if len(profile.Nicknames) > 0 {
+		if detail != "" {
+			detail += "; "
+		}
+		detail += "called " + strings.Join(profile.Nicknames, ", ")
+	}
+	if detail == "" {
+		return profile.Name
+	}
+	return profile.Name + " (" + detail + ")"
+}
+
+// profileSet collects the profiles found across batches. Known profiles are
+// never replaced; discovered ones are merged by name.
+type profileSet struct {
+	known map[string]bool
+	order []string
+	byKey map[string]*Profile
+}
+
+func newProfileSet(known []Profile) *profileSet {
+	set := &profileSet{known: make(map[string]bool), byKey: make(map[string]*Profile)}
+	for _, profile := range known {
+		set.known[strings.ToLower(profile.Name)] = true
+	}
+	return set
+}
+
+func (s *profileSet) add(profile Profile) {
+	profile.Name = strings.TrimSpace(profile.Name)
+	key := strings.ToLower(profile.Name)
+	if key == "" || s.known[key] {
+		return
+	}
+	current, ok := s.byKey[key]
+	if !ok {
+		s.order = append(s.order, key)
+		s.byKey[key] = &profile
+		return
+	}
+	if current.Gender == "" || current.Gender == "unknown" {
+		current.Gender = profile.Gender
+	}
+	if current.Formality == "" {
+		current.Formality = profile.Formality
+	}
+	for _, nickname := range profile.Nicknames {
+		if !contains(current.Nicknames, nickname) {
+			current.Nicknames = append(current.Nicknames, nickname)
+		}
+	}
+}
+
+func (s *profileSet) discovered() []Profile {
+	ret := make([]Profile, 0, len(s.order))
+	for _, key := range s.order {
+		ret = append(ret, *s.byKey[key])
+	}
+	return ret
+}
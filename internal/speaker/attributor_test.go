@@ -0,0 +1,106 @@
+package speaker
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/MimeLyc/contextual-sub-translator/internal/agent"
+	"github.com/MimeLyc/contextual-sub-translator/internal/media"
+	"github.com/MimeLyc/contextual-sub-translator/internal/subtitle"
+	"github.com/MimeLyc/contextual-sub-translator/internal/tools"
+)
+
+// newFakeAgent returns an agent backed by a fake chat completions API that
+// always answers with content and records the request bodies
+func newFakeAgent(t *testing.T, content string, requests *[]string) *agent.LLMAgent {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		*requests = append(*requests, string(body))
+
+		encoded, _ := json.Marshal(content)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"chatcmpl-1","object":"chat.completion","created":1,"model":"test-model",` +
+			`"choices":[{"index":0,"finish_reason":"stop","message":{"role":"assistant","content":` + string(encoded) + `}}],` +
+			`"usage":{"prompt_tokens":1,"completion_tokens":1,"total_tokens":2}}`))
+	}))
+	t.Cleanup(server.Close)
+
+	llm, err := agent.NewLLMAgent(agent.LLMConfig{
+		APIKey:  "test-key",
+		APIURL:  server.URL,
+		Model:   "test-model",
+		Timeout: 10,
+	}, tools.NewRegistry(), 1)
+	require.NoError(t, err)
+	return llm
+}
+
+func TestAttributor_Attribute(t *testing.T) {
+	var requests []string
+	llm := newFakeAgent(t, "```json\n"+`{
+		"speakers": [
+			{"index": 1, "speaker": "Okarun"},
+			{"index": 2, "speaker": "Okarun"},
+			{"index": 3, "speaker": "Momo / Okarun"},
+			{"index": 9, "speaker": "Nobody"}
+		],
+		"characters": [
+			{"name": "Momo", "gender": "female", "formality": "casual"},
+			{"name": "Turbo Granny", "gender": "female", "formality": "rough", "nicknames": ["Granny"]},
+			{"name": "turbo granny", "nicknames": ["Turbo"]}
+		]
+	}`+"\n```", &requests)
+
+	file := &subtitle.File{
+		Format: "VTT",
+		Lines: []subtitle.Line{
+			{Index: 1, Text: "Good morning, Ayase-san."},
+			{Index: 2, Text: "Stop calling me that."},
+			{Index: 3, Text: "- Okarun!\n- What?"},
+		},
+		VTT: &subtitle.VTTDocument{Blocks: []subtitle.VTTBlock{{LineIndex: 2, Voice: "Momo"}}},
+	}
+	showInfo := media.TVShowInfo{
+		Title:  "Dandadan",
+		Actors: []media.Actor{{Name: "Shion Wakayama", Role: "Momo Ayase"}},
+	}
+	known := []Profile{{Name: "Momo", Gender: "female", Formality: "casual", Nicknames: []string{"Ayase-san"}}}
+
+	attribution, err := NewAttributor(llm).Attribute(context.Background(), showInfo, file, known)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"Okarun", "Momo", "Momo / Okarun"}, attribution.Speakers, "speakers named by the file win")
+	assert.Equal(t, []Profile{
+		{Name: "Turbo Granny", Gender: "female", Formality: "rough", Nicknames: []string{"Granny", "Turbo"}},
+	}, attribution.Profiles, "known profiles are not returned again")
+
+	require.Len(t, requests, 1)
+	assert.Contains(t, requests[0], "Characters: Momo Ayase")
+	assert.Contains(t, requests[0], "Momo (female, casual; called Ayase-san)")
+	assert.Contains(t, requests[0], `{\"index\":2,\"text\":\"Stop calling me that.\",\"speaker\":\"Momo\"}`)
+	assert.Contains(t, requests[0], `\"dialogue\":true`)
+}
+
+func TestAttributor_AttributeFailureKeepsFileSpeakers(t *testing.T) {
+	var requests []string
+	llm := newFakeAgent(t, "I cannot tell who speaks.", &requests)
+
+	file := &subtitle.File{Lines: []subtitle.Line{{Index: 1, Text: "<v Momo>Hi"}, {Index: 2, Text: "Hey"}}}
+	attribution, err := NewAttributor(llm).Attribute(context.Background(), media.TVShowInfo{}, file, nil)
+	assert.ErrorContains(t, err, "speaker attribution failed")
+	assert.Equal(t, []string{"Momo", ""}, attribution.Speakers)
+}
+
+func TestFormatProfile(t *testing.T) {
+	assert.Equal(t, "Momo", FormatProfile(Profile{Name: "Momo"}))
+	assert.Equal(t, "Momo (called Momo-chan)", FormatProfile(Profile{Name: "Momo", Nicknames: []string{"Momo-chan"}}))
+	assert.Equal(t, "Momo (female, casual)", FormatProfile(Profile{Name: "Momo", Gender: "female", Formality: "casual"}))
+}
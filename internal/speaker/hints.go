@@ -0,0 +1,95 @@
+package speaker
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/MimeLyc/contextual-sub-translator/internal/subtitle"
+)
+
+var (
+	// voiceTagPattern matches WebVTT voice spans such as <v Momo> or <v.loud Okarun>
+	voiceTagPattern = regexp.MustCompile(`<v(?:\.[^\s>]+)*\s+([^>]+)>`)
+	// dialogueDashPattern matches a text line opened by a dialogue dash,
+	// e.g. "- Hello" or "-Hello", but not "--" or "..."
+	dialogueDashPattern = regexp.MustCompile(`^(?:\{[^}]*\}|<[^<>]*>)*\s*-(?:\s|[^-\s])`)
+)
+
+// Hint is what the subtitle file itself tells about the speakers of a line
+type Hint struct {
+	// Speakers are named by the file: <v> voice tags, the cue voice of a
+	// WebVTT file or the Name field of an ASS event
+	Speakers []string
+	// Dialogue is set for lines whose dashes mark more than one speaker
+	Dialogue bool
+}
+
+// Hints returns the speaker hints of every line of file
+func Hints(file *subtitle.File) []Hint {
+	hints := make([]Hint, len(file.Lines))
+	named := fileSpeakers(file)
+	for i, line := range file.Lines {
+		if name := named[line.Index]; name != "" {
+			hints[i].Speakers = append(hints[i].Speakers, name)
+		}
+		for _, match := range voiceTagPattern.FindAllStringSubmatch(line.Text, -1) {
+			if name := strings.TrimSpace(match[1]); name != "" && !contains(hints[i].Speakers, name) {
+				hints[i].Speakers = append(hints[i].Speakers, name)
+			}
+		}
+		hints[i].Dialogue = isDialogue(line.Text)
+	}
+	return hints
+}
+
+// fileSpeakers returns the speakers kept outside the line text by line index
+func fileSpeakers(file *subtitle.File) map[int]string {
+	ret := make(map[int]string)
+	if file.VTT != nil {
+		for _, block := range file.VTT.Blocks {
+			if block.LineIndex > 0 && block.Voice != "" {
+				ret[block.LineIndex] = block.Voice
+			}
+		}
+	}
+	if file.ASS != nil {
+		nameIdx := -1
+		for i, field := range file.ASS.EventFormat {
+			if strings.EqualFold(strings.TrimSpace(field), "Name") {
+				nameIdx = i
+			}
+		}
+		if nameIdx < 0 {
+			return ret
+		}
+		for _, event := range file.ASS.Events {
+			if event.LineIndex > 0 && nameIdx < len(event.Fields) {
+				if name := strings.TrimSpace(event.Fields[nameIdx]); name != "" {
+					ret[event.LineIndex] = name
+				}
+			}
+		}
+	}
+	return ret
+}
+
+// isDialogue reports whether text puts two speakers in one cue: a line after
+// the first opens with a dialogue dash, as in "- Hi.\n- Hello." or "Hi.\n- Hello."
+func isDialogue(text string) bool {
+	parts := strings.Split(text, "\n")
+	for _, part := range parts[1:] {
+		if dialogueDashPattern.MatchString(part) {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
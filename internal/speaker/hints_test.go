@@ -0,0 +1,47 @@
+package speaker
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/MimeLyc/contextual-sub-translator/internal/subtitle"
+)
+
+func TestHints_VTTVoices(t *testing.T) {
+	data := []byte("WEBVTT\n\n00:00:01.000 --> 00:00:02.000\n<v Momo>Hello there\n\n" +
+		"00:00:03.000 --> 00:00:04.000\n<v Okarun>Hi</v> <v Turbo Granny>Hey</v>\n\n" +
+		"00:00:05.000 --> 00:00:06.000\nNobody knows\n")
+	file, err := subtitle.ReadVTTBytes(data, "sample.vtt")
+	require.NoError(t, err)
+
+	hints := Hints(file)
+	require.Len(t, hints, 3)
+	assert.Equal(t, []string{"Momo"}, hints[0].Speakers)
+	assert.Equal(t, []string{"Okarun", "Turbo Granny"}, hints[1].Speakers)
+	assert.Empty(t, hints[2].Speakers)
+}
+
+func TestHints_ASSNameField(t *testing.T) {
+	data := []byte("[Script Info]\nScriptType: v4.00+\n\n[Events]\n" +
+		"Format: Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text\n" +
+		"Dialogue: 0,0:00:01.00,0:00:02.00,Default,Momo,0,0,0,,Hello\n" +
+		"Dialogue: 0,0:00:03.00,0:00:04.00,Default,,0,0,0,,Who?\n")
+	file, err := subtitle.ReadASSBytes(data, "sample.ass")
+	require.NoError(t, err)
+
+	hints := Hints(file)
+	require.Len(t, hints, 2)
+	assert.Equal(t, []string{"Momo"}, hints[0].Speakers)
+	assert.Empty(t, hints[1].Speakers)
+}
+
+func TestIsDialogue(t *testing.T) {
+	assert.True(t, isDialogue("- Where?\n- Here."))
+	assert.True(t, isDialogue("Where?\n-Here."))
+	assert.True(t, isDialogue("<i>- Where?</i>\n<i>- Here.</i>"))
+	assert.False(t, isDialogue("- Where are you going\nat this hour?"))
+	assert.False(t, isDialogue("Wait--\n-- what?"))
+	assert.False(t, isDialogue("Hello"))
+}
@@ -0,0 +1,384 @@
+package subtitle
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ASSScript keeps everything of an ASS/SSA file that is not dialogue text,
+// so a translated file can be written back with styles and tags intact.
+type ASSScript struct {
+	Header      string     // raw sections before [Events] ([Script Info], [V4+ Styles], ...)
+	Footer      string     // raw sections after [Events] ([Fonts], [Graphics], ...)
+	EventFormat []string   // field names from the [Events] Format line
+	Events      []ASSEvent // all event lines in file order
+}
+
+// ASSEvent represents a single line of the [Events] section
+type ASSEvent struct {
+	Kind      string   // Dialogue, Comment, or any other event type
+	Fields    []string // field values in EventFormat order; nil for raw lines
+	Raw       string   // original line for events that are not parsed
+	Override  string   // leading override block(s) stripped from the text, e.g. {\an8\pos(10,20)}
+	LineIndex int      // index of the Line carrying the text, 0 if the event is not translatable
+}
+
+var (
+	assLeadingOverridePattern = regexp.MustCompile(`^(?:\{[^}]*\})+`)
+	assDrawingPattern         = regexp.MustCompile(`\\p[1-9]`)
+	assOverrideBlockPattern   = regexp.MustCompile(`\{[^}]*\}`)
+)
+
+var defaultASSEventFormat = []string{"Layer", "Start", "End", "Style", "Name", "MarginL", "MarginR", "MarginV", "Effect", "Text"}
+
+const defaultASSHeader = `[Script Info]
+ScriptType: v4.00+
+WrapStyle: 0
+ScaledBorderAndShadow: yes
+PlayResX: 1920
+PlayResY: 1080
+
+[V4+ Styles]
+Format: Name, Fontname, Fontsize, PrimaryColour, SecondaryColour, OutlineColour, BackColour, Bold, Italic, Underline, StrikeOut, ScaleX, ScaleY, Spacing, Angle, BorderStyle, Outline, Shadow, Alignment, MarginL, MarginR, MarginV, Encoding
+Style: Default,Arial,64,&H00FFFFFF,&H000000FF,&H00000000,&H80000000,0,0,0,0,100,100,0,0,1,3,1,2,40,40,40,1
+`
+
+// ASSReader reads ASS/SSA subtitle files
+type ASSReader struct {
+	path string
+}
+
+// NewASSReader creates a new ASS/SSA subtitle file reader
+func NewASSReader(path string) Reader {
+	return &ASSReader{
+		path: path,
+	}
+}
+
+func (r *ASSReader) Read() (*File, error) {
+	if _, err := os.Stat(r.path); os.IsNotExist(err) {
+		return nil, fmt.Errorf("subtitle file does not exist: %s", r.path)
+	}
+
+	data, err := os.ReadFile(r.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read subtitle file: %w", err)
+	}
+	return parseDecoded(data, r.path, ReadASSBytes)
+}
+
+// ReadASSBytes parses ASS/SSA content. Only the text of dialogue events becomes
+// translatable Lines; everything else is kept in File.ASS for writing back.
+func ReadASSBytes(data []byte, path string) (*File, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	script := &ASSScript{}
+	var header, footer strings.Builder
+	var lines []Line
+
+	section := ""
+	seenEvents := false
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+
+	for scanner.Scan() {
+		raw := strings.TrimRight(scanner.Text(), "\r")
+		trimmed := strings.TrimSpace(raw)
+
+		if strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]") {
+			section = strings.ToLower(trimmed)
+			if section == "[events]" {
+				seenEvents = true
+				continue
+			}
+		}
+
+		switch {
+		case section == "[events]":
+			if trimmed == "" {
+				continue
+			}
+			kind, value, ok := strings.Cut(raw, ":")
+			kind = strings.TrimSpace(kind)
+			if !ok || strings.HasPrefix(trimmed, ";") {
+				script.Events = append(script.Events, ASSEvent{Raw: raw})
+				continue
+			}
+			if strings.EqualFold(kind, "Format") {
+				script.EventFormat = splitASSFormat(value)
+				continue
+			}
+			if !strings.EqualFold(kind, "Dialogue") && !strings.EqualFold(kind, "Comment") {
+				script.Events = append(script.Events, ASSEvent{Kind: kind, Raw: raw})
+				continue
+			}
+			if len(script.EventFormat) == 0 {
+				script.EventFormat = append([]string(nil), defaultASSEventFormat...)
+			}
+
+			event, err := parseASSEvent(kind, value, script.EventFormat)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse event %q: %w", trimmed, err)
+			}
+			if line, ok := assEventLine(&event, script.EventFormat, len(lines)+1); ok {
+				lines = append(lines, line)
+			}
+			script.Events = append(script.Events, event)
+		case seenEvents:
+			footer.WriteString(raw)
+			footer.WriteString("\n")
+		default:
+			header.WriteString(raw)
+			header.WriteString("\n")
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read subtitle file: %w", err)
+	}
+	if !seenEvents {
+		return nil, fmt.Errorf("no [Events] section found in %s", path)
+	}
+
+	script.Header = header.String()
+	script.Footer = footer.String()
+
+	return &File{
+		Lines:    lines,
+		Language: detectLanguage(lines),
+		Format:   "ASS",
+		Path:     path,
+		ASS:      script,
+	}, nil
+}
+
+func splitASSFormat(value string) []string {
+	parts := strings.Split(value, ",")
+	ret := make([]string, 0, len(parts))
+	for _, part := range parts {
+		ret = append(ret, strings.TrimSpace(part))
+	}
+	return ret
+}
+
+func parseASSEvent(kind string, value string, format []string) (ASSEvent, error) {
+	value = strings.TrimPrefix(value, " ")
+	fields := strings.SplitN(value, ",", len(format))
+	if len(fields) != len(format) {
+		return ASSEvent{}, fmt.Errorf("expected %d fields, got %d", len(format), len(fields))
+	}
+	return ASSEvent{
+		Kind:   kind,
+		Fields: fields,
+	}, nil
+}
+
+// assEventLine builds the translatable Line for a dialogue event and links the
+// event to it. Comments, drawings and tag-only events are kept untouched.
+func assEventLine(event *ASSEvent, format []string, index int) (Line, bool) {
+	if !strings.EqualFold(event.Kind, "Dialogue") {
+		return Line{}, false
+	}
+	textIdx := assFieldIndex(format, "Text")
+	startIdx := assFieldIndex(format, "Start")
+	endIdx := assFieldIndex(format, "End")
+	if textIdx < 0 || startIdx < 0 || endIdx < 0 {
+		return Line{}, false
+	}
+
+	text := event.Fields[textIdx]
+	if assDrawingPattern.MatchString(strings.Join(assOverrideBlockPattern.FindAllString(text, -1), "")) {
+		return Line{}, false
+	}
+	override := assLeadingOverridePattern.FindString(text)
+	body := strings.TrimPrefix(text, override)
+	if strings.TrimSpace(assOverrideBlockPattern.ReplaceAllString(body, "")) == "" {
+		return Line{}, false
+	}
+
+	start, err := parseASSTime(event.Fields[startIdx])
+	if err != nil {
+		return Line{}, false
+	}
+	end, err := parseASSTime(event.Fields[endIdx])
+	if err != nil {
+		return Line{}, false
+	}
+
+	event.Override = override
+	event.LineIndex = index
+	return Line{
+		Index:     index,
+		StartTime: start,
+		EndTime:   end,
+		Text:      fromASSText(body),
+	}, true
+}
+
+func assFieldIndex(format []string, name string) int {
+	for i, field := range format {
+		if strings.EqualFold(field, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// parseASSTime parses ASS time format, e.g. 0:02:16.61
+func parseASSTime(raw string) (time.Duration, error) {
+	raw = strings.TrimSpace(raw)
+	parts := strings.Split(raw, ":")
+	if len(parts) != 3 {
+		return 0, fmt.Errorf("invalid time format: %s", raw)
+	}
+	h, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, fmt.Errorf("invalid time format: %s", raw)
+	}
+	m, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, fmt.Errorf("invalid time format: %s", raw)
+	}
+	seconds, err := strconv.ParseFloat(parts[2], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time format: %s", raw)
+	}
+	return time.Duration(h)*time.Hour +
+		time.Duration(m)*time.Minute +
+		time.Duration(seconds*1000+0.5)*time.Millisecond, nil
+}
+
+// formatASSTime formats time.Duration to ASS time format (centisecond precision)
+func formatASSTime(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	cs := int((d + 5*time.Millisecond) / (10 * time.Millisecond))
+	hours := cs / 360000
+	minutes := (cs / 6000) % 60
+	seconds := (cs / 100) % 60
+	return fmt.Sprintf("%d:%02d:%02d.%02d", hours, minutes, seconds, cs%100)
+}
+
+func fromASSText(text string) string {
+	return strings.ReplaceAll(text, `\N`, "\n")
+}
+
+func toASSText(text string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	return strings.ReplaceAll(text, "\n", `\N`)
+}
+
+// ASSWriter writes ASS subtitle files
+type ASSWriter struct{}
+
+// NewASSWriter creates a new ASS subtitle file writer
+func NewASSWriter() Writer {
+	return &ASSWriter{}
+}
+
+// Write writes the subtitle as ASS. When the file was read from ASS, the
+// original script is reproduced and only times and dialogue text are replaced.
+func (w *ASSWriter) Write(path string, subtitle *File) error {
+	if subtitle == nil {
+		return fmt.Errorf("subtitle data is empty")
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+
+	// A short write must fail the job, the output is muxed into the media next.
+	writer := bufio.NewWriter(file)
+	writeASS(writer, subtitle)
+	if err := writer.Flush(); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to close output file: %w", err)
+	}
+	return nil
+}
+
+func writeASS(writer *bufio.Writer, subtitle *File) {
+	script := subtitle.ASS
+	if script == nil {
+		script = defaultASSScript(subtitle.Lines)
+	}
+
+	header := script.Header
+	if strings.TrimSpace(header) == "" {
+		header = defaultASSHeader
+	}
+	writer.WriteString(header)
+	if !strings.HasSuffix(header, "\n\n") {
+		writer.WriteString("\n")
+	}
+
+	format := script.EventFormat
+	if len(format) == 0 {
+		format = defaultASSEventFormat
+	}
+	writer.WriteString("[Events]\n")
+	fmt.Fprintf(writer, "Format: %s\n", strings.Join(format, ", "))
+
+	byIndex := make(map[int]Line, len(subtitle.Lines))
+	for _, line := range subtitle.Lines {
+		byIndex[line.Index] = line
+	}
+
+	textIdx := assFieldIndex(format, "Text")
+	startIdx := assFieldIndex(format, "Start")
+	endIdx := assFieldIndex(format, "End")
+	for _, event := range script.Events {
+		if event.Fields == nil {
+			fmt.Fprintf(writer, "%s\n", event.Raw)
+			continue
+		}
+		fields := append([]string(nil), event.Fields...)
+		if line, ok := byIndex[event.LineIndex]; ok && event.LineIndex > 0 && textIdx >= 0 {
+			text := line.TranslatedText
+			if text == "" {
+				text = line.Text
+			}
+			fields[textIdx] = event.Override + toASSText(text)
+			if startIdx >= 0 {
+				fields[startIdx] = formatASSTime(line.StartTime)
+			}
+			if endIdx >= 0 {
+				fields[endIdx] = formatASSTime(line.EndTime)
+			}
+		}
+		fmt.Fprintf(writer, "%s: %s\n", event.Kind, strings.Join(fields, ","))
+	}
+
+	if script.Footer != "" {
+		writer.WriteString("\n")
+		writer.WriteString(strings.TrimLeft(script.Footer, "\n"))
+	}
+}
+
+// defaultASSScript builds a script with a single Default style for subtitles
+// that did not come from an ASS source.
+func defaultASSScript(lines []Line) *ASSScript {
+	script := &ASSScript{
+		EventFormat: append([]string(nil), defaultASSEventFormat...),
+		Events:      make([]ASSEvent, 0, len(lines)),
+	}
+	for _, line := range lines {
+		script.Events = append(script.Events, ASSEvent{
+			Kind:      "Dialogue",
+			Fields:    []string{"0", formatASSTime(line.StartTime), formatASSTime(line.EndTime), "Default", "", "0", "0", "0", "", ""},
+			LineIndex: line.Index,
+		})
+	}
+	return script
+}
@@ -0,0 +1,111 @@
+package subtitle
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const sampleASS = "\ufeff[Script Info]\r\n" +
+	"Title: Sample\r\n" +
+	"ScriptType: v4.00+\r\n" +
+	"\r\n" +
+	"[V4+ Styles]\r\n" +
+	"Format: Name, Fontname, Fontsize, PrimaryColour, SecondaryColour, OutlineColour, BackColour, Bold, Italic, Underline, StrikeOut, ScaleX, ScaleY, Spacing, Angle, BorderStyle, Outline, Shadow, Alignment, MarginL, MarginR, MarginV, Encoding\r\n" +
+	"Style: Default,Arial,20,&H00FFFFFF,&H000000FF,&H00000000,&H00000000,0,0,0,0,100,100,0,0,1,2,2,2,10,10,10,1\r\n" +
+	"Style: Signs,Arial,18,&H00FFFFFF,&H000000FF,&H00000000,&H00000000,0,0,0,0,100,100,0,0,1,2,2,8,10,10,10,1\r\n" +
+	"\r\n" +
+	"[Events]\r\n" +
+	"Format: Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text\r\n" +
+	"Comment: 0,0:00:00.00,0:00:01.00,Default,,0,0,0,,Timing by someone\r\n" +
+	"Dialogue: 0,0:00:01.00,0:00:02.50,Default,Momo,0,0,0,,Hello, there\\Nfriend\r\n" +
+	"Dialogue: 1,0:00:03.00,0:00:04.00,Signs,,0,0,0,,{\\an8\\pos(320,50)}Station {\\i1}Exit{\\i0}\r\n" +
+	"Dialogue: 0,0:00:05.00,0:00:06.00,Signs,,0,0,0,,{\\p1}m 0 0 l 100 0 100 100{\\p0}\r\n" +
+	"\r\n" +
+	"[Fonts]\r\n" +
+	"fontname: custom.ttf\r\n"
+
+func TestReadASSBytes(t *testing.T) {
+	file, err := ReadASSBytes([]byte(sampleASS), "sample.ass")
+	require.NoError(t, err)
+
+	require.Len(t, file.Lines, 2)
+	assert.Equal(t, "ASS", file.Format)
+	assert.Equal(t, 1, file.Lines[0].Index)
+	assert.Equal(t, time.Second, file.Lines[0].StartTime)
+	assert.Equal(t, 2500*time.Millisecond, file.Lines[0].EndTime)
+	assert.Equal(t, "Hello, there\nfriend", file.Lines[0].Text)
+	assert.Equal(t, "Station {\\i1}Exit{\\i0}", file.Lines[1].Text)
+
+	require.NotNil(t, file.ASS)
+	assert.Contains(t, file.ASS.Header, "Style: Signs,Arial")
+	assert.Contains(t, file.ASS.Footer, "[Fonts]")
+	require.Len(t, file.ASS.Events, 4)
+	assert.Equal(t, 0, file.ASS.Events[0].LineIndex)
+	assert.Equal(t, `{\an8\pos(320,50)}`, file.ASS.Events[2].Override)
+	assert.Equal(t, 0, file.ASS.Events[3].LineIndex)
+}
+
+func TestReadASSBytes_MissingEvents(t *testing.T) {
+	_, err := ReadASSBytes([]byte("[Script Info]\nTitle: x\n"), "broken.ass")
+	require.Error(t, err)
+}
+
+func TestASSWriter_RoundTripKeepsStylesAndTags(t *testing.T) {
+	file, err := ReadASSBytes([]byte(sampleASS), "sample.ass")
+	require.NoError(t, err)
+	file.Lines[0].TranslatedText = "你好\n朋友"
+	file.Lines[1].TranslatedText = "车站{\\i1}出口{\\i0}"
+
+	out := filepath.Join(t.TempDir(), "sample_ctxtrans.zh.ass")
+	require.NoError(t, NewWriter().Write(out, file))
+
+	data, err := os.ReadFile(out)
+	require.NoError(t, err)
+	content := string(data)
+	assert.Contains(t, content, "Style: Signs,Arial,18")
+	assert.Contains(t, content, "Comment: 0,0:00:00.00,0:00:01.00,Default,,0,0,0,,Timing by someone")
+	assert.Contains(t, content, "Dialogue: 0,0:00:01.00,0:00:02.50,Default,Momo,0,0,0,,你好\\N朋友")
+	assert.Contains(t, content, "Dialogue: 1,0:00:03.00,0:00:04.00,Signs,,0,0,0,,{\\an8\\pos(320,50)}车站{\\i1}出口{\\i0}")
+	assert.Contains(t, content, "{\\p1}m 0 0 l 100 0 100 100{\\p0}")
+	assert.Contains(t, content, "fontname: custom.ttf")
+
+	reread, err := NewReader(out).Read()
+	require.NoError(t, err)
+	require.Len(t, reread.Lines, 2)
+	assert.Equal(t, "你好\n朋友", reread.Lines[0].Text)
+	assert.Len(t, reread.ASS.Events, 4)
+}
+
+func TestASSWriter_FromSRTLinesUsesDefaultStyle(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "plain.ass")
+	err := NewASSWriter().Write(out, &File{
+		Lines: []Line{
+			{Index: 1, StartTime: 1500 * time.Millisecond, EndTime: 3 * time.Second, Text: "Hi", TranslatedText: "嗨"},
+		},
+		Format: "SRT",
+	})
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(out)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "Style: Default,")
+	assert.Contains(t, string(data), "Dialogue: 0,0:00:01.50,0:00:03.00,Default,,0,0,0,,嗨")
+}
+
+func TestASSWriter_ReportsShortWrites(t *testing.T) {
+	if _, err := os.Stat("/dev/full"); err != nil {
+		t.Skip("/dev/full is not available")
+	}
+	err := NewASSWriter().Write("/dev/full", &File{
+		Lines: []Line{
+			{Index: 1, StartTime: time.Second, EndTime: 2 * time.Second, Text: "Hi", TranslatedText: "嗨"},
+		},
+		Format: "SRT",
+	})
+	assert.Error(t, err)
+}
@@ -0,0 +1,338 @@
+package subtitle
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// OutputMode controls how translated and original text are combined on output
+type OutputMode string
+
+const (
+	// OutputTranslated writes only the translated text
+	OutputTranslated OutputMode = "translated"
+	// OutputBilingual stacks the translated text above the original in one cue
+	OutputBilingual OutputMode = "bilingual"
+	// OutputBilingualOriginalFirst stacks the original text above the translation
+	OutputBilingualOriginalFirst OutputMode = "bilingual_original_first"
+	// OutputBilingualStyled writes the original as separate events in a smaller
+	// style for ASS output; other formats fall back to OutputBilingual
+	OutputBilingualStyled OutputMode = "bilingual_styled"
+)
+
+const (
+	// originalStyleSuffix is appended to a style name to form the style of original-text events
+	originalStyleSuffix = " Original"
+	// originalStyleScale is the font size of the original style relative to its base style
+	originalStyleScale = 0.7
+)
+
+// ParseOutputMode parses an output mode, an empty value means OutputTranslated
+func ParseOutputMode(raw string) (OutputMode, error) {
+	mode := OutputMode(strings.ToLower(strings.TrimSpace(raw)))
+	switch mode {
+	case "":
+		return OutputTranslated, nil
+	case OutputTranslated, OutputBilingual, OutputBilingualOriginalFirst, OutputBilingualStyled:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unsupported output mode %q", raw)
+	}
+}
+
+// IsBilingual reports whether the mode writes the original text as well
+func (m OutputMode) IsBilingual() bool {
+	switch m {
+	case OutputBilingual, OutputBilingualOriginalFirst, OutputBilingualStyled:
+		return true
+	default:
+		return false
+	}
+}
+
+// applyOutputMode returns the file to hand to the writer of formatName.
+// The input file is not modified.
+func applyOutputMode(subtitle *File, mode OutputMode, formatName string) *File {
+	if !mode.IsBilingual() {
+		return subtitle
+	}
+	if mode == OutputBilingualStyled && formatName == "ASS" {
+		if styled, ok := styledBilingualASS(subtitle); ok {
+			return styled
+		}
+	}
+
+	ret := *subtitle
+	ret.Lines = make([]Line, len(subtitle.Lines))
+	for i, line := range subtitle.Lines {
+		ret.Lines[i] = line
+		if !hasDistinctTranslation(line) {
+			continue
+		}
+		if mode == OutputBilingualOriginalFirst {
+			ret.Lines[i].TranslatedText = line.Text + "\n" + line.TranslatedText
+		} else {
+			ret.Lines[i].TranslatedText = line.TranslatedText + "\n" + line.Text
+		}
+	}
+	return &ret
+}
+
+func hasDistinctTranslation(line Line) bool {
+	translated := strings.TrimSpace(line.TranslatedText)
+	return translated != "" && translated != strings.TrimSpace(line.Text)
+}
+
+// styledBilingualASS adds an event with the original text in front of every
+// translated dialogue event. The original uses a smaller copy of the event's
+// style, so renderers stack it below the translation. Events positioned with
+// \pos or \move are left alone because both texts would overlap.
+func styledBilingualASS(subtitle *File) (*File, bool) {
+	script := subtitle.ASS
+	if script == nil {
+		script = defaultASSScript(subtitle.Lines)
+	}
+	format := script.EventFormat
+	if len(format) == 0 {
+		format = defaultASSEventFormat
+	}
+	styleIdx := assFieldIndex(format, "Style")
+	if styleIdx < 0 {
+		return nil, false
+	}
+
+	byIndex := make(map[int]Line, len(subtitle.Lines))
+	nextIndex := 1
+	for _, line := range subtitle.Lines {
+		byIndex[line.Index] = line
+		nextIndex = max(nextIndex, line.Index+1)
+	}
+
+	lines := append([]Line(nil), subtitle.Lines...)
+	events := make([]ASSEvent, 0, len(script.Events)*2)
+	var styles []string
+	for _, event := range script.Events {
+		line, ok := byIndex[event.LineIndex]
+		if event.Fields == nil || event.LineIndex <= 0 || !ok || !hasDistinctTranslation(line) ||
+			strings.Contains(event.Override, `\pos`) || strings.Contains(event.Override, `\move`) {
+			events = append(events, event)
+			continue
+		}
+
+		style := strings.TrimSpace(event.Fields[styleIdx])
+		if style == "" {
+			style = "Default"
+		}
+		original := event
+		original.Fields = append([]string(nil), event.Fields...)
+		original.Fields[styleIdx] = style + originalStyleSuffix
+		original.LineIndex = nextIndex
+		lines = append(lines, Line{
+			Index:     nextIndex,
+			StartTime: line.StartTime,
+			EndTime:   line.EndTime,
+			Text:      line.Text,
+		})
+		nextIndex++
+		events = append(events, original, event)
+		styles = append(styles, style)
+	}
+
+	header := script.Header
+	if strings.TrimSpace(header) == "" {
+		header = defaultASSHeader
+	}
+
+	ret := *subtitle
+	ret.Lines = lines
+	ret.ASS = &ASSScript{
+		Header:      addOriginalStyles(header, styles),
+		Footer:      script.Footer,
+		EventFormat: format,
+		Events:      events,
+	}
+	return &ret, true
+}
+
+// addOriginalStyles appends a smaller copy of every named style to the styles
+// section of an ASS header. Styles that are missing from the header are copied
+// from Default, and copies that already exist are kept as they are.
+func addOriginalStyles(header string, names []string) string {
+	if len(names) == 0 {
+		return header
+	}
+	lines := strings.Split(header, "\n")
+
+	var styleFormat []string
+	existing := make(map[string][]string)
+	lastStyle := -1
+	inStyles := false
+	for i, raw := range lines {
+		trimmed := strings.TrimSpace(raw)
+		if strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]") {
+			inStyles = strings.EqualFold(trimmed, "[V4+ Styles]") || strings.EqualFold(trimmed, "[V4 Styles]")
+			continue
+		}
+		if !inStyles {
+			continue
+		}
+		kind, value, ok := strings.Cut(trimmed, ":")
+		if !ok {
+			continue
+		}
+		switch strings.TrimSpace(kind) {
+		case "Format":
+			styleFormat = splitASSFormat(value)
+		case "Style":
+			if len(styleFormat) == 0 {
+				continue
+			}
+			fields := splitASSFormat(value)
+			if nameIdx := assFieldIndex(styleFormat, "Name"); nameIdx >= 0 && nameIdx < len(fields) {
+				existing[fields[nameIdx]] = fields
+			}
+			lastStyle = i
+		}
+	}
+	nameIdx := assFieldIndex(styleFormat, "Name")
+	sizeIdx := assFieldIndex(styleFormat, "Fontsize")
+	if lastStyle < 0 || nameIdx < 0 {
+		return header
+	}
+
+	var added []string
+	seen := make(map[string]bool)
+	for _, name := range names {
+		derived := name + originalStyleSuffix
+		if seen[derived] || existing[derived] != nil {
+			continue
+		}
+		seen[derived] = true
+		base, ok := existing[name]
+		if !ok {
+			if base, ok = existing["Default"]; !ok {
+				continue
+			}
+		}
+		fields := append([]string(nil), base...)
+		fields[nameIdx] = derived
+		if sizeIdx >= 0 && sizeIdx < len(fields) {
+			if size, err := strconv.ParseFloat(fields[sizeIdx], 64); err == nil {
+				fields[sizeIdx] = strconv.Itoa(int(math.Round(size * originalStyleScale)))
+			}
+		}
+		added = append(added, "Style: "+strings.Join(fields, ","))
+	}
+	if len(added) == 0 {
+		return header
+	}
+
+	ret := append([]string(nil), lines[:lastStyle+1]...)
+	ret = append(ret, added...)
+	ret = append(ret, lines[lastStyle+1:]...)
+	return strings.Join(ret, "\n")
+}
+
+// DetectOutputMode returns the output mode a file read back from disk was
+// written with, told by the original text of source it carries: events in
+// an original style, or cues with the original stacked above or below.
+// Files without any are OutputTranslated.
+func DetectOutputMode(subtitle *File, source []Line) OutputMode {
+	if subtitle == nil {
+		return OutputTranslated
+	}
+	if script := subtitle.ASS; script != nil {
+		if styleIdx := assFieldIndex(script.EventFormat, "Style"); styleIdx >= 0 {
+			for _, event := range script.Events {
+				if event.LineIndex > 0 && strings.HasSuffix(event.Fields[styleIdx], originalStyleSuffix) {
+					return OutputBilingualStyled
+				}
+			}
+		}
+	}
+
+	originalFirst, translatedFirst := 0, 0
+	for i, line := range subtitle.Lines {
+		if i >= len(source) || strings.TrimSpace(source[i].Text) == "" {
+			continue
+		}
+		original := source[i].Text
+		switch {
+		case strings.HasPrefix(line.Text, original+"\n"):
+			originalFirst++
+		case strings.HasSuffix(line.Text, "\n"+original):
+			translatedFirst++
+		}
+	}
+	switch {
+	case originalFirst == 0 && translatedFirst == 0:
+		return OutputTranslated
+	case originalFirst > translatedFirst:
+		return OutputBilingualOriginalFirst
+	default:
+		return OutputBilingual
+	}
+}
+
+// StripOriginal undoes a bilingual output mode on a file that was read back
+// from disk: original-text events are dropped and stacked original text is
+// removed, so Line.Text only holds the translation. source holds the lines
+// of the original subtitle in order.
+func StripOriginal(subtitle *File, source []Line, mode OutputMode) *File {
+	if subtitle == nil || !mode.IsBilingual() {
+		return subtitle
+	}
+	ret := *subtitle
+	ret.Lines = append([]Line(nil), subtitle.Lines...)
+
+	if script := subtitle.ASS; script != nil {
+		styleIdx := assFieldIndex(script.EventFormat, "Style")
+		dropped := make(map[int]bool)
+		events := make([]ASSEvent, 0, len(script.Events))
+		for _, event := range script.Events {
+			if event.LineIndex > 0 && styleIdx >= 0 && strings.HasSuffix(event.Fields[styleIdx], originalStyleSuffix) {
+				dropped[event.LineIndex] = true
+				continue
+			}
+			events = append(events, event)
+		}
+		if len(dropped) > 0 {
+			renumbered := make(map[int]int)
+			lines := make([]Line, 0, len(ret.Lines)-len(dropped))
+			for _, line := range ret.Lines {
+				if dropped[line.Index] {
+					continue
+				}
+				renumbered[line.Index] = len(lines) + 1
+				line.Index = len(lines) + 1
+				lines = append(lines, line)
+			}
+			for i := range events {
+				if events[i].LineIndex > 0 {
+					events[i].LineIndex = renumbered[events[i].LineIndex]
+				}
+			}
+			ret.Lines = lines
+		}
+		stripped := *script
+		stripped.Events = events
+		ret.ASS = &stripped
+	}
+
+	for i := range ret.Lines {
+		if i >= len(source) || strings.TrimSpace(source[i].Text) == "" {
+			continue
+		}
+		original := source[i].Text
+		text := ret.Lines[i].Text
+		if mode == OutputBilingualOriginalFirst {
+			text = strings.TrimPrefix(text, original+"\n")
+		} else {
+			text = strings.TrimSuffix(text, "\n"+original)
+		}
+		ret.Lines[i].Text = text
+	}
+	return &ret
+}
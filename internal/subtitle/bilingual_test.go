@@ -0,0 +1,120 @@
+package subtitle
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseOutputMode(t *testing.T) {
+	mode, err := ParseOutputMode("")
+	require.NoError(t, err)
+	assert.Equal(t, OutputTranslated, mode)
+
+	mode, err = ParseOutputMode(" Bilingual_Styled ")
+	require.NoError(t, err)
+	assert.Equal(t, OutputBilingualStyled, mode)
+	assert.True(t, mode.IsBilingual())
+
+	_, err = ParseOutputMode("side_by_side")
+	require.Error(t, err)
+}
+
+func TestWriterWithMode_StacksSRTCues(t *testing.T) {
+	file := &File{
+		Format: "SRT",
+		Lines: []Line{
+			{Index: 1, Text: "Hello", TranslatedText: "你好"},
+			{Index: 2, Text: "OK", TranslatedText: "OK"},
+			{Index: 3, Text: "Bye"},
+		},
+	}
+
+	tests := []struct {
+		mode OutputMode
+		want string
+	}{
+		{OutputTranslated, "你好\n\n"},
+		{OutputBilingual, "你好\nHello\n\n"},
+		{OutputBilingualOriginalFirst, "Hello\n你好\n\n"},
+		{OutputBilingualStyled, "你好\nHello\n\n"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.mode), func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "out.srt")
+			require.NoError(t, NewWriterWithMode(tt.mode).Write(path, file))
+
+			content, err := os.ReadFile(path)
+			require.NoError(t, err)
+			assert.Contains(t, string(content), "00:00:00,000 --> 00:00:00,000\n"+tt.want)
+			// identical and missing translations are not duplicated
+			assert.Contains(t, string(content), "\nOK\n\n")
+			assert.Contains(t, string(content), "\nBye\n\n")
+
+			read, err := NewReader(path).Read()
+			require.NoError(t, err)
+			stripped := StripOriginal(read, file.Lines, tt.mode)
+			assert.Equal(t, "你好", stripped.Lines[0].Text)
+			// the layout can be told from the file alone
+			stripped = StripOriginal(read, file.Lines, DetectOutputMode(read, file.Lines))
+			assert.Equal(t, "你好", stripped.Lines[0].Text)
+		})
+	}
+	assert.Equal(t, "你好", file.Lines[0].TranslatedText, "input file must not be modified")
+}
+
+func TestWriterWithMode_StyledASS(t *testing.T) {
+	file, err := ReadASSBytes([]byte(sampleASS), "sample.ass")
+	require.NoError(t, err)
+	source := append([]Line(nil), file.Lines...)
+	file.Lines[0].TranslatedText = "你好，朋友"
+	file.Lines[1].TranslatedText = "车站{\\i1}出口{\\i0}"
+
+	path := filepath.Join(t.TempDir(), "out.ass")
+	require.NoError(t, NewWriterWithMode(OutputBilingualStyled).Write(path, file))
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	text := string(content)
+	assert.Contains(t, text, "Style: Default Original,Arial,14,")
+	assert.Equal(t, 1, strings.Count(text, "Style: Default Original"))
+	assert.Contains(t, text, "Dialogue: 0,0:00:01.00,0:00:02.50,Default Original,Momo,0,0,0,,Hello, there\\Nfriend\n"+
+		"Dialogue: 0,0:00:01.00,0:00:02.50,Default,Momo,0,0,0,,你好，朋友\n")
+	// positioned signs only carry the translation
+	assert.NotContains(t, text, "Signs Original")
+	assert.Contains(t, text, "{\\an8\\pos(320,50)}车站{\\i1}出口{\\i0}")
+
+	read, err := NewReader(path).Read()
+	require.NoError(t, err)
+	require.Len(t, read.Lines, 3)
+
+	assert.Equal(t, OutputBilingualStyled, DetectOutputMode(read, source))
+	stripped := StripOriginal(read, source, OutputBilingualStyled)
+	require.Len(t, stripped.Lines, 2)
+	assert.Equal(t, "你好，朋友", stripped.Lines[0].Text)
+	assert.Equal(t, "车站{\\i1}出口{\\i0}", stripped.Lines[1].Text)
+
+	// rewriting the stripped script does not add the original twice
+	stripped.Lines[0].TranslatedText = "你好"
+	stripped.Lines[0].Text = source[0].Text
+	require.NoError(t, NewWriterWithMode(OutputBilingualStyled).Write(path, stripped))
+	content, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, 1, strings.Count(string(content), "Style: Default Original"))
+	assert.Equal(t, 1, strings.Count(string(content), ",Default Original,Momo,"))
+}
+
+func TestDetectOutputMode(t *testing.T) {
+	source := []Line{{Index: 1, Text: "Hello"}, {Index: 2, Text: "Bye"}}
+	read := func(text1, text2 string) *File {
+		return &File{Lines: []Line{{Index: 1, Text: text1}, {Index: 2, Text: text2}}}
+	}
+	assert.Equal(t, OutputTranslated, DetectOutputMode(read("你好", "再见"), source))
+	assert.Equal(t, OutputBilingual, DetectOutputMode(read("你好\nHello", "再见\nBye"), source))
+	assert.Equal(t, OutputBilingualOriginalFirst, DetectOutputMode(read("Hello\n你好", "Bye\n再见"), source))
+	assert.Equal(t, OutputTranslated, DetectOutputMode(nil, source))
+}
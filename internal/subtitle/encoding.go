@@ -0,0 +1,266 @@
+package subtitle
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/korean"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/traditionalchinese"
+	"golang.org/x/text/encoding/unicode"
+
+	"github.com/MimeLyc/contextual-sub-translator/pkg/log"
+)
+
+// Character encodings recorded in File.Encoding and accepted by writers
+const (
+	EncodingUTF8     = "UTF-8"
+	EncodingUTF8BOM  = "UTF-8-BOM"
+	EncodingUTF16LE  = "UTF-16LE"
+	EncodingUTF16BE  = "UTF-16BE"
+	EncodingGB18030  = "GB18030" // superset of GBK and GB2312
+	EncodingBig5     = "Big5"
+	EncodingShiftJIS = "Shift_JIS"
+	EncodingEUCKR    = "EUC-KR"
+	// EncodingUnknown marks content that matched no encoding; invalid bytes
+	// were replaced while reading
+	EncodingUnknown = "unknown"
+	// EncodingSource writes a subtitle in the encoding it was read from
+	EncodingSource = "source"
+)
+
+var textEncodings = map[string]encoding.Encoding{
+	EncodingUTF8:     unicode.UTF8,
+	EncodingUTF8BOM:  unicode.UTF8BOM,
+	EncodingUTF16LE:  unicode.UTF16(unicode.LittleEndian, unicode.UseBOM),
+	EncodingUTF16BE:  unicode.UTF16(unicode.BigEndian, unicode.UseBOM),
+	EncodingGB18030:  simplifiedchinese.GB18030,
+	EncodingBig5:     traditionalchinese.Big5,
+	EncodingShiftJIS: japanese.ShiftJIS,
+	EncodingEUCKR:    korean.EUCKR,
+}
+
+// encodingAliases maps lower-case names used by players and editors to the
+// encodings above
+var encodingAliases = map[string]string{
+	"utf8":      EncodingUTF8,
+	"utf-8":     EncodingUTF8,
+	"utf-8-bom": EncodingUTF8BOM,
+	"utf8-bom":  EncodingUTF8BOM,
+	"utf-16":    EncodingUTF16LE,
+	"utf16":     EncodingUTF16LE,
+	"utf-16le":  EncodingUTF16LE,
+	"utf-16be":  EncodingUTF16BE,
+	"gb18030":   EncodingGB18030,
+	"gbk":       EncodingGB18030,
+	"gb2312":    EncodingGB18030,
+	"cp936":     EncodingGB18030,
+	"big5":      EncodingBig5,
+	"cp950":     EncodingBig5,
+	"shift_jis": EncodingShiftJIS,
+	"shift-jis": EncodingShiftJIS,
+	"sjis":      EncodingShiftJIS,
+	"cp932":     EncodingShiftJIS,
+	"euc-kr":    EncodingEUCKR,
+	"cp949":     EncodingEUCKR,
+	"source":    EncodingSource,
+}
+
+// legacyEncodings are tried in order for content that is not valid UTF-8
+var legacyEncodings = []string{EncodingGB18030, EncodingBig5, EncodingShiftJIS, EncodingEUCKR}
+
+// ParseEncoding parses an output encoding name, an empty value means UTF-8
+func ParseEncoding(raw string) (string, error) {
+	name := strings.ToLower(strings.TrimSpace(raw))
+	if name == "" {
+		return EncodingUTF8, nil
+	}
+	if encoding, ok := encodingAliases[name]; ok {
+		return encoding, nil
+	}
+	return "", fmt.Errorf("unsupported encoding %q", raw)
+}
+
+// decodeText converts subtitle content to UTF-8 and reports the encoding it
+// was read from. Content no encoding fits is returned with invalid bytes
+// replaced and ok set to false.
+func decodeText(data []byte) (text []byte, name string, ok bool) {
+	switch {
+	case bytes.HasPrefix(data, []byte("\xef\xbb\xbf")):
+		return data[3:], EncodingUTF8BOM, true
+	case bytes.HasPrefix(data, []byte("\xff\xfe")):
+		return decodeWith(data, EncodingUTF16LE)
+	case bytes.HasPrefix(data, []byte("\xfe\xff")):
+		return decodeWith(data, EncodingUTF16BE)
+	}
+	if name, ok := sniffUTF16(data); ok {
+		return decodeWith(data, name)
+	}
+	if utf8.Valid(data) {
+		return data, EncodingUTF8, true
+	}
+
+	var best []byte
+	bestScore := 0
+	for _, candidate := range legacyEncodings {
+		decoded, err := textEncodings[candidate].NewDecoder().Bytes(data)
+		if err != nil || bytes.ContainsRune(decoded, utf8.RuneError) {
+			continue
+		}
+		if score := cjkScore(decoded); best == nil || score > bestScore {
+			best, name, bestScore = decoded, candidate, score
+		}
+	}
+	if best != nil {
+		return best, name, true
+	}
+	return bytes.ToValidUTF8(data, []byte("\ufffd")), EncodingUnknown, false
+}
+
+func decodeWith(data []byte, name string) ([]byte, string, bool) {
+	decoded, err := textEncodings[name].NewDecoder().Bytes(data)
+	if err != nil {
+		return bytes.ToValidUTF8(data, []byte("\ufffd")), EncodingUnknown, false
+	}
+	return decoded, name, true
+}
+
+// sniffUTF16 detects UTF-16 without BOM from the zero high bytes of the
+// ASCII digits and punctuation every subtitle has
+func sniffUTF16(data []byte) (string, bool) {
+	head := data[:min(len(data), 4096)]
+	if len(head) < 4 {
+		return "", false
+	}
+	var evenZeros, oddZeros int
+	for i, b := range head {
+		if b != 0 {
+			continue
+		}
+		if i%2 == 0 {
+			evenZeros++
+		} else {
+			oddZeros++
+		}
+	}
+	pairs := len(head) / 2
+	switch {
+	case oddZeros > pairs/4 && evenZeros < oddZeros/8:
+		return EncodingUTF16LE, true
+	case evenZeros > pairs/4 && oddZeros < evenZeros/8:
+		return EncodingUTF16BE, true
+	}
+	return "", false
+}
+
+// commonHan holds frequent characters in both simplified and traditional
+// forms. Text decoded with the wrong legacy encoding turns into rare
+// characters, so these tell GBK and Big5 apart.
+const commonHan = "的一是不了人我在有他这這中大来來上个個国國到说說们們为為子和你地出道也时時年得就那要下以生会會自着著去之过過家学學对對可她里裡后後小么麼心多天而能好都然没沒日于於起还還发發成事只作当當想看文无無开開手十用主行方又如前所本见見经經头頭面公同三已老从從动動两兩长長吗嗎啊吧呢什谁誰知"
+
+var commonHanSet = func() map[rune]bool {
+	ret := make(map[rune]bool)
+	for _, r := range commonHan {
+		ret[r] = true
+	}
+	return ret
+}()
+
+// cjkScore rates how much text looks like real Chinese, Japanese or Korean
+func cjkScore(text []byte) int {
+	score := 0
+	for _, r := range string(text) {
+		switch {
+		case r < 0x80:
+		case r >= 0x3040 && r <= 0x30ff: // hiragana and katakana
+			score += 2
+		case r >= 0xac00 && r <= 0xd7a3: // hangul syllables
+			if isKSX1001Hangul(r) {
+				score += 2
+			} else {
+				score -= 2
+			}
+		case commonHanSet[r]:
+			score += 2
+		case r >= 0x4e00 && r <= 0x9fff:
+		case r >= 0x3000 && r <= 0x303f, r >= 0xff01 && r <= 0xff5e: // CJK and fullwidth punctuation
+		case r >= 0xff61 && r <= 0xff9f: // halfwidth katakana, mostly misdecoded double-byte text
+			score--
+		default:
+			score -= 2
+		}
+	}
+	return score
+}
+
+// isKSX1001Hangul reports whether r is one of the 2350 syllables of the
+// EUC-KR character set. Korean text uses them almost exclusively, while other
+// double-byte text decoded as EUC-KR lands in the extended syllables.
+func isKSX1001Hangul(r rune) bool {
+	encoded, err := korean.EUCKR.NewEncoder().String(string(r))
+	return err == nil && len(encoded) == 2 && encoded[0] >= 0xb0 && encoded[1] >= 0xa1
+}
+
+// encodeText converts UTF-8 text to the named encoding
+func encodeText(text []byte, name string) ([]byte, error) {
+	enc, ok := textEncodings[name]
+	if !ok {
+		return nil, fmt.Errorf("unsupported encoding %q", name)
+	}
+	if name == EncodingUTF8 {
+		return text, nil
+	}
+	encoded, err := enc.NewEncoder().Bytes(text)
+	if err != nil {
+		return nil, fmt.Errorf("text cannot be represented in %s: %w", name, err)
+	}
+	return encoded, nil
+}
+
+// encodeFile rewrites a UTF-8 file written by a format writer in the named
+// encoding. Text the encoding cannot represent, e.g. Chinese in Shift_JIS,
+// only logs a warning and keeps the file as UTF-8, which every player reads.
+func encodeFile(path string, name string) error {
+	if name == "" || name == EncodingUTF8 {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read output file: %w", err)
+	}
+	encoded, err := encodeText(data, name)
+	if err != nil {
+		log.Warn("%v, %s was kept as UTF-8", err, path)
+		return nil
+	}
+	return os.WriteFile(path, encoded, 0o644)
+}
+
+// parseDecoded converts data to UTF-8 before handing it to parse and
+// records the source encoding on the parsed file
+func parseDecoded(data []byte, path string, parse func(data []byte, path string) (*File, error)) (*File, error) {
+	text, name, ok := decodeText(data)
+	file, err := parse(text, path)
+	if err != nil {
+		return nil, err
+	}
+	recordEncoding(file, name, ok)
+	return file, nil
+}
+
+// recordEncoding stores the source encoding on file and reports content
+// whose encoding could not be detected
+func recordEncoding(file *File, name string, ok bool) {
+	file.Encoding = name
+	if !ok {
+		file.Diagnostics = append([]Diagnostic{{
+			Problem: "unknown character encoding",
+			Action:  "invalid bytes replaced",
+		}}, file.Diagnostics...)
+	}
+}
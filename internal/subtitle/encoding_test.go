@@ -0,0 +1,132 @@
+package subtitle
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/language"
+)
+
+func srtSample(text string) string {
+	return "1\r\n00:00:01,000 --> 00:00:02,000\r\n" + text + "\r\n\r\n2\r\n00:00:03,000 --> 00:00:04,000\r\n" + text + "\r\n"
+}
+
+func TestReadBytes_DetectsEncoding(t *testing.T) {
+	tests := []struct {
+		name     string
+		encoding string
+		text     string
+		language language.Tag
+	}{
+		{"utf-8", EncodingUTF8, "我们今天晚上去哪里吃饭？你想吃什么都可以。", language.Chinese},
+		{"gbk", EncodingGB18030, "我们今天晚上去哪里吃饭？你想吃什么都可以。", language.Chinese},
+		{"big5", EncodingBig5, "我們今天晚上去哪裡吃飯？你想吃什麼都可以。", language.Chinese},
+		{"shift-jis", EncodingShiftJIS, "今日はどこでご飯を食べますか？何でもいいですよ。", language.Japanese},
+		{"euc-kr", EncodingEUCKR, "오늘 저녁은 어디서 먹을까요? 뭐든지 괜찮아요.", language.Korean},
+		{"utf-16 with bom", EncodingUTF16LE, "我们今天晚上去哪里吃饭？", language.Chinese},
+		{"utf-16be with bom", EncodingUTF16BE, "今日はどこでご飯を食べますか？", language.Japanese},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := encodeText([]byte(srtSample(tt.text)), tt.encoding)
+			require.NoError(t, err)
+
+			file, err := ReadBytes(data, "sample.srt")
+			require.NoError(t, err)
+			assert.Equal(t, tt.encoding, file.Encoding)
+			require.Len(t, file.Lines, 2)
+			assert.Equal(t, tt.text, file.Lines[0].Text)
+			assert.Equal(t, tt.language, file.Language)
+			assert.Empty(t, file.Diagnostics)
+		})
+	}
+}
+
+func TestReadBytes_UTF16WithoutBOM(t *testing.T) {
+	data, err := encodeText([]byte(srtSample("Hello there")), EncodingUTF16LE)
+	require.NoError(t, err)
+
+	file, err := ReadBytes(data[2:], "extracted")
+	require.NoError(t, err)
+	assert.Equal(t, EncodingUTF16LE, file.Encoding)
+	assert.Equal(t, "SRT", file.Format, "format is sniffed after decoding")
+	assert.Equal(t, "Hello there", file.Lines[0].Text)
+}
+
+func TestReadBytes_UnknownEncoding(t *testing.T) {
+	data := []byte("1\n00:00:01,000 --> 00:00:02,000\nbad \xff\xfe\xff byte\n")
+
+	file, err := ReadBytes(data, "sample.srt")
+	require.NoError(t, err)
+	assert.Equal(t, EncodingUnknown, file.Encoding)
+	assert.Equal(t, "unknown character encoding", file.Diagnostics[0].Problem)
+}
+
+func TestASSReader_DetectsEncoding(t *testing.T) {
+	content := "[Script Info]\nScriptType: v4.00+\n\n[Events]\nFormat: Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text\n" +
+		"Dialogue: 0,0:00:01.00,0:00:02.00,Default,,0,0,0,,我们今天晚上去哪里吃饭\n"
+	data, err := encodeText([]byte(content), EncodingGB18030)
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "sample.ass")
+	require.NoError(t, os.WriteFile(path, data, 0o644))
+
+	file, err := NewASSReader(path).Read()
+	require.NoError(t, err)
+	assert.Equal(t, EncodingGB18030, file.Encoding)
+	assert.Equal(t, "我们今天晚上去哪里吃饭", file.Lines[0].Text)
+}
+
+func TestParseEncoding(t *testing.T) {
+	for raw, want := range map[string]string{
+		"":          EncodingUTF8,
+		"GBK":       EncodingGB18030,
+		" sjis ":    EncodingShiftJIS,
+		"Big5":      EncodingBig5,
+		"utf-16":    EncodingUTF16LE,
+		"UTF-8-BOM": EncodingUTF8BOM,
+		"source":    EncodingSource,
+	} {
+		got, err := ParseEncoding(raw)
+		require.NoError(t, err, raw)
+		assert.Equal(t, want, got, raw)
+	}
+	_, err := ParseEncoding("latin-2")
+	assert.Error(t, err)
+}
+
+func TestWriterWithEncoding(t *testing.T) {
+	dir := t.TempDir()
+	file := &File{
+		Format:   "SRT",
+		Encoding: EncodingBig5,
+		Lines: []Line{
+			{Index: 1, StartTime: 1000000000, EndTime: 2000000000, Text: "Hello", TranslatedText: "我們走吧"},
+		},
+	}
+
+	gbkPath := filepath.Join(dir, "gbk.srt")
+	require.NoError(t, NewWriterWithEncoding(OutputTranslated, EncodingGB18030).Write(gbkPath, file))
+	data, err := os.ReadFile(gbkPath)
+	require.NoError(t, err)
+	want, err := encodeText([]byte("1\n00:00:01,000 --> 00:00:02,000\n我們走吧\n\n"), EncodingGB18030)
+	require.NoError(t, err)
+	assert.Equal(t, want, data)
+
+	sourcePath := filepath.Join(dir, "source.srt")
+	require.NoError(t, NewWriterWithEncoding(OutputTranslated, EncodingSource).Write(sourcePath, file))
+	read, err := NewReader(sourcePath).Read()
+	require.NoError(t, err)
+	assert.Equal(t, EncodingBig5, read.Encoding)
+	assert.Equal(t, "我們走吧", read.Lines[0].Text)
+
+	sjisPath := filepath.Join(dir, "sjis.srt")
+	require.NoError(t, NewWriterWithEncoding(OutputTranslated, EncodingShiftJIS).Write(sjisPath, &File{
+		Lines: []Line{{Index: 1, Text: "안녕"}},
+	}), "text the encoding cannot represent falls back to UTF-8")
+	data, err = os.ReadFile(sjisPath)
+	require.NoError(t, err)
+	assert.Equal(t, "1\n00:00:00,000 --> 00:00:00,000\n안녕\n\n", string(data))
+}
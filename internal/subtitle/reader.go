@@ -1,7 +1,6 @@
 package subtitle
 
 import (
-	"bufio"
 	"bytes"
 	"fmt"
 	"os"
@@ -28,10 +27,10 @@ func NewReader(
 	}
 }
 
-// ReadSubtitle reads subtitle file
+// ReadSubtitle reads subtitle file in any registered format
 func (r *DefaultReader) Read() (*File, error) {
-	if !strings.HasSuffix(strings.ToLower(r.path), ".srt") {
-		return nil, fmt.Errorf("only SRT format subtitle files are supported: %s", r.path)
+	if _, ok := FormatByExtension(r.path); !ok {
+		return nil, fmt.Errorf("unsupported subtitle format, expected one of %s: %s", strings.Join(SupportedExtensions(), ", "), r.path)
 	}
 
 	if _, err := os.Stat(r.path); os.IsNotExist(err) {
@@ -42,97 +41,193 @@ func (r *DefaultReader) Read() (*File, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to read subtitle file: %w", err)
 	}
-	return ReadSRTBytes(data, r.path)
+	return ReadBytes(data, r.path)
 }
 
+// srtTimingPattern accepts the timing lines seen in the wild: hours of any
+// width or no hours at all, "," "." or ":" before the milliseconds, missing
+// milliseconds and short arrows
+var srtTimingPattern = regexp.MustCompile(`^(?:(\d+):)?(\d{1,2}):(\d{1,2})(?:[,.:](\d{1,3}))?\s*-{1,2}>\s*(?:(\d+):)?(\d{1,2}):(\d{1,2})(?:[,.:](\d{1,3}))?(?:\s.*)?$`)
+
+// srtStandardTiming is the timing line format of the SRT spec
+var srtStandardTiming = regexp.MustCompile(`^\d{2}:\d{2}:\d{2},\d{3} --> \d{2}:\d{2}:\d{2},\d{3}`)
+
+// defaultCueDuration is used for cues whose end time cannot be trusted
+const defaultCueDuration = 2 * time.Second
+
+type srtCue struct {
+	line       Line
+	sourceLine int // line number of the timing line
+	skip       bool
+	gap        int // line number of a blank line after text, 0 when there is none
+}
+
+// ReadSRTBytes parses SRT content. Malformed input is repaired where
+// possible instead of failing the whole file; every repair is recorded in
+// File.Diagnostics.
 func ReadSRTBytes(data []byte, path string) (*File, error) {
+	data = bytes.TrimPrefix(data, []byte("\ufeff"))
+	text := strings.ReplaceAll(strings.ReplaceAll(string(data), "\r\n", "\n"), "\r", "\n")
+	rawLines := strings.Split(text, "\n")
+
 	var lines []Line
-	scanner := bufio.NewScanner(bytes.NewReader(data))
+	var timingLines []int // line number of the timing line of every cue
+	var diagnostics []Diagnostic
+	report := func(line int, problem string, action string) {
+		diagnostics = append(diagnostics, Diagnostic{Line: line, Problem: problem, Action: action})
+	}
+
+	var current *srtCue
+	pendingIndex, pendingIndexLine := 0, 0
+	lastIndex := 0
+	flush := func() {
+		if current == nil || current.skip {
+			current = nil
+			return
+		}
+		if strings.TrimSpace(current.line.Text) == "" {
+			report(current.sourceLine, "cue has no text", "cue skipped")
+			current = nil
+			return
+		}
+		lines = append(lines, current.line)
+		timingLines = append(timingLines, current.sourceLine)
+		current = nil
+	}
 
-	currentLine := Line{}
-	state := "index" // possible values: "index", "time", "text", "empty"
-	var textLines []string
+	for i, raw := range rawLines {
+		lineNo := i + 1
+		line := strings.TrimSpace(raw)
 
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case looksLikeSRTTiming(line):
+			flush()
+			index := pendingIndex
+			indexLine := pendingIndexLine
+			pendingIndex, pendingIndexLine = 0, 0
 
-		switch state {
-		case "index":
-			if line == "" {
+			start, end, err := parseSRTTime(line)
+			if err != nil {
+				report(lineNo, fmt.Sprintf("invalid timestamp %q", line), "cue skipped")
+				current = &srtCue{skip: true}
 				continue
 			}
-			index, err := strconv.Atoi(line)
-			if err != nil {
-				continue // skip non-index lines
+			if !srtStandardTiming.MatchString(line) {
+				report(lineNo, fmt.Sprintf("non-standard timestamp %q", line), "parsed leniently")
 			}
-			currentLine.Index = index
-			state = "time"
 
-		case "time":
-			if line == "" {
-				continue
+			switch {
+			case indexLine == 0:
+				index = lastIndex + 1
+				report(lineNo, "missing cue number", fmt.Sprintf("numbered %d", index))
+			case index <= lastIndex:
+				report(indexLine, fmt.Sprintf("cue number %d is out of order", index), fmt.Sprintf("renumbered %d", lastIndex+1))
+				index = lastIndex + 1
 			}
-			startTime, endTime, err := parseSRTTime(line)
-			if err != nil {
-				return nil, fmt.Errorf("failed to parse time: %w", err)
+			lastIndex = index
+			current = &srtCue{
+				line:       Line{Index: index, StartTime: start, EndTime: end},
+				sourceLine: lineNo,
 			}
-			currentLine.StartTime = startTime
-			currentLine.EndTime = endTime
-			state = "text"
-			textLines = []string{}
-
-		case "text":
-			if line == "" {
-				// subtitle text ends
-				if len(textLines) > 0 {
-					currentLine.Text = strings.Join(textLines, "\n")
-					lines = append(lines, currentLine)
-					currentLine = Line{}
-				}
-				state = "index"
-				textLines = []string{}
-			} else {
-				textLines = append(textLines, line)
+
+		case line == "":
+			if current != nil && current.line.Text != "" && current.gap == 0 {
+				current.gap = lineNo
 			}
-		}
-	}
 
-	// handle last subtitle group
-	if state == "text" && len(textLines) > 0 {
-		currentLine.Text = strings.Join(textLines, "\n")
-		lines = append(lines, currentLine)
+		case isSRTIndex(line) && nextSRTLineIsTiming(rawLines, i+1):
+			pendingIndex, _ = strconv.Atoi(line)
+			pendingIndexLine = lineNo
+
+		case current == nil:
+			report(lineNo, "text outside of a cue", "line ignored")
+
+		case current.skip:
+			// text of a cue with an invalid timestamp
+
+		default:
+			if current.gap != 0 {
+				report(current.gap, "blank line inside cue text", "text kept in one cue")
+				current.gap = 0
+			}
+			if current.line.Text != "" {
+				current.line.Text += "\n"
+			}
+			current.line.Text += line
+		}
 	}
+	flush()
 
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("failed to read subtitle file: %w", err)
+	for i := range lines {
+		if lines[i].EndTime > lines[i].StartTime {
+			continue
+		}
+		end := lines[i].StartTime + defaultCueDuration
+		if i+1 < len(lines) && lines[i+1].StartTime > lines[i].StartTime {
+			end = min(end, lines[i+1].StartTime)
+		}
+		report(timingLines[i], "cue ends before it starts", fmt.Sprintf("end time set to %s", formatDuration(end)))
+		lines[i].EndTime = end
 	}
 
 	// detect language (simple detection based on text content)
 	language := detectLanguage(lines)
 
 	return &File{
-		Lines:    lines,
-		Language: language,
-		Format:   "SRT",
-		Path:     path,
+		Lines:       lines,
+		Language:    language,
+		Format:      "SRT",
+		Path:        path,
+		Diagnostics: diagnostics,
 	}, nil
 }
 
-// parseSRTTime parses SRT time format
-func parseSRTTime(timeString string) (time.Duration, time.Duration, error) {
-	// SRT time format: 00:02:16,612 --> 00:02:19,376
-	re := regexp.MustCompile(`(\d{2}):(\d{2}):(\d{2}),(\d{3}) --> (\d{2}):(\d{2}):(\d{2}),(\d{3})`)
-	matches := re.FindStringSubmatch(timeString)
+func isSRTIndex(line string) bool {
+	_, err := strconv.Atoi(line)
+	return err == nil
+}
 
+// nextSRTLineIsTiming reports whether the next non-blank line is a timing line
+func nextSRTLineIsTiming(rawLines []string, from int) bool {
+	for _, raw := range rawLines[min(from, len(rawLines)):] {
+		line := strings.TrimSpace(raw)
+		if line == "" {
+			continue
+		}
+		return looksLikeSRTTiming(line)
+	}
+	return false
+}
+
+// looksLikeSRTTiming reports whether a line is meant as a timing line, even
+// one too broken to parse; cue text may contain arrows but does not start
+// with a digit next to one
+func looksLikeSRTTiming(line string) bool {
+	if srtTimingPattern.MatchString(line) {
+		return true
+	}
+	return strings.Contains(line, "-->") && line[0] >= '0' && line[0] <= '9'
+}
+
+// parseSRTTime parses an SRT timing line like 00:02:16,612 --> 00:02:19,376
+func parseSRTTime(timeString string) (time.Duration, time.Duration, error) {
+	matches := srtTimingPattern.FindStringSubmatch(strings.TrimSpace(timeString))
 	if len(matches) != 9 {
 		return 0, 0, fmt.Errorf("invalid time format: %s", timeString)
 	}
 
-	parseTime := func(hours, minutes, seconds, milliseconds string) (time.Duration, error) {
+	parseTime := func(hours, minutes, seconds, fraction string) (time.Duration, error) {
 		h, _ := strconv.Atoi(hours)
 		m, _ := strconv.Atoi(minutes)
 		s, _ := strconv.Atoi(seconds)
-		ms, _ := strconv.Atoi(milliseconds)
+		if m > 59 || s > 59 {
+			return 0, fmt.Errorf("invalid time: %s:%s:%s", hours, minutes, seconds)
+		}
+		// "5" and "50" are fractions of a second, not milliseconds
+		ms := 0
+		if fraction != "" {
+			ms, _ = strconv.Atoi((fraction + "00")[:3])
+		}
 
 		return time.Duration(h)*time.Hour +
 			time.Duration(m)*time.Minute +
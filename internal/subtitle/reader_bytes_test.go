@@ -2,6 +2,7 @@ package subtitle
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -18,3 +19,71 @@ func TestReadSRTBytes(t *testing.T) {
 	assert.Equal(t, "SRT", file.Format)
 	assert.Equal(t, "embedded://sample", file.Path)
 }
+
+func TestReadSRTBytes_WellFormedHasNoDiagnostics(t *testing.T) {
+	data := []byte("\ufeff1\r\n00:00:01,000 --> 00:00:02,000\r\nHello\r\n\r\n2\r\n00:00:03,000 --> 00:00:04,000\r\nWorld\r\n")
+
+	file, err := ReadSRTBytes(data, "sample.srt")
+	require.NoError(t, err)
+	require.Len(t, file.Lines, 2)
+	assert.Equal(t, 1, file.Lines[0].Index, "BOM must not end up in the first index")
+	assert.Equal(t, "Hello", file.Lines[0].Text)
+	assert.Empty(t, file.Diagnostics)
+}
+
+func TestReadSRTBytes_Lenient(t *testing.T) {
+	data := []byte(`1
+0:00:01.5 --> 0:00:02.75
+Short hours and dots
+
+2
+00:00:03,000 --> 00:0x:04,000
+Broken timestamp
+
+3
+00:00:05,000 --> 00:00:06,000
+First part
+
+second part after a blank line
+
+00:00:07,000 --> 00:00:08,000
+No cue number
+
+3
+00:00:09,000 --> 00:00:08,000
+Ends before it starts
+arrows --> in text are text
+
+6
+00:00:10,000 --> 00:00:11,000
+
+7
+00:00:12,000 --> 00:00:13,000
+Last
+`)
+
+	file, err := ReadSRTBytes(data, "sample.srt")
+	require.NoError(t, err)
+
+	require.Len(t, file.Lines, 5)
+	assert.Equal(t, Line{Index: 1, StartTime: 1500 * time.Millisecond, EndTime: 2750 * time.Millisecond, Text: "Short hours and dots"}, file.Lines[0])
+	assert.Equal(t, "First part\nsecond part after a blank line", file.Lines[1].Text)
+	assert.Equal(t, 3, file.Lines[1].Index)
+	assert.Equal(t, 4, file.Lines[2].Index)
+	assert.Equal(t, "No cue number", file.Lines[2].Text)
+	assert.Equal(t, 5, file.Lines[3].Index)
+	assert.Equal(t, "Ends before it starts\narrows --> in text are text", file.Lines[3].Text)
+	assert.Equal(t, 11*time.Second, file.Lines[3].EndTime, "end is capped at the default cue duration")
+	assert.Equal(t, "Last", file.Lines[4].Text)
+	assert.Equal(t, 7, file.Lines[4].Index)
+
+	assert.Equal(t, []Diagnostic{
+		{Line: 2, Problem: `non-standard timestamp "0:00:01.5 --> 0:00:02.75"`, Action: "parsed leniently"},
+		{Line: 6, Problem: `invalid timestamp "00:00:03,000 --> 00:0x:04,000"`, Action: "cue skipped"},
+		{Line: 12, Problem: "blank line inside cue text", Action: "text kept in one cue"},
+		{Line: 15, Problem: "missing cue number", Action: "numbered 4"},
+		{Line: 18, Problem: "cue number 3 is out of order", Action: "renumbered 5"},
+		{Line: 24, Problem: "cue has no text", Action: "cue skipped"},
+		{Line: 19, Problem: "cue ends before it starts", Action: "end time set to 00:00:11,000"},
+	}, file.Diagnostics)
+}
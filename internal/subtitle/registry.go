@@ -0,0 +1,212 @@
+package subtitle
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Format describes a subtitle format the pipeline can read and write.
+// Adding a format only requires registering it with RegisterFormat.
+type Format struct {
+	// Name is the value stored in File.Format, e.g. SRT
+	Name string
+	// Extensions are lower-case file extensions including the dot
+	Extensions []string
+	// Sniff reports whether data looks like this format; used when the
+	// extension is unknown, e.g. for subtitles extracted from media files
+	Sniff func(data []byte) bool
+	// Parse parses content into a File. ReadBytes converts the content to
+	// UTF-8 first.
+	Parse func(data []byte, path string) (*File, error)
+	// NewWriter creates a writer emitting this format
+	NewWriter func() Writer
+}
+
+type formatRegistry struct {
+	mu      sync.RWMutex
+	formats []Format
+}
+
+var srtSniffPattern = regexp.MustCompile(`(?m)^\s*\d+\s*\r?\n\s*\d+:\d{2}:\d{2}[,.]\d{1,3}\s*-->`)
+
+// registry holds the built-in formats; more can be added with RegisterFormat
+var registry = &formatRegistry{
+	formats: []Format{
+		{
+			Name:       "SRT",
+			Extensions: []string{".srt"},
+			Sniff: func(data []byte) bool {
+				return srtSniffPattern.Match(sniffHead(data))
+			},
+			Parse:     ReadSRTBytes,
+			NewWriter: NewSRTWriter,
+		},
+		{
+			Name:       "ASS",
+			Extensions: []string{".ass", ".ssa"},
+			Sniff: func(data []byte) bool {
+				return bytes.HasPrefix(bytes.ToLower(sniffHead(data)), []byte("[script info]"))
+			},
+			Parse:     ReadASSBytes,
+			NewWriter: NewASSWriter,
+		},
+		{
+			Name:       "VTT",
+			Extensions: []string{".vtt"},
+			Sniff: func(data []byte) bool {
+				return bytes.HasPrefix(sniffHead(data), []byte("WEBVTT"))
+			},
+			Parse:     ReadVTTBytes,
+			NewWriter: NewVTTWriter,
+		},
+	},
+}
+
+// RegisterFormat adds a format to the registry. A format registered with an
+// existing name replaces the previous registration.
+func RegisterFormat(format Format) {
+	format.Name = strings.ToUpper(strings.TrimSpace(format.Name))
+	extensions := make([]string, len(format.Extensions))
+	for i, ext := range format.Extensions {
+		extensions[i] = strings.ToLower(ext)
+	}
+	format.Extensions = extensions
+
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	for i, existing := range registry.formats {
+		if existing.Name == format.Name {
+			registry.formats[i] = format
+			return
+		}
+	}
+	registry.formats = append(registry.formats, format)
+}
+
+// LookupFormat returns the format registered under name (case-insensitive)
+func LookupFormat(name string) (Format, bool) {
+	name = strings.ToUpper(strings.TrimSpace(name))
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	for _, format := range registry.formats {
+		if format.Name == name {
+			return format, true
+		}
+	}
+	return Format{}, false
+}
+
+// FormatByExtension returns the format handling the extension of path
+func FormatByExtension(path string) (Format, bool) {
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext == "" {
+		return Format{}, false
+	}
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	for _, format := range registry.formats {
+		if slices.Contains(format.Extensions, ext) {
+			return format, true
+		}
+	}
+	return Format{}, false
+}
+
+// DetectFormat detects the format of data, first by the extension of path,
+// then by sniffing the content.
+func DetectFormat(path string, data []byte) (Format, bool) {
+	if format, ok := FormatByExtension(path); ok {
+		return format, true
+	}
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	for _, format := range registry.formats {
+		if format.Sniff != nil && format.Sniff(data) {
+			return format, true
+		}
+	}
+	return Format{}, false
+}
+
+// SupportedExtensions lists the extensions of all registered formats
+func SupportedExtensions() []string {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	ret := make([]string, 0, len(registry.formats))
+	for _, format := range registry.formats {
+		ret = append(ret, format.Extensions...)
+	}
+	return ret
+}
+
+// IsSupportedExtension reports whether a registered format reads files with ext
+func IsSupportedExtension(ext string) bool {
+	return slices.Contains(SupportedExtensions(), strings.ToLower(ext))
+}
+
+// sidecarExtensions are subtitle files players load next to a media file
+// that no registered format may read, e.g. PGS or VobSub images
+var sidecarExtensions = []string{
+	".sub",  // MicroDVD/SubViewer, VobSub images
+	".idx",  // VobSub index
+	".sup",  // Blu-ray PGS
+	".txt",  // plain text subtitles
+	".usf",  // Universal Subtitle Format
+	".ttml", // Timed Text Markup Language
+	".dfxp", // Distribution Format Exchange Profile
+	".sbv",  // YouTube
+}
+
+// IsSidecarExtension reports whether files with ext are subtitles, read by a
+// registered format or not. A sidecar in a language means the media has a
+// subtitle in it, only registered formats can be translated from.
+func IsSidecarExtension(ext string) bool {
+	ext = strings.ToLower(ext)
+	return slices.Contains(sidecarExtensions, ext) || IsSupportedExtension(ext)
+}
+
+// ReadBytes parses subtitle content with the format detected for path and
+// data. Legacy encodings like GBK, Big5, Shift-JIS and UTF-16 are converted
+// to UTF-8; File.Encoding records the one detected.
+func ReadBytes(data []byte, path string) (*File, error) {
+	text, name, ok := decodeText(data)
+	format, found := DetectFormat(path, text)
+	if !found {
+		return nil, fmt.Errorf("unsupported subtitle format: %s", path)
+	}
+	file, err := format.Parse(text, path)
+	if err != nil {
+		return nil, err
+	}
+	recordEncoding(file, name, ok)
+	return file, nil
+}
+
+// outputFormat decides the format to write, either from the output extension
+// or, for unknown extensions, from the format the subtitle was read from.
+// It falls back to SRT.
+func outputFormat(path string, subtitle *File) Format {
+	if format, ok := FormatByExtension(path); ok {
+		return format
+	}
+	if format, ok := LookupFormat(subtitle.Format); ok {
+		return format
+	}
+	format, _ := LookupFormat("SRT")
+	return format
+}
+
+// sniffHead returns the beginning of data without BOM and leading whitespace
+func sniffHead(data []byte) []byte {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	data = bytes.TrimLeft(data, " \t\r\n")
+	if len(data) > 4096 {
+		data = data[:4096]
+	}
+	return data
+}
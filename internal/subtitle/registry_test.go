@@ -0,0 +1,94 @@
+package subtitle
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectFormat(t *testing.T) {
+	srt := []byte("1\n00:00:01,000 --> 00:00:02,000\nHello\n")
+
+	tests := []struct {
+		name string
+		path string
+		data []byte
+		want string
+		ok   bool
+	}{
+		{"srt by extension", "movie.srt", srt, "SRT", true},
+		{"ssa by extension", "movie.SSA", []byte(sampleASS), "ASS", true},
+		{"vtt by extension", "movie.vtt", []byte(sampleVTT), "VTT", true},
+		{"sniff srt", "stream.sub", srt, "SRT", true},
+		{"sniff ass", "stream", []byte(sampleASS), "ASS", true},
+		{"sniff vtt", "stream", []byte(sampleVTT), "VTT", true},
+		{"unknown", "movie.txt", []byte("just text"), "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			format, ok := DetectFormat(tt.path, tt.data)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.want, format.Name)
+		})
+	}
+}
+
+func TestIsSupportedExtension(t *testing.T) {
+	assert.True(t, IsSupportedExtension(".srt"))
+	assert.True(t, IsSupportedExtension(".ASS"))
+	assert.True(t, IsSupportedExtension(".vtt"))
+	assert.False(t, IsSupportedExtension(".sup"))
+	assert.False(t, IsSupportedExtension(".txt"))
+}
+
+func TestDefaultReaderRejectsUnsupportedExtension(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "movie.txt")
+	require.NoError(t, os.WriteFile(path, []byte("1\n00:00:01,000 --> 00:00:02,000\nHello\n"), 0644))
+
+	_, err := NewReader(path).Read()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported subtitle format")
+}
+
+func TestRegisterFormat(t *testing.T) {
+	original := registry.formats
+	registry.formats = append([]Format(nil), original...)
+	t.Cleanup(func() { registry.formats = original })
+
+	extensions := []string{".TXT"}
+	RegisterFormat(Format{
+		Name:       "txt",
+		Extensions: extensions,
+		Parse: func(data []byte, path string) (*File, error) {
+			return &File{Lines: []Line{{Index: 1, Text: string(data)}}, Format: "TXT", Path: path}, nil
+		},
+		NewWriter: NewSRTWriter,
+	})
+
+	format, ok := LookupFormat("TXT")
+	require.True(t, ok)
+	assert.Equal(t, []string{".txt"}, format.Extensions)
+	assert.Equal(t, []string{".TXT"}, extensions, "the caller's slice is left as it is")
+	assert.True(t, IsSupportedExtension(".txt"))
+
+	file, err := ReadBytes([]byte("hello"), "notes.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "TXT", file.Format)
+	assert.Equal(t, "hello", file.Lines[0].Text)
+}
+
+func TestDefaultWriterUsesSourceFormatForUnknownExtension(t *testing.T) {
+	file, err := ReadVTTBytes([]byte(sampleVTT), "sample.vtt")
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "out.sub")
+	require.NoError(t, NewWriter().Write(path, file))
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "WEBVTT")
+}
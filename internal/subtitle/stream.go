@@ -0,0 +1,163 @@
+package subtitle
+
+import (
+	"slices"
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+// bitmapCodecs are subtitle codecs stored as images, which cannot be
+// converted to text without OCR
+var bitmapCodecs = []string{"hdmv_pgs_subtitle", "pgssub", "dvd_subtitle", "dvdsub", "dvb_subtitle", "dvbsub", "xsub"}
+
+// IsBitmap reports whether the stream is image-based (PGS, VobSub, DVB)
+func (d Description) IsBitmap() bool {
+	return slices.Contains(bitmapCodecs, strings.ToLower(d.Codec))
+}
+
+// styledCodecs are text subtitle codecs with styles and positioning, which
+// are extracted as they are instead of being converted to SRT
+var styledCodecs = []string{"ass", "ssa"}
+
+// ExtractFormat returns the format a text stream is extracted in: "ass" for
+// ASS/SSA streams, so their styles and override tags survive, "srt" for
+// plain text codecs
+func (d Description) ExtractFormat() string {
+	if slices.Contains(styledCodecs, strings.ToLower(d.Codec)) {
+		return "ass"
+	}
+	return "srt"
+}
+
+// IsForced reports whether the stream only carries forced or signs/songs
+// lines, either by disposition or by its title
+func (d Description) IsForced() bool {
+	if d.Forced {
+		return true
+	}
+	title := strings.ToLower(d.SubLanguage)
+	return strings.Contains(title, "forced") || strings.Contains(title, "signs")
+}
+
+// IsSDH reports whether the stream is meant for deaf and hard-of-hearing
+// viewers, either by disposition or by its title
+func (d Description) IsSDH() bool {
+	if d.HearingImpaired {
+		return true
+	}
+	for _, word := range strings.FieldsFunc(strings.ToLower(d.SubLanguage), func(r rune) bool {
+		return !('a' <= r && r <= 'z')
+	}) {
+		if word == "sdh" || word == "cc" {
+			return true
+		}
+	}
+	return strings.Contains(strings.ToLower(d.SubLanguage), "hearing impaired")
+}
+
+// StreamPolicy selects an embedded subtitle stream. All set fields must
+// match; among the matching streams text-based, non-forced, non-SDH and
+// default streams are preferred, then the earliest one.
+type StreamPolicy struct {
+	Language      string   `json:"language,omitempty"`       // e.g. en, eng, ja
+	Title         string   `json:"title,omitempty"`          // case-insensitive substring of the stream title
+	ExcludeForced bool     `json:"exclude_forced,omitempty"` // skip forced and signs/songs streams
+	ExcludeSDH    bool     `json:"exclude_sdh,omitempty"`    // skip SDH/CC streams
+	Codecs        []string `json:"codecs,omitempty"`         // allowed codecs, e.g. subrip, ass
+}
+
+// Matches reports whether the stream satisfies every field of the policy
+func (p StreamPolicy) Matches(d Description) bool {
+	if lang := strings.TrimSpace(p.Language); lang != "" && !descriptionHasLanguage(d, lang) {
+		return false
+	}
+	if title := strings.TrimSpace(p.Title); title != "" &&
+		!strings.Contains(strings.ToLower(d.SubLanguage), strings.ToLower(title)) {
+		return false
+	}
+	if p.ExcludeForced && d.IsForced() {
+		return false
+	}
+	if p.ExcludeSDH && d.IsSDH() {
+		return false
+	}
+	if len(p.Codecs) > 0 && !slices.ContainsFunc(p.Codecs, func(codec string) bool {
+		return normalizeCodec(codec) == normalizeCodec(d.Codec)
+	}) {
+		return false
+	}
+	return true
+}
+
+// Select returns the best stream matching the policy
+func (d Descriptions) Select(policy StreamPolicy) (Description, bool) {
+	best := -1
+	bestScore := -1
+	for i, desc := range d {
+		if !policy.Matches(desc) {
+			continue
+		}
+		if score := streamScore(desc); score > bestScore {
+			best, bestScore = i, score
+		}
+	}
+	if best < 0 {
+		return Description{}, false
+	}
+	return d[best], true
+}
+
+// ByIndex returns the stream at the given subtitle stream index
+func (d Descriptions) ByIndex(index int) (Description, bool) {
+	for _, desc := range d {
+		if desc.Index == index {
+			return desc, true
+		}
+	}
+	return Description{}, false
+}
+
+func streamScore(d Description) int {
+	score := 0
+	if !d.IsBitmap() {
+		score += 8
+	}
+	if !d.IsForced() {
+		score += 4
+	}
+	if !d.IsSDH() {
+		score += 2
+	}
+	if d.Default {
+		score++
+	}
+	return score
+}
+
+func descriptionHasLanguage(d Description, lang string) bool {
+	if strings.EqualFold(d.Language, lang) {
+		return true
+	}
+	tag, err := language.Parse(lang)
+	if err != nil {
+		return false
+	}
+	want, _ := tag.Base()
+	got, _ := d.LangTag.Base()
+	return got.String() != "und" && got == want
+}
+
+func normalizeCodec(codec string) string {
+	codec = strings.ToLower(strings.TrimSpace(codec))
+	switch codec {
+	case "srt":
+		return "subrip"
+	case "ssa":
+		return "ass"
+	case "vtt":
+		return "webvtt"
+	default:
+		return codec
+	}
+}
@@ -0,0 +1,93 @@
+package subtitle
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/text/language"
+)
+
+func sampleStreams() Descriptions {
+	return Descriptions{
+		{Index: 0, Language: "eng", SubLanguage: "Signs/Songs", LangTag: language.English, Codec: "ass", Default: true},
+		{Index: 1, Language: "eng", SubLanguage: "English SDH", LangTag: language.English, Codec: "subrip"},
+		{Index: 2, Language: "eng", SubLanguage: "English", LangTag: language.English, Codec: "hdmv_pgs_subtitle"},
+		{Index: 3, Language: "eng", SubLanguage: "Full Dialogue", LangTag: language.English, Codec: "ass"},
+		{Index: 4, Language: "jpn", SubLanguage: "", LangTag: language.Japanese, Codec: "subrip", Forced: true},
+	}
+}
+
+func TestDescriptionsSelect(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy StreamPolicy
+		want   int
+		ok     bool
+	}{
+		{"auto prefers full text dialogue", StreamPolicy{}, 3, true},
+		{"language by iso 639-1", StreamPolicy{Language: "ja"}, 4, true},
+		{"language excluding forced", StreamPolicy{Language: "jpn", ExcludeForced: true}, 0, false},
+		{"title", StreamPolicy{Title: "sdh"}, 1, true},
+		{"codec alias", StreamPolicy{Codecs: []string{"srt"}, ExcludeSDH: true, ExcludeForced: true}, 0, false},
+		{"codec", StreamPolicy{Codecs: []string{"srt"}, ExcludeForced: true}, 1, true},
+		{"bitmap only when asked", StreamPolicy{Codecs: []string{"hdmv_pgs_subtitle"}}, 2, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := sampleStreams().Select(tt.policy)
+			assert.Equal(t, tt.ok, ok)
+			if tt.ok {
+				assert.Equal(t, tt.want, got.Index)
+			}
+		})
+	}
+}
+
+func TestDescriptionFlags(t *testing.T) {
+	streams := sampleStreams()
+	assert.True(t, streams[0].IsForced())
+	assert.True(t, streams[1].IsSDH())
+	assert.False(t, streams[3].IsSDH())
+	assert.True(t, streams[2].IsBitmap())
+	assert.False(t, Description{SubLanguage: "Commentary"}.IsSDH())
+	assert.Equal(t, "ass", Description{Codec: "ASS"}.ExtractFormat())
+	assert.Equal(t, "ass", Description{Codec: "ssa"}.ExtractFormat())
+	assert.Equal(t, "srt", Description{Codec: "subrip"}.ExtractFormat())
+	assert.Equal(t, "srt", Description{Codec: "webvtt"}.ExtractFormat())
+
+	stream, ok := streams.ByIndex(4)
+	assert.True(t, ok)
+	assert.True(t, stream.IsForced())
+	_, ok = streams.ByIndex(9)
+	assert.False(t, ok)
+}
+
+func TestSameLanguage(t *testing.T) {
+	assert.True(t, SameLanguage(language.Chinese, language.MustParse("zh-Hans")))
+	assert.True(t, SameLanguage(language.MustParse("zh-TW"), language.MustParse("zh-Hant")))
+	assert.False(t, SameLanguage(language.MustParse("zh-Hant"), language.MustParse("zh-Hans")))
+	assert.False(t, SameLanguage(language.Chinese, language.MustParse("zh-HK")))
+	assert.True(t, SameLanguage(language.English, language.MustParse("en-GB")))
+	assert.False(t, SameLanguage(language.English, language.Japanese))
+	assert.False(t, SameLanguage(language.Und, language.Chinese))
+
+	assert.True(t, MatchesTarget(language.MustParse("zh-HK"), language.Chinese), "a bare target takes any script")
+	assert.False(t, MatchesTarget(language.MustParse("zh-HK"), language.MustParse("zh-Hans")))
+	assert.False(t, MatchesTarget(language.Und, language.English), "und is no language")
+
+	tag, ok := ParseLanguageToken("CHT")
+	assert.True(t, ok)
+	assert.Equal(t, language.TraditionalChinese, tag)
+	tag, ok = ParseLanguageToken("zh_tw")
+	assert.True(t, ok)
+	assert.True(t, MatchesTarget(tag, language.TraditionalChinese))
+	_, ok = ParseLanguageToken("forced")
+	assert.False(t, ok)
+
+	streams := Descriptions{{Index: 0, LangTag: language.Make("chi")}, {Index: 1, LangTag: language.Make("eng")}}
+	assert.True(t, streams.HasLanguage(language.Chinese))
+	assert.True(t, streams.HasLanguage(language.MustParse("zh-Hans")))
+	assert.False(t, streams.HasLanguage(language.MustParse("zh-Hant")))
+	assert.True(t, streams.HasLanguage(language.MustParse("en-US")))
+}
@@ -1,6 +1,7 @@
 package subtitle
 
 import (
+	"regexp"
 	"strings"
 	"time"
 
@@ -24,6 +25,16 @@ type Line struct {
 	EndTime        time.Duration // end time
 	Text           string        // subtitle text
 	TranslatedText string        // translated text
+	Speaker        string        // likely speaker, inferred before translation; never written out
+	Song           bool          // part of an opening, ending or insert song, detected before translation; never written out
+}
+
+// formattingPattern matches ASS override blocks and HTML-style tags
+var formattingPattern = regexp.MustCompile(`\{[^}]*\}|<[^<>]*>`)
+
+// PlainText returns the text without formatting tags, line breaks joined by spaces
+func (l Line) PlainText() string {
+	return strings.Join(strings.Fields(formattingPattern.ReplaceAllString(l.Text, "")), " ")
 }
 
 // File represents subtitle file
@@ -32,25 +43,96 @@ type File struct {
 	Language language.Tag
 	Format   string // e.g. SRT, ASS, VTT etc
 	Path     string
+	ASS      *ASSScript   // styles and events of ASS/SSA sources, nil for other formats
+	VTT      *VTTDocument // cue settings and blocks of WebVTT sources, nil for other formats
+	// Encoding is the character encoding the file was read from, e.g. UTF-8,
+	// GB18030 or Shift_JIS; empty for subtitles not read from bytes
+	Encoding string
+	// Diagnostics lists the problems the reader repaired, empty for well-formed files
+	Diagnostics []Diagnostic
+}
+
+// Diagnostic describes one problem found while reading a subtitle file
+type Diagnostic struct {
+	Line    int    `json:"line"` // 1-based line number in the file, 0 when unknown
+	Problem string `json:"problem"`
+	Action  string `json:"action"` // what the reader did about it
 }
 
 type Description struct {
-	Language    string
-	SubLanguage string
-	LangTag     language.Tag
+	Index           int // position among the subtitle streams, the N in -map 0:s:N
+	Language        string
+	SubLanguage     string // stream title, e.g. "English SDH" or "Signs/Songs"
+	LangTag         language.Tag
+	Codec           string // ffprobe codec name, e.g. subrip, ass, hdmv_pgs_subtitle
+	Default         bool
+	Forced          bool
+	HearingImpaired bool
 }
 
 type Descriptions []Description
 
 func (d Descriptions) HasLanguage(lang language.Tag) bool {
 	for _, desc := range d {
-		if desc.LangTag == lang {
+		if MatchesTarget(desc.LangTag, lang) {
 			return true
 		}
 	}
 	return false
 }
 
+// SameLanguage reports whether subtitles in language a serve readers of b:
+// the base language and the script must match, so zh-Hant does not stand in
+// for zh-Hans. Tags without a script use the likely script of their region,
+// e.g. zh-TW is Traditional and zh Simplified.
+func SameLanguage(a, b language.Tag) bool {
+	if a == b {
+		return true
+	}
+	// und and other tags without a base language would get a guessed one
+	baseA, confidence := a.Base()
+	baseB, _ := b.Base()
+	if confidence != language.Exact || baseA != baseB {
+		return false
+	}
+	scriptA, _ := a.Script()
+	scriptB, _ := b.Script()
+	return scriptA == scriptB
+}
+
+// MatchesTarget reports whether subtitles in language a count as the target
+// language. A bare target such as zh takes any script or region of it; a
+// target with a script or region takes the same script only, see
+// SameLanguage.
+func MatchesTarget(a, target language.Tag) bool {
+	base, _ := target.Base()
+	if target != language.Make(base.String()) {
+		return SameLanguage(a, target)
+	}
+	baseA, confidence := a.Base()
+	return confidence == language.Exact && baseA == base
+}
+
+// ParseLanguageToken parses the language token of a subtitle file name, e.g.
+// eng, zh_TW or the chs and cht shorthands of Simplified and Traditional
+// Chinese.
+func ParseLanguageToken(token string) (language.Tag, bool) {
+	token = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(token), "_", "-"))
+	switch token {
+	case "":
+		return language.Und, false
+	case "chs":
+		return language.SimplifiedChinese, true
+	case "cht":
+		return language.TraditionalChinese, true
+	}
+	tag, err := language.Parse(token)
+	if err != nil {
+		return language.Und, false
+	}
+	return tag, true
+}
+
 // FormatLanguageCode formats language code
 func FormatLanguageCode(code string) string {
 	code = strings.ToLower(strings.TrimSpace(code))
@@ -0,0 +1,299 @@
+package subtitle
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// VTTDocument keeps the parts of a WebVTT file that are not cue text,
+// so a translated file can be written back with settings and blocks intact.
+type VTTDocument struct {
+	Header string     // "WEBVTT" signature line plus any header text
+	Blocks []VTTBlock // NOTE/STYLE/REGION blocks and cues in file order
+}
+
+// VTTBlock is either a raw NOTE/STYLE/REGION block or a cue
+type VTTBlock struct {
+	Raw        string // block content for NOTE/STYLE/REGION blocks; empty for cues
+	Identifier string // optional cue identifier
+	Settings   string // cue settings, e.g. "position:10% line:0 align:start"
+	Voice      string // speaker of a <v Speaker> span wrapping the whole cue
+	LineIndex  int    // index of the Line carrying the cue text
+}
+
+var (
+	vttTimingPattern = regexp.MustCompile(`^((?:\d+:)?\d{2}:\d{2}\.\d{3})\s+-->\s+((?:\d+:)?\d{2}:\d{2}\.\d{3})\s*(.*)$`)
+	vttVoicePattern  = regexp.MustCompile(`^<v(?:\.[^\s>]+)*\s+([^>]+)>`)
+)
+
+// VTTReader reads WebVTT subtitle files
+type VTTReader struct {
+	path string
+}
+
+// NewVTTReader creates a new WebVTT subtitle file reader
+func NewVTTReader(path string) Reader {
+	return &VTTReader{
+		path: path,
+	}
+}
+
+func (r *VTTReader) Read() (*File, error) {
+	if _, err := os.Stat(r.path); os.IsNotExist(err) {
+		return nil, fmt.Errorf("subtitle file does not exist: %s", r.path)
+	}
+
+	data, err := os.ReadFile(r.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read subtitle file: %w", err)
+	}
+	return parseDecoded(data, r.path, ReadVTTBytes)
+}
+
+// ReadVTTBytes parses WebVTT content. Cue text becomes translatable Lines;
+// identifiers, settings, voices and NOTE/STYLE/REGION blocks are kept in File.VTT.
+func ReadVTTBytes(data []byte, path string) (*File, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	blocks, err := splitVTTBlocks(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read subtitle file: %w", err)
+	}
+	if len(blocks) == 0 || !strings.HasPrefix(blocks[0][0], "WEBVTT") {
+		return nil, fmt.Errorf("missing WEBVTT signature in %s", path)
+	}
+
+	doc := &VTTDocument{
+		Header: strings.Join(blocks[0], "\n"),
+	}
+	var lines []Line
+
+	for _, block := range blocks[1:] {
+		first := block[0]
+		if isVTTRawBlock(first) {
+			doc.Blocks = append(doc.Blocks, VTTBlock{Raw: strings.Join(block, "\n")})
+			continue
+		}
+
+		cue := VTTBlock{}
+		timingIdx := 0
+		if !strings.Contains(first, "-->") {
+			cue.Identifier = first
+			timingIdx = 1
+		}
+		if timingIdx >= len(block) {
+			return nil, fmt.Errorf("cue %q has no timing line", first)
+		}
+		matches := vttTimingPattern.FindStringSubmatch(strings.TrimSpace(block[timingIdx]))
+		if matches == nil {
+			return nil, fmt.Errorf("invalid time format: %s", block[timingIdx])
+		}
+		start, err := parseVTTTime(matches[1])
+		if err != nil {
+			return nil, err
+		}
+		end, err := parseVTTTime(matches[2])
+		if err != nil {
+			return nil, err
+		}
+		cue.Settings = strings.TrimSpace(matches[3])
+
+		text := strings.Join(block[timingIdx+1:], "\n")
+		cue.Voice, text = splitVTTVoice(text)
+		cue.LineIndex = len(lines) + 1
+		doc.Blocks = append(doc.Blocks, cue)
+		lines = append(lines, Line{
+			Index:     cue.LineIndex,
+			StartTime: start,
+			EndTime:   end,
+			Text:      text,
+		})
+	}
+
+	return &File{
+		Lines:    lines,
+		Language: detectLanguage(lines),
+		Format:   "VTT",
+		Path:     path,
+		VTT:      doc,
+	}, nil
+}
+
+// splitVTTBlocks splits content into blocks separated by blank lines
+func splitVTTBlocks(data []byte) ([][]string, error) {
+	var blocks [][]string
+	var current []string
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(line) == "" {
+			if len(current) > 0 {
+				blocks = append(blocks, current)
+				current = nil
+			}
+			continue
+		}
+		current = append(current, line)
+	}
+	if len(current) > 0 {
+		blocks = append(blocks, current)
+	}
+	return blocks, scanner.Err()
+}
+
+func isVTTRawBlock(firstLine string) bool {
+	for _, keyword := range []string{"NOTE", "STYLE", "REGION"} {
+		if firstLine == keyword || strings.HasPrefix(firstLine, keyword+" ") || strings.HasPrefix(firstLine, keyword+"\t") {
+			return true
+		}
+	}
+	return false
+}
+
+// splitVTTVoice extracts the speaker when a single <v Speaker> span wraps the cue text
+func splitVTTVoice(text string) (string, string) {
+	matches := vttVoicePattern.FindStringSubmatch(text)
+	if matches == nil {
+		return "", text
+	}
+	body := text[len(matches[0]):]
+	if strings.Contains(body, "<v") {
+		return "", text
+	}
+	body = strings.TrimSuffix(body, "</v>")
+	return strings.TrimSpace(matches[1]), body
+}
+
+// parseVTTTime parses WebVTT time format, e.g. 01:02:16.612 or 02:16.612
+func parseVTTTime(raw string) (time.Duration, error) {
+	parts := strings.Split(raw, ":")
+	if len(parts) == 2 {
+		parts = append([]string{"0"}, parts...)
+	}
+	if len(parts) != 3 {
+		return 0, fmt.Errorf("invalid time format: %s", raw)
+	}
+	secParts := strings.SplitN(parts[2], ".", 2)
+	if len(secParts) != 2 {
+		return 0, fmt.Errorf("invalid time format: %s", raw)
+	}
+
+	values := make([]int, 0, 4)
+	for _, v := range []string{parts[0], parts[1], secParts[0], secParts[1]} {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return 0, fmt.Errorf("invalid time format: %s", raw)
+		}
+		values = append(values, n)
+	}
+
+	return time.Duration(values[0])*time.Hour +
+		time.Duration(values[1])*time.Minute +
+		time.Duration(values[2])*time.Second +
+		time.Duration(values[3])*time.Millisecond, nil
+}
+
+// formatVTTTime formats time.Duration to WebVTT time format
+func formatVTTTime(d time.Duration) string {
+	return strings.Replace(formatDuration(d), ",", ".", 1)
+}
+
+// VTTWriter writes WebVTT subtitle files
+type VTTWriter struct{}
+
+// NewVTTWriter creates a new WebVTT subtitle file writer
+func NewVTTWriter() Writer {
+	return &VTTWriter{}
+}
+
+// Write writes the subtitle as WebVTT. When the file was read from WebVTT,
+// identifiers, cue settings, voices and NOTE/STYLE/REGION blocks are kept.
+func (w *VTTWriter) Write(path string, subtitle *File) error {
+	if subtitle == nil {
+		return fmt.Errorf("subtitle data is empty")
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+
+	writer := bufio.NewWriter(file)
+	writeVTT(writer, subtitle)
+	if err := writer.Flush(); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to close output file: %w", err)
+	}
+	return nil
+}
+
+func writeVTT(writer *bufio.Writer, subtitle *File) {
+	doc := subtitle.VTT
+	if doc == nil {
+		doc = defaultVTTDocument(subtitle.Lines)
+	}
+
+	header := doc.Header
+	if strings.TrimSpace(header) == "" {
+		header = "WEBVTT"
+	}
+	fmt.Fprintf(writer, "%s\n\n", header)
+
+	byIndex := make(map[int]Line, len(subtitle.Lines))
+	for _, line := range subtitle.Lines {
+		byIndex[line.Index] = line
+	}
+
+	for _, block := range doc.Blocks {
+		if block.Raw != "" {
+			fmt.Fprintf(writer, "%s\n\n", block.Raw)
+			continue
+		}
+		line, ok := byIndex[block.LineIndex]
+		if !ok {
+			continue
+		}
+
+		if block.Identifier != "" {
+			fmt.Fprintf(writer, "%s\n", block.Identifier)
+		}
+		timing := formatVTTTime(line.StartTime) + " --> " + formatVTTTime(line.EndTime)
+		if block.Settings != "" {
+			timing += " " + block.Settings
+		}
+		fmt.Fprintf(writer, "%s\n", timing)
+
+		text := line.TranslatedText
+		if text == "" {
+			text = line.Text
+		}
+		if block.Voice != "" {
+			text = "<v " + block.Voice + ">" + text
+		}
+		fmt.Fprintf(writer, "%s\n\n", text)
+	}
+}
+
+// defaultVTTDocument builds a document with plain cues for subtitles that
+// did not come from a WebVTT source.
+func defaultVTTDocument(lines []Line) *VTTDocument {
+	doc := &VTTDocument{
+		Header: "WEBVTT",
+		Blocks: make([]VTTBlock, 0, len(lines)),
+	}
+	for _, line := range lines {
+		doc.Blocks = append(doc.Blocks, VTTBlock{LineIndex: line.Index})
+	}
+	return doc
+}
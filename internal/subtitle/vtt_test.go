@@ -0,0 +1,105 @@
+package subtitle
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const sampleVTT = `WEBVTT - sample
+
+STYLE
+::cue(v[voice="Momo"]) { color: pink }
+
+NOTE translated by fans
+
+intro
+00:00:01.000 --> 00:00:02.500 position:10% line:0 align:start
+<v Momo>Hello there
+friend</v>
+
+01:03.000 --> 01:04.000
+<i>Whispers</i>
+`
+
+func TestReadVTTBytes(t *testing.T) {
+	file, err := ReadVTTBytes([]byte(sampleVTT), "sample.vtt")
+	require.NoError(t, err)
+
+	require.Len(t, file.Lines, 2)
+	assert.Equal(t, "VTT", file.Format)
+	assert.Equal(t, time.Second, file.Lines[0].StartTime)
+	assert.Equal(t, 2500*time.Millisecond, file.Lines[0].EndTime)
+	assert.Equal(t, "Hello there\nfriend", file.Lines[0].Text)
+	assert.Equal(t, time.Minute+3*time.Second, file.Lines[1].StartTime)
+	assert.Equal(t, "<i>Whispers</i>", file.Lines[1].Text)
+
+	require.NotNil(t, file.VTT)
+	assert.Equal(t, "WEBVTT - sample", file.VTT.Header)
+	require.Len(t, file.VTT.Blocks, 4)
+	assert.Contains(t, file.VTT.Blocks[0].Raw, "::cue")
+	assert.Equal(t, "NOTE translated by fans", file.VTT.Blocks[1].Raw)
+	assert.Equal(t, "intro", file.VTT.Blocks[2].Identifier)
+	assert.Equal(t, "position:10% line:0 align:start", file.VTT.Blocks[2].Settings)
+	assert.Equal(t, "Momo", file.VTT.Blocks[2].Voice)
+	assert.Equal(t, 2, file.VTT.Blocks[3].LineIndex)
+}
+
+func TestReadVTTBytes_MissingSignature(t *testing.T) {
+	_, err := ReadVTTBytes([]byte("00:00:01.000 --> 00:00:02.000\nHi\n"), "broken.vtt")
+	require.Error(t, err)
+}
+
+func TestVTTWriter_RoundTripKeepsCueSettings(t *testing.T) {
+	file, err := ReadVTTBytes([]byte(sampleVTT), "sample.vtt")
+	require.NoError(t, err)
+	file.Lines[0].TranslatedText = "你好\n朋友"
+	file.Lines[1].TranslatedText = "<i>低语</i>"
+
+	out := filepath.Join(t.TempDir(), "sample_ctxtrans.zh.vtt")
+	require.NoError(t, NewWriter().Write(out, file))
+
+	data, err := os.ReadFile(out)
+	require.NoError(t, err)
+	content := string(data)
+	assert.Contains(t, content, "WEBVTT - sample\n\nSTYLE\n")
+	assert.Contains(t, content, "NOTE translated by fans\n")
+	assert.Contains(t, content, "intro\n00:00:01.000 --> 00:00:02.500 position:10% line:0 align:start\n<v Momo>你好\n朋友\n")
+	assert.Contains(t, content, "00:01:03.000 --> 00:01:04.000\n<i>低语</i>\n")
+
+	reread, err := NewReader(out).Read()
+	require.NoError(t, err)
+	require.Len(t, reread.Lines, 2)
+	assert.Equal(t, "Momo", reread.VTT.Blocks[2].Voice)
+}
+
+func TestVTTWriter_FromSRTLines(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "plain.vtt")
+	err := NewVTTWriter().Write(out, &File{
+		Lines: []Line{
+			{Index: 1, StartTime: 1500 * time.Millisecond, EndTime: 3 * time.Second, Text: "Hi", TranslatedText: "嗨"},
+		},
+	})
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(out)
+	require.NoError(t, err)
+	assert.Equal(t, "WEBVTT\n\n00:00:01.500 --> 00:00:03.000\n嗨\n\n", string(data))
+}
+
+func TestVTTWriter_ReportsShortWrites(t *testing.T) {
+	if _, err := os.Stat("/dev/full"); err != nil {
+		t.Skip("/dev/full is not available")
+	}
+	err := NewVTTWriter().Write("/dev/full", &File{
+		Lines: []Line{
+			{Index: 1, StartTime: time.Second, EndTime: 2 * time.Second, Text: "Hi", TranslatedText: "嗨"},
+		},
+		Format: "SRT",
+	})
+	assert.Error(t, err)
+}
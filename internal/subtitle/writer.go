@@ -7,12 +7,35 @@ import (
 	"time"
 )
 
-// DefaultWriter is the default subtitle file writer
-type DefaultWriter struct{}
+// DefaultWriter writes subtitle files in the format chosen by the output
+// extension, falling back to the format the subtitle was read from
+type DefaultWriter struct {
+	mode     OutputMode
+	encoding string
+}
 
-// NewWriter creates a new subtitle file writer
+// NewWriter creates a new subtitle file writer that emits translated text only
 func NewWriter() Writer {
-	return &DefaultWriter{}
+	return &DefaultWriter{
+		mode: OutputTranslated,
+	}
+}
+
+// NewWriterWithMode creates a new subtitle file writer using the given output mode
+func NewWriterWithMode(mode OutputMode) Writer {
+	return &DefaultWriter{
+		mode: mode,
+	}
+}
+
+// NewWriterWithEncoding creates a subtitle file writer using the given output
+// mode that emits the given encoding, see ParseEncoding. EncodingSource
+// keeps the encoding the subtitle was read from.
+func NewWriterWithEncoding(mode OutputMode, encoding string) Writer {
+	return &DefaultWriter{
+		mode:     mode,
+		encoding: encoding,
+	}
 }
 
 // WriteSubtitle writes subtitle file to specified path
@@ -20,6 +43,38 @@ func (w *DefaultWriter) Write(path string, subtitle *File) error {
 	if subtitle == nil {
 		return fmt.Errorf("subtitle data is empty")
 	}
+	format := outputFormat(path, subtitle)
+	if err := format.NewWriter().Write(path, applyOutputMode(subtitle, w.mode, format.Name)); err != nil {
+		return err
+	}
+	return encodeFile(path, w.outputEncoding(subtitle))
+}
+
+// outputEncoding resolves EncodingSource to the encoding of subtitle,
+// falling back to UTF-8 when it is unknown
+func (w *DefaultWriter) outputEncoding(subtitle *File) string {
+	if w.encoding != EncodingSource {
+		return w.encoding
+	}
+	if _, ok := textEncodings[subtitle.Encoding]; ok {
+		return subtitle.Encoding
+	}
+	return EncodingUTF8
+}
+
+// SRTWriter writes SRT subtitle files
+type SRTWriter struct{}
+
+// NewSRTWriter creates a new SRT subtitle file writer
+func NewSRTWriter() Writer {
+	return &SRTWriter{}
+}
+
+// Write writes subtitle as SRT to specified path
+func (w *SRTWriter) Write(path string, subtitle *File) error {
+	if subtitle == nil {
+		return fmt.Errorf("subtitle data is empty")
+	}
 
 	file, err := os.Create(path)
 	if err != nil {
@@ -0,0 +1,148 @@
+package summary
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/MimeLyc/contextual-sub-translator/internal/agent"
+	"github.com/MimeLyc/contextual-sub-translator/internal/media"
+	"github.com/MimeLyc/contextual-sub-translator/internal/subtitle"
+)
+
+// maxTranscriptRunes caps the dialogue sent for one episode; the rest of a
+// long episode is cut off
+const maxTranscriptRunes = 30000
+
+// Summary is the story memory of one episode
+type Summary struct {
+	// Synopsis tells what happens in the episode in a few sentences
+	Synopsis string `json:"synopsis"`
+	// Relationships are the character notes of the series up to and
+	// including the episode
+	Relationships string `json:"relationships"`
+}
+
+// Generator summarizes translated episodes using an LLM agent.
+type Generator struct {
+	agent agent.Agent
+}
+
+// NewGenerator creates a new episode summary generator.
+func NewGenerator(a agent.Agent) *Generator {
+	return &Generator{agent: a}
+}
+
+// Summarize asks the agent for a synopsis of the episode and updates the
+// character notes of the series so far with it.
+func (g *Generator) Summarize(
+	ctx context.Context,
+	showInfo media.TVShowInfo,
+	episode string,
+	lines []subtitle.Line,
+	relationships string,
+) (Summary, error) {
+	transcript := buildTranscript(lines)
+	if transcript == "" {
+		return Summary{}, fmt.Errorf("episode %s has no dialogue to summarize", episode)
+	}
+
+	var userMessage strings.Builder
+	if relationships != "" {
+		userMessage.WriteString("Character notes so far:\n")
+		userMessage.WriteString(relationships)
+		userMessage.WriteString("\n\n")
+	}
+	userMessage.WriteString(fmt.Sprintf("Dialogue of %s:\n", episode))
+	userMessage.WriteString(transcript)
+
+	result, err := g.agent.Execute(ctx, agent.AgentRequest{
+		SystemPrompt:  buildSummaryPrompt(showInfo),
+		UserMessage:   userMessage.String(),
+		MaxIterations: 1,
+		JSONMode:      true,
+	})
+	if err != nil {
+		return Summary{}, fmt.Errorf("agent execution failed: %w", err)
+	}
+
+	summary, err := parseSummaryResponse(result.Content)
+	if err != nil {
+		return Summary{}, fmt.Errorf("%w\nraw response:\n%s", err, result.Content)
+	}
+	return summary, nil
+}
+
+func buildSummaryPrompt(showInfo media.TVShowInfo) string {
+	var prompt strings.Builder
+
+	prompt.WriteString("You keep story notes for the translators of a TV show. You read the dialogue of one episode and output ONLY a JSON object.\n\n")
+
+	prompt.WriteString("=== SHOW INFORMATION ===\n")
+	if showInfo.Title != "" {
+		prompt.WriteString(fmt.Sprintf("Title: %s\n", showInfo.Title))
+	}
+	if showInfo.OriginalTitle != "" {
+		prompt.WriteString(fmt.Sprintf("Original Title: %s\n", showInfo.OriginalTitle))
+	}
+	if len(showInfo.Actors) > 0 {
+		prompt.WriteString("Cast:\n")
+		for _, actor := range showInfo.Actors {
+			if actor.Role != "" {
+				prompt.WriteString(fmt.Sprintf("  - %s as %s\n", actor.Name, actor.Role))
+			}
+		}
+	}
+
+	prompt.WriteString("\n=== TASK ===\n")
+	prompt.WriteString("1. synopsis: what happens in this episode in at most 5 sentences. Mention running jokes, promises and secrets later episodes may call back to.\n")
+	prompt.WriteString("2. relationships: the character notes so far, updated with this episode. Who is related to whom, how they address each other, nicknames. At most 10 sentences.\n\n")
+
+	prompt.WriteString("=== RESPONSE FORMAT (MANDATORY) ===\n")
+	prompt.WriteString("{\"synopsis\": \"...\", \"relationships\": \"...\"}\n")
+	prompt.WriteString("Write the notes in English and keep character names as spelled in the dialogue.\n")
+	prompt.WriteString("NO markdown, NO explanations. The response must start with { and end with }.\n")
+
+	return prompt.String()
+}
+
+// buildTranscript joins the source text of lines without formatting, one
+// line per cue
+func buildTranscript(lines []subtitle.Line) string {
+	var b strings.Builder
+	runes := 0
+	for _, line := range lines {
+		text := line.PlainText()
+		if text == "" {
+			continue
+		}
+		runes += len([]rune(text)) + 1
+		if runes > maxTranscriptRunes {
+			break
+		}
+		b.WriteString(text)
+		b.WriteString("\n")
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// parseSummaryResponse parses the LLM response into a Summary.
+// Handles clean JSON, markdown code fences, and JSON embedded in prose.
+func parseSummaryResponse(content string) (Summary, error) {
+	content = strings.TrimSpace(content)
+	if start, end := strings.Index(content, "{"), strings.LastIndex(content, "}"); start >= 0 && end > start {
+		content = content[start : end+1]
+	}
+
+	var summary Summary
+	if err := json.Unmarshal([]byte(content), &summary); err != nil {
+		return Summary{}, fmt.Errorf("failed to parse summary JSON from response: %w", err)
+	}
+	summary.Synopsis = strings.TrimSpace(summary.Synopsis)
+	summary.Relationships = strings.TrimSpace(summary.Relationships)
+	if summary.Synopsis == "" {
+		return Summary{}, fmt.Errorf("failed to parse summary JSON from response: synopsis is empty")
+	}
+	return summary, nil
+}
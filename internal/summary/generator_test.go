@@ -0,0 +1,80 @@
+package summary
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/MimeLyc/contextual-sub-translator/internal/agent"
+	"github.com/MimeLyc/contextual-sub-translator/internal/media"
+	"github.com/MimeLyc/contextual-sub-translator/internal/subtitle"
+	"github.com/MimeLyc/contextual-sub-translator/internal/tools"
+)
+
+func TestParseSummaryResponse(t *testing.T) {
+	summary, err := parseSummaryResponse("```json\n{\"synopsis\": \" Neo wakes up. \", \"relationships\": \"Morpheus mentors Neo.\"}\n```")
+	require.NoError(t, err)
+	assert.Equal(t, Summary{Synopsis: "Neo wakes up.", Relationships: "Morpheus mentors Neo."}, summary)
+
+	_, err = parseSummaryResponse(`{"synopsis": "", "relationships": "x"}`)
+	assert.ErrorContains(t, err, "synopsis is empty")
+
+	_, err = parseSummaryResponse("no notes today")
+	assert.Error(t, err)
+}
+
+func TestBuildTranscript(t *testing.T) {
+	transcript := buildTranscript([]subtitle.Line{
+		{Text: "{\\an8}<i>Wake up,</i>\nNeo."},
+		{Text: "  "},
+		{Text: "Follow the white rabbit."},
+	})
+	assert.Equal(t, "Wake up, Neo.\nFollow the white rabbit.", transcript)
+}
+
+func TestGenerator_Summarize(t *testing.T) {
+	var request string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		request = string(body)
+
+		encoded, _ := json.Marshal(`{"synopsis":"Neo takes the red pill.","relationships":"Morpheus mentors Neo. Trinity protects him."}`)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"chatcmpl-1","object":"chat.completion","created":1,"model":"test-model",` +
+			`"choices":[{"index":0,"finish_reason":"stop","message":{"role":"assistant","content":` + string(encoded) + `}}],` +
+			`"usage":{"prompt_tokens":1,"completion_tokens":1,"total_tokens":2}}`))
+	}))
+	t.Cleanup(server.Close)
+	llm, err := agent.NewLLMAgent(agent.LLMConfig{
+		APIKey:  "test-key",
+		APIURL:  server.URL,
+		Model:   "test-model",
+		Timeout: 10,
+	}, tools.NewRegistry(), 1)
+	require.NoError(t, err)
+
+	summary, err := NewGenerator(llm).Summarize(
+		context.Background(),
+		media.TVShowInfo{Title: "The Matrix"},
+		"Matrix S01E02",
+		[]subtitle.Line{{Text: "Red pill or blue pill?"}},
+		"Morpheus mentors Neo.",
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "Neo takes the red pill.", summary.Synopsis)
+	assert.Equal(t, "Morpheus mentors Neo. Trinity protects him.", summary.Relationships)
+	assert.Contains(t, request, "Title: The Matrix")
+	assert.Contains(t, request, `Character notes so far:\nMorpheus mentors Neo.`)
+	assert.Contains(t, request, `Dialogue of Matrix S01E02:\nRed pill or blue pill?`)
+}
+
+func TestGenerator_SummarizeWithoutDialogue(t *testing.T) {
+	_, err := NewGenerator(nil).Summarize(context.Background(), media.TVShowInfo{}, "S01E01", []subtitle.Line{{Text: "<i></i>"}}, "")
+	assert.ErrorContains(t, err, "no dialogue")
+}
@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 
 	"golang.org/x/text/language"
 )
@@ -43,6 +45,62 @@ func FindInAncestors(startDir, sourceLang, targetLang string) string {
 	return ""
 }
 
+// ParseFilename returns the language codes of a term map filename, the
+// reverse of Filename.
+func ParseFilename(name string) (sourceLang, targetLang string, ok bool) {
+	pair, ok := strings.CutPrefix(name, "term_map.")
+	if !ok {
+		return "", "", false
+	}
+	pair, ok = strings.CutSuffix(pair, ".json")
+	if !ok {
+		return "", "", false
+	}
+	sourceLang, targetLang, ok = strings.Cut(pair, "-")
+	if !ok || sourceLang == "" || targetLang == "" || strings.Contains(targetLang, "-") {
+		return "", "", false
+	}
+	return sourceLang, targetLang, true
+}
+
+// ListInAncestors walks up from startDir like FindInAncestors and returns,
+// for every language pair, the term map file FindInAncestors would find,
+// sorted by filename.
+func ListInAncestors(startDir string) []string {
+	found := make(map[string]string)
+	currentDir := startDir
+
+	for {
+		entries, _ := os.ReadDir(currentDir)
+		for _, entry := range entries {
+			name := entry.Name()
+			if entry.IsDir() || found[name] != "" {
+				continue
+			}
+			if _, _, ok := ParseFilename(name); ok {
+				found[name] = filepath.Join(currentDir, name)
+			}
+		}
+
+		parentDir := filepath.Dir(currentDir)
+		if parentDir == currentDir {
+			break
+		}
+		currentDir = parentDir
+	}
+
+	names := make([]string, 0, len(found))
+	for name := range found {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	ret := make([]string, 0, len(names))
+	for _, name := range names {
+		ret = append(ret, found[name])
+	}
+	return ret
+}
+
 // Load reads a term map from a JSON file.
 func Load(path string) (TermMap, error) {
 	data, err := os.ReadFile(path)
@@ -139,3 +139,31 @@ func TestNormalizeLanguageCode(t *testing.T) {
 		})
 	}
 }
+
+func TestParseFilename(t *testing.T) {
+	src, tgt, ok := ParseFilename(Filename("ja", "zh-Hant"))
+	require.True(t, ok)
+	assert.Equal(t, "ja", src)
+	assert.Equal(t, "zh", tgt)
+
+	for _, name := range []string{"term_map.json", "term_map.ja-zh.txt", "tvshow.nfo", "term_map.-zh.json", "term_map.ja-zh-Hant.json"} {
+		_, _, ok := ParseFilename(name)
+		assert.False(t, ok, name)
+	}
+}
+
+func TestListInAncestors(t *testing.T) {
+	root := t.TempDir()
+	showDir := filepath.Join(root, "Show")
+	seasonDir := filepath.Join(showDir, "Season 1")
+	require.NoError(t, os.MkdirAll(seasonDir, 0755))
+	require.NoError(t, Save(FilePath(root, "ja", "zh"), TermMap{"a": "b"}))
+	require.NoError(t, Save(FilePath(root, "ja", "en"), TermMap{"a": "b"}))
+	require.NoError(t, Save(FilePath(showDir, "ja", "zh"), TermMap{"a": "b"}))
+	require.NoError(t, os.WriteFile(filepath.Join(showDir, "tvshow.nfo"), []byte("<tvshow/>"), 0644))
+
+	assert.Equal(t, []string{
+		FilePath(root, "ja", "en"),
+		FilePath(showDir, "ja", "zh"),
+	}, ListInAncestors(seasonDir))
+}
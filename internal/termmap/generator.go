@@ -12,11 +12,11 @@ import (
 
 // Generator generates term maps using an LLM agent with web search.
 type Generator struct {
-	agent *agent.LLMAgent
+	agent agent.Agent
 }
 
 // NewGenerator creates a new term map generator.
-func NewGenerator(a *agent.LLMAgent) *Generator {
+func NewGenerator(a agent.Agent) *Generator {
 	return &Generator{agent: a}
 }
 
@@ -87,6 +87,7 @@ func (g *Generator) compileSearchResults(
 		SystemPrompt:  systemPrompt,
 		UserMessage:   userMessage,
 		MaxIterations: 3,
+		JSONMode:      true,
 	})
 }
 
@@ -131,6 +132,7 @@ func (g *Generator) ExtractNewTerms(
 		SystemPrompt:  systemPrompt,
 		UserMessage:   userMessage,
 		MaxIterations: 3,
+		JSONMode:      true,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to extract terms from search results: %w", err)
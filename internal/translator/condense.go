@@ -0,0 +1,148 @@
+package translator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/MimeLyc/contextual-sub-translator/internal/agent"
+	"github.com/MimeLyc/contextual-sub-translator/internal/reading"
+	"github.com/MimeLyc/contextual-sub-translator/internal/subtitle"
+	"github.com/MimeLyc/contextual-sub-translator/internal/termmap"
+)
+
+// condenseBatchSize caps the lines of one condensation request
+const condenseBatchSize = 20
+
+// Condenser shortens translated lines viewers cannot read in time.
+type Condenser struct {
+	agent agent.Agent
+}
+
+// NewCondenser creates a condenser that rewrites lines on a.
+func NewCondenser(a agent.Agent) *Condenser {
+	return &Condenser{agent: a}
+}
+
+type condenseInputLine struct {
+	Index    int    `json:"index"`
+	Source   string `json:"source"`
+	Text     string `json:"text"`
+	MaxChars int    `json:"max_chars,omitempty"`
+}
+
+// Condense asks the model to shorten the translations of lines to fit limits
+// and returns the new translation of each line. A line keeps its translation
+// when the shorter one is empty or no easier to read.
+func (c *Condenser) Condense(
+	ctx context.Context,
+	media MediaMeta,
+	lines []subtitle.Line,
+	targetLanguage string,
+	limits reading.Limits,
+) ([]string, error) {
+	ret := make([]string, len(lines))
+	for i, line := range lines {
+		ret[i] = line.TranslatedText
+	}
+
+	for start := 0; start < len(lines); start += condenseBatchSize {
+		end := min(start+condenseBatchSize, len(lines))
+		batch := lines[start:end]
+
+		texts := make([]string, 0, len(batch))
+		sources := make([]string, 0, len(batch))
+		for _, line := range batch {
+			texts = append(texts, strings.ReplaceAll(line.TranslatedText, "\n", inlineBreakerPlaceholder))
+			sources = append(sources, line.Text)
+		}
+		texts, overrideTags := shieldOverrideTags(texts)
+
+		input := make([]condenseInputLine, 0, len(batch))
+		for i, line := range batch {
+			input = append(input, condenseInputLine{
+				Index:    i + 1,
+				Source:   contextText(line.Text),
+				Text:     texts[i],
+				MaxChars: reading.MaxChars(line.EndTime-line.StartTime, limits),
+			})
+		}
+		userMessage, err := json.Marshal(struct {
+			Lines []condenseInputLine `json:"lines"`
+		}{Lines: input})
+		if err != nil {
+			return nil, fmt.Errorf("build condensation request failed: %w", err)
+		}
+
+		var termMap map[string]string
+		if len(media.TermMap) > 0 {
+			termMap = map[string]string(termmap.Match(termmap.TermMap(media.TermMap), sources).Matched)
+		}
+		result, err := c.agent.Execute(ctx, agent.AgentRequest{
+			SystemPrompt:  buildCondensePrompt(media, targetLanguage, termMap, limits),
+			UserMessage:   string(userMessage),
+			MaxIterations: 1,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("condensation failed for lines %d-%d: %w", start+1, end, err)
+		}
+		condensed, err := parseTranslationOutput(result.Content, len(batch))
+		if err != nil {
+			return nil, fmt.Errorf("condensation failed for lines %d-%d: %w", start+1, end, err)
+		}
+		condensed = restoreOverrideTags(normalizeTranslatedLines(condensed), overrideTags)
+
+		for i, line := range batch {
+			if strings.TrimSpace(condensed[i]) == "" {
+				continue
+			}
+			duration := line.EndTime - line.StartTime
+			before := reading.Check(line.TranslatedText, duration, limits)
+			after := reading.Check(condensed[i], duration, limits)
+			if len(after) < len(before) || reading.Chars(condensed[i]) < reading.Chars(line.TranslatedText) {
+				ret[start+i] = condensed[i]
+			}
+		}
+	}
+	return ret, nil
+}
+
+func buildCondensePrompt(media MediaMeta, targetLanguage string, termMap map[string]string, limits reading.Limits) string {
+	var prompt strings.Builder
+
+	prompt.WriteString("You are a subtitle editor. The " + targetLanguage + " subtitles below are too long to read in the time they are shown. Shorten each one.\n\n")
+
+	if media.Title != "" {
+		prompt.WriteString("=== MEDIA INFORMATION ===\n")
+		prompt.WriteString(fmt.Sprintf("Show Title: %s\n\n", media.Title))
+	}
+
+	if len(termMap) > 0 {
+		prompt.WriteString("=== TERM MAPPINGS ===\n")
+		prompt.WriteString("You MUST keep the mapped target term exactly whenever its source term appears in the source line.\n")
+		for source, target := range termMap {
+			prompt.WriteString(fmt.Sprintf("  %s -> %s\n", source, target))
+		}
+		prompt.WriteString("\n")
+	}
+
+	prompt.WriteString("=== CONDENSATION GUIDELINES ===\n")
+	prompt.WriteString("Each line has its source text, its current translation in text and max_chars, the most characters viewers can read while it is shown.\n")
+	prompt.WriteString("1. Rewrite text to at most max_chars characters; spaces count, line breaks do not\n")
+	prompt.WriteString("2. Keep the meaning, tone and who is spoken to; drop fillers, repetitions and what the picture already shows\n")
+	if limits.MaxLineLength > 0 {
+		prompt.WriteString(fmt.Sprintf("3. Keep every line of a subtitle to at most %d characters; split a subtitle into at most two lines with %s\n", limits.MaxLineLength, inlineBreakerPlaceholder))
+	} else {
+		prompt.WriteString("3. Split a subtitle into at most two lines with " + inlineBreakerPlaceholder + "\n")
+	}
+	prompt.WriteString("4. Placeholders like " + overrideTagPlaceholder(1) + " stand for formatting tags: keep each one exactly once, next to the words it applies to\n")
+	prompt.WriteString("5. Keep one output line per input line index\n")
+
+	prompt.WriteString("\n=== OUTPUT FORMAT ===\n")
+	prompt.WriteString("Return ONLY a valid JSON array of objects.\n")
+	prompt.WriteString("Schema: [{\"index\":1,\"text\":\"shortened line\"}]\n")
+	prompt.WriteString("No markdown, no explanations, no prose outside JSON.\n")
+
+	return prompt.String()
+}
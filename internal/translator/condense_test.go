@@ -0,0 +1,52 @@
+package translator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/MimeLyc/contextual-sub-translator/internal/reading"
+	"github.com/MimeLyc/contextual-sub-translator/internal/subtitle"
+)
+
+func TestCondenser_ShortensLinesOverLimits(t *testing.T) {
+	t.Parallel()
+
+	var requests []string
+	llm := newScriptedAgent(t, func(call int, body string) string {
+		requests = append(requests, body)
+		// the second line comes back longer and keeps its translation
+		return `[{"index":1,"text":"%%tag_1%%天亮前必须走%%tag_2%%"},{"index":2,"text":"我们快点离开这里吧，马上就走"}]`
+	})
+
+	lines := []subtitle.Line{
+		{Index: 1, StartTime: 0, EndTime: 2 * time.Second, Text: "<i>We have to leave before dawn.</i>", TranslatedText: "<i>我们必须在天亮之前离开这座城市</i>"},
+		{Index: 2, StartTime: 3 * time.Second, EndTime: 4 * time.Second, Text: "Let's go.", TranslatedText: "我们快点离开这里吧"},
+	}
+	condensed, err := NewCondenser(llm).Condense(context.Background(), MediaMeta{
+		TermMap: map[string]string{"dawn": "天亮", "Tokyo": "东京"},
+	}, lines, "Chinese", reading.Limits{MaxCPS: 9, MaxLineLength: 16})
+	require.NoError(t, err)
+
+	require.Len(t, requests, 1)
+	assert.Contains(t, requests[0], `\"max_chars\":18`)
+	assert.Contains(t, requests[0], `\"source\":\"We have to leave before dawn.\"`)
+	assert.Contains(t, requests[0], `dawn -\u003e 天亮`)
+	assert.NotContains(t, requests[0], "东京", "only terms of the lines are sent")
+	assert.Equal(t, []string{"<i>天亮前必须走</i>", "我们快点离开这里吧"}, condensed)
+}
+
+func TestCondenser_FailsOnInvalidOutput(t *testing.T) {
+	t.Parallel()
+
+	llm := newScriptedAgent(t, func(call int, body string) string {
+		return "shorter now"
+	})
+	_, err := NewCondenser(llm).Condense(context.Background(), MediaMeta{}, []subtitle.Line{
+		{Index: 1, EndTime: time.Second, Text: "Hello", TranslatedText: "你好你好你好你好你好你好"},
+	}, "Chinese", reading.Limits{MaxCPS: 9})
+	require.Error(t, err)
+}
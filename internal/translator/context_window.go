@@ -0,0 +1,76 @@
+package translator
+
+import (
+	"strings"
+
+	"github.com/MimeLyc/contextual-sub-translator/internal/subtitle"
+)
+
+const (
+	// contextLinesBefore is the number of already translated lines sent
+	// along with a batch
+	contextLinesBefore = 5
+	// contextLinesAfter is the number of upcoming source lines sent along
+	// with a batch
+	contextLinesAfter = 3
+)
+
+// ContextWindow holds the neighbouring lines of a batch. The model sees them
+// as read-only context and must not output them.
+type ContextWindow struct {
+	Before []ContextLine // lines right before the batch, with their translation
+	After  []ContextLine // source lines right after the batch
+}
+
+// ContextLine is a neighbouring line of a batch
+type ContextLine struct {
+	Text           string
+	TranslatedText string
+}
+
+// IsEmpty reports whether the window holds no lines
+func (w ContextWindow) IsEmpty() bool {
+	return len(w.Before) == 0 && len(w.After) == 0
+}
+
+// SurroundingLines returns the context window of the batch lines[start:end].
+// Lines before the batch are expected to carry their translation already,
+// e.g. from a resumed checkpoint.
+func SurroundingLines(lines []subtitle.Line, start int, end int) ContextWindow {
+	translated := make([]string, len(lines))
+	for i, line := range lines {
+		translated[i] = line.TranslatedText
+	}
+	return surroundingLines(ContextWindow{}, lines, translated, start, end)
+}
+
+// surroundingLines returns the context window of the batch lines[start:end].
+// outer is the window around all of lines; it fills up the window of batches
+// at the edges.
+func surroundingLines(outer ContextWindow, lines []subtitle.Line, translated []string, start int, end int) ContextWindow {
+	var window ContextWindow
+
+	from := max(start-contextLinesBefore, 0)
+	if missing := contextLinesBefore - (start - from); missing > 0 {
+		window.Before = append(window.Before, outer.Before[max(len(outer.Before)-missing, 0):]...)
+	}
+	for i := from; i < start; i++ {
+		window.Before = append(window.Before, ContextLine{Text: lines[i].Text, TranslatedText: translated[i]})
+	}
+
+	to := min(end+contextLinesAfter, len(lines))
+	for i := end; i < to; i++ {
+		window.After = append(window.After, ContextLine{Text: lines[i].Text})
+	}
+	if missing := contextLinesAfter - (to - end); missing > 0 {
+		window.After = append(window.After, outer.After[:min(missing, len(outer.After))]...)
+	}
+	return window
+}
+
+// contextText prepares the text of a context line for the prompt. Line
+// breaks and formatting tags are dropped; context is never written back.
+func contextText(text string) string {
+	text = formattingTagPattern.ReplaceAllString(text, "")
+	return strings.Join(strings.Fields(text), " ")
+}
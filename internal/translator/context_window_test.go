@@ -0,0 +1,121 @@
+package translator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/MimeLyc/contextual-sub-translator/internal/subtitle"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func numberedLines(n int) []subtitle.Line {
+	lines := make([]subtitle.Line, n)
+	for i := range lines {
+		lines[i] = subtitle.Line{Index: i + 1, Text: fmt.Sprintf("line %d", i+1), TranslatedText: fmt.Sprintf("行 %d", i+1)}
+	}
+	return lines
+}
+
+func TestSurroundingLines(t *testing.T) {
+	t.Parallel()
+
+	lines := numberedLines(20)
+	window := SurroundingLines(lines, 10, 15)
+	require.Len(t, window.Before, contextLinesBefore)
+	assert.Equal(t, ContextLine{Text: "line 6", TranslatedText: "行 6"}, window.Before[0])
+	assert.Equal(t, ContextLine{Text: "line 10", TranslatedText: "行 10"}, window.Before[contextLinesBefore-1])
+	require.Len(t, window.After, contextLinesAfter)
+	assert.Equal(t, ContextLine{Text: "line 16"}, window.After[0], "upcoming lines carry no translation")
+
+	assert.True(t, SurroundingLines(lines, 0, 20).IsEmpty())
+}
+
+func TestSurroundingLines_FillsEdgesFromOuterWindow(t *testing.T) {
+	t.Parallel()
+
+	lines := numberedLines(4)
+	translated := []string{"甲", "乙", "", ""}
+	outer := ContextWindow{
+		Before: []ContextLine{{Text: "a"}, {Text: "b"}, {Text: "c"}, {Text: "d"}, {Text: "e"}, {Text: "f"}},
+		After:  []ContextLine{{Text: "x"}, {Text: "y"}, {Text: "z"}, {Text: "w"}},
+	}
+
+	window := surroundingLines(outer, lines, translated, 2, 4)
+	assert.Equal(t, []ContextLine{
+		{Text: "d"}, {Text: "e"}, {Text: "f"},
+		{Text: "line 1", TranslatedText: "甲"},
+		{Text: "line 2", TranslatedText: "乙"},
+	}, window.Before)
+	assert.Equal(t, []ContextLine{{Text: "x"}, {Text: "y"}, {Text: "z"}}, window.After)
+}
+
+func TestBuildTranslationUserMessage_ContextWindow(t *testing.T) {
+	t.Parallel()
+
+	payload, err := buildTranslationUserMessage([]string{"line-1"}, nil, ContextWindow{
+		Before: []ContextLine{{Text: "<i>Who's\nthere?</i>", TranslatedText: "谁在\n那儿？"}},
+		After:  []ContextLine{{Text: "{\\an8}It's me."}},
+	})
+	require.NoError(t, err)
+	assert.True(t, strings.Index(payload, "context_before") < strings.Index(payload, `"lines"`))
+
+	var decoded struct {
+		ContextBefore []map[string]any `json:"context_before"`
+		Lines         []map[string]any `json:"lines"`
+		ContextAfter  []map[string]any `json:"context_after"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(payload), &decoded))
+	assert.Equal(t, []map[string]any{{"text": "Who's there?", "translation": "谁在 那儿？"}}, decoded.ContextBefore)
+	assert.Equal(t, []map[string]any{{"text": "It's me."}}, decoded.ContextAfter)
+	require.Len(t, decoded.Lines, 1)
+}
+
+func TestBuildContextPrompt_ContextWindowIsReadOnly(t *testing.T) {
+	t.Parallel()
+
+	translator := &agentTranslator{}
+	prompt := translator.buildContextPrompt(MediaMeta{}, "English", "Chinese", false, []string{"hi"})
+	assert.NotContains(t, prompt, "SURROUNDING LINES")
+
+	prompt = translator.buildContextPrompt(MediaMeta{Window: ContextWindow{After: []ContextLine{{Text: "bye"}}}}, "English", "Chinese", false, []string{"hi"})
+	assert.Contains(t, prompt, "SURROUNDING LINES")
+	assert.Contains(t, prompt, "do NOT translate or output them")
+}
+
+func TestParseTranslationOutput_RejectsIndexOutsideBatch(t *testing.T) {
+	t.Parallel()
+
+	_, err := parseTranslationOutput(`[{"index":1,"text":"你好"},{"index":2,"text":"世界"},{"index":3,"text":"上下文"}]`, 2)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "outside the batch")
+}
+
+func TestBatchTranslate_SendsNeighbouringLines(t *testing.T) {
+	t.Parallel()
+
+	var requests []string
+	llm := newScriptedAgent(t, func(call int, body string) string {
+		requests = append(requests, body)
+		if call == 1 {
+			return `[{"index":1,"text":"你好"},{"index":2,"text":"世界"}]`
+		}
+		return `[{"index":1,"text":"再见"}]`
+	})
+
+	lines, err := NewAgentTranslator(llm, false).BatchTranslate(context.Background(), MediaMeta{}, []subtitle.Line{
+		{Index: 1, Text: "hello"},
+		{Index: 2, Text: "world"},
+		{Index: 3, Text: "bye"},
+	}, "English", "Chinese", 2)
+	require.NoError(t, err)
+	require.Len(t, requests, 2)
+
+	assert.Contains(t, requests[0], `context_after\":[{\"text\":\"bye\"}]`)
+	assert.NotContains(t, requests[0], `\"context_before\"`, "the first batch has no lines before it")
+	assert.Contains(t, requests[1], `context_before\":[{\"text\":\"hello\",\"translation\":\"你好\"},{\"text\":\"world\",\"translation\":\"世界\"}]`)
+	assert.Equal(t, []string{"你好", "世界", "再见"}, []string{lines[0].TranslatedText, lines[1].TranslatedText, lines[2].TranslatedText})
+}
@@ -3,6 +3,7 @@ package translator
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"regexp"
 	"sort"
@@ -11,6 +12,8 @@ import (
 	"time"
 
 	"github.com/MimeLyc/contextual-sub-translator/internal/agent"
+	"github.com/MimeLyc/contextual-sub-translator/internal/reading"
+	"github.com/MimeLyc/contextual-sub-translator/internal/speaker"
 	"github.com/MimeLyc/contextual-sub-translator/internal/subtitle"
 	"github.com/MimeLyc/contextual-sub-translator/internal/termmap"
 	"github.com/MimeLyc/contextual-sub-translator/pkg/log"
@@ -32,10 +35,16 @@ var ignoredProperNouns = map[string]struct{}{
 	"you":  {},
 }
 
+// errTranslationValidation marks a batch whose output stayed unusable after the repair attempt
+var errTranslationValidation = errors.New("translation validation failed")
+
 // agentTranslator is the unified AI layer for all translation
 // It uses an agent with tool calling support for enhanced translation quality
 type agentTranslator struct {
-	agent          *agent.LLMAgent
+	// translate are the models a batch is translated with, in fallback order
+	translate agent.Route
+	// repair are the models of the repair attempt; empty repairs on the translate models
+	repair         agent.Route
 	searchEnabled  bool
 	mu             sync.Mutex
 	collectedCalls []agent.ToolCallRecord
@@ -43,8 +52,16 @@ type agentTranslator struct {
 
 // NewAgentTranslator creates a new agent-based translator
 func NewAgentTranslator(agentInstance *agent.LLMAgent, searchEnabled bool) Translator {
+	return NewRoutedTranslator(agent.Route{agentInstance}, nil, searchEnabled)
+}
+
+// NewRoutedTranslator creates an agent-based translator that falls back to the
+// next translate model when one fails or keeps failing validation, and runs
+// the repair attempt on the repair models when there are any
+func NewRoutedTranslator(translate, repair agent.Route, searchEnabled bool) Translator {
 	return &agentTranslator{
-		agent:         agentInstance,
+		translate:     translate,
+		repair:        repair,
 		searchEnabled: searchEnabled,
 	}
 }
@@ -56,6 +73,9 @@ func (t *agentTranslator) Translate(
 	sourceLang string,
 	targetLang string,
 ) ([]string, error) {
+	// Shield formatting tags so they survive the round trip through the model
+	subtitleTexts, overrideTags := shieldOverrideTags(subtitleTexts)
+
 	// Remember whether the show has a term map before filtering
 	hasTermMap := len(media.TermMap) > 0
 
@@ -66,11 +86,33 @@ func (t *agentTranslator) Translate(
 	}
 
 	systemPrompt := t.buildContextPrompt(media, sourceLang, targetLang, hasTermMap, subtitleTexts)
-	userMessage, err := buildTranslationUserMessage(subtitleTexts)
+	userMessage, err := buildTranslationUserMessage(subtitleTexts, media.Speakers, media.Window)
 	if err != nil {
 		return nil, fmt.Errorf("build translation request failed: %w", err)
 	}
 
+	models := t.translate
+	for {
+		translations, err := t.translateOn(ctx, models, systemPrompt, userMessage, subtitleTexts, overrideTags, media.TermMap)
+		if err == nil || !errors.Is(err, errTranslationValidation) || len(models) <= 1 {
+			return translations, err
+		}
+		log.Warn("Translation with model %s failed validation: %v; falling back to %s", models[0].Model(), err, models[1].Model())
+		models = models[1:]
+	}
+}
+
+// translateOn translates a batch on models, retrying once with a repair prompt
+// when the output fails validation
+func (t *agentTranslator) translateOn(
+	ctx context.Context,
+	models agent.Route,
+	systemPrompt string,
+	userMessage string,
+	subtitleTexts []string,
+	overrideTags [][]shieldedTag,
+	termMap map[string]string,
+) ([]string, error) {
 	maxAttempts := 2
 	var lastErr error
 	var previousOutput string
@@ -78,15 +120,19 @@ func (t *agentTranslator) Translate(
 
 	for attempt := 1; attempt <= maxAttempts; attempt++ {
 		attemptMessage := userMessage
+		route := models
 		if attempt == 2 && strings.TrimSpace(previousOutput) != "" {
 			attemptMessage = buildRepairUserMessage(userMessage, previousOutput, lastErr, len(subtitleTexts))
+			if len(t.repair) > 0 {
+				route = t.repair
+			}
 		}
 
 		req := agent.AgentRequest{
 			SystemPrompt: systemPrompt,
 			UserMessage:  attemptMessage,
 		}
-		result, execErr := t.agent.Execute(ctx, req)
+		result, execErr := route.Execute(ctx, req)
 		if execErr != nil {
 			lastErr = fmt.Errorf("agent execution failed: %w", execErr)
 			if attempt < maxAttempts {
@@ -122,33 +168,37 @@ func (t *agentTranslator) Translate(
 				log.Warn("Translation output failed validation on attempt %d/%d: %v; retrying with repair prompt", attempt, maxAttempts, parseErr)
 				continue
 			}
-			return nil, fmt.Errorf("translation validation failed after repair retry: %w", lastErr)
+			return nil, fmt.Errorf("%w after repair retry: %w", errTranslationValidation, lastErr)
 		}
 
-		// Soft validation (term mapping): quality check, not structural.
-		// Try repair once, but accept the best result if repair also fails.
-		termErr := validateTermMappings(subtitleTexts, translations, media.TermMap)
-		if termErr == nil {
-			return normalizeTranslatedLines(translations), nil
+		// Soft validation (term mapping, formatting tag placeholders): quality
+		// check, not structural. Try repair once, but accept the best result if
+		// repair also fails; restoreOverrideTags repairs misplaced tags.
+		softErr := errors.Join(
+			validateTagPlaceholders(translations, overrideTags),
+			validateTermMappings(subtitleTexts, translations, termMap),
+		)
+		if softErr == nil {
+			return restoreOverrideTags(normalizeTranslatedLines(translations), overrideTags), nil
 		}
 		if attempt < maxAttempts {
-			lastErr = termErr
+			lastErr = softErr
 			bestTranslations = translations
-			log.Warn("Translation output has term mapping issues on attempt %d/%d: %v; retrying with repair prompt", attempt, maxAttempts, termErr)
+			log.Warn("Translation output has term mapping or formatting tag issues on attempt %d/%d: %v; retrying with repair prompt", attempt, maxAttempts, softErr)
 			continue
 		}
-		// Repair also didn't fix term mapping — accept with warning.
-		log.Warn("Accepting translation despite term mapping issues: %v", termErr)
-		return normalizeTranslatedLines(translations), nil
+		// Repair also didn't fix them — accept with warning.
+		log.Warn("Accepting translation despite term mapping or formatting tag issues: %v", softErr)
+		return restoreOverrideTags(normalizeTranslatedLines(translations), overrideTags), nil
 	}
 
 	// Should only reach here if all attempts had hard failures.
 	// If we have a structurally valid result from a previous attempt, use it.
 	if bestTranslations != nil {
-		log.Warn("Returning best-effort translation despite term mapping issues")
-		return normalizeTranslatedLines(bestTranslations), nil
+		log.Warn("Returning best-effort translation despite term mapping or formatting tag issues")
+		return restoreOverrideTags(normalizeTranslatedLines(bestTranslations), overrideTags), nil
 	}
-	return nil, fmt.Errorf("translation validation failed after repair retry: %w", lastErr)
+	return nil, fmt.Errorf("%w after repair retry: %w", errTranslationValidation, lastErr)
 }
 
 func (t *agentTranslator) BatchTranslate(
@@ -162,8 +212,8 @@ func (t *agentTranslator) BatchTranslate(
 		batchSize = 50
 	}
 
-	allTranslations, err := t.batchTranslate(ctx, media, subtitleLines, sourceLanguage, targetLanguage, batchSize, 0, len(subtitleLines))
-	if err != nil {
+	allTranslations := make([]string, len(subtitleLines))
+	if err := t.batchTranslate(ctx, media, subtitleLines, allTranslations, sourceLanguage, targetLanguage, batchSize, 0, len(subtitleLines)); err != nil {
 		return nil, err
 	}
 
@@ -174,70 +224,102 @@ func (t *agentTranslator) BatchTranslate(
 			EndTime:        line.EndTime,
 			Text:           line.Text,
 			TranslatedText: allTranslations[i],
+			Speaker:        line.Speaker,
+			Song:           line.Song,
 		}
 	}
 
 	return subtitleLines, nil
 }
 
+// batchTranslate translates subtitleLines[startIncluded:endExcluded] in
+// batches into translated, which has one entry per subtitle line. Each batch
+// is sent with the lines around it as read-only context; the translations of
+// the lines before it are filled in by then.
 func (t *agentTranslator) batchTranslate(
 	ctx context.Context,
 	media MediaMeta,
 	subtitleLines []subtitle.Line,
+	translated []string,
 	sourceLanguage string,
 	targetLanguage string,
 	batchSize int,
 	startIncluded int,
 	endExcluded int,
-) ([]string, error) {
+) error {
 	if batchSize <= 0 {
-		return nil, fmt.Errorf("batch size must be greater than 0")
+		return fmt.Errorf("batch size must be greater than 0")
 	}
 
-	var allTranslations []string
-
 	for i := startIncluded; i < endExcluded; i += batchSize {
 		batchStart := time.Now()
 		end := min(i+batchSize, endExcluded, len(subtitleLines))
 
 		batch := subtitleLines[i:end]
 
-		var subtitleTexts []string
-		for _, line := range batch {
-			// Deal with original line breaker in subtitle file to avoid LLM misunderstanding
-			formattedText := strings.ReplaceAll(line.Text, "\n", inlineBreakerPlaceholder)
-			subtitleTexts = append(subtitleTexts, formattedText)
-		}
+		// Lines the series translated before and songs that are not
+		// translated skip the model
+		batchTranslations := make([]string, len(batch))
+		groups := pendingGroups(media, batch, batchTranslations)
+		retried := false
+		for _, group := range groups {
+			pendingLines := make([]subtitle.Line, 0, len(group.positions))
+			var subtitleTexts []string
+			for _, idx := range group.positions {
+				line := batch[idx]
+				pendingLines = append(pendingLines, line)
+				// Deal with original line breaker in subtitle file to avoid LLM misunderstanding
+				formattedText := strings.ReplaceAll(line.Text, "\n", inlineBreakerPlaceholder)
+				subtitleTexts = append(subtitleTexts, formattedText)
+			}
 
-		translations, err := t.Translate(ctx, media, subtitleTexts, sourceLanguage, targetLanguage)
-		if err != nil {
-			return nil, fmt.Errorf("batch translation failed for lines %d-%d: %w", i+1, end, err)
-		}
+			batchMedia := media
+			batchMedia.Window = surroundingLines(media.Window, subtitleLines, translated, i, end)
+			batchMedia.Speakers = nil
+			if !group.lyrics {
+				batchMedia.Speakers = lineSpeakers(pendingLines)
+			}
+			batchMedia.Suggestions = group.suggestions
+			batchMedia.Lyrics = group.lyrics
+			translations, err := t.Translate(ctx, batchMedia, subtitleTexts, sourceLanguage, targetLanguage)
+			if err != nil {
+				return fmt.Errorf("batch translation failed for lines %d-%d: %w", i+1, end, err)
+			}
 
-		if len(translations) != len(subtitleTexts) {
-			if len(subtitleTexts) == 1 {
-				if len(translations) == 0 {
-					return nil, fmt.Errorf("single-line translation returned no content for line %d", i+1)
-				}
-				log.Warn("Single-line translation count mismatch at line %d: expected 1, got %d; using first candidate", i+1, len(translations))
-				translations = []string{translations[0]}
-			} else {
-				nextBatchSize := max(batchSize/2, 1)
-				if nextBatchSize == batchSize {
-					return nil, fmt.Errorf("translation count mismatch for lines %d-%d: expected %d, got %d", i+1, end, len(subtitleTexts), len(translations))
-				}
-				log.Warn("batch translation count mismatch for lines %d-%d: expected %d, got %d; retrying with batch size %d", i+1, end, len(subtitleTexts), len(translations), nextBatchSize)
-				if translations, err = t.batchTranslate(ctx, media, subtitleLines, sourceLanguage, targetLanguage, nextBatchSize, i, end); err != nil {
-					return nil, fmt.Errorf("retry batch translation failed for lines %d-%d: %w", i+1, end, err)
+			if len(translations) != len(subtitleTexts) {
+				if len(subtitleTexts) == 1 {
+					if len(translations) == 0 {
+						return fmt.Errorf("single-line translation returned no content for line %d", i+1)
+					}
+					log.Warn("Single-line translation count mismatch at line %d: expected 1, got %d; using first candidate", i+1, len(translations))
+					translations = []string{translations[0]}
+				} else {
+					nextBatchSize := max(batchSize/2, 1)
+					if nextBatchSize == batchSize {
+						return fmt.Errorf("translation count mismatch for lines %d-%d: expected %d, got %d", i+1, end, len(subtitleTexts), len(translations))
+					}
+					log.Warn("batch translation count mismatch for lines %d-%d: expected %d, got %d; retrying with batch size %d", i+1, end, len(subtitleTexts), len(translations), nextBatchSize)
+					if err := t.batchTranslate(ctx, media, subtitleLines, translated, sourceLanguage, targetLanguage, nextBatchSize, i, end); err != nil {
+						return fmt.Errorf("retry batch translation failed for lines %d-%d: %w", i+1, end, err)
+					}
+					retried = true
+					break
 				}
 			}
+
+			for k, idx := range group.positions {
+				batchTranslations[idx] = translations[k]
+			}
+		}
+		if retried {
+			continue
 		}
 
-		allTranslations = append(allTranslations, translations...)
-		log.Debug("Batch translated lines %d-%d in %s (size=%d)", i+1, end, time.Since(batchStart), len(subtitleTexts))
+		copy(translated[i:end], batchTranslations)
+		log.Debug("Batch translated lines %d-%d in %s (size=%d)", i+1, end, time.Since(batchStart), len(batch))
 	}
 
-	return allTranslations, nil
+	return nil
 }
 
 // buildContextPrompt builds the system prompt for translation.
@@ -273,6 +355,17 @@ func (t *agentTranslator) buildContextPrompt(
 		prompt.WriteString(fmt.Sprintf("Plot Summary: %s\n", media.Plot))
 	}
 
+	if len(media.PreviousEpisodes) > 0 || media.Relationships != "" {
+		prompt.WriteString("\n=== STORY SO FAR ===\n")
+		prompt.WriteString("Notes on earlier episodes. Use them to catch callbacks, running jokes and how characters address each other.\n")
+		for _, episode := range media.PreviousEpisodes {
+			prompt.WriteString(fmt.Sprintf("  %s: %s\n", episode.Episode, episode.Synopsis))
+		}
+		if media.Relationships != "" {
+			prompt.WriteString(fmt.Sprintf("Character Relationships: %s\n", media.Relationships))
+		}
+	}
+
 	if len(media.TermMap) > 0 {
 		prompt.WriteString("\n=== TERM MAPPINGS ===\n")
 		prompt.WriteString("You MUST use the mapped target term exactly whenever its source term appears in a line.\n")
@@ -282,6 +375,40 @@ func (t *agentTranslator) buildContextPrompt(
 		}
 	}
 
+	if media.Lyrics {
+		prompt.WriteString("\n=== SONG LYRICS ===\n")
+		prompt.WriteString("These lines are the lyrics of an opening, ending or insert song, not dialogue.\n")
+		prompt.WriteString("Translate them as lyrics: carry the imagery and feeling of each line rather than its literal wording, keep lines short and rhythmic, and add no explanations.\n")
+		prompt.WriteString("Keep lines that are already sung in " + targetLanguage + " as they are.\n")
+	}
+
+	if len(media.Suggestions) > 0 {
+		prompt.WriteString("\n=== TRANSLATION MEMORY ===\n")
+		prompt.WriteString("Earlier episodes translated these similar lines as follows. Where a line means the same, reuse their wording so recurring lines read the same in every episode.\n")
+		for _, match := range media.Suggestions {
+			prompt.WriteString(fmt.Sprintf("  %s -> %s\n", contextText(match.Source), contextText(match.Translation)))
+		}
+	}
+
+	if len(media.Corrections) > 0 {
+		prompt.WriteString("\n=== REVIEWER NOTES ===\n")
+		prompt.WriteString("A reviewer rejected earlier translations of these lines. Translate them again and fix what the reviewer points out.\n")
+		for _, note := range media.Corrections {
+			prompt.WriteString(fmt.Sprintf("  %s -> %s: %s\n", contextText(note.Source), contextText(note.Translation), note.Note))
+		}
+	}
+
+	if len(media.Characters) > 0 || len(media.Speakers) > 0 {
+		prompt.WriteString("\n=== CHARACTERS ===\n")
+		for _, profile := range media.Characters {
+			prompt.WriteString("  " + speaker.FormatProfile(profile) + "\n")
+		}
+		if len(media.Speakers) > 0 {
+			prompt.WriteString("Lines may carry a speaker. It is a guess; trust the dialogue when they disagree.\n")
+		}
+		prompt.WriteString("Match pronouns, gendered forms and politeness levels to the speaker and who they talk to, and use the nicknames characters call each other by.\n")
+	}
+
 	if t.searchEnabled {
 		searchBudget, unresolved := computeWebSearchBudget(subtitleTexts, media.TermMap, hasTermMap)
 		prompt.WriteString("\n=== WEB SEARCH TOOL ===\n")
@@ -305,15 +432,23 @@ func (t *agentTranslator) buildContextPrompt(
 		}
 	}
 
+	if !media.Window.IsEmpty() {
+		prompt.WriteString("\n=== SURROUNDING LINES ===\n")
+		prompt.WriteString("The request includes context_before (the lines right before, with their translation) and context_after (the lines right after).\n")
+		prompt.WriteString("Use them only to follow speakers, tone and what was just said, and keep wording consistent with the earlier translations.\n")
+		prompt.WriteString("They are read-only: do NOT translate or output them. Output only the indices listed in lines.\n")
+	}
+
 	prompt.WriteString("\n=== TRANSLATION GUIDELINES ===\n")
 	prompt.WriteString("1. Maintain character voice and relationship dynamics\n")
 	prompt.WriteString("2. Ensure " + targetLanguage + " flows naturally while preserving meaning\n")
-	prompt.WriteString("3. Keep subtitle length appropriate for screen reading\n")
+	prompt.WriteString("3. Keep subtitle length appropriate for screen reading" + readingGuideline(media.ReadingLimits) + "\n")
 	prompt.WriteString("4. You MUST preserve the count of " + inlineBreakerPlaceholder + " in each line exactly\n")
 	prompt.WriteString("5. Keep one translated line per input line index\n")
 	prompt.WriteString("6. Do NOT merge, split, reorder, or drop lines\n")
 	prompt.WriteString("7. If an input line is empty, output text for that index MUST be an empty string\n")
 	prompt.WriteString("8. Priority for proper nouns and terms: TERM MAPPINGS > official localized names > transliteration\n")
+	prompt.WriteString("9. Placeholders like " + overrideTagPlaceholder(1) + " stand for formatting tags: keep each one exactly once, next to the words it applies to\n")
 
 	prompt.WriteString("\n=== OUTPUT FORMAT ===\n")
 	prompt.WriteString("Return ONLY a valid JSON array of objects.\n")
@@ -325,21 +460,67 @@ func (t *agentTranslator) buildContextPrompt(
 	return prompt.String()
 }
 
+// readingGuideline spells out the reading limits for guideline 3
+func readingGuideline(limits reading.Limits) string {
+	var parts []string
+	if limits.MaxLineLength > 0 {
+		parts = append(parts, fmt.Sprintf("at most %d characters per line", limits.MaxLineLength))
+	}
+	if limits.MaxCPS > 0 {
+		parts = append(parts, fmt.Sprintf("about %g characters per second the line is shown", limits.MaxCPS))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return ": " + strings.Join(parts, " and ")
+}
+
 type translationInputLine struct {
-	Index int    `json:"index"`
-	Text  string `json:"text"`
+	Index   int    `json:"index"`
+	Text    string `json:"text"`
+	Speaker string `json:"speaker,omitempty"`
+}
+
+// lineSpeakers returns the speakers of lines, nil when none is known
+func lineSpeakers(lines []subtitle.Line) []string {
+	var speakers []string
+	for i, line := range lines {
+		if line.Speaker == "" {
+			continue
+		}
+		if speakers == nil {
+			speakers = make([]string, len(lines))
+		}
+		speakers[i] = line.Speaker
+	}
+	return speakers
 }
 
-func buildTranslationUserMessage(subtitleTexts []string) (string, error) {
+// translationContextLine is a read-only neighbouring line; it has no index
+// so it cannot be mistaken for a line to translate
+type translationContextLine struct {
+	Text        string `json:"text"`
+	Translation string `json:"translation,omitempty"`
+}
+
+func buildTranslationUserMessage(subtitleTexts []string, speakers []string, window ContextWindow) (string, error) {
 	lines := make([]translationInputLine, 0, len(subtitleTexts))
 	for i, line := range subtitleTexts {
-		lines = append(lines, translationInputLine{Index: i + 1, Text: line})
+		input := translationInputLine{Index: i + 1, Text: line}
+		if i < len(speakers) {
+			input.Speaker = speakers[i]
+		}
+		lines = append(lines, input)
 	}
 
 	payload := struct {
-		Lines []translationInputLine `json:"lines"`
+		ContextBefore []translationContextLine `json:"context_before,omitempty"`
+		Lines         []translationInputLine   `json:"lines"`
+		ContextAfter  []translationContextLine `json:"context_after,omitempty"`
 	}{
-		Lines: lines,
+		ContextBefore: buildContextLines(window.Before),
+		Lines:         lines,
+		ContextAfter:  buildContextLines(window.After),
 	}
 
 	encoded, err := json.Marshal(payload)
@@ -350,6 +531,17 @@ func buildTranslationUserMessage(subtitleTexts []string) (string, error) {
 	return string(encoded), nil
 }
 
+func buildContextLines(lines []ContextLine) []translationContextLine {
+	ret := make([]translationContextLine, 0, len(lines))
+	for _, line := range lines {
+		ret = append(ret, translationContextLine{
+			Text:        contextText(line.Text),
+			Translation: contextText(line.TranslatedText),
+		})
+	}
+	return ret
+}
+
 type translationOutputLine struct {
 	Index int    `json:"index"`
 	Text  string `json:"text"`
@@ -430,6 +622,9 @@ func reorderIndexedTranslations(lines []translationOutputLine, expectedCount int
 		if line.Index <= 0 {
 			return nil, fmt.Errorf("translation index must be positive: %d", line.Index)
 		}
+		if expectedCount > 0 && line.Index > expectedCount {
+			return nil, fmt.Errorf("translation index %d is outside the batch of %d lines", line.Index, expectedCount)
+		}
 		if _, exists := byIndex[line.Index]; exists {
 			return nil, fmt.Errorf("duplicate translation index: %d", line.Index)
 		}
@@ -605,8 +800,8 @@ func buildRepairUserMessage(originalUserMessage string, previousOutput string, v
 	builder.WriteString(originalUserMessage)
 	builder.WriteString("\n")
 	builder.WriteString("Return ONLY valid JSON array objects using schema [{\"index\":1,\"text\":\"...\"}].\n")
-	builder.WriteString(fmt.Sprintf("Each index from 1 to %d must appear exactly once.\n", expectedCount))
-	builder.WriteString("Preserve all required term mappings and inline break markers exactly.\n")
+	builder.WriteString(fmt.Sprintf("Each index from 1 to %d must appear exactly once. Do NOT output context_before or context_after lines.\n", expectedCount))
+	builder.WriteString("Preserve all required term mappings, inline break markers and formatting tag placeholders exactly.\n")
 	builder.WriteString("Do NOT merge/split lines and do NOT output literal newlines in text; use " + inlineBreakerPlaceholder + " only.\n")
 	return builder.String()
 }
@@ -34,6 +34,26 @@ func TestBuildContextPrompt_NoTermMapUsesDynamicSearchCap(t *testing.T) {
 	assert.Contains(t, prompt, "at most 2 web_search calls")
 }
 
+func TestBuildContextPrompt_StorySoFar(t *testing.T) {
+	t.Parallel()
+
+	translator := &agentTranslator{}
+	prompt := translator.buildContextPrompt(MediaMeta{}, "English", "Chinese", false, []string{"hi"})
+	assert.NotContains(t, prompt, "STORY SO FAR")
+
+	media := MediaMeta{
+		PreviousEpisodes: []EpisodeSummary{
+			{Episode: "Show S01E01", Synopsis: "Neo takes the red pill."},
+			{Episode: "Show S01E02", Synopsis: "Neo learns kung fu."},
+		},
+		Relationships: "Morpheus mentors Neo.",
+	}
+	prompt = translator.buildContextPrompt(media, "English", "Chinese", false, []string{"hi"})
+	assert.Contains(t, prompt, "=== STORY SO FAR ===")
+	assert.Contains(t, prompt, "Show S01E01: Neo takes the red pill.\n  Show S01E02: Neo learns kung fu.")
+	assert.Contains(t, prompt, "Character Relationships: Morpheus mentors Neo.")
+}
+
 func TestFixInlineBreakers_AlreadyCorrect(t *testing.T) {
 	t.Parallel()
 
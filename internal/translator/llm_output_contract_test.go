@@ -1,17 +1,21 @@
 package translator
 
 import (
+	"context"
 	"encoding/json"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/MimeLyc/contextual-sub-translator/internal/speaker"
+	"github.com/MimeLyc/contextual-sub-translator/internal/subtitle"
 )
 
 func TestBuildTranslationUserMessage_IndexedLines(t *testing.T) {
 	t.Parallel()
 
-	payload, err := buildTranslationUserMessage([]string{"line-1", "line-2"})
+	payload, err := buildTranslationUserMessage([]string{"line-1", "line-2"}, nil, ContextWindow{})
 	require.NoError(t, err)
 
 	var decoded struct {
@@ -28,6 +32,31 @@ func TestBuildTranslationUserMessage_IndexedLines(t *testing.T) {
 	assert.Equal(t, "line-2", decoded.Lines[1].Text)
 }
 
+func TestBatchTranslate_SendsSpeakersAndCharacters(t *testing.T) {
+	t.Parallel()
+
+	var request string
+	llm := newScriptedAgent(t, func(call int, body string) string {
+		request = body
+		return `[{"index":1,"text":"桃，早上好"},{"index":2,"text":"早"}]`
+	})
+
+	media := MediaMeta{Characters: []speaker.Profile{
+		{Name: "Okarun", Gender: "male", Formality: "polite", Nicknames: []string{"Ken"}},
+	}}
+	lines, err := NewAgentTranslator(llm, false).BatchTranslate(context.Background(), media, []subtitle.Line{
+		{Index: 1, Text: "Good morning, Momo.", Speaker: "Okarun"},
+		{Index: 2, Text: "Morning."},
+	}, "English", "Chinese", 10)
+	require.NoError(t, err)
+
+	assert.Contains(t, request, "=== CHARACTERS ===")
+	assert.Contains(t, request, "Okarun (male, polite; called Ken)")
+	assert.Contains(t, request, `{\"index\":1,\"text\":\"Good morning, Momo.\",\"speaker\":\"Okarun\"}`)
+	assert.Contains(t, request, `{\"index\":2,\"text\":\"Morning.\"}`)
+	assert.Equal(t, "Okarun", lines[0].Speaker)
+}
+
 func TestParseTranslationOutput_IndexedJSONReordered(t *testing.T) {
 	t.Parallel()
 
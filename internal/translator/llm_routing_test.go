@@ -0,0 +1,53 @@
+package translator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/MimeLyc/contextual-sub-translator/internal/agent"
+	"github.com/MimeLyc/contextual-sub-translator/internal/subtitle"
+)
+
+func TestBatchTranslate_FallsBackAfterRepeatedValidationFailure(t *testing.T) {
+	t.Parallel()
+
+	var weakCalls, strongCalls int
+	weak := newScriptedAgent(t, func(call int, _ string) string {
+		weakCalls = call
+		return "Sorry, I cannot produce JSON today."
+	})
+	strong := newScriptedAgent(t, func(call int, _ string) string {
+		strongCalls = call
+		return `[{"index":1,"text":"你好"}]`
+	})
+
+	lines, err := NewRoutedTranslator(agent.Route{weak, strong}, nil, false).BatchTranslate(
+		context.Background(), MediaMeta{}, []subtitle.Line{{Index: 1, Text: "Hello"}}, "English", "Chinese", 10)
+	require.NoError(t, err)
+	assert.Equal(t, "你好", lines[0].TranslatedText)
+	assert.Equal(t, 2, weakCalls, "first attempt and repair run on the first model")
+	assert.Equal(t, 1, strongCalls)
+}
+
+func TestBatchTranslate_RepairsOnRepairModels(t *testing.T) {
+	t.Parallel()
+
+	var repairRequests []string
+	translate := newScriptedAgent(t, func(int, string) string {
+		return `[{"index":1,"text":"你好"}]` // one line short
+	})
+	repair := newScriptedAgent(t, func(_ int, body string) string {
+		repairRequests = append(repairRequests, body)
+		return `[{"index":1,"text":"你好"},{"index":2,"text":"再见"}]`
+	})
+
+	lines, err := NewRoutedTranslator(agent.Route{translate}, agent.Route{repair}, false).BatchTranslate(
+		context.Background(), MediaMeta{}, []subtitle.Line{{Index: 1, Text: "Hello"}, {Index: 2, Text: "Bye"}}, "English", "Chinese", 10)
+	require.NoError(t, err)
+	assert.Equal(t, "再见", lines[1].TranslatedText)
+	require.Len(t, repairRequests, 1)
+	assert.Contains(t, repairRequests[0], "Previous output")
+}
@@ -0,0 +1,52 @@
+package translator
+
+import "github.com/MimeLyc/contextual-sub-translator/internal/subtitle"
+
+// maxMemorySuggestions caps the near matches sent along with one batch
+const maxMemorySuggestions = 15
+
+// Memory recalls approved translations of earlier lines of the same series
+// and language pair.
+type Memory interface {
+	// Exact returns the translation of a line with the same normalised text
+	Exact(text string) (string, bool)
+	// Similar returns translations of lines close to text, most similar first
+	Similar(text string) []MemoryMatch
+}
+
+// MemoryMatch is an earlier line and its approved translation
+type MemoryMatch struct {
+	Source      string
+	Translation string
+}
+
+// recallMemory fills translated with the remembered translations of the
+// lines at positions and returns the positions left to translate, along with
+// the near matches of those lines.
+func recallMemory(memory Memory, lines []subtitle.Line, positions []int, translated []string) ([]int, []MemoryMatch) {
+	if memory == nil {
+		return positions, nil
+	}
+
+	pending := make([]int, 0, len(positions))
+	var suggestions []MemoryMatch
+	seen := make(map[string]bool)
+	for _, i := range positions {
+		if translation, ok := memory.Exact(lines[i].Text); ok {
+			translated[i] = translation
+			continue
+		}
+		pending = append(pending, i)
+		for _, match := range memory.Similar(lines[i].Text) {
+			if len(suggestions) >= maxMemorySuggestions {
+				break
+			}
+			if seen[match.Source] {
+				continue
+			}
+			seen[match.Source] = true
+			suggestions = append(suggestions, match)
+		}
+	}
+	return pending, suggestions
+}
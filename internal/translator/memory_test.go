@@ -0,0 +1,97 @@
+package translator
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/MimeLyc/contextual-sub-translator/internal/subtitle"
+)
+
+// fakeMemory matches on the exact text and offers similar for every other line
+type fakeMemory struct {
+	exact   map[string]string
+	similar []MemoryMatch
+}
+
+func (m fakeMemory) Exact(text string) (string, bool) {
+	translation, ok := m.exact[text]
+	return translation, ok
+}
+
+func (m fakeMemory) Similar(string) []MemoryMatch {
+	return m.similar
+}
+
+func TestBatchTranslate_ReusesTranslationMemory(t *testing.T) {
+	t.Parallel()
+
+	var requests []string
+	llm := newScriptedAgent(t, func(call int, body string) string {
+		requests = append(requests, body)
+		return `[{"index":1,"text":"我们走"}]`
+	})
+
+	media := MediaMeta{Memory: fakeMemory{
+		exact: map[string]string{
+			"Previously on the show...": "前情提要……",
+			"Believe it!":               "我说到做到！",
+		},
+		similar: []MemoryMatch{{Source: "Let's go,\nNaruto!", Translation: "走吧，\n鸣人！"}},
+	}}
+	lines, err := NewAgentTranslator(llm, false).BatchTranslate(context.Background(), media, []subtitle.Line{
+		{Index: 1, Text: "Previously on the show..."},
+		{Index: 2, Text: "Let's go!"},
+		{Index: 3, Text: "Believe it!"},
+	}, "English", "Chinese", 10)
+	require.NoError(t, err)
+
+	require.Len(t, requests, 1)
+	assert.Equal(t, "前情提要……", lines[0].TranslatedText)
+	assert.Equal(t, "我们走", lines[1].TranslatedText)
+	assert.Equal(t, "我说到做到！", lines[2].TranslatedText)
+
+	assert.Contains(t, requests[0], "=== TRANSLATION MEMORY ===")
+	assert.Contains(t, requests[0], `Let's go, Naruto! -\u003e 走吧， 鸣人！`)
+	assert.Contains(t, requests[0], `{\"index\":1,\"text\":\"Let's go!\"}`)
+	assert.NotContains(t, requests[0], "Believe it!")
+}
+
+func TestBatchTranslate_SkipsModelWhenMemoryCoversBatch(t *testing.T) {
+	t.Parallel()
+
+	llm := newScriptedAgent(t, func(call int, body string) string {
+		t.Errorf("unexpected model call %d", call)
+		return `[]`
+	})
+
+	media := MediaMeta{Memory: fakeMemory{exact: map[string]string{"Believe it!": "我说到做到！"}}}
+	lines, err := NewAgentTranslator(llm, false).BatchTranslate(context.Background(), media, []subtitle.Line{
+		{Index: 1, Text: "Believe it!"},
+		{Index: 2, Text: "Believe it!"},
+	}, "English", "Chinese", 10)
+	require.NoError(t, err)
+	assert.Equal(t, "我说到做到！", lines[1].TranslatedText)
+}
+
+func TestRecallMemory_CapsAndDedupesSuggestions(t *testing.T) {
+	t.Parallel()
+
+	var similar []MemoryMatch
+	for i := range maxMemorySuggestions + 5 {
+		similar = append(similar, MemoryMatch{Source: strings.Repeat("a", i+1), Translation: "x"})
+	}
+	lines := []subtitle.Line{{Text: "one"}, {Text: "two"}}
+	translated := make([]string, len(lines))
+
+	pending, suggestions := recallMemory(fakeMemory{similar: similar}, lines, []int{0, 1}, translated)
+	assert.Equal(t, []int{0, 1}, pending)
+	assert.Len(t, suggestions, maxMemorySuggestions)
+
+	pending, suggestions = recallMemory(nil, lines, []int{0, 1}, translated)
+	assert.Equal(t, []int{0, 1}, pending)
+	assert.Empty(t, suggestions)
+}
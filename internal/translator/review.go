@@ -0,0 +1,214 @@
+package translator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/MimeLyc/contextual-sub-translator/internal/agent"
+	"github.com/MimeLyc/contextual-sub-translator/internal/subtitle"
+	"github.com/MimeLyc/contextual-sub-translator/internal/termmap"
+)
+
+// Severity rates how much a review issue hurts the translation
+type Severity string
+
+const (
+	// SeverityMinor is a wording or style nit viewers hardly notice
+	SeverityMinor Severity = "minor"
+	// SeverityMajor changes or loses part of what is said
+	SeverityMajor Severity = "major"
+	// SeverityCritical says something else than the source or nothing at all
+	SeverityCritical Severity = "critical"
+)
+
+// ParseSeverity parses a severity; empty selects SeverityMajor.
+func ParseSeverity(raw string) (Severity, error) {
+	switch severity := Severity(strings.ToLower(strings.TrimSpace(raw))); severity {
+	case "":
+		return SeverityMajor, nil
+	case SeverityMinor, SeverityMajor, SeverityCritical:
+		return severity, nil
+	default:
+		return "", fmt.Errorf("unsupported severity %q (want minor, major or critical)", raw)
+	}
+}
+
+// AtLeast reports whether s is as severe as threshold or more
+func (s Severity) AtLeast(threshold Severity) bool {
+	return s.rank() >= threshold.rank()
+}
+
+func (s Severity) rank() int {
+	switch s {
+	case SeverityCritical:
+		return 3
+	case SeverityMajor:
+		return 2
+	default:
+		return 1
+	}
+}
+
+// reviewCategories are the kinds of issues the reviewer looks for
+var reviewCategories = map[string]bool{
+	"mistranslation": true,
+	"omission":       true,
+	"tone":           true,
+	"terminology":    true,
+}
+
+// ReviewIssue is a problem the reviewer found in one translated line
+type ReviewIssue struct {
+	// Index is the position of the line in the reviewed lines, from 0
+	Index    int
+	Category string
+	Severity Severity
+	Message  string
+}
+
+// BatchReview is the verdict of the reviewer on a batch of translated lines
+type BatchReview struct {
+	// Score rates the batch from 0 (unusable) to 100 (flawless)
+	Score  float64
+	Issues []ReviewIssue
+}
+
+// Reviewer checks translated lines against their source with a second model call.
+type Reviewer struct {
+	agent agent.Agent
+}
+
+// NewReviewer creates a reviewer that runs on a.
+func NewReviewer(a agent.Agent) *Reviewer {
+	return &Reviewer{agent: a}
+}
+
+type reviewInputLine struct {
+	Index       int    `json:"index"`
+	Source      string `json:"source"`
+	Translation string `json:"translation"`
+}
+
+type reviewResponse struct {
+	Score  float64 `json:"score"`
+	Issues []struct {
+		Index    int    `json:"index"`
+		Category string `json:"category"`
+		Severity string `json:"severity"`
+		Message  string `json:"message"`
+	} `json:"issues"`
+}
+
+// Review asks the model to score the translations of lines and list their
+// issues.
+func (r *Reviewer) Review(
+	ctx context.Context,
+	media MediaMeta,
+	lines []subtitle.Line,
+	sourceLanguage string,
+	targetLanguage string,
+) (BatchReview, error) {
+	input := make([]reviewInputLine, 0, len(lines))
+	sources := make([]string, 0, len(lines))
+	for i, line := range lines {
+		input = append(input, reviewInputLine{
+			Index:       i + 1,
+			Source:      contextText(line.Text),
+			Translation: contextText(line.TranslatedText),
+		})
+		sources = append(sources, line.Text)
+	}
+	userMessage, err := json.Marshal(struct {
+		Lines []reviewInputLine `json:"lines"`
+	}{Lines: input})
+	if err != nil {
+		return BatchReview{}, fmt.Errorf("build review request failed: %w", err)
+	}
+
+	var termMap map[string]string
+	if len(media.TermMap) > 0 {
+		termMap = map[string]string(termmap.Match(termmap.TermMap(media.TermMap), sources).Matched)
+	}
+	result, err := r.agent.Execute(ctx, agent.AgentRequest{
+		SystemPrompt:  buildReviewPrompt(media, sourceLanguage, targetLanguage, termMap),
+		UserMessage:   string(userMessage),
+		MaxIterations: 1,
+		JSONMode:      true,
+	})
+	if err != nil {
+		return BatchReview{}, fmt.Errorf("agent execution failed: %w", err)
+	}
+	return parseReviewResponse(result.Content, len(lines))
+}
+
+func buildReviewPrompt(media MediaMeta, sourceLanguage string, targetLanguage string, termMap map[string]string) string {
+	var prompt strings.Builder
+
+	prompt.WriteString("You review subtitle translations from " + sourceLanguage + " to " + targetLanguage + ". You compare each translation with its source line and output ONLY a JSON object.\n\n")
+
+	if media.Title != "" {
+		prompt.WriteString("=== MEDIA INFORMATION ===\n")
+		prompt.WriteString(fmt.Sprintf("Show Title: %s\n\n", media.Title))
+	}
+
+	if len(termMap) > 0 {
+		prompt.WriteString("=== TERM MAPPINGS ===\n")
+		prompt.WriteString("Translations MUST use the mapped target term whenever its source term appears in the source line.\n")
+		for source, target := range termMap {
+			prompt.WriteString(fmt.Sprintf("  %s -> %s\n", source, target))
+		}
+		prompt.WriteString("\n")
+	}
+
+	prompt.WriteString("=== TASK ===\n")
+	prompt.WriteString("1. score: how good the translations are as a whole, from 0 (unusable) to 100 (flawless).\n")
+	prompt.WriteString("2. issues: the problems of single lines. Each has the index of the line, a category and a severity.\n")
+	prompt.WriteString("Categories: mistranslation (says something else), omission (leaves out part of the meaning), tone (wrong register, politeness or voice), terminology (a name or term differs from the term mappings or from other lines).\n")
+	prompt.WriteString("Severities: minor (a nit viewers hardly notice), major (changes or loses part of what is said), critical (says something else or nothing at all).\n")
+	prompt.WriteString("Subtitles are condensed on purpose: do NOT report shortened wording that keeps the meaning. Report nothing for good lines.\n\n")
+
+	prompt.WriteString("=== RESPONSE FORMAT (MANDATORY) ===\n")
+	prompt.WriteString("{\"score\": 85, \"issues\": [{\"index\": 1, \"category\": \"mistranslation\", \"severity\": \"major\", \"message\": \"...\"}]}\n")
+	prompt.WriteString("Write messages in English, one sentence each.\n")
+	prompt.WriteString("NO markdown, NO explanations. The response must start with { and end with }.\n")
+
+	return prompt.String()
+}
+
+// parseReviewResponse parses the answer of the reviewer. Issues of unknown
+// lines are dropped; unknown categories and severities are kept as minor
+// issues of no category.
+func parseReviewResponse(content string, lineCount int) (BatchReview, error) {
+	raw := strings.TrimSpace(unwrapCodeFence(strings.TrimSpace(content)))
+	if extracted := extractJSONObject(raw); extracted != "" {
+		raw = extracted
+	}
+	var resp reviewResponse
+	if err := json.Unmarshal([]byte(raw), &resp); err != nil {
+		return BatchReview{}, fmt.Errorf("failed to parse review JSON from response: %w", err)
+	}
+
+	review := BatchReview{Score: min(max(resp.Score, 0), 100)}
+	for _, issue := range resp.Issues {
+		if issue.Index < 1 || issue.Index > lineCount {
+			continue
+		}
+		category := strings.ToLower(strings.TrimSpace(issue.Category))
+		if !reviewCategories[category] {
+			category = ""
+		}
+		severity, err := ParseSeverity(issue.Severity)
+		if err != nil || strings.TrimSpace(issue.Severity) == "" {
+			severity = SeverityMinor
+		}
+		review.Issues = append(review.Issues, ReviewIssue{
+			Index:    issue.Index - 1,
+			Category: category,
+			Severity: severity,
+			Message:  strings.TrimSpace(issue.Message),
+		})
+	}
+	return review, nil
+}
@@ -0,0 +1,94 @@
+package translator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/MimeLyc/contextual-sub-translator/internal/subtitle"
+)
+
+func TestParseSeverity(t *testing.T) {
+	t.Parallel()
+
+	severity, err := ParseSeverity("")
+	require.NoError(t, err)
+	assert.Equal(t, SeverityMajor, severity)
+
+	severity, err = ParseSeverity(" Critical ")
+	require.NoError(t, err)
+	assert.Equal(t, SeverityCritical, severity)
+
+	_, err = ParseSeverity("fatal")
+	require.Error(t, err)
+
+	assert.True(t, SeverityCritical.AtLeast(SeverityMajor))
+	assert.True(t, SeverityMajor.AtLeast(SeverityMajor))
+	assert.False(t, SeverityMinor.AtLeast(SeverityMajor))
+}
+
+func TestReviewer_ReportsIssuesPerLine(t *testing.T) {
+	t.Parallel()
+
+	var requests []string
+	llm := newScriptedAgent(t, func(call int, body string) string {
+		requests = append(requests, body)
+		return "```json\n" + `{"score": 120, "issues": [` +
+			`{"index": 2, "category": "Omission", "severity": "critical", "message": "Drops the warning."},` +
+			`{"index": 1, "category": "style", "severity": "whatever", "message": "Reads stiff."},` +
+			`{"index": 9, "category": "tone", "severity": "major", "message": "No such line."}]}` + "\n```"
+	})
+
+	review, err := NewReviewer(llm).Review(context.Background(), MediaMeta{
+		TermMap: map[string]string{"Konoha": "木叶", "Tokyo": "东京"},
+	}, []subtitle.Line{
+		{Index: 7, Text: "Back to Konoha.", TranslatedText: "回木叶。"},
+		{Index: 8, Text: "Watch out,\nit's a trap!", TranslatedText: "小心！"},
+	}, "English", "Chinese")
+	require.NoError(t, err)
+
+	require.Len(t, requests, 1)
+	assert.Contains(t, requests[0], `\"source\":\"Watch out, it's a trap!\"`)
+	assert.Contains(t, requests[0], `Konoha -\u003e 木叶`)
+	assert.NotContains(t, requests[0], "东京", "only terms of the lines are sent")
+
+	assert.Equal(t, 100.0, review.Score)
+	assert.Equal(t, []ReviewIssue{
+		{Index: 1, Category: "omission", Severity: SeverityCritical, Message: "Drops the warning."},
+		{Index: 0, Category: "", Severity: SeverityMinor, Message: "Reads stiff."},
+	}, review.Issues)
+}
+
+func TestReviewer_FailsOnInvalidOutput(t *testing.T) {
+	t.Parallel()
+
+	llm := newScriptedAgent(t, func(call int, body string) string {
+		return "looks fine to me"
+	})
+	_, err := NewReviewer(llm).Review(context.Background(), MediaMeta{}, []subtitle.Line{
+		{Index: 1, Text: "Hello", TranslatedText: "你好"},
+	}, "English", "Chinese")
+	require.Error(t, err)
+}
+
+func TestBatchTranslate_SendsReviewerNotes(t *testing.T) {
+	t.Parallel()
+
+	var requests []string
+	llm := newScriptedAgent(t, func(call int, body string) string {
+		requests = append(requests, body)
+		return `[{"index":1,"text":"小心，是陷阱！"}]`
+	})
+
+	lines, err := NewAgentTranslator(llm, false).BatchTranslate(context.Background(), MediaMeta{
+		Corrections: []ReviewNote{{Source: "Watch out, it's a trap!", Translation: "小心！", Note: "Drops the warning."}},
+	}, []subtitle.Line{{Index: 1, Text: "Watch out, it's a trap!"}}, "English", "Chinese", 10)
+	require.NoError(t, err)
+
+	require.Len(t, requests, 1)
+	assert.Contains(t, requests[0], "=== REVIEWER NOTES ===")
+	assert.Contains(t, requests[0], `Watch out, it's a trap! -\u003e 小心！: Drops the warning.`)
+	assert.Equal(t, "小心，是陷阱！", lines[0].TranslatedText)
+}
@@ -0,0 +1,85 @@
+package translator
+
+import (
+	"context"
+	"fmt"
+
+	"golang.org/x/text/language"
+
+	"github.com/MimeLyc/contextual-sub-translator/internal/subtitle"
+	"github.com/MimeLyc/contextual-sub-translator/internal/zhconv"
+)
+
+// scriptConverter translates between the variants of Chinese with zhconv
+// instead of a model, so it costs no tokens and gives the same output for the
+// same lines.
+type scriptConverter struct{}
+
+// NewScriptConverter creates a Translator that converts Chinese between
+// Simplified and Traditional script and the vocabulary of Taiwan and Hong
+// Kong. Other languages are an error.
+func NewScriptConverter() Translator {
+	return scriptConverter{}
+}
+
+func (scriptConverter) Translate(
+	_ context.Context,
+	_ MediaMeta,
+	subtitleTexts []string,
+	sourceLang string,
+	targetLang string,
+) ([]string, error) {
+	converter, err := newZhConverter(sourceLang, targetLang)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]string, len(subtitleTexts))
+	for i, text := range subtitleTexts {
+		ret[i] = converter.Convert(text)
+	}
+	return ret, nil
+}
+
+func (scriptConverter) BatchTranslate(
+	_ context.Context,
+	_ MediaMeta,
+	subtitleLines []subtitle.Line,
+	sourceLanguage string,
+	targetLanguage string,
+	_ int,
+) ([]subtitle.Line, error) {
+	converter, err := newZhConverter(sourceLanguage, targetLanguage)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]subtitle.Line, len(subtitleLines))
+	for i, line := range subtitleLines {
+		line.TranslatedText = converter.Convert(line.Text)
+		ret[i] = line
+	}
+	return ret, nil
+}
+
+func newZhConverter(sourceLang, targetLang string) (*zhconv.Converter, error) {
+	from, err := zhVariant(sourceLang)
+	if err != nil {
+		return nil, err
+	}
+	to, err := zhVariant(targetLang)
+	if err != nil {
+		return nil, err
+	}
+	return zhconv.New(from, to), nil
+}
+
+func zhVariant(lang string) (zhconv.Variant, error) {
+	tag, err := language.Parse(lang)
+	if err != nil {
+		return "", fmt.Errorf("invalid language %q: %w", lang, err)
+	}
+	variant, ok := zhconv.VariantOf(tag)
+	if !ok {
+		return "", fmt.Errorf("script conversion needs Chinese, got %s", lang)
+	}
+	return variant, nil
+}
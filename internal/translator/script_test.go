@@ -0,0 +1,43 @@
+package translator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/MimeLyc/contextual-sub-translator/internal/subtitle"
+)
+
+func TestScriptConverter_BatchTranslate(t *testing.T) {
+	t.Parallel()
+
+	lines := []subtitle.Line{
+		{Index: 1, StartTime: time.Second, EndTime: 2 * time.Second, Text: "我们走吧", Speaker: "Taro"},
+		{Index: 2, StartTime: 3 * time.Second, EndTime: 4 * time.Second, Text: "头发干净了", Song: true},
+	}
+	ret, err := NewScriptConverter().BatchTranslate(context.Background(), MediaMeta{}, lines, "zh-Hans", "zh-TW", 50)
+	require.NoError(t, err)
+	require.Len(t, ret, 2)
+	assert.Equal(t, "我們走吧", ret[0].TranslatedText)
+	assert.Equal(t, "我们走吧", ret[0].Text)
+	assert.Equal(t, "Taro", ret[0].Speaker)
+	assert.Equal(t, "頭髮乾淨了", ret[1].TranslatedText)
+	assert.True(t, ret[1].Song)
+	assert.Equal(t, 3*time.Second, ret[1].StartTime)
+
+	texts, err := NewScriptConverter().Translate(context.Background(), MediaMeta{}, []string{"軟體"}, "zh-TW", "zh")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"软件"}, texts)
+}
+
+func TestScriptConverter_RejectsOtherLanguages(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewScriptConverter().BatchTranslate(context.Background(), MediaMeta{}, []subtitle.Line{{Text: "hi"}}, "en", "zh-Hant", 50)
+	assert.Error(t, err)
+	_, err = NewScriptConverter().Translate(context.Background(), MediaMeta{}, []string{"你好"}, "zh", "ja")
+	assert.Error(t, err)
+}
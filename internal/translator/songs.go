@@ -0,0 +1,53 @@
+package translator
+
+import (
+	"github.com/MimeLyc/contextual-sub-translator/internal/songs"
+	"github.com/MimeLyc/contextual-sub-translator/internal/subtitle"
+	"github.com/MimeLyc/contextual-sub-translator/pkg/log"
+)
+
+// pendingGroup is a set of lines of a batch sent to the model together
+type pendingGroup struct {
+	// positions of the lines in the batch
+	positions   []int
+	suggestions []MemoryMatch
+	lyrics      bool
+}
+
+// pendingGroups fills translated with what the batch needs no model for:
+// remembered translations and songs that are kept or skipped. It returns the
+// dialogue and the lyrics left to translate, each in a group of its own.
+func pendingGroups(media MediaMeta, batch []subtitle.Line, translated []string) []pendingGroup {
+	var dialogue, lyrics []int
+	for i, line := range batch {
+		if !line.Song || media.SongMode == "" {
+			dialogue = append(dialogue, i)
+			continue
+		}
+		switch media.SongMode {
+		case songs.ModeOriginal:
+			translated[i] = line.Text
+		case songs.ModeLyrics, songs.ModeReuse:
+			lyrics = append(lyrics, i)
+		}
+	}
+
+	var groups []pendingGroup
+	pending, suggestions := recallMemory(media.Memory, batch, dialogue, translated)
+	if reused := len(dialogue) - len(pending); reused > 0 {
+		log.Debug("Reused %d translation(s) from translation memory", reused)
+	}
+	if len(pending) > 0 {
+		groups = append(groups, pendingGroup{positions: pending, suggestions: suggestions})
+	}
+
+	// Lyrics are translated afresh unless the series reuses its songs
+	var lyricSuggestions []MemoryMatch
+	if media.SongMode == songs.ModeReuse {
+		lyrics, lyricSuggestions = recallMemory(media.Memory, batch, lyrics, translated)
+	}
+	if len(lyrics) > 0 {
+		groups = append(groups, pendingGroup{positions: lyrics, suggestions: lyricSuggestions, lyrics: true})
+	}
+	return groups
+}
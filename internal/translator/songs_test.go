@@ -0,0 +1,91 @@
+package translator
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/MimeLyc/contextual-sub-translator/internal/songs"
+	"github.com/MimeLyc/contextual-sub-translator/internal/subtitle"
+)
+
+func songBatch() []subtitle.Line {
+	return []subtitle.Line{
+		{Index: 1, Text: "Kimi no koe ga kikoeru", Song: true},
+		{Index: 2, Text: "Where are you going?", Speaker: "Momo"},
+		{Index: 3, Text: "Sora ni todoke", Song: true},
+	}
+}
+
+func TestBatchTranslate_TranslatesSongsAsLyrics(t *testing.T) {
+	t.Parallel()
+
+	var requests []string
+	llm := newScriptedAgent(t, func(call int, body string) string {
+		requests = append(requests, body)
+		if strings.Contains(body, "=== SONG LYRICS ===") {
+			return `[{"index":1,"text":"听见你的声音"},{"index":2,"text":"传达到天空"}]`
+		}
+		return `[{"index":1,"text":"你要去哪？"}]`
+	})
+
+	// Lyrics mode retranslates songs even when they are remembered
+	media := MediaMeta{
+		SongMode: songs.ModeLyrics,
+		Memory:   fakeMemory{exact: map[string]string{"Sora ni todoke": "旧译"}},
+	}
+	lines, err := NewAgentTranslator(llm, false).BatchTranslate(context.Background(), media, songBatch(), "Japanese", "Chinese", 10)
+	require.NoError(t, err)
+
+	require.Len(t, requests, 2)
+	assert.NotContains(t, requests[0], "=== SONG LYRICS ===")
+	assert.Contains(t, requests[0], `\"speaker\":\"Momo\"`)
+	assert.NotContains(t, requests[0], "Kimi no koe")
+	assert.Contains(t, requests[1], "Kimi no koe ga kikoeru")
+	assert.NotContains(t, requests[1], "Where are you going?")
+
+	assert.Equal(t, []string{"听见你的声音", "你要去哪？", "传达到天空"}, []string{
+		lines[0].TranslatedText, lines[1].TranslatedText, lines[2].TranslatedText,
+	})
+	assert.True(t, lines[0].Song)
+}
+
+func TestBatchTranslate_SongModes(t *testing.T) {
+	t.Parallel()
+
+	for mode, want := range map[songs.Mode][]string{
+		songs.ModeOriginal: {"Kimi no koe ga kikoeru", "你要去哪？", "Sora ni todoke"},
+		songs.ModeSkip:     {"", "你要去哪？", ""},
+		songs.ModeReuse:    {"听见你的声音", "你要去哪？", "传达到天空"},
+	} {
+		var requests []string
+		llm := newScriptedAgent(t, func(call int, body string) string {
+			requests = append(requests, body)
+			if strings.Contains(body, "=== SONG LYRICS ===") {
+				return `[{"index":1,"text":"听见你的声音"}]`
+			}
+			return `[{"index":1,"text":"你要去哪？"}]`
+		})
+
+		media := MediaMeta{
+			SongMode: mode,
+			Memory:   fakeMemory{exact: map[string]string{"Sora ni todoke": "传达到天空"}},
+		}
+		lines, err := NewAgentTranslator(llm, false).BatchTranslate(context.Background(), media, songBatch(), "Japanese", "Chinese", 10)
+		require.NoError(t, err, mode)
+
+		var got []string
+		for _, line := range lines {
+			got = append(got, line.TranslatedText)
+		}
+		assert.Equal(t, want, got, mode)
+		if mode == songs.ModeReuse {
+			assert.Len(t, requests, 2, "only the song line without a stored translation is sent")
+		} else {
+			assert.Len(t, requests, 1, mode)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package translator
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// formattingTagPattern matches the inline formatting of subtitle text:
+// ASS/SSA override blocks such as {\i1}, {\an8} or {\pos(10,20)}, and
+// HTML-style tags such as <i>, </b> or <font color="#ffff00">.
+var formattingTagPattern = regexp.MustCompile(`\{[^}]*\}|</?[A-Za-z][A-Za-z0-9]*(?:\s[^<>]*)?>`)
+
+// tagPlaceholderPattern matches placeholders including the variants models
+// tend to produce, like %%TAG_1%% or %% tag_1 %%.
+var tagPlaceholderPattern = regexp.MustCompile(`(?i)%%\s*tag_(\d+)\s*%%`)
+
+// overrideTagPlaceholder returns the placeholder that stands for the n-th
+// (1-based) formatting tag of a line while it is sent to the LLM.
+func overrideTagPlaceholder(n int) string {
+	return fmt.Sprintf("%%%%tag_%d%%%%", n)
+}
+
+// shieldedTag is a formatting tag replaced by a placeholder
+type shieldedTag struct {
+	text string
+	// leading tags start the line, a dropped one is put back at the start
+	leading bool
+}
+
+// shieldOverrideTags replaces formatting tags in every line with indexed
+// placeholders, so the model cannot drop, move or rewrite them.
+// It returns the shielded lines and the original tags per line.
+func shieldOverrideTags(lines []string) ([]string, [][]shieldedTag) {
+	shielded := make([]string, len(lines))
+	tags := make([][]shieldedTag, len(lines))
+	found := false
+	for i, line := range lines {
+		var b strings.Builder
+		last := 0
+		for n, loc := range formattingTagPattern.FindAllStringIndex(line, -1) {
+			tags[i] = append(tags[i], shieldedTag{
+				text: line[loc[0]:loc[1]],
+				// only tags precede it
+				leading: loc[0] == last && (n == 0 || tags[i][n-1].leading),
+			})
+			b.WriteString(line[last:loc[0]])
+			b.WriteString(overrideTagPlaceholder(n + 1))
+			last = loc[1]
+		}
+		b.WriteString(line[last:])
+		shielded[i] = b.String()
+		found = found || len(tags[i]) > 0
+	}
+	if !found {
+		return lines, nil
+	}
+	return shielded, tags
+}
+
+// validateTagPlaceholders reports lines whose translation does not keep every
+// placeholder of the source exactly once
+func validateTagPlaceholders(translatedLines []string, tags [][]shieldedTag) error {
+	if len(tags) == 0 {
+		return nil
+	}
+	violations := make([]string, 0, 3)
+	for i, line := range translatedLines {
+		if i >= len(tags) {
+			break
+		}
+		counts := make(map[int]int)
+		for _, match := range tagPlaceholderPattern.FindAllStringSubmatch(line, -1) {
+			n, _ := strconv.Atoi(match[1])
+			counts[n]++
+		}
+		for n := range tags[i] {
+			if count := counts[n+1]; count != 1 {
+				violations = append(violations, fmt.Sprintf("line %d has %s %d times, expected once", i+1, overrideTagPlaceholder(n+1), count))
+			}
+			delete(counts, n+1)
+		}
+		for n := range counts {
+			violations = append(violations, fmt.Sprintf("line %d has unknown placeholder %s", i+1, overrideTagPlaceholder(n)))
+		}
+		if len(violations) >= cap(violations) {
+			break
+		}
+	}
+	if len(violations) > 0 {
+		return fmt.Errorf("formatting tag placeholders not preserved: %s", strings.Join(violations, "; "))
+	}
+	return nil
+}
+
+// restoreOverrideTags puts the original tags back in place of their placeholders.
+// A placeholder the model dropped gets its tag back at the start of the line
+// when it was leading and at the end otherwise. Duplicated and unknown
+// placeholders are removed.
+func restoreOverrideTags(lines []string, tags [][]shieldedTag) []string {
+	if len(tags) == 0 {
+		return lines
+	}
+	for i := range lines {
+		if i >= len(tags) {
+			break
+		}
+		seen := make(map[int]bool)
+		line := tagPlaceholderPattern.ReplaceAllStringFunc(lines[i], func(placeholder string) string {
+			n, _ := strconv.Atoi(tagPlaceholderPattern.FindStringSubmatch(placeholder)[1])
+			if n < 1 || n > len(tags[i]) || seen[n] {
+				return ""
+			}
+			seen[n] = true
+			return tags[i][n-1].text
+		})
+		var leading strings.Builder
+		for n, tag := range tags[i] {
+			if seen[n+1] {
+				continue
+			}
+			if tag.leading {
+				leading.WriteString(tag.text)
+			} else {
+				line += tag.text
+			}
+		}
+		lines[i] = leading.String() + line
+	}
+	return lines
+}
@@ -0,0 +1,142 @@
+package translator
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/MimeLyc/contextual-sub-translator/internal/agent"
+	"github.com/MimeLyc/contextual-sub-translator/internal/subtitle"
+	"github.com/MimeLyc/contextual-sub-translator/internal/tools"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShieldOverrideTags_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	shielded, tags := shieldOverrideTags([]string{`Station {\i1}Exit{\i0}`, "plain"})
+	assert.Equal(t, []string{"Station %%tag_1%%Exit%%tag_2%%", "plain"}, shielded)
+
+	restored := restoreOverrideTags([]string{"车站%%tag_1%%出口%%tag_2%%", "普通"}, tags)
+	assert.Equal(t, []string{`车站{\i1}出口{\i0}`, "普通"}, restored)
+}
+
+func TestShieldOverrideTags_NoTags(t *testing.T) {
+	t.Parallel()
+
+	lines := []string{"hello", "world"}
+	shielded, tags := shieldOverrideTags(lines)
+	assert.Equal(t, lines, shielded)
+	assert.Nil(t, tags)
+}
+
+func TestRestoreOverrideTags_RepairsDroppedAndDuplicatedPlaceholders(t *testing.T) {
+	t.Parallel()
+
+	_, tags := shieldOverrideTags([]string{`{\i1}Exit{\i0}`})
+	restored := restoreOverrideTags([]string{"%%tag_1%%出口%%tag_1%%"}, tags)
+	assert.Equal(t, []string{`{\i1}出口{\i0}`}, restored)
+}
+
+func TestShieldOverrideTags_HTMLTags(t *testing.T) {
+	t.Parallel()
+
+	shielded, tags := shieldOverrideTags([]string{`{\an8}<i>Where is <font color="#ffff00">Exit</font>?</i>`})
+	assert.Equal(t, []string{"%%tag_1%%%%tag_2%%Where is %%tag_3%%Exit%%tag_4%%?%%tag_5%%"}, shielded)
+
+	restored := restoreOverrideTags([]string{"%%tag_1%%%%tag_2%%%%tag_3%%出口%%tag_4%%在哪？%%tag_5%%"}, tags)
+	assert.Equal(t, []string{`{\an8}<i><font color="#ffff00">出口</font>在哪？</i>`}, restored)
+}
+
+func TestShieldOverrideTags_KeepsPlainAngleBrackets(t *testing.T) {
+	t.Parallel()
+
+	lines := []string{"I <3 you", "1 < 2 > 0"}
+	shielded, tags := shieldOverrideTags(lines)
+	assert.Equal(t, lines, shielded)
+	assert.Nil(t, tags)
+}
+
+func TestRestoreOverrideTags_DroppedLeadingTagGoesFirst(t *testing.T) {
+	t.Parallel()
+
+	_, tags := shieldOverrideTags([]string{`{\an8}<i>Run!</i>`})
+	restored := restoreOverrideTags([]string{"%%TAG_2%%快跑！ %% tag_9 %%"}, tags)
+	assert.Equal(t, []string{`{\an8}<i>快跑！ </i>`}, restored)
+}
+
+func TestValidateTagPlaceholders(t *testing.T) {
+	t.Parallel()
+
+	_, tags := shieldOverrideTags([]string{"<i>Exit</i>", "plain"})
+	assert.NoError(t, validateTagPlaceholders([]string{"%%tag_1%%出口%%Tag_2%%", "普通"}, tags))
+
+	err := validateTagPlaceholders([]string{"%%tag_1%%出口%%tag_1%%", "普通%%tag_3%%"}, tags)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "line 1 has %%tag_1%% 2 times")
+	assert.Contains(t, err.Error(), "line 1 has %%tag_2%% 0 times")
+	assert.Contains(t, err.Error(), "line 2 has unknown placeholder %%tag_3%%")
+}
+
+// newScriptedAgent returns an agent backed by a fake chat completions API
+// that answers with reply(call, requestBody), call counting from 1
+func newScriptedAgent(t *testing.T, reply func(call int, body string) string) *agent.LLMAgent {
+	t.Helper()
+	var mu sync.Mutex
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		calls++
+		content := reply(calls, string(body))
+		mu.Unlock()
+
+		encoded, _ := json.Marshal(content)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"chatcmpl-1","object":"chat.completion","created":1,"model":"test-model",` +
+			`"choices":[{"index":0,"finish_reason":"stop","message":{"role":"assistant","content":` + string(encoded) + `}}],` +
+			`"usage":{"prompt_tokens":1,"completion_tokens":1,"total_tokens":2}}`))
+	}))
+	t.Cleanup(server.Close)
+
+	llm, err := agent.NewLLMAgent(agent.LLMConfig{
+		APIKey:  "test-key",
+		APIURL:  server.URL,
+		Model:   "test-model",
+		Timeout: 10,
+	}, tools.NewRegistry(), 1)
+	require.NoError(t, err)
+	return llm
+}
+
+func TestBatchTranslate_ShieldsAndRestoresTags(t *testing.T) {
+	t.Parallel()
+
+	var requests []string
+	llm := newScriptedAgent(t, func(call int, body string) string {
+		requests = append(requests, body)
+		// the first answer drops a placeholder and gets repaired
+		if call == 1 {
+			return `[{"index":1,"text":"%%tag_1%%出口"},{"index":2,"text":"你好"}]`
+		}
+		return `[{"index":1,"text":"%%tag_1%%出口%%tag_2%%"},{"index":2,"text":"你好"}]`
+	})
+
+	lines, err := NewAgentTranslator(llm, false).BatchTranslate(context.Background(), MediaMeta{}, []subtitle.Line{
+		{Index: 1, Text: "<i>Exit</i>"},
+		{Index: 2, Text: "Hello"},
+	}, "English", "Chinese", 10)
+	require.NoError(t, err)
+
+	require.Len(t, requests, 2)
+	assert.Contains(t, requests[0], "%%tag_1%%Exit%%tag_2%%")
+	assert.NotContains(t, requests[0], "\\u003ci\\u003e")
+	assert.Contains(t, requests[1], "formatting tag placeholders not preserved")
+	assert.Equal(t, "<i>出口</i>", lines[0].TranslatedText)
+	assert.Equal(t, "你好", lines[1].TranslatedText)
+}
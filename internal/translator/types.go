@@ -5,6 +5,9 @@ import (
 
 	"github.com/MimeLyc/contextual-sub-translator/internal/agent"
 	"github.com/MimeLyc/contextual-sub-translator/internal/media"
+	"github.com/MimeLyc/contextual-sub-translator/internal/reading"
+	"github.com/MimeLyc/contextual-sub-translator/internal/songs"
+	"github.com/MimeLyc/contextual-sub-translator/internal/speaker"
 	"github.com/MimeLyc/contextual-sub-translator/internal/subtitle"
 )
 
@@ -17,6 +20,46 @@ type MediaMeta struct {
 	media.TVShowInfo
 	media.Actor
 	TermMap map[string]string
+	// Window holds the lines around the lines to translate, sent as read-only context
+	Window ContextWindow
+	// PreviousEpisodes are synopses of the episodes right before this one, oldest first
+	PreviousEpisodes []EpisodeSummary
+	// Relationships are the character notes of the series so far
+	Relationships string
+	// Characters are the profiles of the characters of the series
+	Characters []speaker.Profile
+	// Speakers holds the likely speaker per line to translate, set per batch
+	// from subtitle.Line.Speaker
+	Speakers []string
+	// Memory recalls earlier translations of the series; nil disables reuse
+	Memory Memory
+	// Suggestions are earlier translations of lines close to the lines to
+	// translate, set per batch from Memory
+	Suggestions []MemoryMatch
+	// SongMode selects how lines marked subtitle.Line.Song are translated;
+	// empty translates them as dialogue
+	SongMode songs.Mode
+	// Lyrics is set when the lines to translate are song lyrics
+	Lyrics bool
+	// ReadingLimits are the reading speed limits of the target language,
+	// spelled out in the guidelines when set
+	ReadingLimits reading.Limits
+	// Corrections are the reviewer notes on earlier translations of the lines
+	// to translate, set when lines are translated again after a review
+	Corrections []ReviewNote
+}
+
+// ReviewNote is the issue a reviewer found in the translation of a line
+type ReviewNote struct {
+	Source      string
+	Translation string
+	Note        string
+}
+
+// EpisodeSummary is the synopsis of an earlier episode of the same show
+type EpisodeSummary struct {
+	Episode  string
+	Synopsis string
 }
 
 // Translator defines the interface for translating subtitles.
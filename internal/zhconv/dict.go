@@ -0,0 +1,97 @@
+package zhconv
+
+import (
+	"embed"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// The dictionaries follow the OpenCC layout: one key per line, a tab, then
+// its conversions separated by spaces, the first being the default.
+//
+//go:embed dict/*.txt
+var dictFiles embed.FS
+
+type dict struct {
+	entries map[string]string
+	// maxLen is the length in runes of the longest key
+	maxLen int
+}
+
+func parseDict(data string) *dict {
+	ret := &dict{entries: make(map[string]string)}
+	for _, line := range strings.Split(data, "\n") {
+		key, values, ok := strings.Cut(strings.TrimRight(line, "\r"), "\t")
+		if !ok || key == "" {
+			continue
+		}
+		fields := strings.Fields(values)
+		if len(fields) == 0 {
+			continue
+		}
+		ret.entries[key] = fields[0]
+		ret.maxLen = max(ret.maxLen, utf8.RuneCountInString(key))
+	}
+	return ret
+}
+
+// loadDicts reads the embedded dictionaries by name, e.g. "STCharacters"
+var loadDicts = sync.OnceValue(func() map[string]*dict {
+	entries, err := dictFiles.ReadDir("dict")
+	if err != nil {
+		panic(err)
+	}
+	ret := make(map[string]*dict, len(entries))
+	for _, entry := range entries {
+		data, err := dictFiles.ReadFile("dict/" + entry.Name())
+		if err != nil {
+			panic(err)
+		}
+		ret[strings.TrimSuffix(entry.Name(), ".txt")] = parseDict(string(data))
+	}
+	return ret
+})
+
+// group converts text with several dictionaries at once: the longest key
+// found in any of them wins, the earlier dictionary on equal lengths, e.g.
+// phrases before characters.
+type group []*dict
+
+func (g group) convert(text string) string {
+	maxLen := 0
+	for _, d := range g {
+		maxLen = max(maxLen, d.maxLen)
+	}
+	runes := []rune(text)
+	var sb strings.Builder
+	sb.Grow(len(text))
+	for i := 0; i < len(runes); {
+		n, value := g.match(runes[i:], maxLen)
+		if n == 0 {
+			sb.WriteRune(runes[i])
+			i++
+			continue
+		}
+		sb.WriteString(value)
+		i += n
+	}
+	return sb.String()
+}
+
+// match returns the length and conversion of the longest key text starts
+// with, 0 when there is none
+func (g group) match(text []rune, maxLen int) (int, string) {
+	for n := min(maxLen, len(text)); n > 0; n-- {
+		key := string(text[:n])
+		for _, d := range g {
+			if n > d.maxLen {
+				continue
+			}
+			if value, ok := d.entries[key]; ok {
+				return n, value
+			}
+		}
+	}
+	return 0, ""
+}
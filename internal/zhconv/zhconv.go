@@ -0,0 +1,87 @@
+package zhconv
+
+import (
+	"golang.org/x/text/language"
+)
+
+// Variant is a written standard of Chinese: a script, and for Taiwan and Hong
+// Kong the vocabulary of the region as well
+type Variant string
+
+const (
+	Simplified  Variant = "zh-Hans"
+	Traditional Variant = "zh-Hant"
+	Taiwan      Variant = "zh-TW"
+	HongKong    Variant = "zh-HK"
+)
+
+// VariantOf returns the variant text in tag is written in, false when tag is
+// not Chinese. An explicit script wins over the region; Chinese without
+// either is Simplified.
+func VariantOf(tag language.Tag) (Variant, bool) {
+	base, conf := tag.Base()
+	if conf != language.Exact || base.String() != "zh" {
+		return "", false
+	}
+	script, conf := tag.Script()
+	if conf == language.Exact && script.String() == "Hans" {
+		return Simplified, true
+	}
+	if region, conf := tag.Region(); conf == language.Exact {
+		switch region.String() {
+		case "TW":
+			return Taiwan, true
+		case "HK", "MO":
+			return HongKong, true
+		}
+	}
+	if script.String() == "Hant" {
+		return Traditional, true
+	}
+	return Simplified, true
+}
+
+// Converter converts text from one variant to another with dictionaries
+// embedded in the binary; it needs no model and gives the same output for the
+// same text.
+type Converter struct {
+	chain []group
+}
+
+// New creates a Converter from one variant to another. Regional text goes
+// back to the common Traditional form first, so Taiwan to Hong Kong swaps
+// the vocabulary without a round trip through Simplified.
+func New(from, to Variant) *Converter {
+	if from == to {
+		return &Converter{}
+	}
+	d := loadDicts()
+	var chain []group
+	switch from {
+	case Taiwan:
+		chain = append(chain, group{d["TWPhrasesRev"]})
+	case HongKong:
+		chain = append(chain, group{d["HKVariantsRev"]}, group{d["HKPhrasesRev"]})
+	}
+	switch {
+	case to == Simplified:
+		chain = append(chain, group{d["TSPhrases"], d["TSCharacters"]})
+	case from == Simplified:
+		chain = append(chain, group{d["STPhrases"], d["STCharacters"]})
+	}
+	switch to {
+	case Taiwan:
+		chain = append(chain, group{d["TWPhrases"]})
+	case HongKong:
+		chain = append(chain, group{d["HKPhrases"]}, group{d["HKVariants"]})
+	}
+	return &Converter{chain: chain}
+}
+
+// Convert converts text, leaving what no dictionary knows as it is
+func (c *Converter) Convert(text string) string {
+	for _, g := range c.chain {
+		text = g.convert(text)
+	}
+	return text
+}
@@ -0,0 +1,67 @@
+package zhconv
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/text/language"
+)
+
+func TestVariantOf(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]Variant{
+		"zh":         Simplified,
+		"zh-CN":      Simplified,
+		"zh-Hans":    Simplified,
+		"zh-Hans-HK": Simplified,
+		"zh-Hant":    Traditional,
+		"zh-TW":      Taiwan,
+		"zh-Hant-TW": Taiwan,
+		"zh-HK":      HongKong,
+		"zh-MO":      HongKong,
+	}
+	for tag, want := range tests {
+		got, ok := VariantOf(language.MustParse(tag))
+		assert.True(t, ok, tag)
+		assert.Equal(t, want, got, tag)
+	}
+
+	_, ok := VariantOf(language.Japanese)
+	assert.False(t, ok)
+	_, ok = VariantOf(language.Und)
+	assert.False(t, ok)
+}
+
+func TestConverter_SimplifiedToTraditional(t *testing.T) {
+	t.Parallel()
+
+	c := New(Simplified, Traditional)
+	assert.Equal(t, "我們的頭髮乾淨了，以後再說。", c.Convert("我们的头发干净了，以后再说。"))
+	assert.Equal(t, "這隻貓叫什麼？你幹什麼呢", c.Convert("这只猫叫什么？你干什么呢"))
+	assert.Equal(t, "關係很複雜，沒關係", c.Convert("关系很复杂，没关系"))
+	assert.Equal(t, "皇后在麵包店裡", c.Convert("皇后在面包店里"), "phrases pick the conversion of ambiguous characters")
+	assert.Equal(t, "沖田先生在沖繩", c.Convert("冲田先生在冲绳"))
+	assert.Equal(t, "{\\i1}Hello 世界{\\i0}", c.Convert("{\\i1}Hello 世界{\\i0}"), "tags and Latin text stay as they are")
+}
+
+func TestConverter_Regions(t *testing.T) {
+	t.Parallel()
+
+	text := "出租车来了，我们用软件看视频吧"
+	assert.Equal(t, "計程車來了，我們用軟體看影片吧", New(Simplified, Taiwan).Convert(text))
+	assert.Equal(t, "的士來了，我們用軟件看影片吧", New(Simplified, HongKong).Convert(text))
+
+	assert.Equal(t, "他著名的作品，看着我，裏面", New(Simplified, HongKong).Convert("他著名的作品，看着我，里面"))
+	assert.Equal(t, "他著名的作品，看著我，裡面", New(HongKong, Taiwan).Convert("他著名的作品，看着我，裏面"))
+	assert.Equal(t, "的士來了", New(Taiwan, HongKong).Convert("計程車來了"))
+}
+
+func TestConverter_TraditionalToSimplified(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "我们的头发干净了，以后再说。", New(Traditional, Simplified).Convert("我們的頭髮乾淨了，以後再說。"))
+	assert.Equal(t, "乾隆皇帝很著名，看着我", New(Traditional, Simplified).Convert("乾隆皇帝很著名，看著我"))
+	assert.Equal(t, "我的软件坏了", New(Taiwan, Simplified).Convert("我的軟體壞了"))
+	assert.Equal(t, "一样", New(Simplified, Simplified).Convert("一样"))
+}
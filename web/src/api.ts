@@ -21,6 +21,14 @@ export interface SubtitleStatus {
   source_subtitle_files: string[];
   target_subtitle_files: string[];
   languages: string[];
+  targets?: TargetSubtitleStatus[];
+}
+
+export interface TargetSubtitleStatus {
+  language: string;
+  has_subtitle: boolean;
+  has_embedded_subtitle: boolean;
+  subtitle_files: string[];
 }
 
 export interface Episode {
@@ -44,6 +52,7 @@ export interface Job {
   payload: JobPayload;
   status: "pending" | "running" | "success" | "failed" | "skipped";
   error?: string;
+  hold_reason?: string;
   created_at: string;
   updated_at: string;
 }
@@ -52,6 +61,18 @@ export interface JobPayload {
   media_file: string;
   subtitle_file: string;
   nfo_file: string;
+  output_mode?: OutputMode;
+  target_language?: string;
+  stream_index?: number;
+  stream_policy?: StreamPolicy;
+}
+
+export interface StreamPolicy {
+  language?: string;
+  title?: string;
+  exclude_forced?: boolean;
+  exclude_sdh?: boolean;
+  codecs?: string[];
 }
 
 export interface JobProgress {
@@ -71,10 +92,48 @@ export interface JobEpisodeInfo {
   output_subtitle_path: string;
 }
 
+export type ReadingViolationKind = "cps" | "line_length";
+
+export interface ReadingViolation {
+  kind: ReadingViolationKind;
+  value: number;
+  limit: number;
+}
+
+export type ReviewSeverity = "minor" | "major" | "critical";
+
+export interface ReviewIssue {
+  line: number;
+  category: string;
+  severity: ReviewSeverity;
+  message: string;
+  retranslated: boolean;
+}
+
+export interface LineReview {
+  score: number;
+  issues: ReviewIssue[];
+}
+
+export interface JobReview {
+  score: number;
+  batches: number;
+  issues: number;
+  retranslated: number;
+}
+
 export interface JobPreviewLine {
   index: number;
   original_text: string;
   translated_text: string;
+  violations?: ReadingViolation[];
+  review?: LineReview;
+}
+
+export interface SubtitleDiagnostic {
+  line: number;
+  problem: string;
+  action: string;
 }
 
 export interface JobDetail {
@@ -86,6 +145,69 @@ export interface JobDetail {
   preview_offset: number;
   preview_limit: number;
   editable: boolean;
+  source_diagnostics: SubtitleDiagnostic[];
+  reading_violations: number;
+  review?: JobReview;
+  usage?: UsageTotals;
+}
+
+export interface UsageTotals {
+  calls: number;
+  prompt_tokens: number;
+  completion_tokens: number;
+  total_tokens: number;
+  cost: number;
+}
+
+export interface UsageGroup extends UsageTotals {
+  key: string;
+}
+
+export interface UsageReport {
+  since: string;
+  total: UsageTotals;
+  daily: UsageGroup[];
+  series: UsageGroup[];
+  models: UsageGroup[];
+  providers: UsageGroup[];
+}
+
+export interface TermJob {
+  job_id: string;
+  media_file?: string;
+  used_at: string;
+}
+
+export interface Term {
+  term: string;
+  translation: string;
+  locked: boolean;
+  jobs: TermJob[];
+}
+
+// TermMap is a term_map.<src>-<tgt>.json the series uses; inherited ones lie
+// in a parent directory and are shared with other series.
+export interface TermMap {
+  path: string;
+  source_language: string;
+  target_language: string;
+  inherited: boolean;
+  terms: Term[];
+}
+
+export interface TermMapsResponse {
+  series: string;
+  term_maps: TermMap[];
+}
+
+export interface UpdateTermRequest {
+  series: string;
+  source_language: string;
+  target_language: string;
+  term: string;
+  translation: string;
+  locked: boolean;
+  previous_term?: string;
 }
 
 export interface JobLinePatch {
@@ -93,12 +215,33 @@ export interface JobLinePatch {
   translated_text: string;
 }
 
+export type OutputMode = "translated" | "bilingual" | "bilingual_original_first" | "bilingual_styled";
+
+export type LLMProvider = "openai" | "anthropic" | "ollama";
+
 export interface RuntimeSettings {
+  llm_provider?: LLMProvider | "";
   llm_api_url: string;
   llm_api_key: string;
   llm_model: string;
   cron_expr: string;
   target_language: string;
+  target_languages?: string[];
+  output_mode: OutputMode | "";
+  budget?: BudgetConfig;
+}
+
+// BudgetCap limits tokens and cost in USD; 0 leaves a limit unset.
+export interface BudgetCap {
+  tokens: number;
+  cost: number;
+}
+
+export interface BudgetConfig {
+  daily: BudgetCap;
+  monthly: BudgetCap;
+  job: BudgetCap;
+  series: BudgetCap;
 }
 
 export interface CreateJobRequest {
@@ -107,11 +250,16 @@ export interface CreateJobRequest {
   mediaPath: string;
   subtitlePath?: string;
   nfoPath?: string;
+  outputMode?: OutputMode;
+  targetLanguage?: string;
+  streamIndex?: number;
+  streamPolicy?: StreamPolicy;
 }
 
 export interface CreateJobResponse {
   created: boolean;
   job: Job;
+  jobs: Job[];
 }
 
 async function request<T>(path: string, init?: RequestInit): Promise<T> {
@@ -140,6 +288,7 @@ export function listItems(sourceId: string): Promise<Item[]> {
 
 export interface EpisodesResponse {
   target_language: string;
+  target_languages?: string[];
   episodes: Episode[];
 }
 
@@ -159,6 +308,10 @@ export function getJobDetail(jobId: string, offset = 0, limit = 80): Promise<Job
   return request<JobDetail>(`/api/jobs/${encodeURIComponent(jobId)}?${q.toString()}`);
 }
 
+export function getUsage(days = 30): Promise<UsageReport> {
+  return request<UsageReport>(`/api/usage?days=${days}`);
+}
+
 export function updateJobLines(jobId: string, lines: JobLinePatch[]): Promise<JobDetail> {
   return request<JobDetail>(`/api/jobs/${encodeURIComponent(jobId)}/lines`, {
     method: "PUT",
@@ -174,7 +327,11 @@ export function createJob(req: CreateJobRequest): Promise<CreateJobResponse> {
       dedupe_key: req.dedupeKey,
       media_path: req.mediaPath,
       subtitle_path: req.subtitlePath || "",
-      nfo_path: req.nfoPath || ""
+      nfo_path: req.nfoPath || "",
+      output_mode: req.outputMode || "",
+      target_language: req.targetLanguage || "",
+      stream_index: req.streamIndex,
+      stream_policy: req.streamPolicy
     })
   });
 }
@@ -193,3 +350,29 @@ export function updateSettings(settings: RuntimeSettings): Promise<RuntimeSettin
     body: JSON.stringify(settings)
   });
 }
+
+export function listTermMaps(series: string): Promise<TermMapsResponse> {
+  const q = new URLSearchParams({ series });
+  return request<TermMapsResponse>(`/api/termmaps?${q.toString()}`);
+}
+
+export function updateTerm(req: UpdateTermRequest): Promise<TermMap> {
+  return request<TermMap>("/api/termmaps", {
+    method: "PUT",
+    body: JSON.stringify(req)
+  });
+}
+
+export async function deleteTerm(series: string, sourceLanguage: string, targetLanguage: string, term: string): Promise<void> {
+  const q = new URLSearchParams({
+    series,
+    source_language: sourceLanguage,
+    target_language: targetLanguage,
+    term
+  });
+  const res = await fetch(`/api/termmaps?${q.toString()}`, { method: "DELETE" });
+  if (!res.ok) {
+    const msg = await res.text();
+    throw new Error(msg || `request failed: ${res.status}`);
+  }
+}
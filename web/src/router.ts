@@ -2,6 +2,7 @@ import { createRouter, createWebHistory } from "vue-router";
 import LibraryView from "./views/LibraryView.vue";
 import SourceView from "./views/SourceView.vue";
 import SeriesView from "./views/SeriesView.vue";
+import TermMapView from "./views/TermMapView.vue";
 import JobsView from "./views/JobsView.vue";
 import SettingsView from "./views/SettingsView.vue";
 
@@ -12,6 +13,7 @@ const router = createRouter({
     { path: "/library", component: LibraryView },
     { path: "/library/:sourceId", component: SourceView, props: true },
     { path: "/series/:itemId", component: SeriesView, props: true },
+    { path: "/series/:itemId/terms", component: TermMapView, props: true },
     { path: "/jobs", component: JobsView },
     { path: "/settings", component: SettingsView }
   ]